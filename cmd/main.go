@@ -1,19 +1,26 @@
 package main
 
 import (
+	"context"
 	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/buildinfo"
 	"mini-e-commerce/internal/cache"
 	"mini-e-commerce/internal/config"
 	"mini-e-commerce/internal/database"
+	"mini-e-commerce/internal/health"
 	"mini-e-commerce/internal/logger"
 	"mini-e-commerce/internal/middleware"
+	"mini-e-commerce/internal/server"
 	"mini-e-commerce/internal/swagger"
+	"mini-e-commerce/internal/tracing"
+	"mini-e-commerce/migrations"
 	"mini-e-commerce/routes"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/gin-gonic/gin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
@@ -24,6 +31,7 @@ func main() {
 		panic("Failed to initialize logger: " + err.Error())
 	}
 	defer logger.Sync()
+	buildinfo.LogStartup(logger, configLog.ServiceName, configLog.AppVersion)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -34,31 +42,113 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to load config: ", zap.Error(err))
 	}
-	db := database.Connect(cfg.DatabaseUrl, logger)
-	if err := database.Migrate(db, logger); err != nil {
-		logger.Fatal("Failed to migrate database: ", zap.Error(err))
+	// Spans are only exported when an OTLP endpoint is configured
+	var traceProvider *sdktrace.TracerProvider
+	if cfg.OTLPEndpoint != "" {
+		traceProvider, err = tracing.NewProvider(context.Background(), cfg.OTLPEndpoint, cfg.TracingServiceName, logger.GetZapLogger())
+		if err != nil {
+			logger.Fatal("Failed to start tracing: ", zap.Error(err))
+		}
+		logger.Info("Tracing enabled", zap.String("otlp_endpoint", cfg.OTLPEndpoint))
+	}
+
+	connectRetry := database.RetryConfig{Attempts: cfg.ConnectAttempts, BaseDelay: cfg.ConnectBaseDelay}
+	db := database.Connect(cfg.DatabaseUrl, database.PoolConfig{
+		MaxOpenConns:    cfg.DBMaxOpenConns,
+		MaxIdleConns:    cfg.DBMaxIdleConns,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+	}, connectRetry, cfg.DBSlowQuery, logger)
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		logger.Fatal("Failed to register tracing plugin: ", zap.Error(err))
+	}
+	if cfg.DBAutoMigrate {
+		if err := database.Migrate(db, logger); err != nil {
+			logger.Fatal("Failed to migrate database: ", zap.Error(err))
+		}
+	} else {
+		schemaMigrations, err := database.LoadMigrations(migrations.FS)
+		if err != nil {
+			logger.Fatal("Failed to load migrations: ", zap.Error(err))
+		}
+		if err := database.NewMigrator(db, schemaMigrations, logger).Up(context.Background()); err != nil {
+			logger.Fatal("Failed to migrate database: ", zap.Error(err))
+		}
+	}
+	poolStatsCtx, stopPoolStats := context.WithCancel(context.Background())
+	if sqlDB, err := db.DB(); err == nil {
+		go database.RecordPoolStats(poolStatsCtx, sqlDB, database.DefaultPoolStatsInterval)
+	}
+
+	rdb := database.ConnectRedis(cfg.RedisAddr, cfg.RedisPassword, database.RedisPoolConfig{
+		PoolSize:     cfg.RedisPoolSize,
+		MinIdleConns: cfg.RedisMinIdleConns,
+		DialTimeout:  cfg.RedisDialTimeout,
+		ReadTimeout:  cfg.RedisReadTimeout,
+		MaxRetries:   cfg.RedisMaxRetries,
+	}, connectRetry, logger)
+	if err := tracing.InstrumentRedis(rdb); err != nil {
+		logger.Fatal("Failed to register redis tracing: ", zap.Error(err))
 	}
-	rdb := database.ConnectRedis(cfg.RedisAddr, cfg.RedisPassword, logger)
 
 	redisCache := cache.NewRedisCache(rdb, logger.GetZapLogger())
 
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, cfg.JWTExpiration, logger.GetZapLogger())
-	sessionManager := auth.NewSessionManager(rdb, logger.GetZapLogger())
+	healthChecker := health.NewChecker(logger)
+	healthChecker.Register("postgres", health.DatabaseCheck(db))
+	healthChecker.Register("redis", health.RedisCheck(rdb))
+	healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+	go healthChecker.Run(healthCtx, health.DefaultCheckInterval)
+
+	var jwtManager auth.JWTManagerInterface
+	if cfg.JWTAlgorithm == auth.AlgorithmRS256 {
+		privateKey, publicKey, err := auth.LoadRSAKeys(cfg.JWTPrivateKeyPath, cfg.JWTPublicKeyPath)
+		if err != nil {
+			logger.Fatal("Failed to load JWT keys: ", zap.Error(err))
+		}
+		jwtManager = auth.NewRSAJWTManager(privateKey, publicKey, cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTExpiration, cfg.JWTLeeway, logger.GetZapLogger())
+	} else {
+		jwtManager = auth.NewJWTManager(cfg.JWTSecret, cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTExpiration, cfg.JWTLeeway, logger.GetZapLogger())
+	}
+	sessionManager := auth.NewSessionManager(rdb, logger.GetZapLogger(), auth.SessionFallbackConfig{
+		FailureThreshold: cfg.SessionFailureThreshold,
+		Cooldown:         cfg.SessionBreakerCooldown,
+		AllowJWTOnly:     cfg.SessionAllowJWTOnly,
+	})
+	tokenManager := auth.NewTokenManager(rdb, logger.GetZapLogger())
 
 	logger.Info("Hybrid auth system initialized",
+		zap.String("jwt_algorithm", cfg.JWTAlgorithm),
 		zap.Duration("jwt_expiration", cfg.JWTExpiration),
 		zap.Duration("refresh_expiration", cfg.RefreshExpiration),
+		zap.Duration("session_ttl", cfg.SessionTTL),
 	)
 
 	r := gin.Default()
 	r.Use(middleware.RequestLogger(logger))
+	r.Use(middleware.Tracing(cfg.TracingServiceName)...)
+	r.Use(middleware.Metrics())
 	r.Use(middleware.ErrorLogger(logger))
+	r.Use(middleware.BodyLimit(cfg.MaxBodyBytes))
+	if cfg.PayloadLogging {
+		r.Use(middleware.PayloadLogger(logger, middleware.PayloadLoggerConfig{
+			RedactFields: cfg.PayloadLogRedact,
+			MaxBytes:     cfg.PayloadLogMaxBytes,
+			Paths:        cfg.PayloadLogPaths,
+		}))
+		logger.Warn("Payload logging enabled", zap.Strings("paths", cfg.PayloadLogPaths))
+	}
+	r.Use(middleware.CORS(middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+	}))
 
 	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
 		logger.Fatal("Failed to set trusted proxies: ", zap.Error(err))
 	}
 
-	routes.RegisterRoutes(r, db, redisCache, logger, jwtManager, sessionManager, &cfg)
+	routes.RegisterRoutes(r, db, rdb, redisCache, logger, healthChecker, jwtManager, sessionManager, tokenManager, &cfg)
 
 	port := cfg.Port
 	if port == "" {
@@ -67,13 +157,38 @@ func main() {
 
 	logger.Info("Starting server", zap.String("port", port))
 
+	srv := server.New(r, ":"+port, logger)
 	go func() {
-		if err := r.Run(":" + port); err != nil {
+		if err := srv.Start(); err != nil {
 			logger.Fatal("Failed to run server: ", zap.Error(err))
 		}
 	}()
 
 	<-quit
-	logger.Info("Server shutting down gracefully...")
+	logger.Info("Server shutting down gracefully...", zap.Duration("timeout", cfg.ShutdownTimeout))
+
+	if err := srv.Shutdown(cfg.ShutdownTimeout); err != nil {
+		logger.Error("Server forced to shutdown", zap.Error(err))
+	}
+
+	stopHealthChecks()
+	stopPoolStats()
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			logger.Error("Failed to close database connection", zap.Error(err))
+		}
+	}
+	if err := rdb.Close(); err != nil {
+		logger.Error("Failed to close redis connection", zap.Error(err))
+	}
+
+	if traceProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		if err := traceProvider.Shutdown(ctx); err != nil {
+			logger.Error("Failed to flush traces", zap.Error(err))
+		}
+		cancel()
+	}
 
+	logger.Info("Server exited")
 }
@@ -445,7 +445,7 @@ const docTemplate = `{
                 }
             },
             "patch": {
-                "description": "Update an order by Id",
+                "description": "Update an order by Id. Orders are marked PAID through /orders/{id}/pay, not here.",
                 "consumes": [
                     "application/json"
                 ],
@@ -0,0 +1,27 @@
+package admin
+
+import "mini-e-commerce/internal/order"
+
+type StatsQuery struct {
+	// Days is how many days of revenue to report, today included
+	Days int `form:"days,default=7" binding:"min=1,max=90"`
+	// LowStockThreshold counts products with at most this many units as low on stock
+	LowStockThreshold int `form:"low_stock_threshold,default=5" binding:"min=0"`
+}
+
+// DashboardStats is the store wide overview shown on the admin dashboard
+type DashboardStats struct {
+	TotalUsers         int64                       `json:"total_users"`
+	TotalProducts      int64                       `json:"total_products"`
+	LowStockProducts   int64                       `json:"low_stock_products"`
+	LowStockThreshold  int                         `json:"low_stock_threshold"`
+	RevenueByDay       []DailyRevenue              `json:"revenue_by_day"`
+	OrderCountByStatus map[order.OrderStatus]int64 `json:"order_count_by_status"`
+}
+
+// DailyRevenue is the paid order total of one UTC day keyed by currency, days without
+// paid orders are listed with an empty map
+type DailyRevenue struct {
+	Date    string           `json:"date"`
+	Revenue map[string]int64 `json:"revenue"`
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ErrMsgFailedToFetchStats = "Failed to fetch dashboard stats"
+)
+
+type Handler struct {
+	service        Service
+	logger         logger.Logger
+	responseHelper *response.ResponseHelper
+}
+
+func NewHandler(service Service, log logger.Logger) *Handler {
+	return &Handler{
+		service:        service,
+		logger:         log,
+		responseHelper: response.NewResponseHelper(log),
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware, adminMiddleware gin.HandlerFunc) {
+	group := r.Group("/admin", authMiddleware, adminMiddleware)
+	group.GET("/stats", h.GetStats)
+}
+
+// GetStats godoc
+// @Summary Get dashboard stats
+// @Description Get total users and products, the number of products low on stock, paid revenue per day and currency for the last days, and order counts by status (admin only). Revenue days are UTC. Figures are cached for up to 30 seconds.
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param days query int false "Days of revenue to report, today included" minimum(1) maximum(90) default(7)
+// @Param low_stock_threshold query int false "Products with at most this many units count as low on stock" minimum(0) default(5)
+// @Success 200 {object} response.SuccessResponse{data=DashboardStats}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/stats [get]
+func (h *Handler) GetStats(c *gin.Context) {
+	var query StatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	stats, err := h.service.GetStats(c.Request.Context(), query)
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetchStats, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Dashboard stats retrieved successfully", stats)
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+type stubService struct {
+	query *StatsQuery
+}
+
+func (s *stubService) GetStats(ctx context.Context, query StatsQuery) (*DashboardStats, error) {
+	s.query = &query
+	return &DashboardStats{TotalUsers: 12}, nil
+}
+
+func setupRouter(service Service, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	log, _ := logger.NewLogger(&logger.Config{ServiceName: "test", AppVersion: "test", LogLevel: zapcore.FatalLevel, Mode: "development"})
+
+	authenticate := func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("role", role)
+		c.Next()
+	}
+
+	r := gin.New()
+	NewHandler(service, log).RegisterRoutes(r.Group("/api"), authenticate, middleware.RequireRole(auth.RoleAdmin))
+	return r
+}
+
+func TestHandler_GetStats(t *testing.T) {
+	t.Run("should return stats to admins with default query", func(t *testing.T) {
+		service := &stubService{}
+
+		w := httptest.NewRecorder()
+		setupRouter(service, auth.RoleAdmin).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"total_users":12`)
+		assert.Equal(t, &StatsQuery{Days: 7, LowStockThreshold: 5}, service.query)
+	})
+
+	t.Run("should forbid non admins", func(t *testing.T) {
+		service := &stubService{}
+
+		w := httptest.NewRecorder()
+		setupRouter(service, auth.RoleUser).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Nil(t, service.query)
+	})
+
+	t.Run("should reject a window longer than 90 days", func(t *testing.T) {
+		service := &stubService{}
+
+		w := httptest.NewRecorder()
+		setupRouter(service, auth.RoleAdmin).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/stats?days=365", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Nil(t, service.query)
+	})
+}
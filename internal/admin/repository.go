@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"context"
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/order"
+	"mini-e-commerce/internal/product"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductCounts is the number of listed products and how many of them run low on stock
+type ProductCounts struct {
+	Total    int64
+	LowStock int64
+}
+
+// RevenueRow is the paid order total of one day in one currency
+type RevenueRow struct {
+	Day      time.Time
+	Currency string
+	Revenue  int64
+}
+
+type Repository interface {
+	CountUsers(ctx context.Context) (int64, error)
+	CountProducts(ctx context.Context, lowStockThreshold int) (ProductCounts, error)
+	RevenueByDay(ctx context.Context, since time.Time) ([]RevenueRow, error)
+	OrderCountsByStatus(ctx context.Context) ([]order.StatusCount, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CountUsers(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&auth.User{}).Count(&count).Error
+	return count, err
+}
+
+// CountProducts counts the catalog and its low stock products in a single scan
+func (r *repository) CountProducts(ctx context.Context, lowStockThreshold int) (ProductCounts, error) {
+	var counts ProductCounts
+	err := r.db.WithContext(ctx).Model(&product.Product{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE stock <= ?) AS low_stock", lowStockThreshold).
+		Scan(&counts).Error
+	return counts, err
+}
+
+// RevenueByDay sums paid orders created since the given time per day and currency
+func (r *repository) RevenueByDay(ctx context.Context, since time.Time) ([]RevenueRow, error) {
+	var rows []RevenueRow
+	err := r.db.WithContext(ctx).Model(&order.Order{}).
+		Select("DATE(created_at) AS day, currency, SUM(total_price) AS revenue").
+		Where("status = ? AND created_at >= ?", order.StatusPaid, since).
+		Group("DATE(created_at), currency").
+		Order("day, currency").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *repository) OrderCountsByStatus(ctx context.Context) ([]order.StatusCount, error) {
+	var counts []order.StatusCount
+	err := r.db.WithContext(ctx).Model(&order.Order{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/order"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_Aggregates(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should count users", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		count, err := repo.CountUsers(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should count products and low stock in one query", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) AS total, COUNT(*) FILTER (WHERE stock <= $1) AS low_stock FROM "products" WHERE "products"."deleted_at" IS NULL`)).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"total", "low_stock"}).AddRow(40, 3))
+
+		counts, err := repo.CountProducts(ctx, 5)
+
+		require.NoError(t, err)
+		assert.Equal(t, ProductCounts{Total: 40, LowStock: 3}, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should sum paid revenue per day and currency", func(t *testing.T) {
+		since := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT DATE(created_at) AS day, currency, SUM(total_price) AS revenue FROM "orders" WHERE (status = $1 AND created_at >= $2) AND "orders"."deleted_at" IS NULL GROUP BY DATE(created_at), currency ORDER BY day, currency`)).
+			WithArgs(order.StatusPaid, since).
+			WillReturnRows(sqlmock.NewRows([]string{"day", "currency", "revenue"}).
+				AddRow(since, "IDR", 150000))
+
+		rows, err := repo.RevenueByDay(ctx, since)
+
+		require.NoError(t, err)
+		assert.Equal(t, []RevenueRow{{Day: since, Currency: "IDR", Revenue: 150000}}, rows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should group orders by status", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT status, COUNT(*) AS count FROM "orders" WHERE "orders"."deleted_at" IS NULL GROUP BY "status"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+				AddRow(order.StatusPending, 4).
+				AddRow(order.StatusPaid, 9))
+
+		counts, err := repo.OrderCountsByStatus(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []order.StatusCount{{Status: order.StatusPending, Count: 4}, {Status: order.StatusPaid, Count: 9}}, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/order"
+	"mini-e-commerce/internal/tracing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	CacheKeyStats = "admin:stats:%d:%d" // days:lowStockThreshold
+	CacheTTLStats = 30 * time.Second
+
+	dateLayout = "2006-01-02"
+)
+
+type Service interface {
+	GetStats(ctx context.Context, query StatsQuery) (*DashboardStats, error)
+}
+
+type service struct {
+	repo   Repository
+	cache  *cache.RedisCache
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewService(repo Repository, cache *cache.RedisCache, logger *zap.Logger) Service {
+	return &service{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// GetStats aggregates the dashboard figures, they are cached briefly so a dashboard
+// polling every few seconds does not rerun the queries
+func (s *service) GetStats(ctx context.Context, query StatsQuery) (*DashboardStats, error) {
+	ctx, span := tracing.Start(ctx, "admin.GetStats")
+	defer span.End()
+
+	var stats DashboardStats
+	cacheKey := fmt.Sprintf(CacheKeyStats, query.Days, query.LowStockThreshold)
+	err := s.cache.GetOrSet(ctx, cacheKey, CacheTTLStats, &stats, func() (any, error) {
+		return s.loadStats(ctx, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (s *service) loadStats(ctx context.Context, query StatsQuery) (*DashboardStats, error) {
+	users, err := s.repo.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.repo.CountProducts(ctx, query.LowStockThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, 1-query.Days)
+	revenue, err := s.repo.RevenueByDay(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	statusCounts, err := s.repo.OrderCountsByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &DashboardStats{
+		TotalUsers:         users,
+		TotalProducts:      products.Total,
+		LowStockProducts:   products.LowStock,
+		LowStockThreshold:  query.LowStockThreshold,
+		RevenueByDay:       dailyRevenue(since, query.Days, revenue),
+		OrderCountByStatus: map[order.OrderStatus]int64{order.StatusPending: 0, order.StatusPaid: 0, order.StatusCancelled: 0},
+	}
+	for _, count := range statusCounts {
+		stats.OrderCountByStatus[count.Status] = count.Count
+	}
+	return stats, nil
+}
+
+// dailyRevenue lays the rows out over every day from since, so charts get a point per day
+func dailyRevenue(since time.Time, days int, rows []RevenueRow) []DailyRevenue {
+	result := make([]DailyRevenue, days)
+	index := make(map[string]int, days)
+	for i := range result {
+		date := since.AddDate(0, 0, i).Format(dateLayout)
+		result[i] = DailyRevenue{Date: date, Revenue: map[string]int64{}}
+		index[date] = i
+	}
+
+	for _, row := range rows {
+		if i, ok := index[row.Day.Format(dateLayout)]; ok {
+			result[i].Revenue[row.Currency] += row.Revenue
+		}
+	}
+	return result
+}
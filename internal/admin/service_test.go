@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/order"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type stubRepository struct {
+	since   time.Time
+	revenue []RevenueRow
+	calls   int
+}
+
+func (r *stubRepository) CountUsers(ctx context.Context) (int64, error) {
+	r.calls++
+	return 12, nil
+}
+
+func (r *stubRepository) CountProducts(ctx context.Context, lowStockThreshold int) (ProductCounts, error) {
+	return ProductCounts{Total: 40, LowStock: 3}, nil
+}
+
+func (r *stubRepository) RevenueByDay(ctx context.Context, since time.Time) ([]RevenueRow, error) {
+	r.since = since
+	return r.revenue, nil
+}
+
+func (r *stubRepository) OrderCountsByStatus(ctx context.Context) ([]order.StatusCount, error) {
+	return []order.StatusCount{{Status: order.StatusPaid, Count: 9}}, nil
+}
+
+func setupTestCache(t *testing.T) *cache.RedisCache {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewRedisCache(client, zap.NewNop())
+}
+
+func TestService_GetStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("should list every day of the window and cache the result", func(t *testing.T) {
+		repo := &stubRepository{revenue: []RevenueRow{
+			{Day: day(14), Currency: "IDR", Revenue: 150000},
+			{Day: day(16), Currency: "IDR", Revenue: 50000},
+			{Day: day(16), Currency: "USD", Revenue: 20},
+		}}
+		svc := NewService(repo, setupTestCache(t), zap.NewNop()).(*service)
+		svc.now = func() time.Time { return time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC) }
+
+		stats, err := svc.GetStats(context.Background(), StatsQuery{Days: 3, LowStockThreshold: 5})
+
+		require.NoError(t, err)
+		assert.Equal(t, day(14), repo.since)
+		assert.Equal(t, []DailyRevenue{
+			{Date: "2026-10-14", Revenue: map[string]int64{"IDR": 150000}},
+			{Date: "2026-10-15", Revenue: map[string]int64{}},
+			{Date: "2026-10-16", Revenue: map[string]int64{"IDR": 50000, "USD": 20}},
+		}, stats.RevenueByDay)
+		assert.Equal(t, int64(12), stats.TotalUsers)
+		assert.Equal(t, int64(3), stats.LowStockProducts)
+		assert.Equal(t, map[order.OrderStatus]int64{order.StatusPending: 0, order.StatusPaid: 9, order.StatusCancelled: 0}, stats.OrderCountByStatus)
+
+		_, err = svc.GetStats(context.Background(), StatsQuery{Days: 3, LowStockThreshold: 5})
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.calls)
+	})
+}
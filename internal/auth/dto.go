@@ -1,5 +1,10 @@
 package auth
 
+import (
+	"mini-e-commerce/internal/dto"
+	"time"
+)
+
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email" validate:"required,email"`
 	Password string `json:"password" binding:"required" validate:"required,min=8"`
@@ -8,15 +13,59 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email" validate:"required,email"`
 	Password string `json:"password" binding:"required" validate:"required"`
+	// RememberMe keeps the session for the longer remember-me lifetime and makes the
+	// auth cookies outlive the browser session
+	RememberMe bool `json:"remember_me"`
 }
 
 type UpdateUserRequest struct {
-	Email *string `json:"email" validate:"omitempty,email"`
+	Email *string `json:"email" binding:"omitempty,email" validate:"omitempty,email"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required" validate:"required"`
+	NewPassword string `json:"new_password" binding:"required" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required" validate:"required"`
+	NewPassword string `json:"new_password" binding:"required" validate:"required"`
 }
 
 type AuthResponse struct {
-	User         User   `json:"user"`
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	SessionID    string `json:"session_id"`
+	User         User      `json:"user"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	SessionID    string    `json:"session_id"`
+	ExpiresAt    time.Time `json:"expires_at"`
+
+	// refreshTTL is how long the stored session lives, used for the cookie lifetime
+	refreshTTL time.Duration
+}
+
+// ImpersonationResponse carries a short-lived access token for acting as User. It has
+// no refresh token or session, the admin asks for a new one when it expires.
+type ImpersonationResponse struct {
+	User        User      `json:"user"`
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// UserSearchQuery matches Q anywhere in the email, an empty Q lists every user
+type UserSearchQuery struct {
+	Q string `form:"q" binding:"max=254"`
+	dto.PaginationQuery
+}
+
+type UserListResponse struct {
+	Data       []User                 `json:"data"`
+	Pagination dto.PaginationMetadata `json:"pagination"`
 }
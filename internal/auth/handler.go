@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"mini-e-commerce/internal/logger"
 	"mini-e-commerce/internal/response"
+	"mini-e-commerce/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -18,29 +21,79 @@ const (
 	ErrMsgFailedToLogin      = "Failed to login user"
 	ErrMsgInvalidCredentials = "Invalid credentials"
 	ErrMsgFailedToLogout     = "Failed to logout"
+	ErrMsgUserNotFound       = "User not found"
+	ErrMsgFailedToFetchUser  = "Failed to fetch user"
+	ErrMsgFailedToUpdateUser = "Failed to update user"
+	ErrMsgFailedToDeleteUser = "Failed to delete user"
+	ErrMsgInvalidUserContext = "Invalid user id in context"
+	ErrMsgFailedToChangePass = "Failed to change password"
+	ErrMsgFailedToResetPass  = "Failed to reset password"
+	ErrMsgSessionUnavailable = "Session store temporarily unavailable"
+	ErrMsgInvalidUserID      = "Invalid user ID"
+	ErrMsgCannotImpersonate  = "This user cannot be impersonated"
+	ErrMsgImpersonateFailed  = "Failed to impersonate user"
+	ErrMsgAccountDeactivated = "Account deactivated"
+	ErrMsgCannotDeactivate   = "This user cannot be deactivated"
+	ErrMsgFailedToDeactivate = "Failed to deactivate user"
+	ErrMsgFailedToActivate   = "Failed to activate user"
 )
 
+var errMissingUserID = errors.New("missing user_id in context")
+
 type Handler struct {
 	service        Service
 	logger         logger.Logger
+	audit          logger.AuditLogger
 	responseHelper *response.ResponseHelper
+	cookieSecure   bool
+	cookieSameSite http.SameSite
 }
 
-func NewHandler(service Service, log logger.Logger) *Handler {
+func NewHandler(service Service, log logger.Logger, cookieSecure bool, cookieSameSite http.SameSite) *Handler {
 	return &Handler{
 		service:        service,
 		logger:         log,
+		audit:          log.WithAudit(),
 		responseHelper: response.NewResponseHelper(log),
+		cookieSecure:   cookieSecure,
+		cookieSameSite: cookieSameSite,
 	}
 }
 
-func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware, adminMiddleware, rateLimiter gin.HandlerFunc) {
 	group := r.Group("/auth")
 	{
-		group.POST("/register", h.Register)
-		group.POST("/login", h.Login)
+		group.POST("/register", rateLimiter, h.Register)
+		group.POST("/login", rateLimiter, h.Login)
 		group.POST("/refresh", h.RefreshToken)
 		group.POST("/logout", h.Logout)
+		group.POST("/logout-all", authMiddleware, h.LogoutAll)
+		group.GET("/sessions", authMiddleware, h.ListSessions)
+		group.GET("/verify", h.VerifyEmail)
+		group.POST("/verify/resend", rateLimiter, h.ResendVerification)
+		group.POST("/password", authMiddleware, h.ChangePassword)
+		group.POST("/password/forgot", h.ForgotPassword)
+		group.POST("/password/reset", h.ResetPassword)
+	}
+
+	me := group.Group("/me", authMiddleware)
+	{
+		me.GET("", h.GetProfile)
+		me.PATCH("", h.UpdateProfile)
+		me.DELETE("", h.DeleteAccount)
+	}
+
+	users := group.Group("/users", authMiddleware, adminMiddleware)
+	{
+		users.GET("", h.ListUsers)
+	}
+
+	admin := r.Group("/admin/users", authMiddleware, adminMiddleware)
+	{
+		admin.GET("", h.SearchUsers)
+		admin.POST("/:id/impersonate", h.Impersonate)
+		admin.POST("/:id/deactivate", h.DeactivateUser)
+		admin.POST("/:id/activate", h.ActivateUser)
 	}
 }
 
@@ -53,23 +106,24 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 // @Param   request body RegisterRequest true "User request body"
 // @Success 201 {object} response.SuccessResponse{data=AuthResponse}
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /auth/register [post]
 func (h *Handler) Register(c *gin.Context) {
 	var input RegisterRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
 	user, err := h.service.RegisterUser(c.Request.Context(), input)
 	if err != nil {
-		if err.Error() == ErrEmailAlreadyExists {
-			h.responseHelper.BadRequest(c, "Email already exists", err.Error())
+		if errors.Is(err, ErrEmailAlreadyExists) {
+			h.responseHelper.Error(c, http.StatusConflict, "Email already exists", response.ErrCodeDataAlreadyExists, err.Error())
 			return
 		}
-		if err.Error() == ErrWeakPassword {
+		if errors.Is(err, ErrWeakPassword) {
 			h.responseHelper.BadRequest(c, "Password too weak", err.Error())
 			return
 		}
@@ -98,29 +152,61 @@ func (h *Handler) Register(c *gin.Context) {
 // @Success 200 {object} response.SuccessResponse{data=AuthResponse}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Failure 503 {object} response.ErrorResponse
 // @Router /auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var input LoginRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
-	authResp, err := h.service.LoginUser(c.Request.Context(), input)
+	meta := SessionMetadata{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+
+	authResp, err := h.service.LoginUser(c.Request.Context(), input, meta)
 	if err != nil {
+		h.audit.Record(c, logger.AuditActionLogin, input.Email, logger.AuditResultFailure, zap.Error(err))
 		if errors.Is(err, ErrInvalidCredentials) {
 			h.responseHelper.Error(c, http.StatusUnauthorized, ErrMsgInvalidCredentials, response.ErrCodeInvalidCredentials, err.Error())
 			return
 		}
+		if errors.Is(err, ErrEmailNotVerified) {
+			h.responseHelper.Error(c, http.StatusForbidden, "Email not verified", response.ErrCodeForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, ErrAccountDeactivated) {
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgAccountDeactivated, response.ErrCodeAccountDeactivated, err.Error())
+			return
+		}
+		if errors.Is(err, ErrSessionStoreUnavailable) {
+			h.responseHelper.Error(c, http.StatusServiceUnavailable, ErrMsgSessionUnavailable, response.ErrCodeServiceUnavailable, err.Error())
+			return
+		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToLogin, err.Error())
 		return
 	}
 
-	cookieMaxAge := 3600 * 24 * 7
-	c.SetCookie("session_id", authResp.SessionID, cookieMaxAge, "/", "", false, true)
-	c.SetCookie("refresh_token", authResp.RefreshToken, cookieMaxAge, "/", "", false, true)
-	c.SetCookie("user_id", fmt.Sprint(authResp.User.ID), cookieMaxAge, "/", "", false, true)
+	// Without a session (degraded mode) the client only gets the access token
+	if authResp.SessionID != "" {
+		// Without remember me the cookies are dropped when the browser closes
+		maxAge := 0
+		if input.RememberMe {
+			maxAge = int(authResp.refreshTTL.Seconds())
+		}
+		h.setAuthCookies(c, authResp, maxAge)
+	}
+
+	h.audit.Log(logger.AuditEvent{
+		ActorID: fmt.Sprint(authResp.User.ID),
+		Action:  logger.AuditActionLogin,
+		Target:  fmt.Sprintf("user:%d", authResp.User.ID),
+		Result:  logger.AuditResultSuccess,
+		IP:      meta.IPAddress,
+	})
 
 	h.logger.Info("User logged in successfully",
 		zap.Uint("user_id", authResp.User.ID),
@@ -172,6 +258,11 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 			zap.Error(err),
 			zap.Uint("user_id", uint(userID)),
 		)
+		if errors.Is(err, ErrAccountDeactivated) {
+			h.clearAuthCookies(c)
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgAccountDeactivated, response.ErrCodeAccountDeactivated, err.Error())
+			return
+		}
 		h.responseHelper.Error(c, http.StatusUnauthorized, "Failed to refresh token", response.ErrCodeUnauthorized, err.Error())
 		return
 	}
@@ -213,14 +304,24 @@ func (h *Handler) Logout(c *gin.Context) {
 		return
 	}
 
+	logoutEvent := logger.AuditEvent{
+		ActorID: userIDStr,
+		Action:  logger.AuditActionLogout,
+		Target:  "session:" + sessionID,
+		Result:  logger.AuditResultSuccess,
+		IP:      c.ClientIP(),
+	}
+
 	if err := h.service.LogoutUser(c.Request.Context(), uint(userID), sessionID); err != nil {
+		logoutEvent.Result = logger.AuditResultFailure
+		h.audit.Log(logoutEvent, zap.Error(err))
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToLogout, err.Error())
 		return
 	}
 
-	c.SetCookie("session_id", "", -1, "/", "", false, true)
-	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
-	c.SetCookie("user_id", "", -1, "/", "", false, true)
+	h.audit.Log(logoutEvent)
+	h.revokeAccessToken(c, uint(userID))
+	h.clearAuthCookies(c)
 
 	h.logger.Info("User logged out successfully",
 		zap.Uint("user_id", uint(userID)),
@@ -229,3 +330,597 @@ func (h *Handler) Logout(c *gin.Context) {
 
 	h.responseHelper.SuccessOK(c, "Logout successfully", nil)
 }
+
+// AuthLogoutAll godoc
+// @Summary Logout user from all devices
+// @Description Invalidate every session of the current authenticated user
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/logout-all [post]
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	target := fmt.Sprintf("user:%d", userID)
+	if err := h.service.LogoutAllSessions(c.Request.Context(), userID); err != nil {
+		h.audit.Record(c, logger.AuditActionLogoutAll, target, logger.AuditResultFailure, zap.Error(err))
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToLogout, err.Error())
+		return
+	}
+	h.audit.Record(c, logger.AuditActionLogoutAll, target, logger.AuditResultSuccess)
+
+	h.revokeAccessToken(c, userID)
+	h.clearAuthCookies(c)
+
+	h.logger.Info("User logged out from all devices", zap.Uint("user_id", userID))
+
+	h.responseHelper.SuccessOK(c, "Logout from all devices successfully", nil)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Verify the email address of a user with the token sent after registration
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   token query string true "Verification token"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/verify [get]
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, "token is required")
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), token); err != nil {
+		if errors.Is(err, ErrInvalidVerifyToken) {
+			h.responseHelper.BadRequest(c, "Invalid verification token", err.Error())
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, "Failed to verify email", err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Email verified successfully", nil)
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification email to the given email if it belongs to an unverified user, at most once per minute. Always responds with success to avoid revealing registered emails
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   request body ResendVerificationRequest true "Resend verification request body"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 429 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/verify/resend [post]
+func (h *Handler) ResendVerification(c *gin.Context) {
+	var input ResendVerificationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), input.Email); err != nil {
+		h.responseHelper.InternalServerError(c, "Failed to resend verification email", err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "If the email is registered and unverified, a verification link has been sent", nil)
+}
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description List the active sessions of the current authenticated user with the current session flagged
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse{data=[]SessionInfo}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/sessions [get]
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	currentSessionID, _ := c.Cookie("session_id")
+
+	sessions, err := h.service.ListSessions(c.Request.Context(), userID, currentSessionID)
+	if err != nil {
+		h.responseHelper.InternalServerError(c, "Failed to fetch sessions", err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "List session retrieved successfully", sessions)
+}
+
+// GetProfile godoc
+// @Summary Get current user profile
+// @Description Get the profile of the currently authenticated user
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse{data=User}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/me [get]
+func (h *Handler) GetProfile(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	user, err := h.service.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetchUser, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "User profile retrieved successfully", user)
+}
+
+// UpdateProfile godoc
+// @Summary Update current user profile
+// @Description Update the profile of the currently authenticated user
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param   request body UpdateUserRequest true "User update request body"
+// @Success 200 {object} response.SuccessResponse{data=User}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/me [patch]
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var input UpdateUserRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	user, err := h.service.UpdateUser(c.Request.Context(), userID, input)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrEmailAlreadyExists) {
+			h.responseHelper.Error(c, http.StatusConflict, "Email already exists", response.ErrCodeDataAlreadyExists, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToUpdateUser, err.Error())
+		return
+	}
+
+	h.logger.WithContext(c).Info("User profile updated",
+		zap.Uint("user_id", user.ID),
+		zap.String("email", user.Email),
+	)
+
+	h.responseHelper.SuccessOK(c, "User profile updated successfully", user)
+}
+
+// DeleteAccount godoc
+// @Summary Delete current user account
+// @Description Delete the account of the currently authenticated user, revoke all of its sessions and clear auth cookies
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/me [delete]
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteUser(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToDeleteUser, err.Error())
+		return
+	}
+
+	h.clearAuthCookies(c)
+
+	h.logger.WithContext(c).Info("User account deleted", zap.Uint("user_id", userID))
+
+	h.responseHelper.SuccessOK(c, "User account deleted successfully", nil)
+}
+
+// ListUsers godoc
+// @Summary Get all users
+// @Description Get a list of all registered users (admin only)
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse{data=[]User}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	users, err := h.service.GetAllUsers(c.Request.Context())
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetchUser, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "List user retrieved successfully", users)
+}
+
+// SearchUsers godoc
+// @Summary Search users by email
+// @Description Find users whose email contains q, case-insensitively, for support lookups (admin only)
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param q query string false "Part of the email to match, empty lists every user"
+// @Param page query int false "Page number" minimum(1) default(1)
+// @Param page_size query int false "Page size, values above 100 are clamped" minimum(1) default(10)
+// @Success 200 {object} response.SuccessResponse{data=[]User}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/users [get]
+func (h *Handler) SearchUsers(c *gin.Context) {
+	var query UserSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	result, err := h.service.SearchUsers(c.Request.Context(), query)
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetchUser, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessPaginated(c, "Users retrieved successfully", result.Data, result.Pagination)
+}
+
+// Impersonate godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token for acting as the user, requests made with it are audited as impersonation. Deactivated accounts cannot be impersonated (admin only)
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "User ID"
+// @Success 200 {object} response.SuccessResponse{data=ImpersonationResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/users/{id}/impersonate [post]
+func (h *Handler) Impersonate(c *gin.Context) {
+	targetID, err := utils.ParseUserIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidUserID, err.Error())
+		return
+	}
+
+	adminID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	target := fmt.Sprintf("user:%d", targetID)
+	result, err := h.service.Impersonate(c.Request.Context(), adminID, targetID)
+	if err != nil {
+		h.audit.Record(c, logger.AuditActionImpersonate, target, logger.AuditResultFailure, zap.Error(err))
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+		case errors.Is(err, ErrCannotImpersonate):
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgCannotImpersonate, response.ErrCodeForbidden, err.Error())
+		case errors.Is(err, ErrAccountDeactivated):
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgCannotImpersonate, response.ErrCodeAccountDeactivated, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgImpersonateFailed, err.Error())
+		}
+		return
+	}
+
+	h.audit.Record(c, logger.AuditActionImpersonate, target, logger.AuditResultSuccess)
+	h.responseHelper.SuccessOK(c, "Impersonation token issued", result)
+}
+
+// DeactivateUser godoc
+// @Summary Deactivate a user
+// @Description Suspend an account without deleting it, its sessions are revoked and it can no longer log in (admin only)
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "User ID"
+// @Success 200 {object} response.SuccessResponse{data=User}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/users/{id}/deactivate [post]
+func (h *Handler) DeactivateUser(c *gin.Context) {
+	targetID, err := utils.ParseUserIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidUserID, err.Error())
+		return
+	}
+
+	target := fmt.Sprintf("user:%d", targetID)
+	user, err := h.service.DeactivateUser(c.Request.Context(), targetID)
+	if err != nil {
+		h.audit.Record(c, logger.AuditActionDeactivate, target, logger.AuditResultFailure, zap.Error(err))
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+		case errors.Is(err, ErrCannotDeactivate):
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgCannotDeactivate, response.ErrCodeForbidden, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToDeactivate, err.Error())
+		}
+		return
+	}
+
+	h.audit.Record(c, logger.AuditActionDeactivate, target, logger.AuditResultSuccess)
+	h.responseHelper.SuccessOK(c, "User deactivated successfully", user)
+}
+
+// ActivateUser godoc
+// @Summary Activate a user
+// @Description Lift the suspension of a deactivated account so it can log in again (admin only)
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "User ID"
+// @Success 200 {object} response.SuccessResponse{data=User}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/users/{id}/activate [post]
+func (h *Handler) ActivateUser(c *gin.Context) {
+	targetID, err := utils.ParseUserIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidUserID, err.Error())
+		return
+	}
+
+	target := fmt.Sprintf("user:%d", targetID)
+	user, err := h.service.ActivateUser(c.Request.Context(), targetID)
+	if err != nil {
+		h.audit.Record(c, logger.AuditActionActivate, target, logger.AuditResultFailure, zap.Error(err))
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToActivate, err.Error())
+		return
+	}
+
+	h.audit.Record(c, logger.AuditActionActivate, target, logger.AuditResultSuccess)
+	h.responseHelper.SuccessOK(c, "User activated successfully", user)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the password of the currently authenticated user after verifying the old password
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param   request body ChangePasswordRequest true "Change password request body"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/password [post]
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var input ChangePasswordRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		h.handleUserContextError(c, err)
+		return
+	}
+
+	target := fmt.Sprintf("user:%d", userID)
+	if err := h.service.ChangePassword(c.Request.Context(), userID, input); err != nil {
+		h.audit.Record(c, logger.AuditActionPasswordChange, target, logger.AuditResultFailure, zap.Error(err))
+		if errors.Is(err, ErrInvalidOldPassword) {
+			h.responseHelper.BadRequest(c, "Invalid old password", err.Error())
+			return
+		}
+		if errors.Is(err, ErrWeakPassword) {
+			h.responseHelper.BadRequest(c, "Password too weak", err.Error())
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToChangePass, err.Error())
+		return
+	}
+
+	h.audit.Record(c, logger.AuditActionPasswordChange, target, logger.AuditResultSuccess)
+	h.revokeAccessToken(c, userID)
+	h.logger.WithContext(c).Info("User password changed", zap.Uint("user_id", userID))
+
+	h.responseHelper.SuccessOK(c, "Password changed successfully", nil)
+}
+
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Send a password reset token to the given email if it belongs to a registered user. Always responds with success to avoid revealing registered emails
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   request body ForgotPasswordRequest true "Forgot password request body"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/password/forgot [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), input.Email); err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToResetPass, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a password reset token. All existing sessions of the user are invalidated
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   request body ResetPasswordRequest true "Reset password request body"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/password/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input ResetPasswordRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	// The caller is unauthenticated, the reset token is what identifies the account
+	if err := h.service.ConfirmPasswordReset(c.Request.Context(), input.Token, input.NewPassword); err != nil {
+		h.audit.Record(c, logger.AuditActionPasswordReset, "reset_token", logger.AuditResultFailure, zap.Error(err))
+		if errors.Is(err, ErrInvalidResetToken) {
+			h.responseHelper.BadRequest(c, "Invalid reset token", err.Error())
+			return
+		}
+		if errors.Is(err, ErrWeakPassword) {
+			h.responseHelper.BadRequest(c, "Password too weak", err.Error())
+			return
+		}
+		if errors.Is(err, ErrUserNotFound) {
+			h.responseHelper.NotFound(c, ErrMsgUserNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToResetPass, err.Error())
+		return
+	}
+
+	h.audit.Record(c, logger.AuditActionPasswordReset, "reset_token", logger.AuditResultSuccess)
+
+	h.responseHelper.SuccessOK(c, "Password reset successfully", nil)
+}
+
+// Helpers
+func (h *Handler) getUserIDFromContext(c *gin.Context) (uint, error) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return 0, errMissingUserID
+	}
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		return 0, errors.New("invalid user_id type in context")
+	}
+	return userIDUint, nil
+}
+
+func (h *Handler) handleUserContextError(c *gin.Context, err error) {
+	if errors.Is(err, errMissingUserID) {
+		h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		return
+	}
+	h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+}
+
+// revokeAccessToken denylists the bearer token of the request, if any. A failure is
+// only logged since the session side of the operation already succeeded.
+func (h *Handler) revokeAccessToken(c *gin.Context, userID uint) {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return
+	}
+
+	if err := h.service.RevokeAccessToken(c.Request.Context(), strings.TrimPrefix(authHeader, "Bearer ")); err != nil {
+		h.logger.Warn("Failed to revoke access token", zap.Error(err), zap.Uint("user_id", userID))
+	}
+}
+
+// setAuthCookies sets the session cookies, a maxAge of zero makes them session cookies.
+// The csrf_token cookie is readable by scripts so clients can echo it in the
+// X-CSRF-Token header on cookie authenticated mutations.
+func (h *Handler) setAuthCookies(c *gin.Context, authResp *AuthResponse, cookieMaxAge int) {
+	c.SetSameSite(h.cookieSameSite)
+	c.SetCookie("session_id", authResp.SessionID, cookieMaxAge, "/", "", h.cookieSecure, true)
+	c.SetCookie("refresh_token", authResp.RefreshToken, cookieMaxAge, "/", "", h.cookieSecure, true)
+	c.SetCookie("user_id", fmt.Sprint(authResp.User.ID), cookieMaxAge, "/", "", h.cookieSecure, true)
+	c.SetCookie("csrf_token", uuid.New().String(), cookieMaxAge, "/", "", h.cookieSecure, false)
+}
+
+func (h *Handler) clearAuthCookies(c *gin.Context) {
+	ClearAuthCookies(c, h.cookieSecure, h.cookieSameSite)
+}
+
+// ClearAuthCookies expires the cookies set on login so the browser stops sending a session
+// that can no longer authenticate. secure and sameSite must match how they were set.
+func ClearAuthCookies(c *gin.Context, secure bool, sameSite http.SameSite) {
+	c.SetSameSite(sameSite)
+	c.SetCookie("session_id", "", -1, "/", "", secure, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", secure, true)
+	c.SetCookie("user_id", "", -1, "/", "", secure, true)
+	c.SetCookie("csrf_token", "", -1, "/", "", secure, false)
+}
@@ -7,15 +7,21 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"mini-e-commerce/internal/dto"
 	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type MockService struct {
@@ -30,8 +36,8 @@ func (m *MockService) RegisterUser(ctx context.Context, input RegisterRequest) (
 	return args.Get(0).(*User), args.Error(1)
 }
 
-func (m *MockService) LoginUser(ctx context.Context, input LoginRequest) (*AuthResponse, error) {
-	args := m.Called(ctx, input)
+func (m *MockService) LoginUser(ctx context.Context, input LoginRequest, meta SessionMetadata) (*AuthResponse, error) {
+	args := m.Called(ctx, input, meta)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -51,6 +57,42 @@ func (m *MockService) LogoutUser(ctx context.Context, userID uint, sessionID str
 	return args.Error(0)
 }
 
+func (m *MockService) LogoutAllSessions(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockService) Impersonate(ctx context.Context, adminID, userID uint) (*ImpersonationResponse, error) {
+	args := m.Called(ctx, adminID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ImpersonationResponse), args.Error(1)
+}
+
+func (m *MockService) ResendVerification(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockService) RevokeAccessToken(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockService) ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]SessionInfo, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SessionInfo), args.Error(1)
+}
+
+func (m *MockService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
 func (m *MockService) GetUserByID(ctx context.Context, id uint) (*User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -80,6 +122,45 @@ func (m *MockService) GetAllUsers(ctx context.Context) ([]User, error) {
 	return args.Get(0).([]User), args.Error(1)
 }
 
+func (m *MockService) DeactivateUser(ctx context.Context, id uint) (*User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockService) ActivateUser(ctx context.Context, id uint) (*User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*User), args.Error(1)
+}
+
+func (m *MockService) SearchUsers(ctx context.Context, query UserSearchQuery) (*UserListResponse, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserListResponse), args.Error(1)
+}
+
+func (m *MockService) RequestPasswordReset(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	args := m.Called(ctx, token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockService) ChangePassword(ctx context.Context, userID uint, input ChangePasswordRequest) error {
+	args := m.Called(ctx, userID, input)
+	return args.Error(0)
+}
+
 func setupLogger() logger.Logger {
 	logConfig := &logger.Config{
 		ServiceName: "test",
@@ -97,7 +178,7 @@ func TestHandler_Register(t *testing.T) {
 	t.Run("should register user successfully", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		input := RegisterRequest{
 			Email:    "test@example.com",
@@ -127,7 +208,7 @@ func TestHandler_Register(t *testing.T) {
 	t.Run("should return error for invalid JSON", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -140,17 +221,40 @@ func TestHandler_Register(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
-	t.Run("should return error when email already exists", func(t *testing.T) {
+	t.Run("should return field details for invalid registration", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(`{"email":"not-an-email"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.Register(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var body response.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, response.ErrCodeValidationError, body.Error.Code)
+		assert.Equal(t, "must be a valid email address", body.Error.Fields["email"])
+		assert.Equal(t, "is required", body.Error.Fields["password"])
+		mockService.AssertNotCalled(t, "RegisterUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return conflict when email already exists", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		input := RegisterRequest{
 			Email:    "existing@example.com",
 			Password: "password123",
 		}
 
-		mockService.On("RegisterUser", mock.Anything, input).Return(nil, errors.New(ErrEmailAlreadyExists))
+		mockService.On("RegisterUser", mock.Anything, input).Return(nil, ErrEmailAlreadyExists)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -161,7 +265,10 @@ func TestHandler_Register(t *testing.T) {
 
 		handler.Register(c)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var resp response.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, response.ErrCodeDataAlreadyExists, resp.Error.Code)
 		mockService.AssertExpectations(t)
 	})
 }
@@ -169,10 +276,31 @@ func TestHandler_Register(t *testing.T) {
 func TestHandler_Login(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	t.Run("should reject a deactivated account", func(t *testing.T) {
+		mockService := new(MockService)
+		handler := NewHandler(mockService, setupLogger(), false, http.SameSiteLaxMode)
+
+		input := LoginRequest{Email: "test@example.com", Password: "password123"}
+		mockService.On("LoginUser", mock.Anything, input, mock.AnythingOfType("auth.SessionMetadata")).Return(nil, ErrAccountDeactivated)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodeAccountDeactivated)
+		assert.Empty(t, w.Result().Cookies())
+	})
+
 	t.Run("should login user successfully", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		input := LoginRequest{
 			Email:    "test@example.com",
@@ -189,7 +317,7 @@ func TestHandler_Login(t *testing.T) {
 			SessionID:    "session-id",
 		}
 
-		mockService.On("LoginUser", mock.Anything, input).Return(authResp, nil)
+		mockService.On("LoginUser", mock.Anything, input, mock.AnythingOfType("auth.SessionMetadata")).Return(authResp, nil)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -201,6 +329,7 @@ func TestHandler_Login(t *testing.T) {
 		handler.Login(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"expires_at"`)
 
 		cookies := w.Result().Cookies()
 		require.NotEmpty(t, cookies)
@@ -216,17 +345,95 @@ func TestHandler_Login(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("should set configured cookie attributes", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, true, http.SameSiteStrictMode)
+
+		input := LoginRequest{
+			Email:    "test@example.com",
+			Password: "password123",
+		}
+
+		authResp := &AuthResponse{
+			User:         User{ID: 1, Email: input.Email},
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			SessionID:    "session-id",
+		}
+
+		mockService.On("LoginUser", mock.Anything, input, mock.AnythingOfType("auth.SessionMetadata")).Return(authResp, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		setCookies := w.Header().Values("Set-Cookie")
+		require.Len(t, setCookies, 4)
+		for _, header := range setCookies {
+			assert.Contains(t, header, "Secure")
+			assert.Contains(t, header, "SameSite=Strict")
+			if strings.HasPrefix(header, "csrf_token=") {
+				assert.NotContains(t, header, "HttpOnly")
+			} else {
+				assert.Contains(t, header, "HttpOnly")
+			}
+		}
+	})
+
+	t.Run("should only persist cookies when remember me is set", func(t *testing.T) {
+		login := func(rememberMe bool) []*http.Cookie {
+			mockService := new(MockService)
+			handler := NewHandler(mockService, setupLogger(), false, http.SameSiteLaxMode)
+
+			input := LoginRequest{Email: "test@example.com", Password: "password123", RememberMe: rememberMe}
+			authResp := &AuthResponse{
+				User:         User{ID: 1, Email: input.Email},
+				AccessToken:  "access-token",
+				RefreshToken: "refresh-token",
+				SessionID:    "session-id",
+				refreshTTL:   30 * 24 * time.Hour,
+			}
+			mockService.On("LoginUser", mock.Anything, input, mock.AnythingOfType("auth.SessionMetadata")).Return(authResp, nil)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body, _ := json.Marshal(input)
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.Login(c)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			return w.Result().Cookies()
+		}
+
+		for _, cookie := range login(true) {
+			assert.Equal(t, 30*24*3600, cookie.MaxAge, cookie.Name)
+		}
+		for _, cookie := range login(false) {
+			assert.Zero(t, cookie.MaxAge, cookie.Name)
+		}
+	})
+
 	t.Run("should return error for invalid credentials", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		input := LoginRequest{
 			Email:    "test@example.com",
 			Password: "wrong-password",
 		}
 
-		mockService.On("LoginUser", mock.Anything, input).Return(nil, ErrInvalidCredentials)
+		mockService.On("LoginUser", mock.Anything, input, mock.AnythingOfType("auth.SessionMetadata")).Return(nil, ErrInvalidCredentials)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -248,7 +455,7 @@ func TestHandler_Logout(t *testing.T) {
 	t.Run("should logout user successfully", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		mockService.On("LogoutUser", mock.Anything, uint(1), "session-123").Return(nil)
 
@@ -276,10 +483,31 @@ func TestHandler_Logout(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("should revoke bearer access token on logout", func(t *testing.T) {
+		mockService := new(MockService)
+		handler := NewHandler(mockService, setupLogger(), false, http.SameSiteLaxMode)
+
+		mockService.On("LogoutUser", mock.Anything, uint(1), "session-123").Return(nil)
+		mockService.On("RevokeAccessToken", mock.Anything, "access-token").Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+		c.Request.Header.Set("Authorization", "Bearer access-token")
+		c.Request.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+		c.Request.AddCookie(&http.Cookie{Name: "user_id", Value: "1"})
+
+		handler.Logout(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
 	t.Run("should return error when session_id cookie is missing", func(t *testing.T) {
 		mockService := new(MockService)
 		log := setupLogger()
-		handler := NewHandler(mockService, log)
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -291,3 +519,566 @@ func TestHandler_Logout(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
+
+func TestHandler_GetProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should return profile of authenticated user", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		expectedUser := &User{ID: 1, Email: "test@example.com"}
+		mockService.On("GetUserByID", mock.Anything, uint(1)).Return(expectedUser, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		c.Set("user_id", uint(1))
+
+		handler.GetProfile(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return unauthorized when user_id is missing in context", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+
+		handler.GetProfile(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found when user does not exist", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		mockService.On("GetUserByID", mock.Anything, uint(999)).Return(nil, ErrUserNotFound)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+		c.Set("user_id", uint(999))
+
+		handler.GetProfile(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandler_UpdateProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should update profile of authenticated user", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		newEmail := "new@example.com"
+		input := UpdateUserRequest{Email: &newEmail}
+		mockService.On("UpdateUser", mock.Anything, uint(1), input).Return(&User{ID: 1, Email: newEmail}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPatch, "/auth/me", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		handler.UpdateProfile(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return conflict when email is taken", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		takenEmail := "taken@example.com"
+		input := UpdateUserRequest{Email: &takenEmail}
+		mockService.On("UpdateUser", mock.Anything, uint(1), input).Return(nil, ErrEmailAlreadyExists)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPatch, "/auth/me", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		handler.UpdateProfile(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var resp response.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, response.ErrCodeDataAlreadyExists, resp.Error.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should reject malformed email before calling the service", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodPatch, "/auth/me", bytes.NewBufferString(`{"email":"not-an-email"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		handler.UpdateProfile(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandler_DeleteAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should delete account and clear cookies", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		mockService.On("DeleteUser", mock.Anything, uint(1)).Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodDelete, "/auth/me", nil)
+		c.Set("user_id", uint(1))
+
+		handler.DeleteAccount(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		for _, cookie := range w.Result().Cookies() {
+			assert.Equal(t, -1, cookie.MaxAge)
+		}
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandler_ListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should list users", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		users := []User{{ID: 1, Email: "a@example.com"}, {ID: 2, Email: "b@example.com"}}
+		mockService.On("GetAllUsers", mock.Anything).Return(users, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/users", nil)
+
+		handler.ListUsers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandler_DeactivateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(mockService *MockService) (*gin.Engine, *observer.ObservedLogs) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		handler := NewHandler(mockService, logger.NewLoggerFromZap(zap.New(core), &logger.Config{}), false, http.SameSiteLaxMode)
+
+		r := gin.New()
+		r.POST("/admin/users/:id/deactivate", handler.DeactivateUser)
+		r.POST("/admin/users/:id/activate", handler.ActivateUser)
+		return r, logs
+	}
+
+	t.Run("should deactivate the user and audit it", func(t *testing.T) {
+		mockService := new(MockService)
+		r, logs := setup(mockService)
+
+		mockService.On("DeactivateUser", mock.Anything, uint(7)).Return(&User{ID: 7, Role: RoleUser, Active: false}, nil)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/7/deactivate", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"active":false`)
+
+		audits := logs.Filter(isAuditEntry).All()
+		require.Len(t, audits, 1)
+		assert.Equal(t, logger.AuditActionDeactivate, audits[0].ContextMap()["action"])
+		assert.Equal(t, "user:7", audits[0].ContextMap()["target"])
+	})
+
+	t.Run("should forbid deactivating an admin", func(t *testing.T) {
+		mockService := new(MockService)
+		r, _ := setup(mockService)
+
+		mockService.On("DeactivateUser", mock.Anything, uint(2)).Return(nil, ErrCannotDeactivate)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/2/deactivate", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("should return not found when activating an unknown user", func(t *testing.T) {
+		mockService := new(MockService)
+		r, _ := setup(mockService)
+
+		mockService.On("ActivateUser", mock.Anything, uint(99)).Return(nil, ErrUserNotFound)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/99/activate", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandler_SearchUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(mockService *MockService) *gin.Engine {
+		handler := NewHandler(mockService, setupLogger(), false, http.SameSiteLaxMode)
+		r := gin.New()
+		r.GET("/admin/users", handler.SearchUsers)
+		return r
+	}
+
+	t.Run("should return the matching users without password hashes", func(t *testing.T) {
+		mockService := new(MockService)
+		r := setup(mockService)
+
+		query := UserSearchQuery{Q: "alice"}
+		query.Page = 2
+		query.PageSize = 5
+		mockService.On("SearchUsers", mock.Anything, query).Return(&UserListResponse{
+			Data:       []User{{ID: 6, Email: "alice@example.com", Password: "hashed-password", Role: RoleUser}},
+			Pagination: dto.PaginationMetadata{Page: 2, PageSize: 5, Total: 6, TotalPages: 2},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users?q=alice&page=2&page_size=5", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "alice@example.com")
+		assert.NotContains(t, w.Body.String(), "hashed-password")
+		assert.NotContains(t, w.Body.String(), `"password"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should reject an invalid page", func(t *testing.T) {
+		mockService := new(MockService)
+		r := setup(mockService)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users?q=alice&page=0", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return 500 when the search fails", func(t *testing.T) {
+		mockService := new(MockService)
+		r := setup(mockService)
+
+		mockService.On("SearchUsers", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users?q=alice", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestHandler_ChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should change password successfully", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		input := ChangePasswordRequest{OldPassword: "password123", NewPassword: "newpassword123"}
+		mockService.On("ChangePassword", mock.Anything, uint(1), input).Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/password", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		handler.ChangePassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request for wrong old password", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		input := ChangePasswordRequest{OldPassword: "wrong-password", NewPassword: "newpassword123"}
+		mockService.On("ChangePassword", mock.Anything, uint(1), input).Return(ErrInvalidOldPassword)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/password", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", uint(1))
+
+		handler.ChangePassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandler_VerifyEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should verify email successfully", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		mockService.On("VerifyEmail", mock.Anything, "valid-token").Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/verify?token=valid-token", nil)
+
+		handler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request for invalid token", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		mockService.On("VerifyEmail", mock.Anything, "expired-token").Return(ErrInvalidVerifyToken)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/verify?token=expired-token", nil)
+
+		handler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request when token is missing", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/verify", nil)
+
+		handler.VerifyEmail(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "VerifyEmail", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandler_ResendVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(mockService *MockService) *gin.Engine {
+		handler := NewHandler(mockService, setupLogger(), false, http.SameSiteLaxMode)
+		// unverified users cannot log in, so the route must work without a session
+		rejectAll := func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) }
+		passThrough := func(c *gin.Context) { c.Next() }
+
+		r := gin.New()
+		handler.RegisterRoutes(r.Group(""), rejectAll, rejectAll, passThrough)
+		return r
+	}
+	post := func(r *gin.Engine, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/auth/verify/resend", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should respond the same without a session whether or not the email is registered", func(t *testing.T) {
+		mockService := new(MockService)
+		mockService.On("ResendVerification", mock.Anything, "unverified@example.com").Return(nil)
+
+		w := post(setup(mockService), `{"email":"unverified@example.com"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "If the email is registered and unverified")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should reject a missing email", func(t *testing.T) {
+		mockService := new(MockService)
+
+		w := post(setup(mockService), `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ResendVerification", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandler_ForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should return success regardless of whether email exists", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		mockService.On("RequestPasswordReset", mock.Anything, "unknown@example.com").Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(ForgotPasswordRequest{Email: "unknown@example.com"})
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/password/forgot", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.ForgotPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should reset password successfully", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		input := ResetPasswordRequest{Token: "reset-token", NewPassword: "newpassword123"}
+		mockService.On("ConfirmPasswordReset", mock.Anything, input.Token, input.NewPassword).Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.ResetPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("should return bad request for invalid token", func(t *testing.T) {
+		mockService := new(MockService)
+		log := setupLogger()
+		handler := NewHandler(mockService, log, false, http.SameSiteLaxMode)
+
+		input := ResetPasswordRequest{Token: "used-token", NewPassword: "newpassword123"}
+		mockService.On("ConfirmPasswordReset", mock.Anything, input.Token, input.NewPassword).Return(ErrInvalidResetToken)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		body, _ := json.Marshal(input)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.ResetPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestHandler_Impersonate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(mockService *MockService) (*gin.Engine, *observer.ObservedLogs) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		handler := NewHandler(mockService, logger.NewLoggerFromZap(zap.New(core), &logger.Config{}), false, http.SameSiteLaxMode)
+
+		r := gin.New()
+		r.POST("/admin/users/:id/impersonate", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Next()
+		}, handler.Impersonate)
+		return r, logs
+	}
+
+	t.Run("should return the token and audit the impersonation", func(t *testing.T) {
+		mockService := new(MockService)
+		r, logs := setup(mockService)
+
+		mockService.On("Impersonate", mock.Anything, uint(1), uint(7)).Return(&ImpersonationResponse{
+			User:        User{ID: 7, Role: RoleUser},
+			AccessToken: "impersonation-token",
+		}, nil)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/7/impersonate", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "impersonation-token")
+
+		audits := logs.Filter(isAuditEntry).All()
+		require.Len(t, audits, 1)
+		fields := audits[0].ContextMap()
+		assert.Equal(t, logger.AuditActionImpersonate, fields["action"])
+		assert.Equal(t, "1", fields["actor_id"])
+		assert.Equal(t, "user:7", fields["target"])
+		assert.Equal(t, logger.AuditResultSuccess, fields["result"])
+	})
+
+	t.Run("should forbid impersonating an admin", func(t *testing.T) {
+		mockService := new(MockService)
+		r, logs := setup(mockService)
+
+		mockService.On("Impersonate", mock.Anything, uint(1), uint(2)).Return(nil, ErrCannotImpersonate)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/users/2/impersonate", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		audits := logs.Filter(isAuditEntry).All()
+		require.Len(t, audits, 1)
+		assert.Equal(t, logger.AuditResultFailure, audits[0].ContextMap()["result"])
+	})
+}
+
+func isAuditEntry(e observer.LoggedEntry) bool {
+	return e.LoggerName == logger.AuditLoggerName
+}
@@ -2,12 +2,21 @@ package auth
 
 import (
 	"errors"
+	"strings"
+	"unicode"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidOldPassword = errors.New("old password is incorrect")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+	ErrEmailNotVerified   = errors.New("email address is not verified")
+	ErrInvalidResetToken  = errors.New("invalid or expired password reset token")
+	ErrCannotImpersonate  = errors.New("admins cannot be impersonated")
+	ErrAccountDeactivated = errors.New("account is deactivated")
+	ErrCannotDeactivate   = errors.New("admins cannot be deactivated")
 )
 
 func HashPassword(password string) (string, error) {
@@ -19,3 +28,30 @@ func CheckPassword(hashedPassword, plain string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plain))
 	return err == nil
 }
+
+// NormalizeEmail trims and lowercases an email so lookups do not depend on how the
+// user typed it
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func ValidatePasswordStrength(password string) error {
+	if len(password) < MinPasswordLength || strings.TrimSpace(password) == "" {
+		return ErrWeakPassword
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrWeakPassword
+	}
+
+	return nil
+}
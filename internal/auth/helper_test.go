@@ -92,3 +92,37 @@ func TestCheckPassword(t *testing.T) {
 		assert.False(t, resultUpper, "passwords should be case-sensitive")
 	})
 }
+
+func TestValidatePasswordStrength(t *testing.T) {
+	t.Run("should accept password with letters and digits", func(t *testing.T) {
+		assert.NoError(t, ValidatePasswordStrength("password123"))
+	})
+
+	t.Run("should reject password shorter than minimum length", func(t *testing.T) {
+		err := ValidatePasswordStrength("pass123")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+	})
+
+	t.Run("should reject whitespace-only password", func(t *testing.T) {
+		err := ValidatePasswordStrength("        ")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+	})
+
+	t.Run("should reject password without a digit", func(t *testing.T) {
+		err := ValidatePasswordStrength("passwordonly")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+	})
+
+	t.Run("should reject password without a letter", func(t *testing.T) {
+		err := ValidatePasswordStrength("1234567890")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+	})
+}
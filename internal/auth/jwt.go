@@ -1,53 +1,144 @@
 package auth
 
 import (
+	"crypto/rsa"
 	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
 var (
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrInvalidToken      = errors.New("invalid token")
+	ErrExpiredToken      = errors.New("token has expired")
+	ErrRevokedToken      = errors.New("token has been revoked")
+	ErrMissingSigningKey = errors.New("signing key is not configured")
 )
 
+// ImpersonationTokenTTL is how long an admin may act as another user per token
+const ImpersonationTokenTTL = 15 * time.Minute
+
 type JWTManagerInterface interface {
-	Generate(userID uint) (string, error)
+	Generate(userID uint, role string) (string, error)
+	// GenerateImpersonation issues a token for userID that records actorID as the
+	// admin acting on their behalf
+	GenerateImpersonation(userID uint, role string, actorID uint, duration time.Duration) (string, error)
 	Verify(tokenStr string) (*UserClaims, error)
+	// Leeway is how long past exp Verify still accepts a token
+	Leeway() time.Duration
 }
 
 type JWTManager struct {
 	SecretKey     string
 	TokenDuration time.Duration
+	Issuer        string
+	Audience      string
+	leeway        time.Duration
+	method        jwt.SigningMethod
+	privateKey    *rsa.PrivateKey
+	publicKey     *rsa.PublicKey
 	logger        *zap.Logger
 }
 
 type UserClaims struct {
-	UserID uint `json:"user_id"`
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+	// ActAs is the id of the admin impersonating UserID, zero on regular tokens
+	ActAs uint `json:"act_as,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTManager(secret string, duration time.Duration, logger *zap.Logger) JWTManagerInterface {
+// NewJWTManager creates an HMAC manager. Leeway tolerates clock skew when checking exp, nbf and iat.
+func NewJWTManager(secret, issuer, audience string, duration, leeway time.Duration, logger *zap.Logger) JWTManagerInterface {
 	return &JWTManager{
 		SecretKey:     secret,
 		TokenDuration: duration,
+		leeway:        leeway,
+		Issuer:        issuer,
+		Audience:      audience,
+		method:        jwt.SigningMethodHS256,
 		logger:        logger,
 	}
 }
 
-func (j *JWTManager) Generate(userID uint) (string, error) {
-	claims := UserClaims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.TokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// NewRSAJWTManager creates a manager that signs with the RSA private key and verifies with the public key.
+// The private key may be nil for services that only need to verify tokens.
+func NewRSAJWTManager(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey, issuer, audience string, duration, leeway time.Duration, logger *zap.Logger) JWTManagerInterface {
+	return &JWTManager{
+		TokenDuration: duration,
+		leeway:        leeway,
+		Issuer:        issuer,
+		Audience:      audience,
+		method:        jwt.SigningMethodRS256,
+		privateKey:    privateKey,
+		publicKey:     publicKey,
+		logger:        logger,
+	}
+}
+
+// LoadRSAKeys reads PEM encoded RSA keys from disk. An empty private key path skips loading the private key.
+func LoadRSAKeys(privateKeyPath, publicKeyPath string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	var privateKey *rsa.PrivateKey
+	if privateKeyPath != "" {
+		data, err := os.ReadFile(privateKeyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	return privateKey, publicKey, nil
+}
+
+func (j *JWTManager) Generate(userID uint, role string) (string, error) {
+	return j.generate(UserClaims{UserID: userID, Role: role}, j.TokenDuration)
+}
+
+func (j *JWTManager) GenerateImpersonation(userID uint, role string, actorID uint, duration time.Duration) (string, error) {
+	return j.generate(UserClaims{UserID: userID, Role: role, ActAs: actorID}, duration)
+}
+
+func (j *JWTManager) generate(claims UserClaims, duration time.Duration) (string, error) {
+	userID := claims.UserID
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Issuer:    j.Issuer,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+	if j.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.Audience}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(j.SecretKey))
+	signingKey, err := j.signingKey()
+	if err != nil {
+		j.logger.Error("Failed to generate JWT token", zap.Error(err), zap.Uint("user_id", userID))
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(j.method, claims)
+	signedToken, err := token.SignedString(signingKey)
 	if err != nil {
 		j.logger.Error("Failed to generate JWT token", zap.Error(err), zap.Uint("user_id", userID))
 		return "", err
@@ -57,13 +148,23 @@ func (j *JWTManager) Generate(userID uint) (string, error) {
 	return signedToken, nil
 }
 
+func (j *JWTManager) Leeway() time.Duration {
+	return j.leeway
+}
+
 func (j *JWTManager) Verify(tokenStr string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(j.SecretKey), nil
-	})
+	var opts []jwt.ParserOption
+	if j.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.Issuer))
+	}
+	if j.Audience != "" {
+		opts = append(opts, jwt.WithAudience(j.Audience))
+	}
+	if j.leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(j.leeway))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, j.verificationKey, opts...)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -83,3 +184,30 @@ func (j *JWTManager) Verify(tokenStr string) (*UserClaims, error) {
 	j.logger.Debug("JWT token verified successfully", zap.Uint("user_id", claims.UserID))
 	return claims, nil
 }
+
+func (j *JWTManager) signingKey() (any, error) {
+	if j.method == jwt.SigningMethodRS256 {
+		if j.privateKey == nil {
+			return nil, ErrMissingSigningKey
+		}
+		return j.privateKey, nil
+	}
+	return []byte(j.SecretKey), nil
+}
+
+func (j *JWTManager) verificationKey(token *jwt.Token) (any, error) {
+	if j.method == jwt.SigningMethodRS256 {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrInvalidToken
+		}
+		if j.publicKey == nil {
+			return nil, ErrMissingSigningKey
+		}
+		return j.publicKey, nil
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, ErrInvalidToken
+	}
+	return []byte(j.SecretKey), nil
+}
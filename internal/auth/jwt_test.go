@@ -1,6 +1,12 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -10,13 +16,18 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	testIssuer   = "mini-e-commerce"
+	testAudience = "mini-e-commerce-api"
+)
+
 func TestNewJWTManager(t *testing.T) {
 	t.Run("should create JWTManager successfully", func(t *testing.T) {
 		secret := "test-secret"
 		duration := time.Hour
 		logger := zap.NewNop()
 
-		jwtManager := NewJWTManager(secret, duration, logger)
+		jwtManager := NewJWTManager(secret, testIssuer, testAudience, duration, 0, logger)
 
 		assert.NotNil(t, jwtManager)
 	})
@@ -26,12 +37,12 @@ func TestJWTManager_Generate(t *testing.T) {
 	secret := "test-secret"
 	duration := time.Hour
 	logger := zap.NewNop()
-	jwtManager := NewJWTManager(secret, duration, logger)
+	jwtManager := NewJWTManager(secret, testIssuer, testAudience, duration, 0, logger)
 
 	t.Run("should generate token successfully", func(t *testing.T) {
 		userID := uint(123)
 
-		token, err := jwtManager.Generate(userID)
+		token, err := jwtManager.Generate(userID, RoleUser)
 
 		require.NoError(t, err)
 		assert.NotEmpty(t, token)
@@ -40,9 +51,9 @@ func TestJWTManager_Generate(t *testing.T) {
 	t.Run("should generate different tokens for same user", func(t *testing.T) {
 		userID := uint(123)
 
-		token1, err1 := jwtManager.Generate(userID)
+		token1, err1 := jwtManager.Generate(userID, RoleUser)
 		time.Sleep(time.Second)
-		token2, err2 := jwtManager.Generate(userID)
+		token2, err2 := jwtManager.Generate(userID, RoleUser)
 
 		require.NoError(t, err1)
 		require.NoError(t, err2)
@@ -52,7 +63,7 @@ func TestJWTManager_Generate(t *testing.T) {
 	t.Run("should generate token with correct claims", func(t *testing.T) {
 		userID := uint(456)
 
-		tokenString, err := jwtManager.Generate(userID)
+		tokenString, err := jwtManager.Generate(userID, RoleUser)
 		require.NoError(t, err)
 
 		token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (any, error) {
@@ -63,8 +74,51 @@ func TestJWTManager_Generate(t *testing.T) {
 		claims, ok := token.Claims.(*UserClaims)
 		require.True(t, ok)
 		assert.Equal(t, userID, claims.UserID)
+		assert.Equal(t, RoleUser, claims.Role)
 		assert.NotNil(t, claims.ExpiresAt)
 		assert.NotNil(t, claims.IssuedAt)
+		assert.NotEmpty(t, claims.ID)
+	})
+
+	t.Run("should assign a unique jti to every token", func(t *testing.T) {
+		token1, err := jwtManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+		token2, err := jwtManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims1, err := jwtManager.Verify(token1)
+		require.NoError(t, err)
+		claims2, err := jwtManager.Verify(token2)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, claims1.ID, claims2.ID)
+	})
+}
+
+func TestJWTManager_GenerateImpersonation(t *testing.T) {
+	jwtManager := NewJWTManager("test-secret", testIssuer, testAudience, time.Hour, 0, zap.NewNop())
+
+	t.Run("should carry the target user and the acting admin", func(t *testing.T) {
+		token, err := jwtManager.GenerateImpersonation(7, RoleUser, 1, ImpersonationTokenTTL)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), claims.UserID)
+		assert.Equal(t, RoleUser, claims.Role)
+		assert.Equal(t, uint(1), claims.ActAs)
+		assert.WithinDuration(t, time.Now().Add(ImpersonationTokenTTL), claims.ExpiresAt.Time, 5*time.Second)
+	})
+
+	t.Run("should leave act_as out of regular tokens", func(t *testing.T) {
+		token, err := jwtManager.Generate(7, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		require.NoError(t, err)
+		assert.Zero(t, claims.ActAs)
 	})
 }
 
@@ -72,11 +126,11 @@ func TestJWTManager_Verify(t *testing.T) {
 	secret := "test-secret"
 	duration := time.Hour
 	logger := zap.NewNop()
-	jwtManager := NewJWTManager(secret, duration, logger)
+	jwtManager := NewJWTManager(secret, testIssuer, testAudience, duration, 0, logger)
 
 	t.Run("should verify valid token successfully", func(t *testing.T) {
 		userID := uint(123)
-		token, err := jwtManager.Generate(userID)
+		token, err := jwtManager.Generate(userID, RoleUser)
 		require.NoError(t, err)
 
 		claims, err := jwtManager.Verify(token)
@@ -98,10 +152,10 @@ func TestJWTManager_Verify(t *testing.T) {
 
 	t.Run("should return error for expired token", func(t *testing.T) {
 		shortDuration := time.Millisecond
-		shortJWTManager := NewJWTManager(secret, shortDuration, logger)
+		shortJWTManager := NewJWTManager(secret, testIssuer, testAudience, shortDuration, 0, logger)
 
 		userID := uint(123)
-		token, err := shortJWTManager.Generate(userID)
+		token, err := shortJWTManager.Generate(userID, RoleUser)
 		require.NoError(t, err)
 
 		time.Sleep(10 * time.Millisecond)
@@ -115,10 +169,10 @@ func TestJWTManager_Verify(t *testing.T) {
 
 	t.Run("should return error for token with wrong secret", func(t *testing.T) {
 		userID := uint(123)
-		token, err := jwtManager.Generate(userID)
+		token, err := jwtManager.Generate(userID, RoleUser)
 		require.NoError(t, err)
 
-		differentJWTManager := NewJWTManager("different-secret", duration, logger)
+		differentJWTManager := NewJWTManager("different-secret", testIssuer, testAudience, duration, 0, logger)
 
 		claims, err := differentJWTManager.Verify(token)
 
@@ -164,3 +218,175 @@ func TestJWTManager_Verify(t *testing.T) {
 		assert.Equal(t, ErrInvalidToken, err)
 	})
 }
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func TestJWTManager_Leeway(t *testing.T) {
+	secret := "test-secret"
+	leeway := 30 * time.Second
+	logger := zap.NewNop()
+
+	t.Run("should accept token expired within the leeway", func(t *testing.T) {
+		jwtManager := NewJWTManager(secret, testIssuer, testAudience, -10*time.Second, leeway, logger)
+		token, err := jwtManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(123), claims.UserID)
+	})
+
+	t.Run("should reject token expired beyond the leeway", func(t *testing.T) {
+		jwtManager := NewJWTManager(secret, testIssuer, testAudience, -time.Minute, leeway, logger)
+		token, err := jwtManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		assert.Nil(t, claims)
+		assert.Equal(t, ErrExpiredToken, err)
+	})
+}
+
+func TestJWTManager_RS256(t *testing.T) {
+	duration := time.Hour
+	logger := zap.NewNop()
+	privateKey := generateRSAKey(t)
+
+	t.Run("should sign with private key and verify with public key", func(t *testing.T) {
+		signer := NewRSAJWTManager(privateKey, &privateKey.PublicKey, testIssuer, testAudience, duration, 0, logger)
+		verifier := NewRSAJWTManager(nil, &privateKey.PublicKey, testIssuer, testAudience, duration, 0, logger)
+
+		token, err := signer.Generate(123, RoleAdmin)
+		require.NoError(t, err)
+
+		claims, err := verifier.Verify(token)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(123), claims.UserID)
+		assert.Equal(t, RoleAdmin, claims.Role)
+	})
+
+	t.Run("should reject token signed with a different key", func(t *testing.T) {
+		otherKey := generateRSAKey(t)
+		signer := NewRSAJWTManager(otherKey, &otherKey.PublicKey, testIssuer, testAudience, duration, 0, logger)
+		verifier := NewRSAJWTManager(nil, &privateKey.PublicKey, testIssuer, testAudience, duration, 0, logger)
+
+		token, err := signer.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := verifier.Verify(token)
+
+		assert.Nil(t, claims)
+		assert.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("should reject HS256 token when configured for RS256", func(t *testing.T) {
+		hmacManager := NewJWTManager("test-secret", testIssuer, testAudience, duration, 0, logger)
+		verifier := NewRSAJWTManager(nil, &privateKey.PublicKey, testIssuer, testAudience, duration, 0, logger)
+
+		token, err := hmacManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := verifier.Verify(token)
+
+		assert.Nil(t, claims)
+		assert.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("should return error when generating without private key", func(t *testing.T) {
+		verifier := NewRSAJWTManager(nil, &privateKey.PublicKey, testIssuer, testAudience, duration, 0, logger)
+
+		token, err := verifier.Generate(123, RoleUser)
+
+		assert.Empty(t, token)
+		assert.ErrorIs(t, err, ErrMissingSigningKey)
+	})
+}
+
+func TestLoadRSAKeys(t *testing.T) {
+	privateKey := generateRSAKey(t)
+	dir := t.TempDir()
+
+	privatePath := filepath.Join(dir, "private.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	}), 0o600))
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	publicPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicDER,
+	}), 0o644))
+
+	t.Run("should load key pair from PEM files", func(t *testing.T) {
+		loadedPrivate, loadedPublic, err := LoadRSAKeys(privatePath, publicPath)
+
+		require.NoError(t, err)
+		assert.True(t, privateKey.Equal(loadedPrivate))
+		assert.True(t, privateKey.PublicKey.Equal(loadedPublic))
+	})
+
+	t.Run("should load only public key when private key path is empty", func(t *testing.T) {
+		loadedPrivate, loadedPublic, err := LoadRSAKeys("", publicPath)
+
+		require.NoError(t, err)
+		assert.Nil(t, loadedPrivate)
+		assert.NotNil(t, loadedPublic)
+	})
+
+	t.Run("should return error for missing file", func(t *testing.T) {
+		_, _, err := LoadRSAKeys("", filepath.Join(dir, "missing.pem"))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestJWTManager_IssuerAudience(t *testing.T) {
+	secret := "test-secret"
+	duration := time.Hour
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager(secret, testIssuer, testAudience, duration, 0, logger)
+
+	t.Run("should set issuer and audience claims", func(t *testing.T) {
+		token, err := jwtManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		require.NoError(t, err)
+		assert.Equal(t, testIssuer, claims.Issuer)
+		assert.Equal(t, jwt.ClaimStrings{testAudience}, claims.Audience)
+	})
+
+	t.Run("should reject token with wrong issuer", func(t *testing.T) {
+		otherManager := NewJWTManager(secret, "other-service", testAudience, duration, 0, logger)
+		token, err := otherManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		assert.Nil(t, claims)
+		assert.Equal(t, ErrInvalidToken, err)
+	})
+
+	t.Run("should reject token with wrong audience", func(t *testing.T) {
+		otherManager := NewJWTManager(secret, testIssuer, "other-api", duration, 0, logger)
+		token, err := otherManager.Generate(123, RoleUser)
+		require.NoError(t, err)
+
+		claims, err := jwtManager.Verify(token)
+
+		assert.Nil(t, claims)
+		assert.Equal(t, ErrInvalidToken, err)
+	})
+}
@@ -2,9 +2,18 @@ package auth
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string    `gorm:"not null" json:"-"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Email         string    `gorm:"uniqueIndex;not null" json:"email"`
+	Password      string    `gorm:"not null" json:"-"`
+	Role          string    `gorm:"type:varchar(20);default:'user'" json:"role"`
+	EmailVerified bool      `gorm:"not null;default:false" json:"email_verified"`
+	Active        bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_JSONOmitsPassword(t *testing.T) {
+	t.Run("should not serialize password on user", func(t *testing.T) {
+		user := User{
+			ID:       1,
+			Email:    "test@example.com",
+			Password: "$2a$10$hashedpassword",
+		}
+
+		data, err := json.Marshal(user)
+		require.NoError(t, err)
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(data, &payload))
+
+		assert.NotContains(t, payload, "password")
+		assert.NotContains(t, string(data), user.Password)
+	})
+
+	t.Run("should not serialize password on auth response", func(t *testing.T) {
+		authResp := AuthResponse{
+			User: User{
+				ID:       1,
+				Email:    "test@example.com",
+				Password: "$2a$10$hashedpassword",
+			},
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			SessionID:    "session-id",
+		}
+
+		data, err := json.Marshal(authResp)
+		require.NoError(t, err)
+
+		var payload struct {
+			User map[string]any `json:"user"`
+		}
+		require.NoError(t, json.Unmarshal(data, &payload))
+
+		assert.Contains(t, payload.User, "email")
+		assert.NotContains(t, payload.User, "password")
+		assert.NotContains(t, string(data), authResp.User.Password)
+	})
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type Notifier interface {
+	SendVerificationEmail(ctx context.Context, email, token string) error
+	SendPasswordResetEmail(ctx context.Context, email, token string) error
+	SendBackInStockEmail(ctx context.Context, email, productName string) error
+}
+
+type noopNotifier struct {
+	logger *zap.Logger
+}
+
+func NewNoopNotifier(logger *zap.Logger) Notifier {
+	return &noopNotifier{logger: logger}
+}
+
+func (n *noopNotifier) SendVerificationEmail(ctx context.Context, email, token string) error {
+	n.logger.Debug("Verification email not sent, no mailer configured", zap.String("email", email))
+	return nil
+}
+
+func (n *noopNotifier) SendPasswordResetEmail(ctx context.Context, email, token string) error {
+	n.logger.Debug("Password reset email not sent, no mailer configured", zap.String("email", email))
+	return nil
+}
+
+func (n *noopNotifier) SendBackInStockEmail(ctx context.Context, email, productName string) error {
+	n.logger.Debug("Back in stock email not sent, no mailer configured", zap.String("email", email), zap.String("product", productName))
+	return nil
+}
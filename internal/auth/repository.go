@@ -3,6 +3,8 @@ package auth
 import (
 	"context"
 
+	"mini-e-commerce/internal/utils"
+
 	"gorm.io/gorm"
 )
 
@@ -13,6 +15,7 @@ type Repository interface {
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id uint) error
 	FindAll(ctx context.Context) ([]User, error)
+	FindByEmailLike(ctx context.Context, term string, offset, limit int) ([]User, int64, error)
 }
 
 type repository struct {
@@ -52,3 +55,22 @@ func (r *repository) FindAll(ctx context.Context) ([]User, error) {
 	err := r.db.WithContext(ctx).Find(&users).Error
 	return users, err
 }
+
+// FindByEmailLike pages through users whose email contains term, case-insensitively. The
+// password column is never selected so the hash cannot leak into search results.
+func (r *repository) FindByEmailLike(ctx context.Context, term string, offset, limit int) ([]User, int64, error) {
+	var users []User
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&User{})
+	if term != "" {
+		db = db.Where("email ILIKE ?", "%"+utils.EscapeLike(term)+"%")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Omit("password").Order("email asc").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
@@ -45,11 +45,13 @@ func TestRepository_Create(t *testing.T) {
 		user := &User{
 			Email:    "test@example.com",
 			Password: "hashed-password",
+			Role:     RoleUser,
+			Active:   true,
 		}
 
 		mock.ExpectBegin()
-		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("email","password","created_at") VALUES ($1,$2,$3) RETURNING "id"`)).
-			WithArgs(user.Email, user.Password, sqlmock.AnyArg()).
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users" ("email","password","role","email_verified","active","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`)).
+			WithArgs(user.Email, user.Password, user.Role, user.EmailVerified, user.Active, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectCommit()
 
@@ -64,11 +66,13 @@ func TestRepository_Create(t *testing.T) {
 		user := &User{
 			Email:    "test@example.com",
 			Password: "hashed-password",
+			Role:     RoleUser,
+			Active:   true,
 		}
 
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
-			WithArgs(user.Email, user.Password, sqlmock.AnyArg()).
+			WithArgs(user.Email, user.Password, user.Role, user.EmailVerified, user.Active, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnError(errors.New("database error"))
 		mock.ExpectRollback()
 
@@ -179,12 +183,13 @@ func TestRepository_Update(t *testing.T) {
 			ID:        1,
 			Email:     "updated@example.com",
 			Password:  "new-hashed-password",
+			Role:      RoleUser,
 			CreatedAt: time.Now(),
 		}
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "email"=$1,"password"=$2,"created_at"=$3 WHERE "id" = $4`)).
-			WithArgs(user.Email, user.Password, user.CreatedAt, user.ID).
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "email"=$1,"password"=$2,"role"=$3,"email_verified"=$4,"active"=$5,"created_at"=$6,"updated_at"=$7 WHERE "id" = $8`)).
+			WithArgs(user.Email, user.Password, user.Role, user.EmailVerified, user.Active, user.CreatedAt, sqlmock.AnyArg(), user.ID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
@@ -194,17 +199,41 @@ func TestRepository_Update(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("should advance updated_at on every update", func(t *testing.T) {
+		createdAt := time.Now().Add(-time.Hour)
+		user := &User{
+			ID:        1,
+			Email:     "updated@example.com",
+			Password:  "new-hashed-password",
+			Role:      RoleUser,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Update(ctx, user))
+
+		assert.True(t, user.UpdatedAt.After(createdAt))
+		assert.Equal(t, createdAt, user.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("should return error when update fails", func(t *testing.T) {
 		user := &User{
 			ID:        1,
 			Email:     "updated@example.com",
 			Password:  "new-hashed-password",
+			Role:      RoleUser,
 			CreatedAt: time.Now(),
 		}
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users"`)).
-			WithArgs(user.Email, user.Password, user.CreatedAt, user.ID).
+			WithArgs(user.Email, user.Password, user.Role, user.EmailVerified, user.Active, user.CreatedAt, sqlmock.AnyArg(), user.ID).
 			WillReturnError(errors.New("database error"))
 		mock.ExpectRollback()
 
@@ -312,3 +341,77 @@ func TestRepository_FindAll(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestRepository_FindByEmailLike(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should match the email with ILIKE and paginate", func(t *testing.T) {
+		now := time.Now()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE email ILIKE $1`)).
+			WithArgs("%alice%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "users"."id","users"."email","users"."role","users"."email_verified","users"."active","users"."created_at","users"."updated_at" FROM "users" WHERE email ILIKE $1 ORDER BY email asc LIMIT $2 OFFSET $3`)).
+			WithArgs("%alice%", 5, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role", "email_verified", "active", "created_at", "updated_at"}).
+				AddRow(11, "alice.k@example.com", RoleUser, true, true, now, now).
+				AddRow(12, "alice.z@example.com", RoleUser, false, false, now, now))
+
+		users, total, err := repo.FindByEmailLike(ctx, "alice", 10, 5)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), total)
+		require.Len(t, users, 2)
+		assert.Equal(t, "alice.k@example.com", users[0].Email)
+		assert.Empty(t, users[0].Password)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should escape LIKE wildcards in the term", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users" WHERE email ILIKE $1`)).
+			WithArgs(`%a\_b\%%`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "users" WHERE email ILIKE $1 ORDER BY email asc LIMIT $2`)).
+			WithArgs(`%a\_b\%%`, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+		users, total, err := repo.FindByEmailLike(ctx, "a_b%", 0, 10)
+
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		assert.Equal(t, int64(0), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not filter when the term is empty", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "users" ORDER BY email asc LIMIT $1`)).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "a@example.com"))
+
+		users, total, err := repo.FindByEmailLike(ctx, "", 0, 10)
+
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(1), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return error when the count fails", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users"`)).
+			WillReturnError(errors.New("database error"))
+
+		users, total, err := repo.FindByEmailLike(ctx, "alice", 0, 10)
+
+		assert.Error(t, err)
+		assert.Nil(t, users)
+		assert.Zero(t, total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -3,6 +3,9 @@ package auth
 import (
 	"context"
 	"errors"
+	"mini-e-commerce/internal/dto"
+	"mini-e-commerce/internal/tracing"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -14,56 +17,90 @@ import (
 const (
 	MinPasswordLength = 8
 
+	DefaultUserPageSize = 10
+	MaxUserPageSize     = 100
+
 	// Error constants
-	ErrEmailAlreadyExists = "email already exists"
-	ErrUserNotFound       = "user not found"
-	ErrWeakPassword       = "password must be at least 8 characters long"
 	ErrInvalidEmailFormat = "invalid email format"
 	ErrPasswordRequired   = "password is required"
 )
 
+var (
+	ErrEmailAlreadyExists = errors.New("email already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters long and contain a letter and a digit")
+)
+
 type Service interface {
 	RegisterUser(ctx context.Context, input RegisterRequest) (*User, error)
-	LoginUser(ctx context.Context, input LoginRequest) (*AuthResponse, error)
+	LoginUser(ctx context.Context, input LoginRequest, meta SessionMetadata) (*AuthResponse, error)
 	RefreshToken(ctx context.Context, userID uint, sessionID, refreshToken string) (*AuthResponse, error)
 	LogoutUser(ctx context.Context, userID uint, sessionID string) error
+	LogoutAllSessions(ctx context.Context, userID uint) error
+	RevokeAccessToken(ctx context.Context, token string) error
+	ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]SessionInfo, error)
 	GetUserByID(ctx context.Context, id uint) (*User, error)
 	UpdateUser(ctx context.Context, id uint, input UpdateUserRequest) (*User, error)
 	DeleteUser(ctx context.Context, id uint) error
 	GetAllUsers(ctx context.Context) ([]User, error)
+	SearchUsers(ctx context.Context, query UserSearchQuery) (*UserListResponse, error)
+	Impersonate(ctx context.Context, adminID, userID uint) (*ImpersonationResponse, error)
+	DeactivateUser(ctx context.Context, id uint) (*User, error)
+	ActivateUser(ctx context.Context, id uint) (*User, error)
+	ChangePassword(ctx context.Context, userID uint, input ChangePasswordRequest) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, token, newPassword string) error
 }
 
 type service struct {
-	repo           Repository
-	jwtManager     JWTManagerInterface
-	sessionManager SessionManagerInterface
-	validator      *validator.Validate
-	logger         *zap.Logger
-	jwtExpiration  time.Duration
-	refreshExp     time.Duration
+	repo                     Repository
+	jwtManager               JWTManagerInterface
+	sessionManager           SessionManagerInterface
+	tokenManager             TokenManagerInterface
+	notifier                 Notifier
+	validator                *validator.Validate
+	logger                   *zap.Logger
+	jwtExpiration            time.Duration
+	sessionTTL               time.Duration
+	rememberMeExp            time.Duration
+	requireEmailVerification bool
 }
 
-func NewService(repo Repository, jwtManager JWTManagerInterface, sessionManager SessionManagerInterface, logger *zap.Logger, jwtExp, refreshExp time.Duration) Service {
+func NewService(repo Repository, jwtManager JWTManagerInterface, sessionManager SessionManagerInterface, tokenManager TokenManagerInterface, notifier Notifier, logger *zap.Logger, jwtExp, sessionTTL, rememberMeExp time.Duration, requireEmailVerification bool) Service {
 	return &service{
-		repo:           repo,
-		jwtManager:     jwtManager,
-		sessionManager: sessionManager,
-		validator:      validator.New(),
-		logger:         logger,
-		jwtExpiration:  jwtExp,
-		refreshExp:     refreshExp,
+		repo:                     repo,
+		jwtManager:               jwtManager,
+		sessionManager:           sessionManager,
+		tokenManager:             tokenManager,
+		notifier:                 notifier,
+		validator:                validator.New(),
+		logger:                   logger,
+		jwtExpiration:            jwtExp,
+		sessionTTL:               sessionTTL,
+		rememberMeExp:            rememberMeExp,
+		requireEmailVerification: requireEmailVerification,
 	}
 }
 
 func (s *service) RegisterUser(ctx context.Context, input RegisterRequest) (*User, error) {
+	ctx, span := tracing.Start(ctx, "auth.RegisterUser")
+	defer span.End()
+
+	input.Email = NormalizeEmail(input.Email)
 	if err := s.validator.Struct(input); err != nil {
 		return nil, err
 	}
 
+	if err := ValidatePasswordStrength(input.Password); err != nil {
+		return nil, err
+	}
+
 	// Check if email already exists
 	_, err := s.repo.FindByEmail(ctx, input.Email)
 	if err == nil {
-		return nil, errors.New(ErrEmailAlreadyExists)
+		return nil, ErrEmailAlreadyExists
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
@@ -77,16 +114,29 @@ func (s *service) RegisterUser(ctx context.Context, input RegisterRequest) (*Use
 	user := User{
 		Email:    input.Email,
 		Password: hashed,
+		Role:     RoleUser,
+		Active:   true,
 	}
 
 	if err := s.repo.Create(ctx, &user); err != nil {
 		return nil, err
 	}
 
+	if err := s.sendVerificationToken(ctx, &user); err != nil {
+		s.logger.Warn("Failed to send verification token after registration",
+			zap.Error(err),
+			zap.Uint("user_id", user.ID),
+		)
+	}
+
 	return &user, nil
 }
 
-func (s *service) LoginUser(ctx context.Context, input LoginRequest) (*AuthResponse, error) {
+func (s *service) LoginUser(ctx context.Context, input LoginRequest, meta SessionMetadata) (*AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.LoginUser")
+	defer span.End()
+
+	input.Email = NormalizeEmail(input.Email)
 	if err := s.validator.Struct(input); err != nil {
 		s.logger.Warn("Login validation failed", zap.Error(err))
 		return nil, err
@@ -107,7 +157,19 @@ func (s *service) LoginUser(ctx context.Context, input LoginRequest) (*AuthRespo
 		return nil, ErrInvalidCredentials
 	}
 
-	accessToken, err := s.jwtManager.Generate(user.ID)
+	if !user.Active {
+		s.logger.Warn("Login attempt on deactivated account", zap.Uint("user_id", user.ID))
+		return nil, ErrAccountDeactivated
+	}
+
+	if s.requireEmailVerification && !user.EmailVerified {
+		s.logger.Warn("Login attempt with unverified email", zap.Uint("user_id", user.ID))
+		return nil, ErrEmailNotVerified
+	}
+
+	// Taken before signing so it never runs past the expiry inside the token
+	expiresAt := time.Now().Add(s.jwtExpiration)
+	accessToken, err := s.jwtManager.Generate(user.ID, user.Role)
 	if err != nil {
 		s.logger.Error("Failed to generate access token", zap.Error(err), zap.Uint("user_id", user.ID))
 		return nil, err
@@ -115,8 +177,23 @@ func (s *service) LoginUser(ctx context.Context, input LoginRequest) (*AuthRespo
 
 	sessionID := uuid.New().String()
 	refreshToken := uuid.New().String()
+	refreshTTL := s.sessionTTL
+	if input.RememberMe {
+		refreshTTL = s.rememberMeExp
+	}
 
-	if err := s.sessionManager.StoreRefreshToken(ctx, user.ID, sessionID, refreshToken, s.refreshExp); err != nil {
+	if err := s.sessionManager.StoreRefreshToken(ctx, user.ID, sessionID, refreshToken, refreshTTL, meta); err != nil {
+		if s.sessionManager.JWTOnlyFallback() {
+			s.logger.Error("Session store unavailable, issuing access token without session",
+				zap.Error(err),
+				zap.Uint("user_id", user.ID),
+			)
+			return &AuthResponse{
+				User:        user,
+				AccessToken: accessToken,
+				ExpiresAt:   expiresAt,
+			}, nil
+		}
 		s.logger.Error("Failed to store refresh token", zap.Error(err), zap.Uint("user_id", user.ID))
 		return nil, err
 	}
@@ -132,10 +209,15 @@ func (s *service) LoginUser(ctx context.Context, input LoginRequest) (*AuthRespo
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		SessionID:    sessionID,
+		ExpiresAt:    expiresAt,
+		refreshTTL:   refreshTTL,
 	}, nil
 }
 
 func (s *service) RefreshToken(ctx context.Context, userID uint, sessionID, refreshToken string) (*AuthResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.RefreshToken")
+	defer span.End()
+
 	if err := s.sessionManager.ValidateRefreshToken(ctx, userID, sessionID, refreshToken); err != nil {
 		s.logger.Warn("Invalid refresh token attempt",
 			zap.Error(err),
@@ -148,10 +230,15 @@ func (s *service) RefreshToken(ctx context.Context, userID uint, sessionID, refr
 	user, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		s.logger.Error("Failed to find user during token refresh", zap.Error(err), zap.Uint("user_id", userID))
-		return nil, errors.New(ErrUserNotFound)
+		return nil, ErrUserNotFound
+	}
+	if !user.Active {
+		s.logger.Warn("Token refresh on deactivated account", zap.Uint("user_id", userID))
+		return nil, ErrAccountDeactivated
 	}
 
-	newAccessToken, err := s.jwtManager.Generate(user.ID)
+	expiresAt := time.Now().Add(s.jwtExpiration)
+	newAccessToken, err := s.jwtManager.Generate(user.ID, user.Role)
 	if err != nil {
 		s.logger.Error("Failed to generate new access token", zap.Error(err), zap.Uint("user_id", user.ID))
 		return nil, err
@@ -167,11 +254,24 @@ func (s *service) RefreshToken(ctx context.Context, userID uint, sessionID, refr
 		AccessToken:  newAccessToken,
 		RefreshToken: refreshToken,
 		SessionID:    sessionID,
+		ExpiresAt:    expiresAt,
 	}, nil
 }
 
 func (s *service) LogoutUser(ctx context.Context, userID uint, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "auth.LogoutUser")
+	defer span.End()
+
 	if err := s.sessionManager.DeleteRefreshToken(ctx, userID, sessionID); err != nil {
+		if s.sessionManager.JWTOnlyFallback() {
+			// The session expires on its own, clearing the cookies is all we can do for now
+			s.logger.Error("Session store unavailable, logging out without deleting session",
+				zap.Error(err),
+				zap.Uint("user_id", userID),
+				zap.String("session_id", sessionID),
+			)
+			return nil
+		}
 		s.logger.Error("Failed to delete refresh token", zap.Error(err), zap.Uint("user_id", userID))
 		return err
 	}
@@ -180,11 +280,71 @@ func (s *service) LogoutUser(ctx context.Context, userID uint, sessionID string)
 	return nil
 }
 
+func (s *service) LogoutAllSessions(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "auth.LogoutAllSessions")
+	defer span.End()
+
+	if err := s.sessionManager.DeleteAllUserSessions(ctx, userID); err != nil {
+		s.logger.Error("Failed to delete all user sessions", zap.Error(err), zap.Uint("user_id", userID))
+		return err
+	}
+
+	s.logger.Info("User logged out from all sessions", zap.Uint("user_id", userID))
+	return nil
+}
+
+// RevokeAccessToken denylists the given access token for the rest of its lifetime, which
+// runs until Verify stops accepting it at exp plus the leeway. Tokens that already expired
+// or were issued without a jti are left alone.
+func (s *service) RevokeAccessToken(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "auth.RevokeAccessToken")
+	defer span.End()
+
+	claims, err := s.jwtManager.Verify(token)
+	if err != nil {
+		if errors.Is(err, ErrExpiredToken) {
+			return nil
+		}
+		return err
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	if err := s.sessionManager.RevokeAccessToken(ctx, claims.ID, time.Until(claims.ExpiresAt.Time)+s.jwtManager.Leeway()); err != nil {
+		s.logger.Error("Failed to revoke access token", zap.Error(err), zap.Uint("user_id", claims.UserID))
+		return err
+	}
+
+	s.logger.Info("Access token revoked", zap.Uint("user_id", claims.UserID))
+	return nil
+}
+
+func (s *service) ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]SessionInfo, error) {
+	ctx, span := tracing.Start(ctx, "auth.ListSessions")
+	defer span.End()
+
+	sessions, err := s.sessionManager.ListSessions(ctx, userID)
+	if err != nil {
+		s.logger.Error("Failed to list user sessions", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
 func (s *service) GetUserByID(ctx context.Context, id uint) (*User, error) {
+	ctx, span := tracing.Start(ctx, "auth.GetUserByID")
+	defer span.End()
+
 	user, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New(ErrUserNotFound)
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -192,6 +352,13 @@ func (s *service) GetUserByID(ctx context.Context, id uint) (*User, error) {
 }
 
 func (s *service) UpdateUser(ctx context.Context, id uint, input UpdateUserRequest) (*User, error) {
+	ctx, span := tracing.Start(ctx, "auth.UpdateUser")
+	defer span.End()
+
+	if input.Email != nil {
+		email := NormalizeEmail(*input.Email)
+		input.Email = &email
+	}
 	if err := s.validator.Struct(input); err != nil {
 		return nil, err
 	}
@@ -199,7 +366,7 @@ func (s *service) UpdateUser(ctx context.Context, id uint, input UpdateUserReque
 	user, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New(ErrUserNotFound)
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -208,7 +375,7 @@ func (s *service) UpdateUser(ctx context.Context, id uint, input UpdateUserReque
 		// Check if new email already exists
 		_, err := s.repo.FindByEmail(ctx, *input.Email)
 		if err == nil {
-			return nil, errors.New(ErrEmailAlreadyExists)
+			return nil, ErrEmailAlreadyExists
 		}
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
@@ -224,17 +391,363 @@ func (s *service) UpdateUser(ctx context.Context, id uint, input UpdateUserReque
 }
 
 func (s *service) DeleteUser(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "auth.DeleteUser")
+	defer span.End()
+
 	_, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New(ErrUserNotFound)
+			return ErrUserNotFound
 		}
 		return err
 	}
 
+	// Revoke sessions first so a failure leaves the account intact and the delete can be retried
+	if err := s.sessionManager.DeleteAllUserSessions(ctx, id); err != nil {
+		s.logger.Error("Failed to invalidate sessions before account deletion", zap.Error(err), zap.Uint("user_id", id))
+		return err
+	}
+
 	return s.repo.Delete(ctx, id)
 }
 
 func (s *service) GetAllUsers(ctx context.Context) ([]User, error) {
+	ctx, span := tracing.Start(ctx, "auth.GetAllUsers")
+	defer span.End()
+
 	return s.repo.FindAll(ctx)
 }
+
+// SearchUsers looks users up by part of their email for support staff
+func (s *service) SearchUsers(ctx context.Context, query UserSearchQuery) (*UserListResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.SearchUsers")
+	defer span.End()
+
+	page := query.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultUserPageSize
+	}
+	if pageSize > MaxUserPageSize {
+		pageSize = MaxUserPageSize
+	}
+
+	users, total, err := s.repo.FindByEmailLike(ctx, strings.TrimSpace(query.Q), (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserListResponse{
+		Data: users,
+		Pagination: dto.PaginationMetadata{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		},
+	}, nil
+}
+
+// DeactivateUser suspends the account without deleting it, so its orders and reviews are
+// kept. Its sessions are revoked and the auth middleware rejects its existing access tokens
+// straight away, including impersonation tokens it issued as an admin.
+func (s *service) DeactivateUser(ctx context.Context, id uint) (*User, error) {
+	ctx, span := tracing.Start(ctx, "auth.DeactivateUser")
+	defer span.End()
+
+	user, err := s.setActive(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The auth middleware rejects the sessions of inactive users anyway, so a failure
+	// here only leaves dead keys in Redis until they expire
+	if err := s.sessionManager.DeleteAllUserSessions(ctx, id); err != nil {
+		s.logger.Warn("Failed to revoke sessions of deactivated user", zap.Error(err), zap.Uint("user_id", id))
+	}
+
+	s.logger.Info("User deactivated", zap.Uint("user_id", id))
+	return user, nil
+}
+
+func (s *service) ActivateUser(ctx context.Context, id uint) (*User, error) {
+	ctx, span := tracing.Start(ctx, "auth.ActivateUser")
+	defer span.End()
+
+	user, err := s.setActive(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("User activated", zap.Uint("user_id", id))
+	return user, nil
+}
+
+func (s *service) setActive(ctx context.Context, id uint, active bool) (*User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if !active && user.Role == RoleAdmin {
+		return nil, ErrCannotDeactivate
+	}
+	if user.Active == active {
+		return &user, nil
+	}
+
+	user.Active = active
+	if err := s.repo.Update(ctx, &user); err != nil {
+		s.logger.Error("Failed to update user active flag", zap.Error(err), zap.Uint("user_id", id))
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Impersonate mints a token that lets adminID act as userID for ImpersonationTokenTTL.
+// Other admins cannot be impersonated, so the token never grants more than a user has.
+func (s *service) Impersonate(ctx context.Context, adminID, userID uint) (*ImpersonationResponse, error) {
+	ctx, span := tracing.Start(ctx, "auth.Impersonate")
+	defer span.End()
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.Role == RoleAdmin {
+		return nil, ErrCannotImpersonate
+	}
+	if !user.Active {
+		return nil, ErrAccountDeactivated
+	}
+
+	expiresAt := time.Now().Add(ImpersonationTokenTTL)
+	accessToken, err := s.jwtManager.GenerateImpersonation(user.ID, user.Role, adminID, ImpersonationTokenTTL)
+	if err != nil {
+		s.logger.Error("Failed to generate impersonation token", zap.Error(err), zap.Uint("user_id", userID))
+		return nil, err
+	}
+
+	s.logger.Info("Impersonation token issued",
+		zap.Uint("user_id", userID),
+		zap.Uint("impersonator_id", adminID),
+	)
+	return &ImpersonationResponse{
+		User:        user,
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func (s *service) ChangePassword(ctx context.Context, userID uint, input ChangePasswordRequest) error {
+	ctx, span := tracing.Start(ctx, "auth.ChangePassword")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return err
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if !CheckPassword(user.Password, input.OldPassword) {
+		s.logger.Warn("Invalid old password on password change", zap.Uint("user_id", userID))
+		return ErrInvalidOldPassword
+	}
+
+	if err := ValidatePasswordStrength(input.NewPassword); err != nil {
+		return err
+	}
+
+	hashed, err := HashPassword(input.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashed
+	if err := s.repo.Update(ctx, &user); err != nil {
+		s.logger.Error("Failed to update password", zap.Error(err), zap.Uint("user_id", userID))
+		return err
+	}
+
+	s.logger.Info("User password changed successfully", zap.Uint("user_id", userID))
+	return nil
+}
+
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "auth.VerifyEmail")
+	defer span.End()
+
+	userID, err := s.tokenManager.ConsumeToken(ctx, TokenPurposeVerify, token)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return ErrInvalidVerifyToken
+		}
+		return err
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	user.EmailVerified = true
+	if err := s.repo.Update(ctx, &user); err != nil {
+		s.logger.Error("Failed to mark email as verified", zap.Error(err), zap.Uint("user_id", userID))
+		return err
+	}
+
+	s.logger.Info("User email verified successfully", zap.Uint("user_id", userID))
+	return nil
+}
+
+// ResendVerification sends a fresh verification token to the unverified user registered
+// with email, at most once per VerificationResendInterval. Earlier tokens stay valid until
+// they expire. It is called without a session, since unverified users cannot log in, so
+// every outcome past the lookup reports success to avoid revealing registered emails.
+func (s *service) ResendVerification(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "auth.ResendVerification")
+	defer span.End()
+
+	email = NormalizeEmail(email)
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Info("Verification resend requested for unknown email", zap.String("email", email))
+			return nil
+		}
+		s.logger.Error("Failed to find user by email", zap.Error(err))
+		return err
+	}
+
+	if user.EmailVerified {
+		s.logger.Info("Verification resend requested for verified email", zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	allowed, err := s.tokenManager.Throttle(ctx, TokenPurposeVerify, user.ID, VerificationResendInterval)
+	if err != nil {
+		s.logger.Error("Failed to throttle verification resend", zap.Error(err), zap.Uint("user_id", user.ID))
+		return nil
+	}
+	if !allowed {
+		s.logger.Info("Verification resend throttled", zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	if err := s.sendVerificationToken(ctx, &user); err != nil {
+		s.logger.Error("Failed to resend verification email", zap.Error(err), zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	s.logger.Info("Verification email resent", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// RequestPasswordReset emails a reset token to the user registered with email. Every outcome
+// past the lookup reports success, a failure only for registered emails would reveal them.
+func (s *service) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "auth.RequestPasswordReset")
+	defer span.End()
+
+	email = NormalizeEmail(email)
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Report success regardless so the endpoint cannot be used to probe registered emails
+			s.logger.Info("Password reset requested for unknown email", zap.String("email", email))
+			return nil
+		}
+		s.logger.Error("Failed to find user by email", zap.Error(err))
+		return err
+	}
+
+	token := uuid.New().String()
+	if err := s.tokenManager.StoreToken(ctx, TokenPurposeReset, token, user.ID, PasswordResetTokenTTL); err != nil {
+		s.logger.Error("Failed to store password reset token", zap.Error(err), zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	if err := s.notifier.SendPasswordResetEmail(ctx, user.Email, token); err != nil {
+		s.logger.Error("Failed to send password reset email", zap.Error(err), zap.Uint("user_id", user.ID))
+		return nil
+	}
+
+	s.logger.Info("Password reset requested", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+func (s *service) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "auth.ConfirmPasswordReset")
+	defer span.End()
+
+	if err := ValidatePasswordStrength(newPassword); err != nil {
+		return err
+	}
+
+	userID, err := s.tokenManager.ConsumeToken(ctx, TokenPurposeReset, token)
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	hashed, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashed
+	if err := s.repo.Update(ctx, &user); err != nil {
+		s.logger.Error("Failed to reset password", zap.Error(err), zap.Uint("user_id", userID))
+		return err
+	}
+
+	if err := s.sessionManager.DeleteAllUserSessions(ctx, userID); err != nil {
+		s.logger.Error("Failed to invalidate sessions after password reset", zap.Error(err), zap.Uint("user_id", userID))
+		return err
+	}
+
+	s.logger.Info("User password reset successfully", zap.Uint("user_id", userID))
+	return nil
+}
+
+// Helpers
+func (s *service) sendVerificationToken(ctx context.Context, user *User) error {
+	token := uuid.New().String()
+	if err := s.tokenManager.StoreToken(ctx, TokenPurposeVerify, token, user.ID, VerificationTokenTTL); err != nil {
+		return err
+	}
+	return s.notifier.SendVerificationEmail(ctx, user.Email, token)
+}
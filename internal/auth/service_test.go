@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -49,15 +51,32 @@ func (m *MockRepository) FindAll(ctx context.Context) ([]User, error) {
 	return args.Get(0).([]User), args.Error(1)
 }
 
+func (m *MockRepository) FindByEmailLike(ctx context.Context, term string, offset, limit int) ([]User, int64, error) {
+	args := m.Called(ctx, term, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]User), args.Get(1).(int64), args.Error(2)
+}
+
 type MockJWTManager struct {
 	mock.Mock
 }
 
-func (m *MockJWTManager) Generate(userID uint) (string, error) {
-	args := m.Called(userID)
+func (m *MockJWTManager) Generate(userID uint, role string) (string, error) {
+	args := m.Called(userID, role)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockJWTManager) GenerateImpersonation(userID uint, role string, actorID uint, duration time.Duration) (string, error) {
+	args := m.Called(userID, role, actorID, duration)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTManager) Leeway() time.Duration {
+	return 0
+}
+
 func (m *MockJWTManager) Verify(tokenStr string) (*UserClaims, error) {
 	args := m.Called(tokenStr)
 	if args.Get(0) == nil {
@@ -66,12 +85,50 @@ func (m *MockJWTManager) Verify(tokenStr string) (*UserClaims, error) {
 	return args.Get(0).(*UserClaims), args.Error(1)
 }
 
+type MockTokenManager struct {
+	mock.Mock
+}
+
+func (m *MockTokenManager) StoreToken(ctx context.Context, purpose, token string, userID uint, ttl time.Duration) error {
+	args := m.Called(ctx, purpose, token, userID, ttl)
+	return args.Error(0)
+}
+
+func (m *MockTokenManager) ConsumeToken(ctx context.Context, purpose, token string) (uint, error) {
+	args := m.Called(ctx, purpose, token)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func (m *MockTokenManager) Throttle(ctx context.Context, purpose string, userID uint, window time.Duration) (bool, error) {
+	args := m.Called(ctx, purpose, userID, window)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) SendVerificationEmail(ctx context.Context, email, token string) error {
+	args := m.Called(ctx, email, token)
+	return args.Error(0)
+}
+
+func (m *MockNotifier) SendPasswordResetEmail(ctx context.Context, email, token string) error {
+	args := m.Called(ctx, email, token)
+	return args.Error(0)
+}
+
+func (m *MockNotifier) SendBackInStockEmail(ctx context.Context, email, productName string) error {
+	args := m.Called(ctx, email, productName)
+	return args.Error(0)
+}
+
 type MockSessionManager struct {
 	mock.Mock
 }
 
-func (m *MockSessionManager) StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration) error {
-	args := m.Called(ctx, userID, sessionID, token, ttl)
+func (m *MockSessionManager) StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration, meta SessionMetadata) error {
+	args := m.Called(ctx, userID, sessionID, token, ttl, meta)
 	return args.Error(0)
 }
 
@@ -85,36 +142,90 @@ func (m *MockSessionManager) DeleteRefreshToken(ctx context.Context, userID uint
 	return args.Error(0)
 }
 
+func (m *MockSessionManager) DeleteAllUserSessions(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionManager) ListSessions(ctx context.Context, userID uint) ([]SessionInfo, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SessionInfo), args.Error(1)
+}
+
+func (m *MockSessionManager) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	args := m.Called(ctx, jti, ttl)
+	return args.Error(0)
+}
+
+func (m *MockSessionManager) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockSessionManager) GetSessionKey(userID uint, sessionID string) string {
 	args := m.Called(userID, sessionID)
 	return args.String(0)
 }
 
+func (m *MockSessionManager) JWTOnlyFallback() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
 func TestService_RegisterUser(t *testing.T) {
 	ctx := context.Background()
 
+	t.Run("should reject an email already registered with different case", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		mockRepo.On("FindByEmail", ctx, "user@example.com").Return(User{ID: 1, Email: "user@example.com"}, nil)
+
+		user, err := service.RegisterUser(ctx, RegisterRequest{Email: "User@Example.com", Password: "password123"})
+
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, ErrEmailAlreadyExists)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("should register user successfully", func(t *testing.T) {
 		mockRepo := new(MockRepository)
 		mockJWT := new(MockJWTManager)
 		mockSession := new(MockSessionManager)
+		mockToken := new(MockTokenManager)
+		mockNotifier := new(MockNotifier)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, mockToken, mockNotifier, logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		input := RegisterRequest{
 			Email:    "test@example.com",
 			Password: "password123",
 		}
 
+		var generatedToken string
 		mockRepo.On("FindByEmail", ctx, input.Email).Return(User{}, gorm.ErrRecordNotFound)
 		mockRepo.On("Create", ctx, mock.AnythingOfType("*auth.User")).Return(nil)
+		mockToken.On("StoreToken", ctx, TokenPurposeVerify, mock.AnythingOfType("string"), mock.AnythingOfType("uint"), VerificationTokenTTL).
+			Run(func(args mock.Arguments) { generatedToken = args.String(2) }).
+			Return(nil)
+		mockNotifier.On("SendVerificationEmail", ctx, input.Email, mock.AnythingOfType("string")).Return(nil)
 
 		user, err := service.RegisterUser(ctx, input)
 
 		require.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, input.Email, user.Email)
+		assert.False(t, user.EmailVerified)
+		assert.NotEmpty(t, generatedToken)
+		mockNotifier.AssertCalled(t, "SendVerificationEmail", ctx, input.Email, generatedToken)
 		mockRepo.AssertExpectations(t)
+		mockToken.AssertExpectations(t)
 	})
 
 	t.Run("should return error when email already exists", func(t *testing.T) {
@@ -123,7 +234,7 @@ func TestService_RegisterUser(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		input := RegisterRequest{
 			Email:    "existing@example.com",
@@ -137,9 +248,49 @@ func TestService_RegisterUser(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
-		assert.Equal(t, ErrEmailAlreadyExists, err.Error())
+		assert.ErrorIs(t, err, ErrEmailAlreadyExists)
 		mockRepo.AssertExpectations(t)
 	})
+	t.Run("should return error for password shorter than minimum length", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		input := RegisterRequest{
+			Email:    "test@example.com",
+			Password: "pass123",
+		}
+
+		user, err := service.RegisterUser(ctx, input)
+
+		assert.Error(t, err)
+		assert.Nil(t, user)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return error for whitespace-only password", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		input := RegisterRequest{
+			Email:    "test@example.com",
+			Password: "        ",
+		}
+
+		user, err := service.RegisterUser(ctx, input)
+
+		assert.Error(t, err)
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestService_LoginUser(t *testing.T) {
@@ -151,13 +302,14 @@ func TestService_LoginUser(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		hashedPassword, _ := HashPassword("password123")
 		user := User{
 			ID:       1,
 			Email:    "test@example.com",
 			Password: hashedPassword,
+			Active:   true,
 		}
 
 		input := LoginRequest{
@@ -166,10 +318,10 @@ func TestService_LoginUser(t *testing.T) {
 		}
 
 		mockRepo.On("FindByEmail", ctx, input.Email).Return(user, nil)
-		mockJWT.On("Generate", user.ID).Return("access-token", nil)
-		mockSession.On("StoreRefreshToken", ctx, user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration")).Return(nil)
+		mockJWT.On("Generate", user.ID, user.Role).Return("access-token", nil)
+		mockSession.On("StoreRefreshToken", ctx, user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration"), mock.AnythingOfType("auth.SessionMetadata")).Return(nil)
 
-		authResp, err := service.LoginUser(ctx, input)
+		authResp, err := service.LoginUser(ctx, input, SessionMetadata{})
 
 		require.NoError(t, err)
 		assert.NotNil(t, authResp)
@@ -182,13 +334,121 @@ func TestService_LoginUser(t *testing.T) {
 		mockSession.AssertExpectations(t)
 	})
 
+	t.Run("should expire the stored session after the configured session TTL", func(t *testing.T) {
+		client, mr := setupTestRedis(t)
+		defer mr.Close()
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		logger := zap.NewNop()
+		sessionTTL := 36 * time.Hour
+		sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
+
+		service := NewService(mockRepo, mockJWT, sessionManager, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, sessionTTL, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword, Active: true}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockJWT.On("Generate", user.ID, user.Role).Return("access-token", nil)
+
+		authResp, err := service.LoginUser(ctx, LoginRequest{Email: user.Email, Password: "password123"}, SessionMetadata{})
+
+		require.NoError(t, err)
+		assert.Equal(t, sessionTTL, mr.TTL(sessionManager.GetSessionKey(user.ID, authResp.SessionID)))
+		assert.Equal(t, sessionTTL, authResp.refreshTTL)
+	})
+
+	t.Run("should store the session for the remember me lifetime", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword, Active: true}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockJWT.On("Generate", user.ID, user.Role).Return("access-token", nil)
+		mockSession.On("StoreRefreshToken", ctx, user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string"), 30*24*time.Hour, mock.AnythingOfType("auth.SessionMetadata")).Return(nil)
+
+		authResp, err := service.LoginUser(ctx, LoginRequest{Email: user.Email, Password: "password123", RememberMe: true}, SessionMetadata{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 30*24*time.Hour, authResp.refreshTTL)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), authResp.ExpiresAt, 5*time.Second)
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("should match the registered email regardless of case", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword, Active: true}
+
+		mockRepo.On("FindByEmail", ctx, "test@example.com").Return(user, nil)
+		mockJWT.On("Generate", user.ID, user.Role).Return("access-token", nil)
+		mockSession.On("StoreRefreshToken", ctx, user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration"), mock.AnythingOfType("auth.SessionMetadata")).Return(nil)
+
+		authResp, err := service.LoginUser(ctx, LoginRequest{Email: " Test@Example.COM ", Password: "password123"}, SessionMetadata{})
+
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, authResp.User.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	for _, tc := range []struct {
+		name         string
+		allowJWTOnly bool
+	}{
+		{name: "should issue access token only when session store is down and fallback is allowed", allowJWTOnly: true},
+		{name: "should fail login when session store is down and fallback is denied", allowJWTOnly: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockJWT := new(MockJWTManager)
+			mockSession := new(MockSessionManager)
+			logger := zap.NewNop()
+
+			service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+			hashedPassword, _ := HashPassword("password123")
+			user := User{ID: 1, Email: "test@example.com", Password: hashedPassword, Active: true}
+			input := LoginRequest{Email: "test@example.com", Password: "password123"}
+
+			mockRepo.On("FindByEmail", ctx, input.Email).Return(user, nil)
+			mockJWT.On("Generate", user.ID, user.Role).Return("access-token", nil)
+			mockSession.On("StoreRefreshToken", ctx, user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration"), mock.AnythingOfType("auth.SessionMetadata")).Return(ErrSessionStoreUnavailable)
+			mockSession.On("JWTOnlyFallback").Return(tc.allowJWTOnly)
+
+			authResp, err := service.LoginUser(ctx, input, SessionMetadata{})
+
+			if tc.allowJWTOnly {
+				require.NoError(t, err)
+				assert.Equal(t, "access-token", authResp.AccessToken)
+				assert.Empty(t, authResp.RefreshToken)
+				assert.Empty(t, authResp.SessionID)
+			} else {
+				assert.ErrorIs(t, err, ErrSessionStoreUnavailable)
+				assert.Nil(t, authResp)
+			}
+			mockSession.AssertExpectations(t)
+		})
+	}
+
 	t.Run("should return error for non-existent user", func(t *testing.T) {
 		mockRepo := new(MockRepository)
 		mockJWT := new(MockJWTManager)
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		input := LoginRequest{
 			Email:    "nonexistent@example.com",
@@ -197,7 +457,7 @@ func TestService_LoginUser(t *testing.T) {
 
 		mockRepo.On("FindByEmail", ctx, input.Email).Return(User{}, gorm.ErrRecordNotFound)
 
-		authResp, err := service.LoginUser(ctx, input)
+		authResp, err := service.LoginUser(ctx, input, SessionMetadata{})
 
 		assert.Error(t, err)
 		assert.Nil(t, authResp)
@@ -211,13 +471,14 @@ func TestService_LoginUser(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		hashedPassword, _ := HashPassword("correct-password")
 		user := User{
 			ID:       1,
 			Email:    "test@example.com",
 			Password: hashedPassword,
+			Active:   true,
 		}
 
 		input := LoginRequest{
@@ -227,7 +488,7 @@ func TestService_LoginUser(t *testing.T) {
 
 		mockRepo.On("FindByEmail", ctx, input.Email).Return(user, nil)
 
-		authResp, err := service.LoginUser(ctx, input)
+		authResp, err := service.LoginUser(ctx, input, SessionMetadata{})
 
 		assert.Error(t, err)
 		assert.Nil(t, authResp)
@@ -236,6 +497,55 @@ func TestService_LoginUser(t *testing.T) {
 	})
 }
 
+func TestService_LoginUser_Deactivated(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reject a deactivated account with the right password", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword, Active: false}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+		authResp, err := service.LoginUser(ctx, LoginRequest{Email: user.Email, Password: "password123"}, SessionMetadata{})
+
+		assert.ErrorIs(t, err, ErrAccountDeactivated)
+		assert.Nil(t, authResp)
+		mockJWT.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+		mockSession.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_LoginUser_RequireEmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reject unverified user when verification is required", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, true)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword, Active: true}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+		authResp, err := service.LoginUser(ctx, LoginRequest{Email: user.Email, Password: "password123"}, SessionMetadata{})
+
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+		assert.Nil(t, authResp)
+		mockJWT.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+	})
+}
+
 func TestService_RefreshToken(t *testing.T) {
 	ctx := context.Background()
 
@@ -245,26 +555,28 @@ func TestService_RefreshToken(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		userID := uint(1)
 		sessionID := "session-123"
 		refreshToken := "refresh-token"
 
 		user := User{
-			ID:    userID,
-			Email: "test@example.com",
+			ID:     userID,
+			Email:  "test@example.com",
+			Active: true,
 		}
 
 		mockSession.On("ValidateRefreshToken", ctx, userID, sessionID, refreshToken).Return(nil)
 		mockRepo.On("FindByID", ctx, userID).Return(user, nil)
-		mockJWT.On("Generate", userID).Return("new-access-token", nil)
+		mockJWT.On("Generate", userID, user.Role).Return("new-access-token", nil)
 
 		authResp, err := service.RefreshToken(ctx, userID, sessionID, refreshToken)
 
 		require.NoError(t, err)
 		assert.NotNil(t, authResp)
 		assert.Equal(t, "new-access-token", authResp.AccessToken)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), authResp.ExpiresAt, 5*time.Second)
 		mockSession.AssertExpectations(t)
 		mockRepo.AssertExpectations(t)
 		mockJWT.AssertExpectations(t)
@@ -280,7 +592,7 @@ func TestService_LogoutUser(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		userID := uint(1)
 		sessionID := "session-123"
@@ -294,6 +606,154 @@ func TestService_LogoutUser(t *testing.T) {
 	})
 }
 
+func TestService_LogoutAllSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should logout all sessions successfully", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		userID := uint(1)
+
+		mockSession.On("DeleteAllUserSessions", ctx, userID).Return(nil)
+
+		err := service.LogoutAllSessions(ctx, userID)
+
+		require.NoError(t, err)
+		mockSession.AssertExpectations(t)
+	})
+}
+
+func TestService_RevokeAccessToken(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager("test-secret", testIssuer, testAudience, time.Hour, 0, logger)
+
+	t.Run("should denylist token jti for its remaining lifetime", func(t *testing.T) {
+		mockSession := new(MockSessionManager)
+		service := NewService(new(MockRepository), jwtManager, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		token, err := jwtManager.Generate(1, RoleUser)
+		require.NoError(t, err)
+		claims, err := jwtManager.Verify(token)
+		require.NoError(t, err)
+
+		mockSession.On("RevokeAccessToken", mock.Anything, claims.ID, mock.MatchedBy(func(ttl time.Duration) bool {
+			return ttl > 0 && ttl <= time.Hour
+		})).Return(nil)
+
+		require.NoError(t, service.RevokeAccessToken(ctx, token))
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("should denylist a token inside its leeway window until the leeway ends", func(t *testing.T) {
+		mockSession := new(MockSessionManager)
+		leewayManager := NewJWTManager("test-secret", testIssuer, testAudience, -10*time.Second, 30*time.Second, logger)
+		service := NewService(new(MockRepository), leewayManager, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		token, err := leewayManager.Generate(1, RoleUser)
+		require.NoError(t, err)
+		claims, err := leewayManager.Verify(token)
+		require.NoError(t, err, "a token 10s past exp is still accepted with 30s leeway")
+
+		mockSession.On("RevokeAccessToken", mock.Anything, claims.ID, mock.MatchedBy(func(ttl time.Duration) bool {
+			return ttl > 15*time.Second && ttl <= 20*time.Second
+		})).Return(nil)
+
+		require.NoError(t, service.RevokeAccessToken(ctx, token))
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("should skip expired token", func(t *testing.T) {
+		mockSession := new(MockSessionManager)
+		expiredManager := NewJWTManager("test-secret", testIssuer, testAudience, -time.Minute, 0, logger)
+		service := NewService(new(MockRepository), expiredManager, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		token, err := expiredManager.Generate(1, RoleUser)
+		require.NoError(t, err)
+
+		require.NoError(t, service.RevokeAccessToken(ctx, token))
+		mockSession.AssertNotCalled(t, "RevokeAccessToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return error for invalid token", func(t *testing.T) {
+		service := NewService(new(MockRepository), jwtManager, new(MockSessionManager), new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		err := service.RevokeAccessToken(ctx, "not-a-token")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestService_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should revoke all sessions before deleting the user", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(User{ID: 1}, nil)
+		mockSession.On("DeleteAllUserSessions", ctx, uint(1)).Return(nil)
+		mockRepo.On("Delete", ctx, uint(1)).Return(nil)
+
+		err := service.DeleteUser(ctx, 1)
+
+		require.NoError(t, err)
+		mockSession.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should keep the user when sessions cannot be revoked", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(User{ID: 1}, nil)
+		mockSession.On("DeleteAllUserSessions", ctx, uint(1)).Return(ErrSessionStoreFailed)
+
+		err := service.DeleteUser(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrSessionStoreFailed)
+		mockRepo.AssertNotCalled(t, "Delete", ctx, uint(1))
+	})
+}
+
+func TestService_ListSessions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should flag the current session", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		userID := uint(1)
+		mockSession.On("ListSessions", ctx, userID).Return([]SessionInfo{
+			{SessionID: "session-1"},
+			{SessionID: "session-2"},
+		}, nil)
+
+		sessions, err := service.ListSessions(ctx, userID, "session-2")
+
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current)
+		mockSession.AssertExpectations(t)
+	})
+}
+
 func TestService_GetUserByID(t *testing.T) {
 	ctx := context.Background()
 
@@ -303,7 +763,7 @@ func TestService_GetUserByID(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		userID := uint(1)
 		expectedUser := User{
@@ -327,7 +787,7 @@ func TestService_GetUserByID(t *testing.T) {
 		mockSession := new(MockSessionManager)
 		logger := zap.NewNop()
 
-		service := NewService(mockRepo, mockJWT, mockSession, logger, time.Hour, 7*24*time.Hour)
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
 
 		userID := uint(999)
 
@@ -337,7 +797,529 @@ func TestService_GetUserByID(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
-		assert.Equal(t, ErrUserNotFound, err.Error())
+		assert.ErrorIs(t, err, ErrUserNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should change password successfully", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword}
+
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(u *User) bool {
+			return CheckPassword(u.Password, "newpassword123")
+		})).Return(nil)
+
+		err := service.ChangePassword(ctx, user.ID, ChangePasswordRequest{
+			OldPassword: "password123",
+			NewPassword: "newpassword123",
+		})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should return error for wrong old password", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword}
+
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+
+		err := service.ChangePassword(ctx, user.ID, ChangePasswordRequest{
+			OldPassword: "wrong-password",
+			NewPassword: "newpassword123",
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidOldPassword)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return error for too weak new password", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		mockSession := new(MockSessionManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, mockJWT, mockSession, new(MockTokenManager), NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		hashedPassword, _ := HashPassword("password123")
+		user := User{ID: 1, Email: "test@example.com", Password: hashedPassword}
+
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+
+		err := service.ChangePassword(ctx, user.ID, ChangePasswordRequest{
+			OldPassword: "password123",
+			NewPassword: "short",
+		})
+
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ResendVerification(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (Service, *MockRepository, TokenManagerInterface, *MockNotifier, *miniredis.Miniredis) {
+		client, mr := setupTestRedis(t)
+		t.Cleanup(mr.Close)
+		logger := zap.NewNop()
+		mockRepo := new(MockRepository)
+		mockNotifier := new(MockNotifier)
+		tokenManager := NewTokenManager(client, logger)
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), tokenManager, mockNotifier, logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+		return service, mockRepo, tokenManager, mockNotifier, mr
+	}
+
+	t.Run("should send a new token that verifies the email", func(t *testing.T) {
+		service, mockRepo, tokenManager, mockNotifier, _ := setup(t)
+		user := User{ID: 1, Email: "test@example.com"}
+
+		var sentToken string
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockNotifier.On("SendVerificationEmail", ctx, user.Email, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { sentToken = args.String(2) }).
+			Return(nil).Once()
+
+		require.NoError(t, service.ResendVerification(ctx, "Test@Example.com"))
+
+		userID, err := tokenManager.ConsumeToken(ctx, TokenPurposeVerify, sentToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("should allow one resend per window", func(t *testing.T) {
+		service, mockRepo, _, mockNotifier, _ := setup(t)
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockNotifier.On("SendVerificationEmail", ctx, user.Email, mock.AnythingOfType("string")).Return(nil)
+
+		require.NoError(t, service.ResendVerification(ctx, user.Email))
+		require.NoError(t, service.ResendVerification(ctx, user.Email))
+		mockNotifier.AssertNumberOfCalls(t, "SendVerificationEmail", 1)
+	})
+
+	t.Run("should allow another resend once the window passed", func(t *testing.T) {
+		service, mockRepo, _, mockNotifier, mr := setup(t)
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockNotifier.On("SendVerificationEmail", ctx, user.Email, mock.AnythingOfType("string")).Return(nil)
+
+		require.NoError(t, service.ResendVerification(ctx, user.Email))
+		mr.FastForward(VerificationResendInterval)
+		require.NoError(t, service.ResendVerification(ctx, user.Email))
+		mockNotifier.AssertNumberOfCalls(t, "SendVerificationEmail", 2)
+	})
+
+	t.Run("should send nothing to a verified or unknown email", func(t *testing.T) {
+		service, mockRepo, _, mockNotifier, _ := setup(t)
+
+		mockRepo.On("FindByEmail", ctx, "verified@example.com").Return(User{ID: 1, Email: "verified@example.com", EmailVerified: true}, nil)
+		mockRepo.On("FindByEmail", ctx, "unknown@example.com").Return(User{}, gorm.ErrRecordNotFound)
+
+		assert.NoError(t, service.ResendVerification(ctx, "verified@example.com"))
+		assert.NoError(t, service.ResendVerification(ctx, "unknown@example.com"))
+		mockNotifier.AssertNotCalled(t, "SendVerificationEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Impersonate(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+
+	setup := func() (Service, *MockRepository, *MockJWTManager) {
+		mockRepo := new(MockRepository)
+		mockJWT := new(MockJWTManager)
+		service := NewService(mockRepo, mockJWT, new(MockSessionManager), new(MockTokenManager), new(MockNotifier), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+		return service, mockRepo, mockJWT
+	}
+
+	t.Run("should issue a short-lived token acting as the user", func(t *testing.T) {
+		service, mockRepo, mockJWT := setup()
+		user := User{ID: 7, Email: "user@example.com", Role: RoleUser, Active: true}
+
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		mockJWT.On("GenerateImpersonation", user.ID, RoleUser, uint(1), ImpersonationTokenTTL).Return("impersonation-token", nil)
+
+		result, err := service.Impersonate(ctx, 1, user.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "impersonation-token", result.AccessToken)
+		assert.Equal(t, user.ID, result.User.ID)
+		assert.WithinDuration(t, time.Now().Add(ImpersonationTokenTTL), result.ExpiresAt, 5*time.Second)
+		mockJWT.AssertExpectations(t)
+	})
+
+	t.Run("should refuse to impersonate an admin", func(t *testing.T) {
+		service, mockRepo, mockJWT := setup()
+
+		mockRepo.On("FindByID", ctx, uint(2)).Return(User{ID: 2, Role: RoleAdmin}, nil)
+
+		_, err := service.Impersonate(ctx, 1, 2)
+
+		assert.ErrorIs(t, err, ErrCannotImpersonate)
+		mockJWT.AssertNotCalled(t, "GenerateImpersonation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should refuse to impersonate a deactivated user", func(t *testing.T) {
+		service, mockRepo, mockJWT := setup()
+
+		mockRepo.On("FindByID", ctx, uint(7)).Return(User{ID: 7, Role: RoleUser, Active: false}, nil)
+
+		_, err := service.Impersonate(ctx, 1, 7)
+
+		assert.ErrorIs(t, err, ErrAccountDeactivated)
+		mockJWT.AssertNotCalled(t, "GenerateImpersonation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found for an unknown user", func(t *testing.T) {
+		service, mockRepo, _ := setup()
+
+		mockRepo.On("FindByID", ctx, uint(99)).Return(User{}, gorm.ErrRecordNotFound)
+
+		_, err := service.Impersonate(ctx, 1, 99)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+}
+
+func TestService_DeactivateUser(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (Service, *MockRepository, *MockSessionManager) {
+		mockRepo := new(MockRepository)
+		mockSession := new(MockSessionManager)
+		service := NewService(mockRepo, new(MockJWTManager), mockSession, new(MockTokenManager), new(MockNotifier), zap.NewNop(), time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+		return service, mockRepo, mockSession
+	}
+
+	t.Run("should deactivate the user and revoke their sessions", func(t *testing.T) {
+		service, mockRepo, mockSession := setup()
+
+		mockRepo.On("FindByID", mock.Anything, uint(7)).Return(User{ID: 7, Role: RoleUser, Active: true}, nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *User) bool { return u.ID == 7 && !u.Active })).Return(nil)
+		mockSession.On("DeleteAllUserSessions", mock.Anything, uint(7)).Return(nil)
+
+		user, err := service.DeactivateUser(ctx, 7)
+
+		require.NoError(t, err)
+		assert.False(t, user.Active)
+		mockRepo.AssertExpectations(t)
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("should refuse to deactivate an admin", func(t *testing.T) {
+		service, mockRepo, mockSession := setup()
+
+		mockRepo.On("FindByID", mock.Anything, uint(2)).Return(User{ID: 2, Role: RoleAdmin, Active: true}, nil)
+
+		_, err := service.DeactivateUser(ctx, 2)
+
+		assert.ErrorIs(t, err, ErrCannotDeactivate)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockSession.AssertNotCalled(t, "DeleteAllUserSessions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return not found for an unknown user", func(t *testing.T) {
+		service, mockRepo, _ := setup()
+
+		mockRepo.On("FindByID", mock.Anything, uint(99)).Return(User{}, gorm.ErrRecordNotFound)
+
+		_, err := service.DeactivateUser(ctx, 99)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
+
+	t.Run("should reactivate a deactivated user", func(t *testing.T) {
+		service, mockRepo, _ := setup()
+
+		mockRepo.On("FindByID", mock.Anything, uint(7)).Return(User{ID: 7, Role: RoleUser, Active: false}, nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *User) bool { return u.ID == 7 && u.Active })).Return(nil)
+
+		user, err := service.ActivateUser(ctx, 7)
+
+		require.NoError(t, err)
+		assert.True(t, user.Active)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should leave an active user untouched", func(t *testing.T) {
+		service, mockRepo, _ := setup()
+
+		mockRepo.On("FindByID", mock.Anything, uint(7)).Return(User{ID: 7, Role: RoleUser, Active: true}, nil)
+
+		user, err := service.ActivateUser(ctx, 7)
+
+		require.NoError(t, err)
+		assert.True(t, user.Active)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_SearchUsers(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (Service, *MockRepository) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), new(MockTokenManager), new(MockNotifier), zap.NewNop(), time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+		return service, mockRepo
+	}
+
+	t.Run("should page through the matching users", func(t *testing.T) {
+		service, mockRepo := setup()
+		users := []User{{ID: 3, Email: "alice@example.com"}}
+
+		mockRepo.On("FindByEmailLike", mock.Anything, "alice", 20, 10).Return(users, int64(21), nil)
+
+		query := UserSearchQuery{Q: "  alice "}
+		query.Page = 3
+		query.PageSize = 10
+		result, err := service.SearchUsers(ctx, query)
+
+		require.NoError(t, err)
+		assert.Equal(t, users, result.Data)
+		assert.Equal(t, 3, result.Pagination.Page)
+		assert.Equal(t, int64(21), result.Pagination.Total)
+		assert.Equal(t, 3, result.Pagination.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should clamp the page size", func(t *testing.T) {
+		service, mockRepo := setup()
+
+		mockRepo.On("FindByEmailLike", mock.Anything, "", 0, MaxUserPageSize).Return([]User{}, int64(0), nil)
+
+		query := UserSearchQuery{}
+		query.Page = 1
+		query.PageSize = 500
+		result, err := service.SearchUsers(ctx, query)
+
+		require.NoError(t, err)
+		assert.Equal(t, MaxUserPageSize, result.Pagination.PageSize)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should return repository errors", func(t *testing.T) {
+		service, mockRepo := setup()
+
+		mockRepo.On("FindByEmailLike", mock.Anything, "alice", 0, DefaultUserPageSize).Return(nil, int64(0), errors.New("database error"))
+
+		result, err := service.SearchUsers(ctx, UserSearchQuery{Q: "alice"})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestService_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should verify email with valid token", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockToken.On("ConsumeToken", ctx, TokenPurposeVerify, "valid-token").Return(user.ID, nil)
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(u *User) bool { return u.EmailVerified })).Return(nil)
+
+		err := service.VerifyEmail(ctx, "valid-token")
+
+		require.NoError(t, err)
+		mockToken.AssertExpectations(t)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("should return error for expired token", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		mockToken.On("ConsumeToken", ctx, TokenPurposeVerify, "expired-token").Return(uint(0), ErrTokenNotFound)
+
+		err := service.VerifyEmail(ctx, "expired-token")
+
+		assert.ErrorIs(t, err, ErrInvalidVerifyToken)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return error for already used token", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockToken.On("ConsumeToken", ctx, TokenPurposeVerify, "used-token").Return(user.ID, nil).Once()
+		mockToken.On("ConsumeToken", ctx, TokenPurposeVerify, "used-token").Return(uint(0), ErrTokenNotFound).Once()
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		mockRepo.On("Update", ctx, mock.AnythingOfType("*auth.User")).Return(nil)
+
+		require.NoError(t, service.VerifyEmail(ctx, "used-token"))
+
+		err := service.VerifyEmail(ctx, "used-token")
+
+		assert.ErrorIs(t, err, ErrInvalidVerifyToken)
+		mockRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+}
+
+func TestService_RequestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should store reset token and notify user when email exists", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		mockNotifier := new(MockNotifier)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, mockNotifier, logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockToken.On("StoreToken", ctx, TokenPurposeReset, mock.AnythingOfType("string"), user.ID, PasswordResetTokenTTL).Return(nil)
+		mockNotifier.On("SendPasswordResetEmail", ctx, user.Email, mock.AnythingOfType("string")).Return(nil)
+
+		err := service.RequestPasswordReset(ctx, user.Email)
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockToken.AssertExpectations(t)
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("should report success when the email cannot be sent", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		mockNotifier := new(MockNotifier)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, mockNotifier, logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+		mockToken.On("StoreToken", ctx, TokenPurposeReset, mock.AnythingOfType("string"), user.ID, PasswordResetTokenTTL).Return(nil)
+		mockNotifier.On("SendPasswordResetEmail", ctx, user.Email, mock.AnythingOfType("string")).Return(errors.New("smtp unavailable"))
+
+		err := service.RequestPasswordReset(ctx, user.Email)
+
+		require.NoError(t, err, "a registered email must look the same as an unknown one")
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("should succeed without sending anything for unknown email", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		mockNotifier := new(MockNotifier)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, mockNotifier, logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		mockRepo.On("FindByEmail", ctx, "unknown@example.com").Return(User{}, gorm.ErrRecordNotFound)
+
+		err := service.RequestPasswordReset(ctx, "unknown@example.com")
+
+		require.NoError(t, err)
+		mockToken.AssertNotCalled(t, "StoreToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockNotifier.AssertNotCalled(t, "SendPasswordResetEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_ConfirmPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reset password and invalidate sessions", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockSession := new(MockSessionManager)
+		mockToken := new(MockTokenManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), mockSession, mockToken, NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		user := User{ID: 1, Email: "test@example.com", Password: "old-hash"}
+
+		mockToken.On("ConsumeToken", ctx, TokenPurposeReset, "reset-token").Return(user.ID, nil)
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(u *User) bool {
+			return CheckPassword(u.Password, "newpassword123")
+		})).Return(nil)
+		mockSession.On("DeleteAllUserSessions", ctx, user.ID).Return(nil)
+
+		err := service.ConfirmPasswordReset(ctx, "reset-token", "newpassword123")
+
+		require.NoError(t, err)
+		mockToken.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+		mockSession.AssertExpectations(t)
+	})
+
+	t.Run("should reject reused token", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockSession := new(MockSessionManager)
+		mockToken := new(MockTokenManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), mockSession, mockToken, NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		user := User{ID: 1, Email: "test@example.com"}
+
+		mockToken.On("ConsumeToken", ctx, TokenPurposeReset, "reset-token").Return(user.ID, nil).Once()
+		mockToken.On("ConsumeToken", ctx, TokenPurposeReset, "reset-token").Return(uint(0), ErrTokenNotFound).Once()
+		mockRepo.On("FindByID", ctx, user.ID).Return(user, nil)
+		mockRepo.On("Update", ctx, mock.AnythingOfType("*auth.User")).Return(nil)
+		mockSession.On("DeleteAllUserSessions", ctx, user.ID).Return(nil)
+
+		require.NoError(t, service.ConfirmPasswordReset(ctx, "reset-token", "newpassword123"))
+
+		err := service.ConfirmPasswordReset(ctx, "reset-token", "anotherpass456")
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		mockRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("should reject weak password without consuming token", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockToken := new(MockTokenManager)
+		logger := zap.NewNop()
+
+		service := NewService(mockRepo, new(MockJWTManager), new(MockSessionManager), mockToken, NewNoopNotifier(logger), logger, time.Hour, 7*24*time.Hour, 30*24*time.Hour, false)
+
+		err := service.ConfirmPasswordReset(ctx, "reset-token", "weak")
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrWeakPassword)
+		mockToken.AssertNotCalled(t, "ConsumeToken", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
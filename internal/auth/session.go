@@ -2,8 +2,13 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -15,30 +20,148 @@ var (
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
 	ErrSessionStoreFailed  = errors.New("failed to store session")
 	ErrSessionDeleteFailed = errors.New("failed to delete session")
+	// ErrSessionStoreUnavailable is returned without contacting Redis while the circuit is open
+	ErrSessionStoreUnavailable = errors.New("session store unavailable")
 )
 
+const (
+	sessionFieldTokenHash = "token_hash"
+	// sessionFieldToken holds the raw token of sessions stored before tokens were
+	// hashed, it is only read until those sessions expire
+	sessionFieldToken     = "token"
+	sessionFieldUserAgent = "user_agent"
+	sessionFieldIPAddress = "ip_address"
+	sessionFieldCreatedAt = "created_at"
+)
+
+type SessionMetadata struct {
+	UserAgent string
+	IPAddress string
+}
+
+type SessionInfo struct {
+	SessionID string    `json:"session_id"`
+	UserAgent string    `json:"user_agent"`
+	IPAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+// SessionFallbackConfig controls how SessionManager behaves when Redis keeps failing.
+// A FailureThreshold of zero disables the circuit breaker.
+type SessionFallbackConfig struct {
+	// FailureThreshold is the number of consecutive Redis errors that opens the circuit
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before Redis is tried again
+	Cooldown time.Duration
+	// AllowJWTOnly lets logins and logouts proceed without a session while the circuit is open
+	AllowJWTOnly bool
+}
+
 type SessionManagerInterface interface {
-	StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration) error
+	StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration, meta SessionMetadata) error
 	ValidateRefreshToken(ctx context.Context, userID uint, sessionID, token string) error
 	DeleteRefreshToken(ctx context.Context, userID uint, sessionID string) error
+	DeleteAllUserSessions(ctx context.Context, userID uint) error
+	ListSessions(ctx context.Context, userID uint) ([]SessionInfo, error)
+	// RevokeAccessToken denylists an access token by jti until it would have expired anyway
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
 	GetSessionKey(userID uint, sessionID string) string
+	// JWTOnlyFallback reports whether callers may continue without sessions right now
+	JWTOnlyFallback() bool
 }
 
 type SessionManager struct {
-	client *redis.Client
-	logger *zap.Logger
+	client   *redis.Client
+	logger   *zap.Logger
+	fallback SessionFallbackConfig
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
 }
 
-func NewSessionManager(client *redis.Client, logger *zap.Logger) SessionManagerInterface {
+func NewSessionManager(client *redis.Client, logger *zap.Logger, fallback SessionFallbackConfig) SessionManagerInterface {
 	return &SessionManager{
-		client: client,
-		logger: logger,
+		client:   client,
+		logger:   logger,
+		fallback: fallback,
 	}
 }
 
-func (s *SessionManager) StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration) error {
+func (s *SessionManager) isOpen() bool {
+	return s.fallback.FailureThreshold > 0 && s.failures >= s.fallback.FailureThreshold
+}
+
+// allow reports whether Redis should be called. Once the cooldown has passed a call
+// is let through to probe whether Redis is back.
+func (s *SessionManager) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.isOpen() || time.Since(s.openedAt) >= s.fallback.Cooldown
+}
+
+// record tracks the outcome of a Redis call. redis.Nil means Redis answered, so it
+// counts as a success. A caller giving up says nothing about Redis, so a cancelled
+// or expired context counts as neither.
+func (s *SessionManager) record(err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil || errors.Is(err, redis.Nil) {
+		if s.isOpen() {
+			s.logger.Info("Session store recovered, closing circuit")
+		}
+		s.failures = 0
+		return
+	}
+
+	s.failures++
+	if s.isOpen() {
+		s.openedAt = time.Now()
+		if s.failures == s.fallback.FailureThreshold {
+			s.logger.Error("Session store unavailable, opening circuit",
+				zap.Error(err),
+				zap.Int("failures", s.failures),
+				zap.Duration("cooldown", s.fallback.Cooldown),
+				zap.Bool("jwt_only_fallback", s.fallback.AllowJWTOnly),
+			)
+		}
+	}
+}
+
+func (s *SessionManager) JWTOnlyFallback() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fallback.AllowJWTOnly && s.isOpen()
+}
+
+func (s *SessionManager) StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration, meta SessionMetadata) error {
+	if !s.allow() {
+		return ErrSessionStoreUnavailable
+	}
+
 	key := fmt.Sprintf("session:%d:%s", userID, sessionID)
-	if err := s.client.Set(ctx, key, token, ttl).Err(); err != nil {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			sessionFieldTokenHash, hashRefreshToken(token),
+			sessionFieldUserAgent, meta.UserAgent,
+			sessionFieldIPAddress, meta.IPAddress,
+			sessionFieldCreatedAt, time.Now().UTC().Format(time.RFC3339),
+		)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	s.record(err)
+	if err != nil {
 		s.logger.Error("Failed to store refresh token",
 			zap.Error(err),
 			zap.Uint("user_id", userID),
@@ -56,16 +179,14 @@ func (s *SessionManager) StoreRefreshToken(ctx context.Context, userID uint, ses
 }
 
 func (s *SessionManager) ValidateRefreshToken(ctx context.Context, userID uint, sessionID, token string) error {
+	if !s.allow() {
+		return ErrSessionStoreUnavailable
+	}
+
 	key := fmt.Sprintf("session:%d:%s", userID, sessionID)
-	val, err := s.client.Get(ctx, key).Result()
+	vals, err := s.client.HMGet(ctx, key, sessionFieldTokenHash, sessionFieldToken).Result()
+	s.record(err)
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			s.logger.Warn("Session not found",
-				zap.Uint("user_id", userID),
-				zap.String("session_id", sessionID),
-			)
-			return ErrSessionNotFound
-		}
 		s.logger.Error("Failed to get session",
 			zap.Error(err),
 			zap.Uint("user_id", userID),
@@ -74,7 +195,23 @@ func (s *SessionManager) ValidateRefreshToken(ctx context.Context, userID uint,
 		return err
 	}
 
-	if val != token {
+	storedHash, hashed := vals[0].(string)
+	legacyToken, legacy := vals[1].(string)
+	if !hashed && !legacy {
+		s.logger.Warn("Session not found",
+			zap.Uint("user_id", userID),
+			zap.String("session_id", sessionID),
+		)
+		return ErrSessionNotFound
+	}
+
+	var match bool
+	if hashed {
+		match = subtle.ConstantTimeCompare([]byte(storedHash), []byte(hashRefreshToken(token))) == 1
+	} else {
+		match = subtle.ConstantTimeCompare([]byte(legacyToken), []byte(token)) == 1
+	}
+	if !match {
 		s.logger.Warn("Invalid refresh token provided",
 			zap.Uint("user_id", userID),
 			zap.String("session_id", sessionID),
@@ -90,8 +227,14 @@ func (s *SessionManager) ValidateRefreshToken(ctx context.Context, userID uint,
 }
 
 func (s *SessionManager) DeleteRefreshToken(ctx context.Context, userID uint, sessionID string) error {
+	if !s.allow() {
+		return ErrSessionStoreUnavailable
+	}
+
 	key := fmt.Sprintf("session:%d:%s", userID, sessionID)
-	if err := s.client.Del(ctx, key).Err(); err != nil {
+	err := s.client.Del(ctx, key).Err()
+	s.record(err)
+	if err != nil {
 		s.logger.Error("Failed to delete session",
 			zap.Error(err),
 			zap.Uint("user_id", userID),
@@ -107,6 +250,140 @@ func (s *SessionManager) DeleteRefreshToken(ctx context.Context, userID uint, se
 	return nil
 }
 
+func (s *SessionManager) DeleteAllUserSessions(ctx context.Context, userID uint) error {
+	if !s.allow() {
+		return ErrSessionStoreUnavailable
+	}
+
+	pattern := fmt.Sprintf("session:%d:*", userID)
+	iter := s.client.Scan(ctx, 0, pattern, 0).Iterator()
+	var keys []string
+
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	err := iter.Err()
+	s.record(err)
+	if err != nil {
+		s.logger.Error("Failed to scan user sessions",
+			zap.Error(err),
+			zap.Uint("user_id", userID),
+		)
+		return ErrSessionDeleteFailed
+	}
+
+	if len(keys) > 0 {
+		err = s.client.Del(ctx, keys...).Err()
+		s.record(err)
+		if err != nil {
+			s.logger.Error("Failed to delete user sessions",
+				zap.Error(err),
+				zap.Uint("user_id", userID),
+				zap.Int("count", len(keys)),
+			)
+			return ErrSessionDeleteFailed
+		}
+	}
+
+	s.logger.Debug("All user sessions deleted successfully",
+		zap.Uint("user_id", userID),
+		zap.Int("count", len(keys)),
+	)
+	return nil
+}
+
+func (s *SessionManager) ListSessions(ctx context.Context, userID uint) ([]SessionInfo, error) {
+	if !s.allow() {
+		return nil, ErrSessionStoreUnavailable
+	}
+
+	prefix := fmt.Sprintf("session:%d:", userID)
+	iter := s.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	sessions := []SessionInfo{}
+
+	for iter.Next(ctx) {
+		key := iter.Val()
+		fields, err := s.client.HGetAll(ctx, key).Result()
+		s.record(err)
+		if err != nil {
+			s.logger.Error("Failed to get session metadata",
+				zap.Error(err),
+				zap.Uint("user_id", userID),
+				zap.String("key", key),
+			)
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		createdAt, _ := time.Parse(time.RFC3339, fields[sessionFieldCreatedAt])
+		sessions = append(sessions, SessionInfo{
+			SessionID: strings.TrimPrefix(key, prefix),
+			UserAgent: fields[sessionFieldUserAgent],
+			IPAddress: fields[sessionFieldIPAddress],
+			CreatedAt: createdAt,
+		})
+	}
+
+	err := iter.Err()
+	s.record(err)
+	if err != nil {
+		s.logger.Error("Failed to scan user sessions",
+			zap.Error(err),
+			zap.Uint("user_id", userID),
+		)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionManager) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	if !s.allow() {
+		return ErrSessionStoreUnavailable
+	}
+
+	err := s.client.Set(ctx, revokedTokenKey(jti), 1, ttl).Err()
+	s.record(err)
+	if err != nil {
+		s.logger.Error("Failed to revoke access token", zap.Error(err), zap.String("jti", jti))
+		return err
+	}
+
+	s.logger.Debug("Access token revoked", zap.String("jti", jti), zap.Duration("ttl", ttl))
+	return nil
+}
+
+func (s *SessionManager) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if !s.allow() {
+		return false, ErrSessionStoreUnavailable
+	}
+
+	n, err := s.client.Exists(ctx, revokedTokenKey(jti)).Result()
+	s.record(err)
+	if err != nil {
+		s.logger.Error("Failed to check access token denylist", zap.Error(err), zap.String("jti", jti))
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func revokedTokenKey(jti string) string {
+	return "jwt:revoked:" + jti
+}
+
+// hashRefreshToken is what gets stored in place of the token, so a Redis dump does not
+// hand out usable refresh tokens
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *SessionManager) GetSessionKey(userID uint, sessionID string) string {
 	return fmt.Sprintf("session:%d:%s", userID, sessionID)
 }
@@ -29,7 +29,7 @@ func TestNewSessionManager(t *testing.T) {
 		defer mr.Close()
 		logger := zap.NewNop()
 
-		sessionManager := NewSessionManager(client, logger)
+		sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
 
 		assert.NotNil(t, sessionManager)
 	})
@@ -39,7 +39,7 @@ func TestSessionManager_StoreRefreshToken(t *testing.T) {
 	client, mr := setupTestRedis(t)
 	defer mr.Close()
 	logger := zap.NewNop()
-	sessionManager := NewSessionManager(client, logger)
+	sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
 	ctx := context.Background()
 
 	t.Run("should store refresh token successfully", func(t *testing.T) {
@@ -48,14 +48,32 @@ func TestSessionManager_StoreRefreshToken(t *testing.T) {
 		token := "refresh-token-123"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl, SessionMetadata{})
 
 		require.NoError(t, err)
 
 		key := sessionManager.GetSessionKey(userID, sessionID)
-		storedToken, err := client.Get(ctx, key).Result()
+		storedHash, err := client.HGet(ctx, key, "token_hash").Result()
 		require.NoError(t, err)
-		assert.Equal(t, token, storedToken)
+		assert.Equal(t, hashRefreshToken(token), storedHash)
+	})
+
+	t.Run("should not store the raw token", func(t *testing.T) {
+		userID := uint(321)
+		sessionID := "session-321"
+		token := "refresh-token-321"
+
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, time.Hour, SessionMetadata{})
+		require.NoError(t, err)
+
+		fields, err := client.HGetAll(ctx, sessionManager.GetSessionKey(userID, sessionID)).Result()
+		require.NoError(t, err)
+		assert.NotContains(t, fields, "token")
+		for _, value := range fields {
+			assert.NotContains(t, value, token)
+		}
+
+		assert.NoError(t, sessionManager.ValidateRefreshToken(ctx, userID, sessionID, token))
 	})
 
 	t.Run("should store refresh token with correct TTL", func(t *testing.T) {
@@ -64,7 +82,7 @@ func TestSessionManager_StoreRefreshToken(t *testing.T) {
 		token := "refresh-token-456"
 		ttl := 5 * time.Second
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl, SessionMetadata{})
 
 		require.NoError(t, err)
 
@@ -81,16 +99,16 @@ func TestSessionManager_StoreRefreshToken(t *testing.T) {
 		token2 := "refresh-token-new"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token1, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token1, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
-		err = sessionManager.StoreRefreshToken(ctx, userID, sessionID, token2, ttl)
+		err = sessionManager.StoreRefreshToken(ctx, userID, sessionID, token2, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
 		key := sessionManager.GetSessionKey(userID, sessionID)
-		storedToken, err := client.Get(ctx, key).Result()
+		storedHash, err := client.HGet(ctx, key, "token_hash").Result()
 		require.NoError(t, err)
-		assert.Equal(t, token2, storedToken)
+		assert.Equal(t, hashRefreshToken(token2), storedHash)
 	})
 }
 
@@ -98,7 +116,7 @@ func TestSessionManager_ValidateRefreshToken(t *testing.T) {
 	client, mr := setupTestRedis(t)
 	defer mr.Close()
 	logger := zap.NewNop()
-	sessionManager := NewSessionManager(client, logger)
+	sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
 	ctx := context.Background()
 
 	t.Run("should validate correct refresh token", func(t *testing.T) {
@@ -107,7 +125,7 @@ func TestSessionManager_ValidateRefreshToken(t *testing.T) {
 		token := "refresh-token-123"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
 		err = sessionManager.ValidateRefreshToken(ctx, userID, sessionID, token)
@@ -132,7 +150,7 @@ func TestSessionManager_ValidateRefreshToken(t *testing.T) {
 		wrongToken := "wrong-token"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, correctToken, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, correctToken, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
 		err = sessionManager.ValidateRefreshToken(ctx, userID, sessionID, wrongToken)
@@ -147,7 +165,7 @@ func TestSessionManager_ValidateRefreshToken(t *testing.T) {
 		correctToken := "correct-token"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, correctToken, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, correctToken, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
 		err = sessionManager.ValidateRefreshToken(ctx, userID, sessionID, "")
@@ -155,13 +173,23 @@ func TestSessionManager_ValidateRefreshToken(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, ErrInvalidRefreshToken, err)
 	})
+
+	t.Run("should still accept a session stored with a plaintext token", func(t *testing.T) {
+		userID := uint(555)
+		sessionID := "session-legacy"
+		key := sessionManager.GetSessionKey(userID, sessionID)
+		require.NoError(t, client.HSet(ctx, key, "token", "legacy-token").Err())
+
+		assert.NoError(t, sessionManager.ValidateRefreshToken(ctx, userID, sessionID, "legacy-token"))
+		assert.Equal(t, ErrInvalidRefreshToken, sessionManager.ValidateRefreshToken(ctx, userID, sessionID, "wrong-token"))
+	})
 }
 
 func TestSessionManager_DeleteRefreshToken(t *testing.T) {
 	client, mr := setupTestRedis(t)
 	defer mr.Close()
 	logger := zap.NewNop()
-	sessionManager := NewSessionManager(client, logger)
+	sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
 	ctx := context.Background()
 
 	t.Run("should delete refresh token successfully", func(t *testing.T) {
@@ -170,14 +198,14 @@ func TestSessionManager_DeleteRefreshToken(t *testing.T) {
 		token := "refresh-token-123"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, token, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
 		err = sessionManager.DeleteRefreshToken(ctx, userID, sessionID)
 		assert.NoError(t, err)
 
 		key := sessionManager.GetSessionKey(userID, sessionID)
-		_, err = client.Get(ctx, key).Result()
+		_, err = client.HGet(ctx, key, "token_hash").Result()
 		assert.Equal(t, redis.Nil, err)
 	})
 
@@ -198,22 +226,137 @@ func TestSessionManager_DeleteRefreshToken(t *testing.T) {
 		token2 := "token-2"
 		ttl := time.Hour
 
-		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID1, token1, ttl)
+		err := sessionManager.StoreRefreshToken(ctx, userID, sessionID1, token1, ttl, SessionMetadata{})
 		require.NoError(t, err)
-		err = sessionManager.StoreRefreshToken(ctx, userID, sessionID2, token2, ttl)
+		err = sessionManager.StoreRefreshToken(ctx, userID, sessionID2, token2, ttl, SessionMetadata{})
 		require.NoError(t, err)
 
 		err = sessionManager.DeleteRefreshToken(ctx, userID, sessionID1)
 		assert.NoError(t, err)
 
 		key1 := sessionManager.GetSessionKey(userID, sessionID1)
-		_, err = client.Get(ctx, key1).Result()
+		_, err = client.HGet(ctx, key1, "token_hash").Result()
 		assert.Equal(t, redis.Nil, err)
 
 		key2 := sessionManager.GetSessionKey(userID, sessionID2)
-		storedToken, err := client.Get(ctx, key2).Result()
+		storedHash, err := client.HGet(ctx, key2, "token_hash").Result()
+		require.NoError(t, err)
+		assert.Equal(t, hashRefreshToken(token2), storedHash)
+	})
+}
+
+func TestSessionManager_DeleteAllUserSessions(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	logger := zap.NewNop()
+	sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
+	ctx := context.Background()
+
+	t.Run("should delete only the target user's sessions", func(t *testing.T) {
+		userID := uint(123)
+		otherUserID := uint(456)
+		ttl := time.Hour
+
+		for _, sessionID := range []string{"session-1", "session-2", "session-3"} {
+			err := sessionManager.StoreRefreshToken(ctx, userID, sessionID, "token-"+sessionID, ttl, SessionMetadata{})
+			require.NoError(t, err)
+		}
+		err := sessionManager.StoreRefreshToken(ctx, otherUserID, "session-other", "token-other", ttl, SessionMetadata{})
+		require.NoError(t, err)
+
+		err = sessionManager.DeleteAllUserSessions(ctx, userID)
+		require.NoError(t, err)
+
+		keys, err := client.Keys(ctx, "session:123:*").Result()
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		otherKey := sessionManager.GetSessionKey(otherUserID, "session-other")
+		storedHash, err := client.HGet(ctx, otherKey, "token_hash").Result()
 		require.NoError(t, err)
-		assert.Equal(t, token2, storedToken)
+		assert.Equal(t, hashRefreshToken("token-other"), storedHash)
+	})
+
+	t.Run("should not return error when user has no sessions", func(t *testing.T) {
+		err := sessionManager.DeleteAllUserSessions(ctx, uint(999))
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestSessionManager_ListSessions(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	logger := zap.NewNop()
+	sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
+	ctx := context.Background()
+
+	t.Run("should list sessions with metadata", func(t *testing.T) {
+		userID := uint(123)
+		ttl := time.Hour
+
+		err := sessionManager.StoreRefreshToken(ctx, userID, "session-1", "token-1", ttl, SessionMetadata{
+			UserAgent: "Mozilla/5.0",
+			IPAddress: "10.0.0.1",
+		})
+		require.NoError(t, err)
+		err = sessionManager.StoreRefreshToken(ctx, userID, "session-2", "token-2", ttl, SessionMetadata{
+			UserAgent: "curl/8.0",
+			IPAddress: "10.0.0.2",
+		})
+		require.NoError(t, err)
+		err = sessionManager.StoreRefreshToken(ctx, uint(456), "session-other", "token-other", ttl, SessionMetadata{})
+		require.NoError(t, err)
+
+		sessions, err := sessionManager.ListSessions(ctx, userID)
+
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		byID := make(map[string]SessionInfo)
+		for _, session := range sessions {
+			byID[session.SessionID] = session
+		}
+		assert.Equal(t, "Mozilla/5.0", byID["session-1"].UserAgent)
+		assert.Equal(t, "10.0.0.1", byID["session-1"].IPAddress)
+		assert.False(t, byID["session-1"].CreatedAt.IsZero())
+		assert.Equal(t, "curl/8.0", byID["session-2"].UserAgent)
+		assert.Equal(t, "10.0.0.2", byID["session-2"].IPAddress)
+	})
+
+	t.Run("should return empty list when user has no sessions", func(t *testing.T) {
+		sessions, err := sessionManager.ListSessions(ctx, uint(999))
+
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+}
+
+func TestSessionManager_RevokeAccessToken(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	sessionManager := NewSessionManager(client, zap.NewNop(), SessionFallbackConfig{})
+	ctx := context.Background()
+
+	t.Run("should denylist jti until the ttl passes", func(t *testing.T) {
+		require.NoError(t, sessionManager.RevokeAccessToken(ctx, "jti-1", time.Minute))
+
+		revoked, err := sessionManager.IsAccessTokenRevoked(ctx, "jti-1")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.Equal(t, time.Minute, mr.TTL("jwt:revoked:jti-1"))
+
+		mr.FastForward(time.Minute)
+
+		revoked, err = sessionManager.IsAccessTokenRevoked(ctx, "jti-1")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("should skip tokens that already expired", func(t *testing.T) {
+		require.NoError(t, sessionManager.RevokeAccessToken(ctx, "jti-2", 0))
+
+		assert.False(t, mr.Exists("jwt:revoked:jti-2"))
 	})
 }
 
@@ -221,7 +364,7 @@ func TestSessionManager_GetSessionKey(t *testing.T) {
 	client, mr := setupTestRedis(t)
 	defer mr.Close()
 	logger := zap.NewNop()
-	sessionManager := NewSessionManager(client, logger)
+	sessionManager := NewSessionManager(client, logger, SessionFallbackConfig{})
 
 	t.Run("should generate correct session key", func(t *testing.T) {
 		userID := uint(123)
@@ -254,3 +397,95 @@ func TestSessionManager_GetSessionKey(t *testing.T) {
 		assert.Equal(t, "session:123:session-2", key2)
 	})
 }
+
+func TestSessionManager_Fallback(t *testing.T) {
+	ctx := context.Background()
+
+	setupUnavailable := func(t *testing.T, fallback SessionFallbackConfig) (SessionManagerInterface, *miniredis.Miniredis) {
+		mr, err := miniredis.Run()
+		require.NoError(t, err)
+		t.Cleanup(mr.Close)
+
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+		t.Cleanup(func() { client.Close() })
+
+		mr.Close()
+		return NewSessionManager(client, zap.NewNop(), fallback), mr
+	}
+
+	t.Run("should open circuit after threshold and fail fast", func(t *testing.T) {
+		sessionManager, _ := setupUnavailable(t, SessionFallbackConfig{FailureThreshold: 2, Cooldown: time.Minute})
+
+		err := sessionManager.ValidateRefreshToken(ctx, 1, "session-123", "token")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrSessionStoreUnavailable)
+
+		err = sessionManager.StoreRefreshToken(ctx, 1, "session-123", "token", time.Hour, SessionMetadata{})
+		assert.ErrorIs(t, err, ErrSessionStoreFailed)
+
+		err = sessionManager.ValidateRefreshToken(ctx, 1, "session-123", "token")
+		assert.ErrorIs(t, err, ErrSessionStoreUnavailable)
+		err = sessionManager.StoreRefreshToken(ctx, 1, "session-123", "token", time.Hour, SessionMetadata{})
+		assert.ErrorIs(t, err, ErrSessionStoreUnavailable)
+	})
+
+	t.Run("should allow JWT only fallback when configured", func(t *testing.T) {
+		sessionManager, _ := setupUnavailable(t, SessionFallbackConfig{FailureThreshold: 1, Cooldown: time.Minute, AllowJWTOnly: true})
+
+		assert.False(t, sessionManager.JWTOnlyFallback())
+		_ = sessionManager.DeleteRefreshToken(ctx, 1, "session-123")
+		assert.True(t, sessionManager.JWTOnlyFallback())
+	})
+
+	t.Run("should deny JWT only fallback when not configured", func(t *testing.T) {
+		sessionManager, _ := setupUnavailable(t, SessionFallbackConfig{FailureThreshold: 1, Cooldown: time.Minute})
+
+		_ = sessionManager.DeleteRefreshToken(ctx, 1, "session-123")
+		assert.False(t, sessionManager.JWTOnlyFallback())
+		assert.ErrorIs(t, sessionManager.DeleteRefreshToken(ctx, 1, "session-123"), ErrSessionStoreUnavailable)
+	})
+
+	t.Run("should never open circuit without threshold", func(t *testing.T) {
+		sessionManager, _ := setupUnavailable(t, SessionFallbackConfig{AllowJWTOnly: true})
+
+		for i := 0; i < 5; i++ {
+			err := sessionManager.ValidateRefreshToken(ctx, 1, "session-123", "token")
+			assert.NotErrorIs(t, err, ErrSessionStoreUnavailable)
+		}
+		assert.False(t, sessionManager.JWTOnlyFallback())
+	})
+
+	t.Run("should not open circuit when the caller's context is done", func(t *testing.T) {
+		client, mr := setupTestRedis(t)
+		defer mr.Close()
+		sessionManager := NewSessionManager(client, zap.NewNop(), SessionFallbackConfig{FailureThreshold: 1, Cooldown: time.Minute, AllowJWTOnly: true})
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := sessionManager.ValidateRefreshToken(cancelled, 1, "session-123", "token")
+		assert.ErrorIs(t, err, context.Canceled)
+
+		expired, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+		defer cancel()
+		err = sessionManager.ValidateRefreshToken(expired, 1, "session-123", "token")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		assert.False(t, sessionManager.JWTOnlyFallback())
+		err = sessionManager.ValidateRefreshToken(ctx, 1, "session-123", "token")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("should close circuit once redis recovers after cooldown", func(t *testing.T) {
+		sessionManager, mr := setupUnavailable(t, SessionFallbackConfig{FailureThreshold: 1, Cooldown: 10 * time.Millisecond, AllowJWTOnly: true})
+
+		_ = sessionManager.ValidateRefreshToken(ctx, 1, "session-123", "token")
+		assert.True(t, sessionManager.JWTOnlyFallback())
+
+		require.NoError(t, mr.Restart())
+		time.Sleep(20 * time.Millisecond)
+
+		err := sessionManager.ValidateRefreshToken(ctx, 1, "session-123", "token")
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+		assert.False(t, sessionManager.JWTOnlyFallback())
+	})
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	TokenPurposeVerify = "verify"
+	TokenPurposeReset  = "reset"
+
+	VerificationTokenTTL  = 24 * time.Hour
+	PasswordResetTokenTTL = 30 * time.Minute
+
+	// VerificationResendInterval is how often a user may ask for a new verification email
+	VerificationResendInterval = time.Minute
+)
+
+var (
+	ErrTokenNotFound    = errors.New("token not found")
+	ErrTokenStoreFailed = errors.New("failed to store token")
+)
+
+type TokenManagerInterface interface {
+	StoreToken(ctx context.Context, purpose, token string, userID uint, ttl time.Duration) error
+	ConsumeToken(ctx context.Context, purpose, token string) (uint, error)
+	// Throttle reports whether the user may do purpose now, and if so blocks it for window
+	Throttle(ctx context.Context, purpose string, userID uint, window time.Duration) (bool, error)
+}
+
+type TokenManager struct {
+	client *redis.Client
+	logger *zap.Logger
+}
+
+func NewTokenManager(client *redis.Client, logger *zap.Logger) TokenManagerInterface {
+	return &TokenManager{
+		client: client,
+		logger: logger,
+	}
+}
+
+func (t *TokenManager) StoreToken(ctx context.Context, purpose, token string, userID uint, ttl time.Duration) error {
+	key := fmt.Sprintf("%s:%s", purpose, token)
+	if err := t.client.Set(ctx, key, userID, ttl).Err(); err != nil {
+		t.logger.Error("Failed to store token",
+			zap.Error(err),
+			zap.String("purpose", purpose),
+			zap.Uint("user_id", userID),
+		)
+		return ErrTokenStoreFailed
+	}
+
+	t.logger.Debug("Token stored successfully",
+		zap.String("purpose", purpose),
+		zap.Uint("user_id", userID),
+		zap.Duration("ttl", ttl),
+	)
+	return nil
+}
+
+func (t *TokenManager) ConsumeToken(ctx context.Context, purpose, token string) (uint, error) {
+	key := fmt.Sprintf("%s:%s", purpose, token)
+	val, err := t.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			t.logger.Warn("Token not found", zap.String("purpose", purpose))
+			return 0, ErrTokenNotFound
+		}
+		t.logger.Error("Failed to consume token", zap.Error(err), zap.String("purpose", purpose))
+		return 0, err
+	}
+
+	userID, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		t.logger.Error("Invalid user id stored for token", zap.Error(err), zap.String("purpose", purpose))
+		return 0, ErrTokenNotFound
+	}
+
+	return uint(userID), nil
+}
+
+func (t *TokenManager) Throttle(ctx context.Context, purpose string, userID uint, window time.Duration) (bool, error) {
+	key := fmt.Sprintf("throttle:%s:%d", purpose, userID)
+	allowed, err := t.client.SetNX(ctx, key, 1, window).Result()
+	if err != nil {
+		t.logger.Error("Failed to check throttle",
+			zap.Error(err),
+			zap.String("purpose", purpose),
+			zap.Uint("user_id", userID),
+		)
+		return false, err
+	}
+	return allowed, nil
+}
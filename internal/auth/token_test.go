@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTokenManager_StoreAndConsumeToken(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	logger := zap.NewNop()
+	tokenManager := NewTokenManager(client, logger)
+	ctx := context.Background()
+
+	t.Run("should consume stored token once", func(t *testing.T) {
+		err := tokenManager.StoreToken(ctx, TokenPurposeVerify, "token-123", uint(42), time.Hour)
+		require.NoError(t, err)
+
+		userID, err := tokenManager.ConsumeToken(ctx, TokenPurposeVerify, "token-123")
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), userID)
+
+		_, err = tokenManager.ConsumeToken(ctx, TokenPurposeVerify, "token-123")
+		assert.Equal(t, ErrTokenNotFound, err)
+	})
+
+	t.Run("should return error for expired token", func(t *testing.T) {
+		err := tokenManager.StoreToken(ctx, TokenPurposeVerify, "token-expired", uint(42), time.Second)
+		require.NoError(t, err)
+
+		mr.FastForward(2 * time.Second)
+
+		_, err = tokenManager.ConsumeToken(ctx, TokenPurposeVerify, "token-expired")
+		assert.Equal(t, ErrTokenNotFound, err)
+	})
+
+	t.Run("should store token under purpose prefixed key", func(t *testing.T) {
+		err := tokenManager.StoreToken(ctx, TokenPurposeVerify, "token-key", uint(7), time.Hour)
+		require.NoError(t, err)
+
+		assert.True(t, mr.Exists("verify:token-key"))
+	})
+}
+
+func TestTokenManager_Throttle(t *testing.T) {
+	client, mr := setupTestRedis(t)
+	defer mr.Close()
+	tokenManager := NewTokenManager(client, zap.NewNop())
+	ctx := context.Background()
+
+	allowed, err := tokenManager.Throttle(ctx, TokenPurposeVerify, 1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = tokenManager.Throttle(ctx, TokenPurposeVerify, 1, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = tokenManager.Throttle(ctx, TokenPurposeVerify, 2, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed, "other users are throttled separately")
+
+	mr.FastForward(time.Minute)
+
+	allowed, err = tokenManager.Throttle(ctx, TokenPurposeVerify, 1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
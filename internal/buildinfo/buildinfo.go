@@ -0,0 +1,36 @@
+// Package buildinfo holds the details of the running binary. Commit and BuildTime are
+// stamped at build time, see the build target of the Makefile:
+//
+//	go build -ldflags "-X mini-e-commerce/internal/buildinfo.Commit=<sha> -X mini-e-commerce/internal/buildinfo.BuildTime=<RFC3339>" ./cmd
+package buildinfo
+
+import (
+	"mini-e-commerce/internal/logger"
+	"runtime"
+
+	"go.uber.org/zap"
+)
+
+const Unknown = "unknown"
+
+var (
+	Commit    = Unknown
+	BuildTime = Unknown
+)
+
+// Fields describes the running binary so log lines can be matched to a deploy
+func Fields(serviceName, version string) []zap.Field {
+	return []zap.Field{
+		zap.String("service", serviceName),
+		zap.String("version", version),
+		zap.String("go_version", runtime.Version()),
+		zap.String("commit", Commit),
+		zap.String("build_time", BuildTime),
+	}
+}
+
+// LogStartup writes the single startup line, before any connection is attempted so it
+// shows up even when the boot fails
+func LogStartup(log logger.Logger, serviceName, version string) {
+	log.Info("Starting service", Fields(serviceName, version)...)
+}
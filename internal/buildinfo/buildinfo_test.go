@@ -0,0 +1,47 @@
+package buildinfo
+
+import (
+	"mini-e-commerce/internal/logger"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogStartup(t *testing.T) {
+	t.Run("should log the build vars in one line", func(t *testing.T) {
+		commit, buildTime := Commit, BuildTime
+		t.Cleanup(func() { Commit, BuildTime = commit, buildTime })
+		Commit = "abc1234"
+		BuildTime = "2025-01-01T00:00:00Z"
+
+		core, logs := observer.New(zapcore.InfoLevel)
+		log := logger.NewLoggerFromZap(zap.New(core), &logger.Config{})
+
+		LogStartup(log, "mini-ecommerce", "1.2.3")
+
+		entries := logs.All()
+		require.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "mini-ecommerce", fields["service"])
+		assert.Equal(t, "1.2.3", fields["version"])
+		assert.Equal(t, runtime.Version(), fields["go_version"])
+		assert.Equal(t, "abc1234", fields["commit"])
+		assert.Equal(t, "2025-01-01T00:00:00Z", fields["build_time"])
+	})
+
+	t.Run("should report unknown when the binary was not stamped", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		log := logger.NewLoggerFromZap(zap.New(core), &logger.Config{})
+
+		LogStartup(log, "mini-ecommerce", "1.2.3")
+
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, Unknown, fields["commit"])
+		assert.Equal(t, Unknown, fields["build_time"])
+	})
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	opGet     = "get"
+	opGetMany = "get_many"
+	opSet     = "set"
+	opDelete  = "delete"
+)
+
+var (
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "Number of cache lookups that found a value.",
+	}, []string{"operation"})
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "Number of cache lookups that found no value.",
+	}, []string{"operation"})
+	cacheErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_errors_total",
+		Help: "Number of failed cache operations.",
+	}, []string{"operation"})
+)
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -21,34 +24,97 @@ func NewRedisCache(client *redis.Client, logger *zap.Logger) *RedisCache {
 	}
 }
 
+// contextError returns the context error behind err, or nil if err is a real Redis
+// failure. A request that was cancelled or timed out is not worth an error log.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		return ctxErr
+	}
+	return nil
+}
+
 func (r *RedisCache) Get(ctx context.Context, key string, dest any) error {
 	val, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
+			cacheMisses.WithLabelValues(opGet).Inc()
 			r.logger.Debug("Cache miss", zap.String("key", key))
+		} else if ctxErr := contextError(ctx, err); ctxErr != nil {
+			r.logger.Debug("Cache get cancelled", zap.String("key", key), zap.Error(ctxErr))
+			return ctxErr
 		} else {
+			cacheErrors.WithLabelValues(opGet).Inc()
 			r.logger.Error("Cache get error", zap.String("key", key), zap.Error(err))
 		}
 		return err
 	}
 
 	if err := json.Unmarshal([]byte(val), dest); err != nil {
+		cacheErrors.WithLabelValues(opGet).Inc()
 		r.logger.Error("Cache unmarshal error", zap.String("key", key), zap.Error(err))
 		return err
 	}
 
+	cacheHits.WithLabelValues(opGet).Inc()
 	r.logger.Debug("Cache hit", zap.String("key", key))
 	return nil
 }
 
+// GetMany reads keys in a single round trip. For every key that is found, newDest is
+// called with its index and must return a pointer to decode the value into. The
+// indexes of keys that were missing or undecodable are returned, on a Redis error
+// that is all of them.
+func (r *RedisCache) GetMany(ctx context.Context, keys []string, newDest func(i int) any) ([]int, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		cacheErrors.WithLabelValues(opGetMany).Inc()
+		r.logger.Error("Cache get many error", zap.Strings("keys", keys), zap.Error(err))
+		missing := make([]int, len(keys))
+		for i := range keys {
+			missing[i] = i
+		}
+		return missing, err
+	}
+
+	var missing []int
+	for i, val := range vals {
+		s, ok := val.(string)
+		if !ok {
+			cacheMisses.WithLabelValues(opGetMany).Inc()
+			missing = append(missing, i)
+			continue
+		}
+		if err := json.Unmarshal([]byte(s), newDest(i)); err != nil {
+			cacheErrors.WithLabelValues(opGetMany).Inc()
+			r.logger.Error("Cache unmarshal error", zap.String("key", keys[i]), zap.Error(err))
+			missing = append(missing, i)
+			continue
+		}
+		cacheHits.WithLabelValues(opGetMany).Inc()
+	}
+
+	r.logger.Debug("Cache get many", zap.Int("keys", len(keys)), zap.Int("misses", len(missing)))
+	return missing, nil
+}
+
 func (r *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
+		cacheErrors.WithLabelValues(opSet).Inc()
 		r.logger.Error("Cache marshal error", zap.String("key", key), zap.Error(err))
 		return err
 	}
 
 	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		if ctxErr := contextError(ctx, err); ctxErr != nil {
+			r.logger.Debug("Cache set cancelled", zap.String("key", key), zap.Error(ctxErr))
+			return ctxErr
+		}
+		cacheErrors.WithLabelValues(opSet).Inc()
 		r.logger.Error("Cache set error", zap.String("key", key), zap.Error(err))
 		return err
 	}
@@ -57,8 +123,149 @@ func (r *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Du
 	return nil
 }
 
+const (
+	lockKeyPrefix   = "lock:"
+	lockTTL         = 5 * time.Second
+	lockWaitTimeout = 2 * time.Second
+	lockRetryDelay  = 25 * time.Millisecond
+)
+
+// releaseLockScript deletes the lock only if it is still held by the caller, so a
+// loader that outlived lockTTL does not release a lock taken by someone else.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type bypassKey struct{}
+
+// WithBypass marks ctx so GetOrSet skips the cached value and reloads it from the source,
+// the fresh value is still written back
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Bypassed reports whether ctx was marked by WithBypass
+func Bypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// GetOrSet reads key into dest. On a miss it calls loader, caches the result for ttl
+// and fills dest with it. Concurrent misses on the same key are collapsed behind a
+// per-key lock so only one caller runs the loader while the others wait for the
+// cached value. Loader errors are returned as is and nothing is cached.
+// A ctx marked by WithBypass always runs the loader.
+func (r *RedisCache) GetOrSet(ctx context.Context, key string, ttl time.Duration, dest any, loader func() (any, error)) error {
+	if Bypassed(ctx) {
+		return r.load(ctx, key, ttl, dest, loader)
+	}
+
+	err := r.Get(ctx, key, dest)
+	if err == nil {
+		return nil
+	}
+	if contextError(ctx, err) != nil {
+		return err
+	}
+	if !errors.Is(err, redis.Nil) {
+		// Redis is unavailable or holds garbage, go straight to the source
+		return r.load(ctx, key, ttl, dest, loader)
+	}
+
+	token, acquired := r.acquireLock(ctx, key)
+	if acquired {
+		defer r.releaseLock(ctx, key, token)
+		return r.load(ctx, key, ttl, dest, loader)
+	}
+
+	if r.waitForValue(ctx, key, dest) {
+		return nil
+	}
+
+	return r.load(ctx, key, ttl, dest, loader)
+}
+
+func (r *RedisCache) load(ctx context.Context, key string, ttl time.Duration, dest any, loader func() (any, error)) error {
+	value, err := loader()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		r.logger.Error("Cache marshal error", zap.String("key", key), zap.Error(err))
+		return err
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		r.logger.Error("Cache unmarshal error", zap.String("key", key), zap.Error(err))
+		return err
+	}
+
+	if err := r.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		cacheErrors.WithLabelValues(opSet).Inc()
+		r.logger.Error("Cache set error", zap.String("key", key), zap.Error(err))
+		return nil
+	}
+
+	r.logger.Debug("Cache set", zap.String("key", key), zap.Duration("ttl", ttl))
+	return nil
+}
+
+func (r *RedisCache) acquireLock(ctx context.Context, key string) (string, bool) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false
+	}
+	token := hex.EncodeToString(buf)
+
+	acquired, err := r.client.SetNX(ctx, lockKeyPrefix+key, token, lockTTL).Result()
+	if err != nil {
+		r.logger.Error("Cache lock error", zap.String("key", key), zap.Error(err))
+		return "", false
+	}
+	return token, acquired
+}
+
+func (r *RedisCache) releaseLock(ctx context.Context, key, token string) {
+	if err := releaseLockScript.Run(ctx, r.client, []string{lockKeyPrefix + key}, token).Err(); err != nil {
+		r.logger.Error("Cache unlock error", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (r *RedisCache) waitForValue(ctx context.Context, key string, dest any) bool {
+	deadline := time.Now().Add(lockWaitTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(lockRetryDelay):
+		}
+
+		if err := r.Get(ctx, key, dest); err == nil {
+			return true
+		} else if !errors.Is(err, redis.Nil) {
+			return false
+		}
+
+		// The holder finished without caching anything, e.g. its loader failed
+		if n, err := r.client.Exists(ctx, lockKeyPrefix+key).Result(); err != nil || n == 0 {
+			return false
+		}
+	}
+	return false
+}
+
 func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
 	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		if ctxErr := contextError(ctx, err); ctxErr != nil {
+			r.logger.Debug("Cache delete cancelled", zap.Strings("keys", keys), zap.Error(ctxErr))
+			return ctxErr
+		}
+		cacheErrors.WithLabelValues(opDelete).Inc()
 		r.logger.Error("Cache delete error", zap.Strings("keys", keys), zap.Error(err))
 		return err
 	}
@@ -67,6 +274,20 @@ func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
 	return nil
 }
 
+// Incr atomically increments the integer stored at key, starting from zero, and
+// returns the new value. The key does not expire.
+func (r *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+	val, err := r.client.Incr(ctx, key).Result()
+	if err != nil {
+		cacheErrors.WithLabelValues(opSet).Inc()
+		r.logger.Error("Cache incr error", zap.String("key", key), zap.Error(err))
+		return 0, err
+	}
+
+	r.logger.Debug("Cache incremented", zap.String("key", key), zap.Int64("value", val))
+	return val, nil
+}
+
 func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
 	iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
 	var keys []string
@@ -76,12 +297,14 @@ func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
 	}
 
 	if err := iter.Err(); err != nil {
+		cacheErrors.WithLabelValues(opDelete).Inc()
 		r.logger.Error("Cache scan error", zap.String("pattern", pattern), zap.Error(err))
 		return err
 	}
 
 	if len(keys) > 0 {
 		if err := r.client.Del(ctx, keys...).Err(); err != nil {
+			cacheErrors.WithLabelValues(opDelete).Inc()
 			r.logger.Error("Cache delete pattern error", zap.String("pattern", pattern), zap.Int("count", len(keys)), zap.Error(err))
 			return err
 		}
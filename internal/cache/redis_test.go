@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type item struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func setupCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisCache(client, zap.NewNop()), mr
+}
+
+func TestRedisCache_GetOrSet(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return cached value without calling loader", func(t *testing.T) {
+		c, _ := setupCache(t)
+		require.NoError(t, c.Set(ctx, "item:1", item{ID: 1, Name: "cached"}, time.Minute))
+
+		var got item
+		err := c.GetOrSet(ctx, "item:1", time.Minute, &got, func() (any, error) {
+			t.Fatal("loader should not be called on a cache hit")
+			return nil, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, item{ID: 1, Name: "cached"}, got)
+	})
+
+	t.Run("should load and populate cache on miss", func(t *testing.T) {
+		c, mr := setupCache(t)
+
+		var got item
+		err := c.GetOrSet(ctx, "item:1", time.Minute, &got, func() (any, error) {
+			return item{ID: 1, Name: "loaded"}, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, item{ID: 1, Name: "loaded"}, got)
+		assert.True(t, mr.Exists("item:1"))
+		assert.Equal(t, time.Minute, mr.TTL("item:1"))
+		assert.False(t, mr.Exists(lockKeyPrefix+"item:1"), "lock should be released")
+
+		var cached item
+		require.NoError(t, c.Get(ctx, "item:1", &cached))
+		assert.Equal(t, got, cached)
+	})
+
+	t.Run("should reload and overwrite cached value when bypassed", func(t *testing.T) {
+		c, _ := setupCache(t)
+		require.NoError(t, c.Set(ctx, "item:1", item{ID: 1, Name: "stale"}, time.Minute))
+
+		var got item
+		err := c.GetOrSet(WithBypass(ctx), "item:1", time.Minute, &got, func() (any, error) {
+			return item{ID: 1, Name: "fresh"}, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, item{ID: 1, Name: "fresh"}, got)
+
+		var cached item
+		require.NoError(t, c.Get(ctx, "item:1", &cached))
+		assert.Equal(t, got, cached)
+	})
+
+	t.Run("should propagate loader error without caching", func(t *testing.T) {
+		c, mr := setupCache(t)
+		loaderErr := errors.New("database unavailable")
+
+		var got item
+		err := c.GetOrSet(ctx, "item:1", time.Minute, &got, func() (any, error) {
+			return nil, loaderErr
+		})
+
+		assert.ErrorIs(t, err, loaderErr)
+		assert.False(t, mr.Exists("item:1"))
+		assert.False(t, mr.Exists(lockKeyPrefix+"item:1"))
+	})
+
+	t.Run("should fall back to loader when redis is down", func(t *testing.T) {
+		c, mr := setupCache(t)
+		mr.Close()
+
+		var got item
+		err := c.GetOrSet(ctx, "item:1", time.Minute, &got, func() (any, error) {
+			return item{ID: 1, Name: "loaded"}, nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, item{ID: 1, Name: "loaded"}, got)
+	})
+
+	t.Run("should call loader once for concurrent misses", func(t *testing.T) {
+		c, _ := setupCache(t)
+
+		var calls int32
+		loader := func() (any, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(50 * time.Millisecond)
+			return item{ID: 1, Name: "loaded"}, nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]item, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, c.GetOrSet(ctx, "item:1", time.Minute, &results[i], loader))
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for _, got := range results {
+			assert.Equal(t, item{ID: 1, Name: "loaded"}, got)
+		}
+	})
+}
+
+func TestRedisCache_GetMany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should decode hits and report missing indexes", func(t *testing.T) {
+		c, mr := setupCache(t)
+		require.NoError(t, c.Set(ctx, "item:1", item{ID: 1, Name: "first"}, time.Minute))
+		require.NoError(t, c.Set(ctx, "item:3", item{ID: 3, Name: "third"}, time.Minute))
+		require.NoError(t, mr.Set("item:4", "not json"))
+
+		got := make([]item, 4)
+		missing, err := c.GetMany(ctx, []string{"item:1", "item:2", "item:3", "item:4"}, func(i int) any {
+			return &got[i]
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 3}, missing)
+		assert.Equal(t, "first", got[0].Name)
+		assert.Equal(t, "third", got[2].Name)
+	})
+
+	t.Run("should report every key missing when redis is down", func(t *testing.T) {
+		c, mr := setupCache(t)
+		mr.Close()
+
+		missing, err := c.GetMany(ctx, []string{"item:1", "item:2"}, func(i int) any {
+			t.Fatal("nothing should be decoded")
+			return nil
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, []int{0, 1}, missing)
+	})
+}
+
+func TestRedisCache_Metrics(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should count hits and misses on get", func(t *testing.T) {
+		c, _ := setupCache(t)
+		hits, misses := testutil.ToFloat64(cacheHits.WithLabelValues(opGet)), testutil.ToFloat64(cacheMisses.WithLabelValues(opGet))
+
+		var got item
+		assert.ErrorIs(t, c.Get(ctx, "item:1", &got), redis.Nil)
+		require.NoError(t, c.Set(ctx, "item:1", item{ID: 1}, time.Minute))
+		require.NoError(t, c.Get(ctx, "item:1", &got))
+
+		assert.Equal(t, hits+1, testutil.ToFloat64(cacheHits.WithLabelValues(opGet)))
+		assert.Equal(t, misses+1, testutil.ToFloat64(cacheMisses.WithLabelValues(opGet)))
+	})
+
+	t.Run("should count errors when redis is down", func(t *testing.T) {
+		c, mr := setupCache(t)
+		mr.Close()
+		getErrors, setErrors := testutil.ToFloat64(cacheErrors.WithLabelValues(opGet)), testutil.ToFloat64(cacheErrors.WithLabelValues(opSet))
+
+		var got item
+		assert.Error(t, c.Get(ctx, "item:1", &got))
+		assert.Error(t, c.Set(ctx, "item:1", item{ID: 1}, time.Minute))
+
+		assert.Equal(t, getErrors+1, testutil.ToFloat64(cacheErrors.WithLabelValues(opGet)))
+		assert.Equal(t, setErrors+1, testutil.ToFloat64(cacheErrors.WithLabelValues(opSet)))
+	})
+}
+
+func TestRedisCache_CancelledContext(t *testing.T) {
+	setupObservedCache := func(t *testing.T) (*RedisCache, *observer.ObservedLogs) {
+		c, _ := setupCache(t)
+		core, logs := observer.New(zapcore.DebugLevel)
+		c.logger = zap.New(core)
+		return c, logs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		op   func(c *RedisCache) error
+	}{
+		{"get", func(c *RedisCache) error { var got item; return c.Get(ctx, "item:1", &got) }},
+		{"set", func(c *RedisCache) error { return c.Set(ctx, "item:1", item{ID: 1}, time.Minute) }},
+		{"delete", func(c *RedisCache) error { return c.Delete(ctx, "item:1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run("should return context error and log at debug on "+tt.name, func(t *testing.T) {
+			c, logs := setupObservedCache(t)
+			getErrors, setErrors := testutil.ToFloat64(cacheErrors.WithLabelValues(opGet)), testutil.ToFloat64(cacheErrors.WithLabelValues(opSet))
+
+			err := tt.op(c)
+
+			assert.Equal(t, context.Canceled, err)
+			assert.Zero(t, logs.FilterLevelExact(zapcore.ErrorLevel).Len())
+			assert.Equal(t, 1, logs.FilterLevelExact(zapcore.DebugLevel).Len())
+			assert.Equal(t, getErrors, testutil.ToFloat64(cacheErrors.WithLabelValues(opGet)))
+			assert.Equal(t, setErrors, testutil.ToFloat64(cacheErrors.WithLabelValues(opSet)))
+		})
+	}
+
+	t.Run("should not call loader for a cancelled request", func(t *testing.T) {
+		c, _ := setupObservedCache(t)
+
+		var got item
+		err := c.GetOrSet(ctx, "item:1", time.Minute, &got, func() (any, error) {
+			t.Fatal("loader should not be called for a cancelled request")
+			return nil, nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should report deadline exceeded", func(t *testing.T) {
+		c, logs := setupObservedCache(t)
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		var got item
+		err := c.Get(ctx, "item:1", &got)
+
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Zero(t, logs.FilterLevelExact(zapcore.ErrorLevel).Len())
+	})
+}
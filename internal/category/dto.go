@@ -0,0 +1,11 @@
+package category
+
+type CreateCategoryRequest struct {
+	Name string `json:"name" binding:"required" validate:"required,max=255"`
+	Slug string `json:"slug" validate:"omitempty,max=255"`
+}
+
+type UpdateCategoryRequest struct {
+	Name *string `json:"name" validate:"omitempty,max=255"`
+	Slug *string `json:"slug" validate:"omitempty,max=255"`
+}
@@ -0,0 +1,212 @@
+package category
+
+import (
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/middleware"
+	"mini-e-commerce/internal/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	ErrMsgInvalidCategoryID = "Invalid category ID"
+	ErrMsgFailedToCreate    = "Failed to create category"
+	ErrMsgFailedToFetch     = "Failed to fetch categories"
+	ErrMsgFailedToUpdate    = "Failed to update category"
+	ErrMsgFailedToDelete    = "Failed to delete category"
+)
+
+type Handler struct {
+	service        Service
+	logger         logger.Logger
+	responseHelper *response.ResponseHelper
+}
+
+func NewHandler(service Service, log logger.Logger) *Handler {
+	return &Handler{
+		service:        service,
+		logger:         log,
+		responseHelper: response.NewResponseHelper(log),
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	adminOnly := middleware.RequireRole(auth.RoleAdmin)
+	group := r.Group("/categories", authMiddleware)
+	group.POST("", adminOnly, h.CreateCategory)
+	group.GET("", h.GetAllCategories)
+	group.GET("/:id", h.GetCategoryByID)
+	group.PATCH("/:id", adminOnly, h.UpdateCategory)
+	group.DELETE("/:id", adminOnly, h.DeleteCategory)
+}
+
+// CreateCategory godoc
+// @Summary Create a new category
+// @Description Create a new product category. The slug is derived from the name when omitted
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Param   request body CreateCategoryRequest true "Category request body"
+// @Success 201 {object} response.SuccessResponse{data=Category}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /categories [post]
+func (h *Handler) CreateCategory(c *gin.Context) {
+	var input CreateCategoryRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	category, err := h.service.CreateCategory(c.Request.Context(), input)
+	if err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToCreate)
+		return
+	}
+
+	h.logger.WithContext(c).Info("Category created",
+		zap.Uint("category_id", category.ID),
+		zap.String("slug", category.Slug),
+	)
+
+	h.responseHelper.SuccessCreated(c, "Category created successfully", category)
+}
+
+// GetAllCategories godoc
+// @Summary Get all categories
+// @Description Get a list of all product categories ordered by name
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse{data=[]Category}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /categories [get]
+func (h *Handler) GetAllCategories(c *gin.Context) {
+	categories, err := h.service.GetAllCategories(c.Request.Context())
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "List category retrieved successfully", categories)
+}
+
+// GetCategoryByID godoc
+// @Summary Get single category
+// @Description Get category by id
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Category ID"
+// @Success 200 {object} response.SuccessResponse{data=Category}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /categories/{id} [get]
+func (h *Handler) GetCategoryByID(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidCategoryID, err.Error())
+		return
+	}
+
+	category, err := h.service.GetCategoryByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToFetch)
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Category retrieved successfully", category)
+}
+
+// UpdateCategory godoc
+// @Summary Update exist category
+// @Description Update name or slug of a single category
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Category ID"
+// @Param   request body UpdateCategoryRequest true "Category request body"
+// @Success 200 {object} response.SuccessResponse{data=Category}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /categories/{id} [patch]
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidCategoryID, err.Error())
+		return
+	}
+
+	var input UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Request.Context(), id, input)
+	if err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToUpdate)
+		return
+	}
+
+	h.logger.WithContext(c).Info("Category updated", zap.Uint("category_id", category.ID))
+
+	h.responseHelper.SuccessOK(c, "Category updated successfully", category)
+}
+
+// DeleteCategory godoc
+// @Summary Delete exist category
+// @Description Delete a single category. Products in the category are left uncategorized
+// @Tags Categories
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Category ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /categories/{id} [delete]
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidCategoryID, err.Error())
+		return
+	}
+
+	if err := h.service.DeleteCategory(c.Request.Context(), id); err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToDelete)
+		return
+	}
+
+	h.logger.WithContext(c).Info("Category deleted", zap.Uint("category_id", id))
+
+	h.responseHelper.SuccessOK(c, "Category deleted successfully", nil)
+}
+
+func (h *Handler) handleServiceError(c *gin.Context, err error, fallbackMsg string) {
+	switch err.Error() {
+	case ErrCategoryNotFound:
+		h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+	case ErrSlugAlreadyExists:
+		h.responseHelper.Error(c, http.StatusConflict, "Category already exists", response.ErrCodeDataAlreadyExists, err.Error())
+	case ErrInvalidSlug:
+		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+	default:
+		h.responseHelper.InternalServerError(c, fallbackMsg, err.Error())
+	}
+}
@@ -0,0 +1,17 @@
+package category
+
+import (
+	"mini-e-commerce/internal/utils"
+	"regexp"
+	"strings"
+)
+
+var ParseIDFromString = utils.ParseIDFromString
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a display name into a lowercase, hyphen separated slug
+func Slugify(name string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}
@@ -0,0 +1,62 @@
+package category
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, category *Category) error
+	FindAll(ctx context.Context) ([]Category, error)
+	FindByID(ctx context.Context, id uint) (Category, error)
+	FindBySlug(ctx context.Context, slug string) (Category, error)
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id uint) error
+	FindProductIDs(ctx context.Context, id uint) ([]uint, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, c *Category) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *repository) FindAll(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := r.db.WithContext(ctx).Order("name asc").Find(&categories).Error
+	return categories, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (Category, error) {
+	var c Category
+	err := r.db.WithContext(ctx).First(&c, id).Error
+	return c, err
+}
+
+func (r *repository) FindBySlug(ctx context.Context, slug string) (Category, error) {
+	var c Category
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&c).Error
+	return c, err
+}
+
+func (r *repository) Update(ctx context.Context, c *Category) error {
+	return r.db.WithContext(ctx).Save(c).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&Category{}, id).Error
+}
+
+// FindProductIDs returns the ids of the products filed under the category
+func (r *repository) FindProductIDs(ctx context.Context, id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Table("products").Where("category_id = ?", id).Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,183 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"mini-e-commerce/internal/tracing"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrCategoryNotFound  = "category not found"
+	ErrSlugAlreadyExists = "category slug already exists"
+	ErrInvalidSlug       = "category slug must contain letters or digits"
+)
+
+type Service interface {
+	CreateCategory(ctx context.Context, input CreateCategoryRequest) (*Category, error)
+	GetAllCategories(ctx context.Context) ([]Category, error)
+	GetCategoryByID(ctx context.Context, id uint) (*Category, error)
+	UpdateCategory(ctx context.Context, id uint, input UpdateCategoryRequest) (*Category, error)
+	DeleteCategory(ctx context.Context, id uint) error
+}
+
+// ProductCache drops cached products, product.Service satisfies it. Cached products and
+// list pages embed their category, so they go stale when it is renamed or deleted.
+type ProductCache interface {
+	InvalidateProducts(ctx context.Context, ids []uint)
+}
+
+type service struct {
+	repo      Repository
+	products  ProductCache
+	validator *validator.Validate
+	logger    *zap.Logger
+}
+
+func NewService(repo Repository, products ProductCache, logger *zap.Logger) Service {
+	return &service{
+		repo:      repo,
+		products:  products,
+		validator: validator.New(),
+		logger:    logger,
+	}
+}
+
+func (s *service) CreateCategory(ctx context.Context, input CreateCategoryRequest) (*Category, error) {
+	ctx, span := tracing.Start(ctx, "category.CreateCategory")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return nil, err
+	}
+
+	slug := input.Slug
+	if slug == "" {
+		slug = input.Name
+	}
+	slug = Slugify(slug)
+	if slug == "" {
+		return nil, errors.New(ErrInvalidSlug)
+	}
+
+	if err := s.ensureSlugAvailable(ctx, slug, 0); err != nil {
+		return nil, err
+	}
+
+	category := Category{
+		Name: input.Name,
+		Slug: slug,
+	}
+	if err := s.repo.Create(ctx, &category); err != nil {
+		s.logger.Error("Failed to create category", zap.Error(err))
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (s *service) GetAllCategories(ctx context.Context) ([]Category, error) {
+	ctx, span := tracing.Start(ctx, "category.GetAllCategories")
+	defer span.End()
+
+	return s.repo.FindAll(ctx)
+}
+
+func (s *service) GetCategoryByID(ctx context.Context, id uint) (*Category, error) {
+	ctx, span := tracing.Start(ctx, "category.GetCategoryByID")
+	defer span.End()
+
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrCategoryNotFound)
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *service) UpdateCategory(ctx context.Context, id uint, input UpdateCategoryRequest) (*Category, error) {
+	ctx, span := tracing.Start(ctx, "category.UpdateCategory")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return nil, err
+	}
+
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrCategoryNotFound)
+		}
+		return nil, err
+	}
+
+	if input.Name != nil {
+		category.Name = *input.Name
+	}
+	if input.Slug != nil {
+		slug := Slugify(*input.Slug)
+		if slug == "" {
+			return nil, errors.New(ErrInvalidSlug)
+		}
+		if slug != category.Slug {
+			if err := s.ensureSlugAvailable(ctx, slug, category.ID); err != nil {
+				return nil, err
+			}
+			category.Slug = slug
+		}
+	}
+
+	productIDs, err := s.repo.FindProductIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, &category); err != nil {
+		s.logger.Error("Failed to update category", zap.Error(err), zap.Uint("category_id", id))
+		return nil, err
+	}
+	s.products.InvalidateProducts(ctx, productIDs)
+
+	return &category, nil
+}
+
+func (s *service) DeleteCategory(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "category.DeleteCategory")
+	defer span.End()
+
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(ErrCategoryNotFound)
+		}
+		return err
+	}
+
+	// looked up first, deleting the category clears category_id on its products
+	productIDs, err := s.repo.FindProductIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.products.InvalidateProducts(ctx, productIDs)
+
+	return nil
+}
+
+func (s *service) ensureSlugAvailable(ctx context.Context, slug string, currentID uint) error {
+	existing, err := s.repo.FindBySlug(ctx, slug)
+	if err == nil && existing.ID != currentID {
+		return errors.New(ErrSlugAlreadyExists)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
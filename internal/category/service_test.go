@@ -0,0 +1,179 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, category *Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindAll(ctx context.Context) ([]Category, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Category), args.Error(1)
+}
+
+func (m *MockRepository) FindByID(ctx context.Context, id uint) (Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Category), args.Error(1)
+}
+
+func (m *MockRepository) FindBySlug(ctx context.Context, slug string) (Category, error) {
+	args := m.Called(ctx, slug)
+	return args.Get(0).(Category), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, category *Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindProductIDs(ctx context.Context, id uint) ([]uint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+type stubProductCache struct {
+	invalidated [][]uint
+}
+
+func (s *stubProductCache) InvalidateProducts(ctx context.Context, ids []uint) {
+	s.invalidated = append(s.invalidated, ids)
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "home-garden", Slugify("Home & Garden"))
+	assert.Equal(t, "tv-audio", Slugify("  TV / Audio  "))
+	assert.Equal(t, "", Slugify("!!!"))
+}
+
+func TestService_CreateCategory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should derive slug from name", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, new(stubProductCache), zap.NewNop())
+
+		mockRepo.On("FindBySlug", ctx, "home-garden").Return(Category{}, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(c *Category) bool {
+			return c.Name == "Home & Garden" && c.Slug == "home-garden"
+		})).Return(nil)
+
+		category, err := service.CreateCategory(ctx, CreateCategoryRequest{Name: "Home & Garden"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "home-garden", category.Slug)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject duplicate slug", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, new(stubProductCache), zap.NewNop())
+
+		mockRepo.On("FindBySlug", ctx, "electronics").Return(Category{ID: 1, Slug: "electronics"}, nil)
+
+		category, err := service.CreateCategory(ctx, CreateCategoryRequest{Name: "Electronics"})
+
+		assert.Nil(t, category)
+		require.Error(t, err)
+		assert.Equal(t, ErrSlugAlreadyExists, err.Error())
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_UpdateCategory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should invalidate the cached products of the category", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		products := new(stubProductCache)
+		service := NewService(mockRepo, products, zap.NewNop())
+
+		name := "Gadgets"
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Category{ID: 1, Name: "Electronics", Slug: "electronics"}, nil)
+		mockRepo.On("FindProductIDs", ctx, uint(1)).Return([]uint{3, 4}, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(c *Category) bool {
+			return c.Name == "Gadgets"
+		})).Return(nil)
+
+		category, err := service.UpdateCategory(ctx, 1, UpdateCategoryRequest{Name: &name})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Gadgets", category.Name)
+		assert.Equal(t, [][]uint{{3, 4}}, products.invalidated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should not invalidate when the update fails", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		products := new(stubProductCache)
+		service := NewService(mockRepo, products, zap.NewNop())
+
+		name := "Gadgets"
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Category{ID: 1, Name: "Electronics", Slug: "electronics"}, nil)
+		mockRepo.On("FindProductIDs", ctx, uint(1)).Return([]uint{3}, nil)
+		mockRepo.On("Update", ctx, mock.Anything).Return(errors.New("db down"))
+
+		_, err := service.UpdateCategory(ctx, 1, UpdateCategoryRequest{Name: &name})
+
+		require.Error(t, err)
+		assert.Empty(t, products.invalidated)
+	})
+}
+
+func TestService_DeleteCategory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should invalidate the products that were in the category", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		products := new(stubProductCache)
+		service := NewService(mockRepo, products, zap.NewNop())
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Category{ID: 1}, nil)
+		mockRepo.On("FindProductIDs", ctx, uint(1)).Return([]uint{3, 4}, nil)
+		mockRepo.On("Delete", ctx, uint(1)).Return(nil)
+
+		require.NoError(t, service.DeleteCategory(ctx, 1))
+
+		assert.Equal(t, [][]uint{{3, 4}}, products.invalidated)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should return not found without invalidating", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		products := new(stubProductCache)
+		service := NewService(mockRepo, products, zap.NewNop())
+
+		mockRepo.On("FindByID", ctx, uint(9)).Return(Category{}, gorm.ErrRecordNotFound)
+
+		err := service.DeleteCategory(ctx, 9)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrCategoryNotFound, err.Error())
+		assert.Empty(t, products.invalidated)
+	})
+}
@@ -1,25 +1,112 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
 )
 
 type Config struct {
 	DatabaseUrl       string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBAutoMigrate     bool
+	DBSlowQuery       time.Duration
+	ConnectAttempts   int
+	ConnectBaseDelay  time.Duration
 	RedisAddr         string
 	RedisPassword     string
 	Port              string
 	TrustedProxies    []string
+	ShutdownTimeout   time.Duration
+	MaxBodyBytes      int64
+	JWTAlgorithm      string
 	JWTSecret         string
+	JWTPrivateKeyPath string
+	JWTPublicKeyPath  string
+	JWTIssuer         string
+	JWTAudience       string
 	JWTExpiration     time.Duration
 	RefreshExpiration time.Duration
+	CookieSecure      bool
+	CookieSameSite    http.SameSite
+
+	// RememberMeExpiration replaces RefreshExpiration for logins that ask to be remembered
+	RememberMeExpiration time.Duration
+
+	// JWTLeeway tolerates clock skew between hosts when validating token timestamps
+	JWTLeeway time.Duration
+
+	// SessionTTL is how long a login's session is kept in Redis, RefreshExpiration when unset
+	SessionTTL time.Duration
+
+	// Redis pool sizing, a zero RedisPoolSize keeps the go-redis default of 10 per CPU
+	RedisPoolSize     int
+	RedisMinIdleConns int
+	RedisDialTimeout  time.Duration
+	RedisReadTimeout  time.Duration
+	RedisMaxRetries   int
+
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	AuthRateLimit       int
+	AuthRateLimitWindow time.Duration
+
+	RequireEmailVerification bool
+
+	OrderMaxItems           int
+	OrderMaxQuantityPerLine int
+	OrderCancellationWindow time.Duration
+
+	// PaymentGateway selects how orders are charged, "fake" approves every charge and is
+	// refused in production, empty turns payments off
+	PaymentGateway string
+
+	ProductNegativeCache bool
+
+	// UploadDir holds uploaded product images, they are served under UploadBaseURL
+	UploadDir     string
+	UploadBaseURL string
+	// MaxUploadBytes caps an image upload, it replaces MaxBodyBytes on that route
+	MaxUploadBytes int64
+
+	// BaseCurrency is the ISO 4217 code products get when created without one
+	BaseCurrency string
+
+	SessionFailureThreshold int
+	SessionBreakerCooldown  time.Duration
+	SessionAllowJWTOnly     bool
+
+	OTLPEndpoint       string
+	TracingServiceName string
+
+	// PayloadLogging logs JSON request and response bodies, with keys containing password,
+	// token or secret and the PayloadLogRedact keys masked, for the PayloadLogPaths prefixes or every path when empty. Debugging only.
+	PayloadLogging     bool
+	PayloadLogMaxBytes int
+	PayloadLogRedact   []string
+	PayloadLogPaths    []string
 }
 
+// Load reads configuration with precedence env vars (including .env) > config.<GIN_MODE>.yaml
+// > config.yaml > defaults
 func Load() (Config, error) {
+	if err := loadDotEnv(".env"); err != nil {
+		return Config{}, err
+	}
+
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
@@ -36,6 +123,10 @@ func Load() (Config, error) {
 		}
 	}
 
+	if err := mergeModeOverlay(); err != nil {
+		return Config{}, err
+	}
+
 	setDefaults()
 
 	var missingVars []string
@@ -55,52 +146,331 @@ func Load() (Config, error) {
 		missingVars = append(missingVars, "PORT")
 	}
 
+	jwtAlgorithm := strings.ToUpper(viper.GetString("jwt.algorithm"))
 	jwtSecret := viper.GetString("jwt.secret")
-	if jwtSecret == "" {
-		missingVars = append(missingVars, "JWT_SECRET")
+	jwtPrivateKeyPath := viper.GetString("jwt.private_key_path")
+	jwtPublicKeyPath := viper.GetString("jwt.public_key_path")
+
+	switch jwtAlgorithm {
+	case "HS256":
+		if jwtSecret == "" {
+			missingVars = append(missingVars, "JWT_SECRET")
+		}
+	case "RS256":
+		if jwtPrivateKeyPath == "" {
+			missingVars = append(missingVars, "JWT_PRIVATE_KEY_PATH")
+		}
+		if jwtPublicKeyPath == "" {
+			missingVars = append(missingVars, "JWT_PUBLIC_KEY_PATH")
+		}
+	default:
+		return Config{}, fmt.Errorf("unsupported jwt algorithm: %s", jwtAlgorithm)
 	}
 
 	if len(missingVars) > 0 {
 		return Config{}, fmt.Errorf("missing required configuration: %s", strings.Join(missingVars, ", "))
 	}
 
-	trustedProxies := viper.GetStringSlice("server.trusted_proxies")
+	trustedProxies, err := parseTrustedProxies(viper.GetStringSlice("server.trusted_proxies"))
+	if err != nil {
+		return Config{}, err
+	}
 	if len(trustedProxies) == 0 {
 		trustedProxies = []string{"127.0.0.1", "::1"}
 	}
 
+	cookieSameSite, err := parseSameSite(viper.GetString("cookie.same_site"))
+	if err != nil {
+		return Config{}, err
+	}
+
 	jwtExpMinutes := viper.GetInt("jwt.exp_minutes")
 	jwtExpiration := time.Duration(jwtExpMinutes) * time.Minute
 
 	refreshExpHours := viper.GetInt("jwt.refresh_exp_hours")
 	refreshExpiration := time.Duration(refreshExpHours) * time.Hour
+	sessionTTL := time.Duration(viper.GetInt("session.ttl_hours")) * time.Hour
+	if sessionTTL == 0 {
+		sessionTTL = refreshExpiration
+	}
 
-	return Config{
+	cfg := Config{
 		DatabaseUrl:       databaseUrl,
+		DBMaxOpenConns:    viper.GetInt("database.max_open_conns"),
+		DBMaxIdleConns:    viper.GetInt("database.max_idle_conns"),
+		DBConnMaxLifetime: time.Duration(viper.GetInt("database.conn_max_lifetime_minutes")) * time.Minute,
+		DBAutoMigrate:     viper.GetBool("database.auto_migrate"),
+		DBSlowQuery:       time.Duration(viper.GetInt("database.slow_query_threshold_ms")) * time.Millisecond,
+		ConnectAttempts:   viper.GetInt("startup.connect_attempts"),
+		ConnectBaseDelay:  time.Duration(viper.GetInt("startup.connect_base_delay_ms")) * time.Millisecond,
 		RedisAddr:         redisAddr,
 		RedisPassword:     viper.GetString("redis.password"),
 		Port:              port,
 		TrustedProxies:    trustedProxies,
+		ShutdownTimeout:   time.Duration(viper.GetInt("server.shutdown_timeout_seconds")) * time.Second,
+		MaxBodyBytes:      viper.GetInt64("server.max_body_bytes"),
+		JWTAlgorithm:      jwtAlgorithm,
 		JWTSecret:         jwtSecret,
+		JWTPrivateKeyPath: jwtPrivateKeyPath,
+		JWTPublicKeyPath:  jwtPublicKeyPath,
+		JWTIssuer:         viper.GetString("jwt.issuer"),
+		JWTAudience:       viper.GetString("jwt.audience"),
 		JWTExpiration:     jwtExpiration,
 		RefreshExpiration: refreshExpiration,
-	}, nil
+		CookieSecure:      viper.GetBool("cookie.secure"),
+		CookieSameSite:    cookieSameSite,
+
+		RememberMeExpiration: time.Duration(viper.GetInt("jwt.remember_me_exp_hours")) * time.Hour,
+		JWTLeeway:            time.Duration(viper.GetInt("jwt.leeway_seconds")) * time.Second,
+
+		SessionTTL: sessionTTL,
+
+		RedisPoolSize:     viper.GetInt("redis.pool_size"),
+		RedisMinIdleConns: viper.GetInt("redis.min_idle_conns"),
+		RedisDialTimeout:  time.Duration(viper.GetInt("redis.dial_timeout_ms")) * time.Millisecond,
+		RedisReadTimeout:  time.Duration(viper.GetInt("redis.read_timeout_ms")) * time.Millisecond,
+		RedisMaxRetries:   viper.GetInt("redis.max_retries"),
+
+		CORSAllowedOrigins:   splitList(viper.GetStringSlice("cors.allowed_origins")),
+		CORSAllowedMethods:   splitList(viper.GetStringSlice("cors.allowed_methods")),
+		CORSAllowedHeaders:   splitList(viper.GetStringSlice("cors.allowed_headers")),
+		CORSAllowCredentials: viper.GetBool("cors.allow_credentials"),
+		CORSMaxAge:           time.Duration(viper.GetInt("cors.max_age_hours")) * time.Hour,
+
+		AuthRateLimit:       viper.GetInt("rate_limit.auth_requests"),
+		AuthRateLimitWindow: time.Duration(viper.GetInt("rate_limit.auth_window_seconds")) * time.Second,
+
+		RequireEmailVerification: viper.GetBool("auth.require_email_verification"),
+
+		OrderMaxItems:           viper.GetInt("order.max_items"),
+		OrderMaxQuantityPerLine: viper.GetInt("order.max_quantity_per_line"),
+		OrderCancellationWindow: time.Duration(viper.GetInt("order.cancellation_window_minutes")) * time.Minute,
+
+		PaymentGateway: viper.GetString("payment.gateway"),
+
+		ProductNegativeCache: viper.GetBool("product.negative_cache"),
+
+		UploadDir:      viper.GetString("upload.dir"),
+		UploadBaseURL:  viper.GetString("upload.base_url"),
+		MaxUploadBytes: viper.GetInt64("upload.max_bytes"),
+
+		BaseCurrency: viper.GetString("currency.base"),
+
+		SessionFailureThreshold: viper.GetInt("session.failure_threshold"),
+		SessionBreakerCooldown:  time.Duration(viper.GetInt("session.breaker_cooldown_seconds")) * time.Second,
+		SessionAllowJWTOnly:     viper.GetBool("session.allow_jwt_only"),
+
+		OTLPEndpoint:       viper.GetString("tracing.otlp_endpoint"),
+		TracingServiceName: viper.GetString("tracing.service_name"),
+
+		PayloadLogging:     viper.GetBool("log.payloads"),
+		PayloadLogMaxBytes: viper.GetInt("log.payload_max_bytes"),
+		PayloadLogRedact:   splitList(viper.GetStringSlice("log.payload_redact")),
+		PayloadLogPaths:    splitList(viper.GetStringSlice("log.payload_paths")),
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// loadDotEnv exports variables from path without overriding ones already set in
+// the environment. A missing file is not an error.
+func loadDotEnv(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := gotenv.Load(path); err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return nil
+}
+
+// mergeModeOverlay merges config.<GIN_MODE>.yaml over the base file when it exists
+func mergeModeOverlay() error {
+	mode := strings.ToLower(os.Getenv("GIN_MODE"))
+	if mode == "" {
+		return nil
+	}
+
+	viper.SetConfigName("config." + mode)
+	if err := viper.MergeInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return fmt.Errorf("error reading %s config file: %w", mode, err)
+		}
+	}
+	return nil
 }
 
 func bindEnvVariables() {
 	viper.BindEnv("database.url", "DATABASE_URL")
+	viper.BindEnv("database.max_open_conns", "DB_MAX_OPEN_CONNS")
+	viper.BindEnv("database.max_idle_conns", "DB_MAX_IDLE_CONNS")
+	viper.BindEnv("database.conn_max_lifetime_minutes", "DB_CONN_MAX_LIFETIME_MINUTES")
+	viper.BindEnv("database.auto_migrate", "DB_AUTO_MIGRATE")
+	viper.BindEnv("database.slow_query_threshold_ms", "DB_SLOW_QUERY_THRESHOLD_MS")
+	viper.BindEnv("startup.connect_attempts", "CONNECT_ATTEMPTS")
+	viper.BindEnv("startup.connect_base_delay_ms", "CONNECT_BASE_DELAY_MS")
 	viper.BindEnv("redis.addr", "REDIS_ADDR")
 	viper.BindEnv("redis.password", "REDIS_PASSWORD")
+	viper.BindEnv("redis.pool_size", "REDIS_POOL_SIZE")
+	viper.BindEnv("redis.min_idle_conns", "REDIS_MIN_IDLE_CONNS")
+	viper.BindEnv("redis.dial_timeout_ms", "REDIS_DIAL_TIMEOUT_MS")
+	viper.BindEnv("redis.read_timeout_ms", "REDIS_READ_TIMEOUT_MS")
+	viper.BindEnv("redis.max_retries", "REDIS_MAX_RETRIES")
 	viper.BindEnv("server.port", "PORT")
 	viper.BindEnv("server.trusted_proxies", "TRUSTED_PROXIES")
+	viper.BindEnv("server.shutdown_timeout_seconds", "SHUTDOWN_TIMEOUT_SECONDS")
+	viper.BindEnv("server.max_body_bytes", "MAX_BODY_BYTES")
+	viper.BindEnv("jwt.algorithm", "JWT_ALGORITHM")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
+	viper.BindEnv("jwt.private_key_path", "JWT_PRIVATE_KEY_PATH")
+	viper.BindEnv("jwt.public_key_path", "JWT_PUBLIC_KEY_PATH")
+	viper.BindEnv("jwt.issuer", "JWT_ISSUER")
+	viper.BindEnv("jwt.audience", "JWT_AUDIENCE")
 	viper.BindEnv("jwt.exp_minutes", "JWT_EXP_MINUTES")
 	viper.BindEnv("jwt.refresh_exp_hours", "REFRESH_EXP_HOURS")
+	viper.BindEnv("jwt.remember_me_exp_hours", "REMEMBER_ME_EXP_HOURS")
+	viper.BindEnv("jwt.leeway_seconds", "JWT_LEEWAY_SECONDS")
+	viper.BindEnv("cookie.secure", "COOKIE_SECURE")
+	viper.BindEnv("cookie.same_site", "COOKIE_SAME_SITE")
+	viper.BindEnv("cors.allowed_origins", "CORS_ALLOWED_ORIGINS")
+	viper.BindEnv("cors.allowed_methods", "CORS_ALLOWED_METHODS")
+	viper.BindEnv("cors.allowed_headers", "CORS_ALLOWED_HEADERS")
+	viper.BindEnv("cors.allow_credentials", "CORS_ALLOW_CREDENTIALS")
+	viper.BindEnv("cors.max_age_hours", "CORS_MAX_AGE_HOURS")
+	viper.BindEnv("rate_limit.auth_requests", "AUTH_RATE_LIMIT_REQUESTS")
+	viper.BindEnv("rate_limit.auth_window_seconds", "AUTH_RATE_LIMIT_WINDOW_SECONDS")
+	viper.BindEnv("auth.require_email_verification", "REQUIRE_EMAIL_VERIFICATION")
+	viper.BindEnv("order.max_items", "ORDER_MAX_ITEMS")
+	viper.BindEnv("order.max_quantity_per_line", "ORDER_MAX_QUANTITY_PER_LINE")
+	viper.BindEnv("order.cancellation_window_minutes", "ORDER_CANCELLATION_WINDOW_MINUTES")
+	viper.BindEnv("payment.gateway", "PAYMENT_GATEWAY")
+	viper.BindEnv("product.negative_cache", "PRODUCT_NEGATIVE_CACHE")
+	viper.BindEnv("upload.dir", "UPLOAD_DIR")
+	viper.BindEnv("upload.base_url", "UPLOAD_BASE_URL")
+	viper.BindEnv("upload.max_bytes", "UPLOAD_MAX_BYTES")
+	viper.BindEnv("currency.base", "BASE_CURRENCY")
+	viper.BindEnv("session.ttl_hours", "SESSION_TTL_HOURS")
+	viper.BindEnv("session.failure_threshold", "SESSION_FAILURE_THRESHOLD")
+	viper.BindEnv("session.breaker_cooldown_seconds", "SESSION_BREAKER_COOLDOWN_SECONDS")
+	viper.BindEnv("session.allow_jwt_only", "SESSION_ALLOW_JWT_ONLY")
+	viper.BindEnv("tracing.otlp_endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	viper.BindEnv("tracing.service_name", "OTEL_SERVICE_NAME")
+	viper.BindEnv("log.payloads", "LOG_PAYLOADS")
+	viper.BindEnv("log.payload_max_bytes", "LOG_PAYLOAD_MAX_BYTES")
+	viper.BindEnv("log.payload_redact", "LOG_PAYLOAD_REDACT")
+	viper.BindEnv("log.payload_paths", "LOG_PAYLOAD_PATHS")
 }
 
 func setDefaults() {
+	viper.SetDefault("database.max_open_conns", 25)
+	viper.SetDefault("database.max_idle_conns", 10)
+	viper.SetDefault("database.conn_max_lifetime_minutes", 30)
+	viper.SetDefault("database.auto_migrate", false)
+	viper.SetDefault("database.slow_query_threshold_ms", 200)
+	viper.SetDefault("startup.connect_attempts", 5)
+	viper.SetDefault("startup.connect_base_delay_ms", 500)
+	viper.SetDefault("redis.pool_size", 0)
+	viper.SetDefault("redis.min_idle_conns", 2)
+	viper.SetDefault("redis.dial_timeout_ms", 5000)
+	viper.SetDefault("redis.read_timeout_ms", 3000)
+	viper.SetDefault("redis.max_retries", 3)
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.trusted_proxies", []string{"127.0.0.1", "::1"})
+	viper.SetDefault("server.shutdown_timeout_seconds", 10)
+	viper.SetDefault("server.max_body_bytes", 1<<20)
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("jwt.issuer", "mini-e-commerce")
+	viper.SetDefault("jwt.audience", "mini-e-commerce-api")
 	viper.SetDefault("jwt.exp_minutes", 15)
 	viper.SetDefault("jwt.refresh_exp_hours", 168)
+	viper.SetDefault("jwt.remember_me_exp_hours", 720)
+	viper.SetDefault("jwt.leeway_seconds", 30)
+	viper.SetDefault("cookie.secure", isProductionMode())
+	viper.SetDefault("cookie.same_site", "lax")
+	viper.SetDefault("cors.allowed_origins", []string{})
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("cors.allowed_headers", []string{"Authorization", "Content-Type", "X-CSRF-Token"})
+	viper.SetDefault("cors.allow_credentials", true)
+	viper.SetDefault("cors.max_age_hours", 12)
+	viper.SetDefault("rate_limit.auth_requests", 10)
+	viper.SetDefault("rate_limit.auth_window_seconds", 60)
+	viper.SetDefault("auth.require_email_verification", false)
+	viper.SetDefault("order.max_items", 50)
+	viper.SetDefault("order.max_quantity_per_line", 1000)
+	viper.SetDefault("order.cancellation_window_minutes", 30)
+	viper.SetDefault("payment.gateway", "")
+	viper.SetDefault("product.negative_cache", false)
+	viper.SetDefault("upload.dir", "uploads")
+	viper.SetDefault("upload.base_url", "/uploads")
+	viper.SetDefault("upload.max_bytes", 10<<20)
+	viper.SetDefault("currency.base", "IDR")
+	viper.SetDefault("session.ttl_hours", 0)
+	viper.SetDefault("session.failure_threshold", 5)
+	viper.SetDefault("session.breaker_cooldown_seconds", 30)
+	viper.SetDefault("session.allow_jwt_only", false)
+	viper.SetDefault("tracing.otlp_endpoint", "")
+	viper.SetDefault("tracing.service_name", "mini-e-commerce")
+	viper.SetDefault("log.payloads", false)
+	viper.SetDefault("log.payload_max_bytes", 4096)
+	viper.SetDefault("log.payload_redact", []string{})
+	viper.SetDefault("log.payload_paths", []string{})
+}
+
+func isProductionMode() bool {
+	mode := strings.ToLower(os.Getenv("GIN_MODE"))
+	return mode == "release" || mode == "production"
+}
+
+// splitList flattens comma separated entries, which is how env vars list values
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				list = append(list, entry)
+			}
+		}
+	}
+	return list
+}
+
+// parseTrustedProxies checks that every entry is an IP address or a CIDR range such as
+// 10.0.0.0/8. Entries may also be comma separated, which is how TRUSTED_PROXIES lists them.
+func parseTrustedProxies(values []string) ([]string, error) {
+	var proxies []string
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			if strings.Contains(entry, "/") {
+				if _, _, err := net.ParseCIDR(entry); err != nil {
+					return nil, fmt.Errorf("invalid trusted proxy %q: not a valid CIDR range", entry)
+				}
+			} else if net.ParseIP(entry) == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: not a valid IP address", entry)
+			}
+			proxies = append(proxies, entry)
+		}
+	}
+	return proxies, nil
+}
+
+func parseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return http.SameSiteDefaultMode, fmt.Errorf("unsupported cookie same_site value: %s", value)
+	}
 }
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupConfigDir writes files into a temp dir, makes it the working directory
+// and resets viper so each test loads from scratch
+func setupConfigDir(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	t.Chdir(dir)
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	t.Setenv("DATABASE_URL", "postgres://localhost/test")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", minProductionSecretLength))
+}
+
+const baseConfig = `
+server:
+  port: "9000"
+redis:
+  addr: base-redis:6379
+jwt:
+  exp_minutes: 30
+`
+
+const releaseConfig = `
+server:
+  port: "9100"
+`
+
+func TestLoad_Layers(t *testing.T) {
+	t.Run("should merge the mode overlay over the base file", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{
+			"config.yaml":         baseConfig,
+			"config.release.yaml": releaseConfig,
+		})
+		t.Setenv("GIN_MODE", "release")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "9100", cfg.Port, "overlay should win over base")
+		assert.Equal(t, "base-redis:6379", cfg.RedisAddr, "base values not in the overlay are kept")
+		assert.Equal(t, 30*time.Minute, cfg.JWTExpiration)
+		assert.Equal(t, 168*time.Hour, cfg.RefreshExpiration, "defaults fill the rest")
+	})
+
+	t.Run("should ignore the overlay of another mode", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{
+			"config.yaml":         baseConfig,
+			"config.release.yaml": releaseConfig,
+		})
+		t.Setenv("GIN_MODE", "debug")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "9000", cfg.Port)
+	})
+
+	t.Run("should let env vars win over the overlay", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{
+			"config.yaml":         baseConfig,
+			"config.release.yaml": releaseConfig,
+		})
+		t.Setenv("GIN_MODE", "release")
+		t.Setenv("PORT", "9200")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "9200", cfg.Port)
+	})
+
+	t.Run("should read .env without overriding the real environment", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{
+			"config.yaml": baseConfig,
+			".env":        "REDIS_ADDR=dotenv-redis:6379\nPORT=9300\n",
+		})
+		t.Setenv("PORT", "9200")
+		// REDIS_ADDR must be unset for .env to apply, restore whatever was there afterwards
+		t.Setenv("REDIS_ADDR", "")
+		require.NoError(t, os.Unsetenv("REDIS_ADDR"))
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "dotenv-redis:6379", cfg.RedisAddr, ".env should win over config files")
+		assert.Equal(t, "9200", cfg.Port, "real env should win over .env")
+	})
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	t.Run("should accept addresses and CIDR ranges", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{"config.yaml": baseConfig})
+		t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.10,fd00::/8")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"}, cfg.TrustedProxies)
+	})
+
+	t.Run("should read a list from the config file", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{"config.yaml": `
+redis:
+  addr: base-redis:6379
+server:
+  trusted_proxies:
+    - 172.16.0.0/12
+    - ::1
+`})
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"172.16.0.0/12", "::1"}, cfg.TrustedProxies)
+	})
+
+	t.Run("should name the malformed entry", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{"config.yaml": baseConfig})
+		t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,10.0.0.300")
+
+		_, err := Load()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"10.0.0.300"`)
+	})
+
+	t.Run("should reject an invalid prefix length", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{"config.yaml": baseConfig})
+		t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+
+		_, err := Load()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"10.0.0.0/33"`)
+	})
+}
+
+func TestLoad_CORS(t *testing.T) {
+	t.Run("should split comma separated env lists", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{"config.yaml": baseConfig})
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://shop.example.com, https://admin.example.com")
+		t.Setenv("CORS_ALLOWED_METHODS", "GET,POST")
+		t.Setenv("CORS_ALLOWED_HEADERS", "Authorization,Content-Type")
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://shop.example.com", "https://admin.example.com"}, cfg.CORSAllowedOrigins)
+		assert.Equal(t, []string{"GET", "POST"}, cfg.CORSAllowedMethods)
+		assert.Equal(t, []string{"Authorization", "Content-Type"}, cfg.CORSAllowedHeaders)
+	})
+
+	t.Run("should reject a wildcard listed among other origins", func(t *testing.T) {
+		setupConfigDir(t, map[string]string{"config.yaml": baseConfig})
+		t.Setenv("CORS_ALLOWED_ORIGINS", "https://shop.example.com,*")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+		_, err := Load()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CORS_ALLOWED_ORIGINS")
+	})
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const minProductionSecretLength = 32
+
+// paymentGatewayFake matches order.GatewayFake, config does not import the order package
+const paymentGatewayFake = "fake"
+
+// FieldError describes a single configuration value that failed validation
+type FieldError struct {
+	Field   string
+	Problem string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Problem
+}
+
+// ValidationError collects every problem found by Validate so they can be fixed in one go
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		problems[i] = fe.Error()
+	}
+	return "invalid configuration: " + strings.Join(problems, "; ")
+}
+
+// Validate checks values that parse fine but would break the app at runtime. It
+// returns a *ValidationError listing all problems, or nil.
+func (c Config) Validate() error {
+	var errs []FieldError
+	add := func(field, problem string) {
+		errs = append(errs, FieldError{Field: field, Problem: problem})
+	}
+
+	if c.JWTExpiration <= 0 {
+		add("JWT_EXP_MINUTES", "must be greater than zero")
+	}
+	if c.RefreshExpiration <= c.JWTExpiration {
+		add("REFRESH_EXP_HOURS", "must be longer than the access token expiration")
+	}
+	if c.RememberMeExpiration < c.RefreshExpiration {
+		add("REMEMBER_ME_EXP_HOURS", "must not be shorter than the refresh token expiration")
+	}
+	if c.SessionTTL <= c.JWTExpiration {
+		add("SESSION_TTL_HOURS", "must be longer than the access token expiration")
+	}
+	if c.RememberMeExpiration < c.SessionTTL {
+		add("REMEMBER_ME_EXP_HOURS", "must not be shorter than the session TTL")
+	}
+	if c.JWTLeeway < 0 {
+		add("JWT_LEEWAY_SECONDS", "must not be negative")
+	}
+	if c.JWTAlgorithm == "HS256" && isProductionMode() && len(c.JWTSecret) < minProductionSecretLength {
+		add("JWT_SECRET", fmt.Sprintf("must be at least %d characters in production", minProductionSecretLength))
+	}
+	if c.DBMaxOpenConns <= 0 {
+		add("DB_MAX_OPEN_CONNS", "must be greater than zero")
+	}
+	if c.DBMaxIdleConns < 0 {
+		add("DB_MAX_IDLE_CONNS", "must not be negative")
+	}
+	if c.DBConnMaxLifetime < 0 {
+		add("DB_CONN_MAX_LIFETIME_MINUTES", "must not be negative")
+	}
+	if c.DBSlowQuery < 0 {
+		add("DB_SLOW_QUERY_THRESHOLD_MS", "must not be negative")
+	}
+	if c.RedisPoolSize < 0 {
+		add("REDIS_POOL_SIZE", "must not be negative")
+	}
+	if c.RedisMinIdleConns < 0 {
+		add("REDIS_MIN_IDLE_CONNS", "must not be negative")
+	} else if c.RedisPoolSize > 0 && c.RedisMinIdleConns > c.RedisPoolSize {
+		add("REDIS_MIN_IDLE_CONNS", "must not exceed REDIS_POOL_SIZE")
+	}
+	if c.RedisDialTimeout <= 0 {
+		add("REDIS_DIAL_TIMEOUT_MS", "must be greater than zero")
+	}
+	if c.RedisReadTimeout <= 0 {
+		add("REDIS_READ_TIMEOUT_MS", "must be greater than zero")
+	}
+	if c.RedisMaxRetries < -1 {
+		add("REDIS_MAX_RETRIES", "must be -1 to disable retries or greater")
+	}
+	if c.ConnectAttempts < 1 {
+		add("CONNECT_ATTEMPTS", "must be at least 1")
+	}
+	if c.ConnectBaseDelay < 0 {
+		add("CONNECT_BASE_DELAY_MS", "must not be negative")
+	}
+	if c.MaxBodyBytes <= 0 {
+		add("MAX_BODY_BYTES", "must be greater than zero")
+	}
+	if c.MaxUploadBytes <= 0 {
+		add("UPLOAD_MAX_BYTES", "must be greater than zero")
+	}
+	if c.AuthRateLimit <= 0 {
+		add("AUTH_RATE_LIMIT_REQUESTS", "must be greater than zero")
+	}
+	if c.AuthRateLimitWindow <= 0 {
+		add("AUTH_RATE_LIMIT_WINDOW_SECONDS", "must be greater than zero")
+	}
+	if c.OrderMaxItems <= 0 {
+		add("ORDER_MAX_ITEMS", "must be greater than zero")
+	}
+	if c.OrderMaxQuantityPerLine <= 0 {
+		add("ORDER_MAX_QUANTITY_PER_LINE", "must be greater than zero")
+	}
+	if c.OrderCancellationWindow <= 0 {
+		add("ORDER_CANCELLATION_WINDOW_MINUTES", "must be greater than zero")
+	}
+	switch c.PaymentGateway {
+	case "":
+	case paymentGatewayFake:
+		// it approves every charge, in production orders would be marked paid for free
+		if isProductionMode() {
+			add("PAYMENT_GATEWAY", "must not be fake in production")
+		}
+	default:
+		add("PAYMENT_GATEWAY", `must be empty or "fake"`)
+	}
+	if err := validator.New().Var(c.BaseCurrency, "required,iso4217"); err != nil {
+		add("BASE_CURRENCY", "must be an uppercase ISO 4217 currency code")
+	}
+	if c.UploadDir == "" {
+		add("UPLOAD_DIR", "must not be empty")
+	}
+	if c.UploadBaseURL == "" {
+		add("UPLOAD_BASE_URL", "must not be empty")
+	}
+	// a wildcard is echoed back as the request origin, with credentials that would let any site act as the user
+	if c.CORSAllowCredentials && slices.Contains(c.CORSAllowedOrigins, "*") {
+		add("CORS_ALLOWED_ORIGINS", "must list explicit origins when CORS_ALLOW_CREDENTIALS is true")
+	}
+	if c.PayloadLogging && c.PayloadLogMaxBytes <= 0 {
+		add("LOG_PAYLOAD_MAX_BYTES", "must be greater than zero when payload logging is on")
+	}
+	if err := validateHostPort(c.RedisAddr); err != nil {
+		add("REDIS_ADDR", err.Error())
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func validateHostPort(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("must be in host:port form, got %q", addr)
+	}
+	if host == "" {
+		return fmt.Errorf("missing host in %q", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port in %q", addr)
+	}
+	return nil
+}
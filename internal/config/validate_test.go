@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() Config {
+	return Config{
+		RedisAddr:               "localhost:6379",
+		DBMaxOpenConns:          25,
+		DBMaxIdleConns:          10,
+		DBConnMaxLifetime:       30 * time.Minute,
+		ConnectAttempts:         5,
+		ConnectBaseDelay:        500 * time.Millisecond,
+		RedisMinIdleConns:       2,
+		RedisDialTimeout:        5 * time.Second,
+		RedisReadTimeout:        3 * time.Second,
+		RedisMaxRetries:         3,
+		JWTAlgorithm:            "HS256",
+		JWTSecret:               strings.Repeat("s", minProductionSecretLength),
+		JWTExpiration:           15 * time.Minute,
+		RefreshExpiration:       168 * time.Hour,
+		RememberMeExpiration:    720 * time.Hour,
+		SessionTTL:              168 * time.Hour,
+		JWTLeeway:               30 * time.Second,
+		MaxBodyBytes:            1 << 20,
+		AuthRateLimit:           10,
+		AuthRateLimitWindow:     time.Minute,
+		OrderMaxItems:           50,
+		OrderMaxQuantityPerLine: 1000,
+		OrderCancellationWindow: 30 * time.Minute,
+		BaseCurrency:            "IDR",
+		UploadDir:               "uploads",
+		UploadBaseURL:           "/uploads",
+		MaxUploadBytes:          10 << 20,
+		PayloadLogMaxBytes:      4096,
+	}
+}
+
+func fieldsOf(t *testing.T, err error) []string {
+	t.Helper()
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	fields := make([]string, len(validationErr.Errors))
+	for i, fe := range validationErr.Errors {
+		fields[i] = fe.Field
+	}
+	return fields
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("should accept a valid config", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "release")
+
+		assert.NoError(t, validConfig().Validate())
+	})
+
+	t.Run("should reject a short secret in production", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "release")
+		cfg := validConfig()
+		cfg.JWTSecret = "too-short"
+
+		err := cfg.Validate()
+
+		assert.Equal(t, []string{"JWT_SECRET"}, fieldsOf(t, err))
+	})
+
+	t.Run("should allow a short secret outside production", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "debug")
+		cfg := validConfig()
+		cfg.JWTSecret = "dev-secret"
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("should reject a zero expiration", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.JWTExpiration = 0
+
+		err := cfg.Validate()
+
+		assert.Equal(t, []string{"JWT_EXP_MINUTES"}, fieldsOf(t, err))
+	})
+
+	t.Run("should reject a session TTL outliving remember me", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.SessionTTL = 1000 * time.Hour
+
+		err := cfg.Validate()
+
+		assert.Equal(t, []string{"REMEMBER_ME_EXP_HOURS"}, fieldsOf(t, err))
+	})
+
+	t.Run("should reject the fake payment gateway in production", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "release")
+		cfg := validConfig()
+		cfg.PaymentGateway = "fake"
+
+		assert.Equal(t, []string{"PAYMENT_GATEWAY"}, fieldsOf(t, cfg.Validate()))
+	})
+
+	t.Run("should allow the fake payment gateway outside production", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "debug")
+		cfg := validConfig()
+		cfg.PaymentGateway = "fake"
+
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("should reject an unknown payment gateway", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.PaymentGateway = "stripe"
+
+		assert.Equal(t, []string{"PAYMENT_GATEWAY"}, fieldsOf(t, cfg.Validate()))
+	})
+
+	t.Run("should list every problem at once", func(t *testing.T) {
+		t.Setenv("GIN_MODE", "release")
+		cfg := validConfig()
+		cfg.JWTSecret = "short"
+		cfg.JWTExpiration = -time.Minute
+		cfg.RefreshExpiration = -time.Hour
+		cfg.RedisAddr = "localhost"
+
+		err := cfg.Validate()
+
+		assert.Equal(t, []string{"JWT_EXP_MINUTES", "REFRESH_EXP_HOURS", "JWT_SECRET", "REDIS_ADDR"}, fieldsOf(t, err))
+		assert.Contains(t, err.Error(), "invalid configuration: JWT_EXP_MINUTES: must be greater than zero")
+	})
+
+	t.Run("should reject an unbounded connection pool", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.DBMaxOpenConns = 0
+		cfg.DBMaxIdleConns = -1
+
+		assert.Equal(t, []string{"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS"}, fieldsOf(t, cfg.Validate()))
+	})
+
+	t.Run("should reject a base currency that is not an ISO 4217 code", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.BaseCurrency = "RUPIAH"
+
+		assert.Equal(t, []string{"BASE_CURRENCY"}, fieldsOf(t, cfg.Validate()))
+	})
+
+	t.Run("should reject a redis address with a bad port", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.RedisAddr = "localhost:redis"
+
+		assert.Equal(t, []string{"REDIS_ADDR"}, fieldsOf(t, cfg.Validate()))
+	})
+
+	t.Run("should reject more idle redis connections than the pool holds", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.RedisPoolSize = 4
+		cfg.RedisMinIdleConns = 8
+
+		assert.Equal(t, []string{"REDIS_MIN_IDLE_CONNS"}, fieldsOf(t, cfg.Validate()))
+	})
+	t.Run("should reject a wildcard origin with credentials", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.CORSAllowedOrigins = []string{"https://shop.example.com", "*"}
+		cfg.CORSAllowCredentials = true
+
+		assert.Equal(t, []string{"CORS_ALLOWED_ORIGINS"}, fieldsOf(t, cfg.Validate()))
+
+		cfg.CORSAllowCredentials = false
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("should reject a rate limit that never lets a request through", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.AuthRateLimit = 0
+		cfg.AuthRateLimitWindow = -time.Second
+
+		assert.Equal(t, []string{"AUTH_RATE_LIMIT_REQUESTS", "AUTH_RATE_LIMIT_WINDOW_SECONDS"}, fieldsOf(t, cfg.Validate()))
+	})
+}
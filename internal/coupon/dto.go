@@ -0,0 +1,21 @@
+package coupon
+
+import "time"
+
+type CreateCouponRequest struct {
+	Code       string `json:"code" binding:"required" validate:"required,max=50"`
+	PercentOff *int   `json:"percent_off" validate:"omitempty,min=1,max=100"`
+	AmountOff  *int   `json:"amount_off" validate:"omitempty,gt=0"`
+	// Currency is the ISO 4217 code of amount_off, the configured base currency when omitted
+	Currency  *string    `json:"currency" binding:"omitempty,iso4217" validate:"omitempty,iso4217"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses" validate:"gte=0"`
+}
+
+type UpdateCouponRequest struct {
+	PercentOff *int       `json:"percent_off" validate:"omitempty,min=1,max=100"`
+	AmountOff  *int       `json:"amount_off" validate:"omitempty,gt=0"`
+	Currency   *string    `json:"currency" binding:"omitempty,iso4217" validate:"omitempty,iso4217"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	MaxUses    *int       `json:"max_uses" validate:"omitempty,gte=0"`
+}
@@ -0,0 +1,212 @@
+package coupon
+
+import (
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/middleware"
+	"mini-e-commerce/internal/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	ErrMsgInvalidCouponID = "Invalid coupon ID"
+	ErrMsgFailedToCreate  = "Failed to create coupon"
+	ErrMsgFailedToFetch   = "Failed to fetch coupons"
+	ErrMsgFailedToUpdate  = "Failed to update coupon"
+	ErrMsgFailedToDelete  = "Failed to delete coupon"
+)
+
+type Handler struct {
+	service        Service
+	logger         logger.Logger
+	responseHelper *response.ResponseHelper
+}
+
+func NewHandler(service Service, log logger.Logger) *Handler {
+	return &Handler{
+		service:        service,
+		logger:         log,
+		responseHelper: response.NewResponseHelper(log),
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	group := r.Group("/coupons", authMiddleware, middleware.RequireRole(auth.RoleAdmin))
+	group.POST("", h.CreateCoupon)
+	group.GET("", h.GetAllCoupons)
+	group.GET("/:id", h.GetCouponByID)
+	group.PATCH("/:id", h.UpdateCoupon)
+	group.DELETE("/:id", h.DeleteCoupon)
+}
+
+// CreateCoupon godoc
+// @Summary Create a new coupon
+// @Description Create a discount code. Exactly one of percent_off or amount_off must be set, max_uses of 0 means unlimited
+// @Tags Coupons
+// @Accept  json
+// @Produce  json
+// @Param   request body CreateCouponRequest true "Coupon request body"
+// @Success 201 {object} response.SuccessResponse{data=Coupon}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /coupons [post]
+func (h *Handler) CreateCoupon(c *gin.Context) {
+	var input CreateCouponRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	coupon, err := h.service.CreateCoupon(c.Request.Context(), input)
+	if err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToCreate)
+		return
+	}
+
+	h.logger.WithContext(c).Info("Coupon created",
+		zap.Uint("coupon_id", coupon.ID),
+		zap.String("code", coupon.Code),
+	)
+
+	h.responseHelper.SuccessCreated(c, "Coupon created successfully", coupon)
+}
+
+// GetAllCoupons godoc
+// @Summary Get all coupons
+// @Description Get a list of all coupons, newest first
+// @Tags Coupons
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse{data=[]Coupon}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /coupons [get]
+func (h *Handler) GetAllCoupons(c *gin.Context) {
+	coupons, err := h.service.GetAllCoupons(c.Request.Context())
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "List coupon retrieved successfully", coupons)
+}
+
+// GetCouponByID godoc
+// @Summary Get single coupon
+// @Description Get coupon by id
+// @Tags Coupons
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Coupon ID"
+// @Success 200 {object} response.SuccessResponse{data=Coupon}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /coupons/{id} [get]
+func (h *Handler) GetCouponByID(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidCouponID, err.Error())
+		return
+	}
+
+	coupon, err := h.service.GetCouponByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToFetch)
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Coupon retrieved successfully", coupon)
+}
+
+// UpdateCoupon godoc
+// @Summary Update exist coupon
+// @Description Update discount, expiry or usage limit of a single coupon
+// @Tags Coupons
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Coupon ID"
+// @Param   request body UpdateCouponRequest true "Coupon request body"
+// @Success 200 {object} response.SuccessResponse{data=Coupon}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /coupons/{id} [patch]
+func (h *Handler) UpdateCoupon(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidCouponID, err.Error())
+		return
+	}
+
+	var input UpdateCouponRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	coupon, err := h.service.UpdateCoupon(c.Request.Context(), id, input)
+	if err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToUpdate)
+		return
+	}
+
+	h.logger.WithContext(c).Info("Coupon updated", zap.Uint("coupon_id", coupon.ID))
+
+	h.responseHelper.SuccessOK(c, "Coupon updated successfully", coupon)
+}
+
+// DeleteCoupon godoc
+// @Summary Delete exist coupon
+// @Description Delete a single coupon. Orders keep the code and discount they were placed with
+// @Tags Coupons
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Coupon ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /coupons/{id} [delete]
+func (h *Handler) DeleteCoupon(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidCouponID, err.Error())
+		return
+	}
+
+	if err := h.service.DeleteCoupon(c.Request.Context(), id); err != nil {
+		h.handleServiceError(c, err, ErrMsgFailedToDelete)
+		return
+	}
+
+	h.logger.WithContext(c).Info("Coupon deleted", zap.Uint("coupon_id", id))
+
+	h.responseHelper.SuccessOK(c, "Coupon deleted successfully", nil)
+}
+
+func (h *Handler) handleServiceError(c *gin.Context, err error, fallbackMsg string) {
+	switch err.Error() {
+	case ErrCouponNotFound:
+		h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+	case ErrCodeAlreadyExists:
+		h.responseHelper.Error(c, http.StatusConflict, "Coupon already exists", response.ErrCodeDataAlreadyExists, err.Error())
+	case ErrInvalidDiscountType, ErrCurrencyWithoutAmount:
+		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+	default:
+		h.responseHelper.InternalServerError(c, fallbackMsg, err.Error())
+	}
+}
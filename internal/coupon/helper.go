@@ -0,0 +1,13 @@
+package coupon
+
+import (
+	"mini-e-commerce/internal/utils"
+	"strings"
+)
+
+var ParseIDFromString = utils.ParseIDFromString
+
+// NormalizeCode makes coupon codes case and whitespace insensitive
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,49 @@
+package coupon
+
+import "time"
+
+// Coupon grants either a percentage or a fixed amount off an order total. A fixed
+// amount is in Currency and only applies to orders in that currency.
+// MaxUses of zero means the coupon can be used any number of times.
+type Coupon struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Code       string     `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
+	PercentOff *int       `json:"percent_off,omitempty"`
+	AmountOff  *int       `json:"amount_off,omitempty"`
+	Currency   *string    `gorm:"type:char(3)" json:"currency,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	MaxUses    int        `gorm:"not null;default:0" json:"max_uses"`
+	UsedCount  int        `gorm:"not null;default:0" json:"used_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (c *Coupon) IsExpired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+func (c *Coupon) IsExhausted() bool {
+	return c.MaxUses > 0 && c.UsedCount >= c.MaxUses
+}
+
+// AppliesTo reports whether the coupon can discount an order in currency, percentage
+// coupons apply to any currency
+func (c *Coupon) AppliesTo(currency string) bool {
+	return c.AmountOff == nil || c.Currency == nil || *c.Currency == currency
+}
+
+// Discount returns the amount taken off total, never more than total itself
+func (c *Coupon) Discount(total int) int {
+	var discount int
+	switch {
+	case c.PercentOff != nil:
+		discount = total * *c.PercentOff / 100
+	case c.AmountOff != nil:
+		discount = *c.AmountOff
+	}
+
+	if discount > total {
+		return total
+	}
+	return discount
+}
@@ -0,0 +1,70 @@
+package coupon
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, coupon *Coupon) error
+	FindAll(ctx context.Context) ([]Coupon, error)
+	FindByID(ctx context.Context, id uint) (Coupon, error)
+	FindByCode(ctx context.Context, code string) (Coupon, error)
+	Update(ctx context.Context, coupon *Coupon) error
+	Delete(ctx context.Context, id uint) error
+	IncrementUsageWithTx(tx *gorm.DB, id uint, now time.Time) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, c *Coupon) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *repository) FindAll(ctx context.Context) ([]Coupon, error) {
+	var coupons []Coupon
+	err := r.db.WithContext(ctx).Order("created_at desc").Find(&coupons).Error
+	return coupons, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (Coupon, error) {
+	var c Coupon
+	err := r.db.WithContext(ctx).First(&c, id).Error
+	return c, err
+}
+
+func (r *repository) FindByCode(ctx context.Context, code string) (Coupon, error) {
+	var c Coupon
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&c).Error
+	return c, err
+}
+
+func (r *repository) Update(ctx context.Context, c *Coupon) error {
+	return r.db.WithContext(ctx).Save(c).Error
+}
+
+func (r *repository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&Coupon{}, id).Error
+}
+
+// IncrementUsageWithTx bumps used_count only while the coupon is still redeemable, so
+// two orders racing for the last use cannot both succeed. It reports whether a row was updated.
+func (r *repository) IncrementUsageWithTx(tx *gorm.DB, id uint, now time.Time) (bool, error) {
+	result := tx.Model(&Coupon{}).
+		Where("id = ?", id).
+		Where("max_uses = 0 OR used_count < max_uses").
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,60 @@
+package coupon
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_IncrementUsageWithTx(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta(`UPDATE "coupons" SET "used_count"=used_count + 1 WHERE id = $1 AND (max_uses = 0 OR used_count < max_uses) AND (expires_at IS NULL OR expires_at > $2)`)
+
+	t.Run("should increment usage while coupon is redeemable", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(query).WithArgs(1, now).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		updated, err := repo.IncrementUsageWithTx(db, 1, now)
+
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report no update when coupon is used up", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(query).WithArgs(1, now).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		updated, err := repo.IncrementUsageWithTx(db, 1, now)
+
+		require.NoError(t, err)
+		assert.False(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
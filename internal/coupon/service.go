@@ -0,0 +1,228 @@
+package coupon
+
+import (
+	"context"
+	"errors"
+	"mini-e-commerce/internal/tracing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrCouponNotFound        = "coupon not found"
+	ErrCouponExpired         = "coupon has expired"
+	ErrCouponExhausted       = "coupon has no uses remaining"
+	ErrCodeAlreadyExists     = "coupon code already exists"
+	ErrInvalidDiscountType   = "coupon must set exactly one of percent_off or amount_off"
+	ErrCurrencyWithoutAmount = "currency only applies to amount_off coupons"
+)
+
+type Service interface {
+	CreateCoupon(ctx context.Context, input CreateCouponRequest) (*Coupon, error)
+	GetAllCoupons(ctx context.Context) ([]Coupon, error)
+	GetCouponByID(ctx context.Context, id uint) (*Coupon, error)
+	UpdateCoupon(ctx context.Context, id uint, input UpdateCouponRequest) (*Coupon, error)
+	DeleteCoupon(ctx context.Context, id uint) error
+	ValidateCoupon(ctx context.Context, code string) (*Coupon, error)
+	RedeemCouponWithTx(tx *gorm.DB, id uint) error
+}
+
+type service struct {
+	repo      Repository
+	validator *validator.Validate
+	// baseCurrency is used for amount_off coupons created without a currency
+	baseCurrency string
+	logger       *zap.Logger
+	now          func() time.Time
+}
+
+func NewService(repo Repository, baseCurrency string, logger *zap.Logger) Service {
+	return &service{
+		repo:         repo,
+		validator:    validator.New(),
+		baseCurrency: baseCurrency,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+func (s *service) currencyOrBase(currency *string) *string {
+	if currency == nil {
+		base := s.baseCurrency
+		return &base
+	}
+	return currency
+}
+
+func (s *service) CreateCoupon(ctx context.Context, input CreateCouponRequest) (*Coupon, error) {
+	ctx, span := tracing.Start(ctx, "coupon.CreateCoupon")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return nil, err
+	}
+
+	if (input.PercentOff == nil) == (input.AmountOff == nil) {
+		return nil, errors.New(ErrInvalidDiscountType)
+	}
+	if input.Currency != nil && input.AmountOff == nil {
+		return nil, errors.New(ErrCurrencyWithoutAmount)
+	}
+
+	code := NormalizeCode(input.Code)
+	_, err := s.repo.FindByCode(ctx, code)
+	if err == nil {
+		return nil, errors.New(ErrCodeAlreadyExists)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	coupon := Coupon{
+		Code:       code,
+		PercentOff: input.PercentOff,
+		AmountOff:  input.AmountOff,
+		ExpiresAt:  input.ExpiresAt,
+		MaxUses:    input.MaxUses,
+	}
+	if coupon.AmountOff != nil {
+		coupon.Currency = s.currencyOrBase(input.Currency)
+	}
+	if err := s.repo.Create(ctx, &coupon); err != nil {
+		s.logger.Error("Failed to create coupon", zap.Error(err))
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (s *service) GetAllCoupons(ctx context.Context) ([]Coupon, error) {
+	ctx, span := tracing.Start(ctx, "coupon.GetAllCoupons")
+	defer span.End()
+
+	return s.repo.FindAll(ctx)
+}
+
+func (s *service) GetCouponByID(ctx context.Context, id uint) (*Coupon, error) {
+	ctx, span := tracing.Start(ctx, "coupon.GetCouponByID")
+	defer span.End()
+
+	coupon, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrCouponNotFound)
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (s *service) UpdateCoupon(ctx context.Context, id uint, input UpdateCouponRequest) (*Coupon, error) {
+	ctx, span := tracing.Start(ctx, "coupon.UpdateCoupon")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return nil, err
+	}
+
+	if input.PercentOff != nil && input.AmountOff != nil {
+		return nil, errors.New(ErrInvalidDiscountType)
+	}
+	if input.PercentOff != nil && input.Currency != nil {
+		return nil, errors.New(ErrCurrencyWithoutAmount)
+	}
+
+	coupon, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrCouponNotFound)
+		}
+		return nil, err
+	}
+
+	// Switching the discount type clears the other one
+	if input.PercentOff != nil {
+		coupon.PercentOff = input.PercentOff
+		coupon.AmountOff = nil
+		coupon.Currency = nil
+	}
+	if input.AmountOff != nil {
+		coupon.AmountOff = input.AmountOff
+		coupon.PercentOff = nil
+	}
+	if input.Currency != nil {
+		if coupon.AmountOff == nil {
+			return nil, errors.New(ErrCurrencyWithoutAmount)
+		}
+		coupon.Currency = input.Currency
+	}
+	if coupon.AmountOff != nil && coupon.Currency == nil {
+		coupon.Currency = s.currencyOrBase(nil)
+	}
+	if input.ExpiresAt != nil {
+		coupon.ExpiresAt = input.ExpiresAt
+	}
+	if input.MaxUses != nil {
+		coupon.MaxUses = *input.MaxUses
+	}
+
+	if err := s.repo.Update(ctx, &coupon); err != nil {
+		s.logger.Error("Failed to update coupon", zap.Error(err), zap.Uint("coupon_id", id))
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+func (s *service) DeleteCoupon(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "coupon.DeleteCoupon")
+	defer span.End()
+
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(ErrCouponNotFound)
+		}
+		return err
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+// ValidateCoupon looks up a coupon by code and checks that it can still be redeemed.
+// The usage count is only reserved by RedeemCouponWithTx.
+func (s *service) ValidateCoupon(ctx context.Context, code string) (*Coupon, error) {
+	ctx, span := tracing.Start(ctx, "coupon.ValidateCoupon")
+	defer span.End()
+
+	coupon, err := s.repo.FindByCode(ctx, NormalizeCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrCouponNotFound)
+		}
+		return nil, err
+	}
+
+	if coupon.IsExpired(s.now()) {
+		return nil, errors.New(ErrCouponExpired)
+	}
+	if coupon.IsExhausted() {
+		return nil, errors.New(ErrCouponExhausted)
+	}
+
+	return &coupon, nil
+}
+
+func (s *service) RedeemCouponWithTx(tx *gorm.DB, id uint) error {
+	updated, err := s.repo.IncrementUsageWithTx(tx, id, s.now())
+	if err != nil {
+		return err
+	}
+	if !updated {
+		// Validated earlier but used up or expired by the time the order commits
+		return errors.New(ErrCouponExhausted)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package coupon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, coupon *Coupon) error {
+	args := m.Called(ctx, coupon)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindAll(ctx context.Context) ([]Coupon, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Coupon), args.Error(1)
+}
+
+func (m *MockRepository) FindByID(ctx context.Context, id uint) (Coupon, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Coupon), args.Error(1)
+}
+
+func (m *MockRepository) FindByCode(ctx context.Context, code string) (Coupon, error) {
+	args := m.Called(ctx, code)
+	return args.Get(0).(Coupon), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, coupon *Coupon) error {
+	args := m.Called(ctx, coupon)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IncrementUsageWithTx(tx *gorm.DB, id uint, now time.Time) (bool, error) {
+	args := m.Called(tx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCoupon_Discount(t *testing.T) {
+	assert.Equal(t, 150, (&Coupon{PercentOff: intPtr(15)}).Discount(1000))
+	assert.Equal(t, 333, (&Coupon{PercentOff: intPtr(33)}).Discount(1010), "percentage discount rounds down")
+	assert.Equal(t, 200, (&Coupon{AmountOff: intPtr(200)}).Discount(1000))
+	assert.Equal(t, 500, (&Coupon{AmountOff: intPtr(800)}).Discount(500), "discount never exceeds the total")
+}
+
+func TestCoupon_AppliesTo(t *testing.T) {
+	idr := "IDR"
+	assert.True(t, (&Coupon{PercentOff: intPtr(10)}).AppliesTo("USD"))
+	assert.True(t, (&Coupon{AmountOff: intPtr(100), Currency: &idr}).AppliesTo("IDR"))
+	assert.False(t, (&Coupon{AmountOff: intPtr(100), Currency: &idr}).AppliesTo("USD"))
+}
+
+func TestService_CreateCoupon(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should normalize code and create coupon", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+
+		mockRepo.On("FindByCode", ctx, "SUMMER10").Return(Coupon{}, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(c *Coupon) bool {
+			return c.Code == "SUMMER10" && *c.PercentOff == 10 && c.AmountOff == nil
+		})).Return(nil)
+
+		coupon, err := service.CreateCoupon(ctx, CreateCouponRequest{Code: " summer10 ", PercentOff: intPtr(10)})
+
+		require.NoError(t, err)
+		assert.Equal(t, "SUMMER10", coupon.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should require exactly one discount type", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+
+		for _, input := range []CreateCouponRequest{
+			{Code: "NONE"},
+			{Code: "BOTH", PercentOff: intPtr(10), AmountOff: intPtr(100)},
+		} {
+			coupon, err := service.CreateCoupon(ctx, input)
+
+			require.Error(t, err)
+			assert.Equal(t, ErrInvalidDiscountType, err.Error())
+			assert.Nil(t, coupon)
+		}
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should default an amount coupon to the base currency", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+
+		mockRepo.On("FindByCode", ctx, "FLAT100").Return(Coupon{}, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*coupon.Coupon")).Return(nil)
+
+		coupon, err := service.CreateCoupon(ctx, CreateCouponRequest{Code: "FLAT100", AmountOff: intPtr(100)})
+
+		require.NoError(t, err)
+		require.NotNil(t, coupon.Currency)
+		assert.Equal(t, "IDR", *coupon.Currency)
+	})
+
+	t.Run("should reject a currency on a percentage coupon", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+		usd := "USD"
+
+		coupon, err := service.CreateCoupon(ctx, CreateCouponRequest{Code: "SUMMER10", PercentOff: intPtr(10), Currency: &usd})
+
+		require.Error(t, err)
+		assert.Equal(t, ErrCurrencyWithoutAmount, err.Error())
+		assert.Nil(t, coupon)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject an invalid currency code", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+		bad := "usd"
+
+		_, err := service.CreateCoupon(ctx, CreateCouponRequest{Code: "FLAT100", AmountOff: intPtr(100), Currency: &bad})
+
+		require.Error(t, err)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject duplicate code", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+
+		mockRepo.On("FindByCode", ctx, "SUMMER10").Return(Coupon{ID: 1, Code: "SUMMER10"}, nil)
+
+		coupon, err := service.CreateCoupon(ctx, CreateCouponRequest{Code: "SUMMER10", AmountOff: intPtr(100)})
+
+		require.Error(t, err)
+		assert.Equal(t, ErrCodeAlreadyExists, err.Error())
+		assert.Nil(t, coupon)
+	})
+}
+
+func TestService_ValidateCoupon(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		coupon      Coupon
+		findErr     error
+		expectedErr string
+	}{
+		{name: "should accept valid coupon", coupon: Coupon{ID: 1, Code: "VALID", PercentOff: intPtr(10), ExpiresAt: &future, MaxUses: 2, UsedCount: 1}},
+		{name: "should accept coupon without limits", coupon: Coupon{ID: 1, Code: "VALID", AmountOff: intPtr(100)}},
+		{name: "should reject expired coupon", coupon: Coupon{ID: 1, Code: "VALID", PercentOff: intPtr(10), ExpiresAt: &past}, expectedErr: ErrCouponExpired},
+		{name: "should reject exhausted coupon", coupon: Coupon{ID: 1, Code: "VALID", PercentOff: intPtr(10), MaxUses: 2, UsedCount: 2}, expectedErr: ErrCouponExhausted},
+		{name: "should reject unknown coupon", findErr: gorm.ErrRecordNotFound, expectedErr: ErrCouponNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewService(mockRepo, "IDR", zap.NewNop())
+
+			mockRepo.On("FindByCode", ctx, "VALID").Return(tt.coupon, tt.findErr)
+
+			coupon, err := service.ValidateCoupon(ctx, "valid")
+
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedErr, err.Error())
+				assert.Nil(t, coupon)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.coupon.ID, coupon.ID)
+		})
+	}
+}
+
+func TestService_RedeemCouponWithTx(t *testing.T) {
+	t.Run("should report exhausted when no row could be updated", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewService(mockRepo, "IDR", zap.NewNop())
+
+		mockRepo.On("IncrementUsageWithTx", mock.Anything, uint(1), mock.AnythingOfType("time.Time")).Return(false, nil)
+
+		err := service.RedeemCouponWithTx(nil, 1)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrCouponExhausted, err.Error())
+	})
+}
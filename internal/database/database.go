@@ -2,10 +2,15 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/category"
+	"mini-e-commerce/internal/coupon"
 	"mini-e-commerce/internal/logger"
 	"mini-e-commerce/internal/order"
 	"mini-e-commerce/internal/product"
+	"mini-e-commerce/internal/review"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -13,22 +18,61 @@ import (
 	"gorm.io/gorm"
 )
 
-func Connect(dsn string, log logger.Logger) *gorm.DB {
+// PoolConfig bounds the connections database/sql keeps open to Postgres
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func Connect(dsn string, pool PoolConfig, retry RetryConfig, slowQueryThreshold time.Duration, log logger.Logger) *gorm.DB {
 	log.Info("Connecting to database...")
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// gorm.Open pings the server, so a database that is still starting fails here
+	db, err := withRetry(retry, "database", log, func() (*gorm.DB, error) {
+		return gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: NewGormLogger(log, slowQueryThreshold),
+		})
+	})
 	if err != nil {
 		log.Fatal("Failed to connect database: ", zap.Error(err))
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("Failed to get database handle: ", zap.Error(err))
+	}
+	ConfigurePool(sqlDB, pool, log)
+
 	log.Info("Database connection established successfully")
 	return db
 }
 
+// ConfigurePool applies pool to sqlDB and returns the settings actually used. Idle
+// connections are capped at the open limit since database/sql would do so anyway.
+func ConfigurePool(sqlDB *sql.DB, pool PoolConfig, log logger.Logger) PoolConfig {
+	if pool.MaxOpenConns > 0 && pool.MaxIdleConns > pool.MaxOpenConns {
+		pool.MaxIdleConns = pool.MaxOpenConns
+	}
+
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+	log.Info("Database connection pool configured",
+		zap.Int("max_open_conns", pool.MaxOpenConns),
+		zap.Int("max_idle_conns", pool.MaxIdleConns),
+		zap.Duration("conn_max_lifetime", pool.ConnMaxLifetime),
+	)
+	return pool
+}
+
+// Migrate syncs tables from the models with AutoMigrate. It never drops columns and keeps
+// no history, so it is only meant for development; deployments use Migrator.
 func Migrate(db *gorm.DB, log logger.Logger) error {
 	log.Info("Starting database migration...")
 
-	if err := db.AutoMigrate(&auth.User{}, &product.Product{}, &order.Order{}, &order.OrderItem{}); err != nil {
+	if err := db.AutoMigrate(&auth.User{}, &category.Category{}, &product.Product{}, &product.StockSubscription{}, &product.ProductImage{}, &coupon.Coupon{}, &order.Order{}, &order.OrderItem{}, &order.OrderStatusHistory{}, &review.Review{}); err != nil {
 		log.Error("Database migration failed", zap.Error(err))
 		return err
 	}
@@ -37,16 +81,52 @@ func Migrate(db *gorm.DB, log logger.Logger) error {
 	return nil
 }
 
-func ConnectRedis(addr, password string, log logger.Logger) *redis.Client {
-	log.Info("Connecting to Redis...", zap.String("addr", addr))
+// RedisPoolConfig sizes the go-redis connection pool. Zero values keep the go-redis
+// defaults, MaxRetries -1 disables retries.
+type RedisPoolConfig struct {
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	MaxRetries   int
+}
 
+// NewRedisClient builds a client from pool without connecting and logs the settings
+// go-redis ends up using once its defaults are filled in
+func NewRedisClient(addr, password string, pool RedisPoolConfig, log logger.Logger) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       0,
+		Addr:         addr,
+		Password:     password,
+		DB:           0,
+		PoolSize:     pool.PoolSize,
+		MinIdleConns: pool.MinIdleConns,
+		DialTimeout:  pool.DialTimeout,
+		ReadTimeout:  pool.ReadTimeout,
+		WriteTimeout: pool.ReadTimeout,
+		MaxRetries:   pool.MaxRetries,
 	})
 
-	if _, err := rdb.Ping(context.Background()).Result(); err != nil {
+	opts := rdb.Options()
+	log.Info("Redis connection pool configured",
+		zap.Int("pool_size", opts.PoolSize),
+		zap.Int("min_idle_conns", opts.MinIdleConns),
+		zap.Duration("dial_timeout", opts.DialTimeout),
+		zap.Duration("read_timeout", opts.ReadTimeout),
+		zap.Duration("write_timeout", opts.WriteTimeout),
+		zap.Int("max_retries", opts.MaxRetries),
+	)
+	return rdb
+}
+
+func ConnectRedis(addr, password string, pool RedisPoolConfig, retry RetryConfig, log logger.Logger) *redis.Client {
+	log.Info("Connecting to Redis...", zap.String("addr", addr))
+
+	rdb := NewRedisClient(addr, password, pool, log)
+
+	_, err := withRetry(retry, "redis", log, func() (string, error) {
+		return rdb.Ping(context.Background()).Result()
+	})
+	if err != nil {
 		log.Fatal("Failed to connect redis: ", zap.Error(err), zap.String("addr", addr))
 	}
 
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConfigurePool(t *testing.T) {
+	log := logger.NewLoggerFromZap(zap.NewNop(), &logger.Config{})
+
+	t.Run("should apply the pool limits to the sql handle", func(t *testing.T) {
+		sqlDB, _, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+
+		applied := ConfigurePool(sqlDB, PoolConfig{MaxOpenConns: 25, MaxIdleConns: 10, ConnMaxLifetime: 30 * time.Minute}, log)
+
+		assert.Equal(t, PoolConfig{MaxOpenConns: 25, MaxIdleConns: 10, ConnMaxLifetime: 30 * time.Minute}, applied)
+		assert.Equal(t, 25, sqlDB.Stats().MaxOpenConnections)
+	})
+
+	t.Run("should cap idle connections at the open limit", func(t *testing.T) {
+		sqlDB, _, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+
+		applied := ConfigurePool(sqlDB, PoolConfig{MaxOpenConns: 5, MaxIdleConns: 20}, log)
+
+		assert.Equal(t, 5, applied.MaxIdleConns)
+		assert.Equal(t, 5, sqlDB.Stats().MaxOpenConnections)
+	})
+}
+
+func TestNewRedisClient(t *testing.T) {
+	log := logger.NewLoggerFromZap(zap.NewNop(), &logger.Config{})
+
+	t.Run("should populate the client options from the pool config", func(t *testing.T) {
+		rdb := NewRedisClient("localhost:6379", "secret", RedisPoolConfig{
+			PoolSize:     20,
+			MinIdleConns: 4,
+			DialTimeout:  2 * time.Second,
+			ReadTimeout:  500 * time.Millisecond,
+			MaxRetries:   5,
+		}, log)
+		t.Cleanup(func() { rdb.Close() })
+
+		opts := rdb.Options()
+		assert.Equal(t, "localhost:6379", opts.Addr)
+		assert.Equal(t, "secret", opts.Password)
+		assert.Equal(t, 20, opts.PoolSize)
+		assert.Equal(t, 4, opts.MinIdleConns)
+		assert.Equal(t, 2*time.Second, opts.DialTimeout)
+		assert.Equal(t, 500*time.Millisecond, opts.ReadTimeout)
+		assert.Equal(t, 500*time.Millisecond, opts.WriteTimeout)
+		assert.Equal(t, 5, opts.MaxRetries)
+	})
+
+	t.Run("should keep the go-redis defaults for a zero config", func(t *testing.T) {
+		rdb := NewRedisClient("localhost:6379", "", RedisPoolConfig{}, log)
+		t.Cleanup(func() { rdb.Close() })
+
+		opts := rdb.Options()
+		assert.Positive(t, opts.PoolSize)
+		assert.Equal(t, 5*time.Second, opts.DialTimeout)
+		assert.Equal(t, 3, opts.MaxRetries)
+	})
+
+	t.Run("should disable retries with -1", func(t *testing.T) {
+		rdb := NewRedisClient("localhost:6379", "", RedisPoolConfig{MaxRetries: -1}, log)
+		t.Cleanup(func() { rdb.Close() })
+
+		assert.Equal(t, 0, rdb.Options().MaxRetries)
+	})
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupLoggedDB(t *testing.T, slowThreshold time.Duration) (*gorm.DB, sqlmock.Sqlmock, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := logger.NewLoggerFromZap(zap.New(core), &logger.Config{})
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: NewGormLogger(log, slowThreshold),
+	})
+	require.NoError(t, err)
+
+	return db, mock, logs
+}
+
+func TestGormLogger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should warn about queries slower than the threshold", func(t *testing.T) {
+		db, mock, logs := setupLoggedDB(t, 10*time.Millisecond)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products"`)).
+			WillDelayFor(30 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		var count int64
+		require.NoError(t, db.WithContext(ctx).Table("products").Count(&count).Error)
+
+		slow := logs.FilterMessage("Slow database query").All()
+		require.Len(t, slow, 1)
+		assert.Equal(t, zapcore.WarnLevel, slow[0].Level)
+		fields := slow[0].ContextMap()
+		assert.Equal(t, `SELECT count(*) FROM "products"`, fields["sql"])
+		assert.GreaterOrEqual(t, fields["elapsed"], 30*time.Millisecond)
+	})
+
+	t.Run("should stay quiet for fast queries", func(t *testing.T) {
+		db, mock, logs := setupLoggedDB(t, time.Second)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+		var count int64
+		require.NoError(t, db.WithContext(ctx).Table("products").Count(&count).Error)
+
+		assert.Zero(t, logs.Len())
+	})
+
+	t.Run("should log failed queries as errors", func(t *testing.T) {
+		db, mock, logs := setupLoggedDB(t, time.Second)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products"`)).
+			WillReturnError(errors.New("connection reset"))
+
+		var count int64
+		require.Error(t, db.WithContext(ctx).Table("products").Count(&count).Error)
+
+		failed := logs.FilterMessage("Database query failed").All()
+		require.Len(t, failed, 1)
+		assert.Equal(t, zapcore.ErrorLevel, failed[0].Level)
+	})
+}
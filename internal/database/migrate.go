@@ -0,0 +1,236 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"mini-e-commerce/internal/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MigrationsTable matches the table the golang-migrate CLI behind `make migrate-*` uses,
+// so both agree on the applied version
+const MigrationsTable = "schema_migrations"
+
+// migrationLockKey keys the advisory lock migrators take, so instances starting at the
+// same time apply each migration once
+const migrationLockKey int64 = 0x6d696e6965636f6d
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with the SQL to apply and revert it
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads <version>_<name>.up.sql / .down.sql pairs from fsys, sorted by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts migrations, recording the current version in MigrationsTable
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	logger     logger.Logger
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration, log logger.Logger) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     log,
+	}
+}
+
+// Up applies every migration newer than the recorded version. Each one runs in its own
+// transaction together with the version bump, so a failure leaves the last good version.
+// Migrations another instance applied while this one waited for the lock are skipped.
+func (m *Migrator) Up(ctx context.Context) error {
+	current, err := m.currentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied := 0
+	for _, migration := range m.migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		ran, err := m.run(ctx, migration.Up, migration.Version, func(locked uint) (bool, error) {
+			if locked >= migration.Version {
+				return false, nil
+			}
+			m.logger.Info("Applying migration", zap.Uint("version", migration.Version), zap.String("name", migration.Name))
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		if ran {
+			applied++
+		}
+	}
+
+	m.logger.Info("Database migrations up to date", zap.Int("applied", applied))
+	return nil
+}
+
+// Down reverts the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	current, err := m.currentVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return errors.New("no migration to roll back")
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version != current {
+			continue
+		}
+
+		var previous uint
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+
+		m.logger.Info("Rolling back migration", zap.Uint("version", migration.Version), zap.String("name", migration.Name))
+		_, err := m.run(ctx, migration.Down, previous, func(locked uint) (bool, error) {
+			if locked != migration.Version {
+				return false, fmt.Errorf("version changed to %d by another migrator", locked)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("applied version %d has no migration file", current)
+}
+
+func (m *Migrator) currentVersion(ctx context.Context) (uint, error) {
+	var version uint
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// concurrent CREATE TABLE IF NOT EXISTS can still collide, so it runs under the lock too
+		if err := lockMigrations(tx); err != nil {
+			return err
+		}
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS ` + MigrationsTable + ` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`).Error; err != nil {
+			return err
+		}
+
+		var err error
+		version, err = readVersion(tx)
+		return err
+	})
+	return version, err
+}
+
+// lockMigrations waits for the migration lock, which is released when tx ends
+func lockMigrations(tx *gorm.DB) error {
+	return tx.Exec(`SELECT pg_advisory_xact_lock(?)`, migrationLockKey).Error
+}
+
+func readVersion(db *gorm.DB) (uint, error) {
+	var rows []struct {
+		Version uint
+		Dirty   bool
+	}
+	if err := db.Raw(`SELECT version, dirty FROM ` + MigrationsTable + ` LIMIT 1`).Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if rows[0].Dirty {
+		return 0, fmt.Errorf("database is dirty at version %d, fix it and run make migrate-force", rows[0].Version)
+	}
+	return rows[0].Version, nil
+}
+
+// run executes sql and records version in one transaction, holding the migration lock.
+// ready is given the version recorded once the lock is held and returns false to skip
+// sql, it reports whether sql ran. Version 0 means nothing applied.
+func (m *Migrator) run(ctx context.Context, sql string, version uint, ready func(locked uint) (bool, error)) (bool, error) {
+	ran := false
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockMigrations(tx); err != nil {
+			return err
+		}
+		locked, err := readVersion(tx)
+		if err != nil {
+			return err
+		}
+		if ran, err = ready(locked); err != nil || !ran {
+			return err
+		}
+
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM ` + MigrationsTable).Error; err != nil {
+			return err
+		}
+		if version == 0 {
+			return nil
+		}
+		return tx.Exec(`INSERT INTO `+MigrationsTable+` (version, dirty) VALUES (?, ?)`, version, false).Error
+	})
+	return ran, err
+}
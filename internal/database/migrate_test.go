@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func versionRows(version uint) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"version", "dirty"})
+	if version > 0 {
+		rows.AddRow(version, false)
+	}
+	return rows
+}
+
+func expectLockedVersion(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(migrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version, dirty FROM schema_migrations LIMIT 1`)).
+		WillReturnRows(rows)
+}
+
+func expectVersion(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(migrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_migrations`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT version, dirty FROM schema_migrations LIMIT 1`)).
+		WillReturnRows(rows)
+}
+
+// expectStep expects sql to run once the lock is held and the table still records locked
+func expectStep(mock sqlmock.Sqlmock, sql string, locked, version uint) {
+	mock.ExpectBegin()
+	expectLockedVersion(mock, versionRows(locked))
+	mock.ExpectExec(regexp.QuoteMeta(sql)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM schema_migrations`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	if version > 0 {
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`)).
+			WithArgs(version, false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("should pair every embedded up migration with a down", func(t *testing.T) {
+		loaded, err := LoadMigrations(migrations.FS)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, loaded)
+		for i, m := range loaded {
+			assert.Equal(t, uint(i+1), m.Version, "migrations must be numbered without gaps")
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+	})
+
+	t.Run("should reject a migration without a down file", func(t *testing.T) {
+		_, err := LoadMigrations(fstest.MapFS{
+			"000001_create_things.up.sql": {Data: []byte("CREATE TABLE things (id INT)")},
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLoggerFromZap(zap.NewNop(), &logger.Config{})
+	schema, err := LoadMigrations(fstest.MapFS{
+		"000001_create_things.up.sql":        {Data: []byte("CREATE TABLE things (id INT)")},
+		"000001_create_things.down.sql":      {Data: []byte("DROP TABLE things")},
+		"000002_add_name_to_things.up.sql":   {Data: []byte("ALTER TABLE things ADD COLUMN name TEXT")},
+		"000002_add_name_to_things.down.sql": {Data: []byte("ALTER TABLE things DROP COLUMN name")},
+	})
+	require.NoError(t, err)
+
+	t.Run("should apply all pending migrations then roll back the last one", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		migrator := NewMigrator(db, schema, log)
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}))
+		mock.ExpectCommit()
+		expectStep(mock, "CREATE TABLE things (id INT)", 0, 1)
+		expectStep(mock, "ALTER TABLE things ADD COLUMN name TEXT", 1, 2)
+		require.NoError(t, migrator.Up(ctx))
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+		mock.ExpectCommit()
+		expectStep(mock, "ALTER TABLE things DROP COLUMN name", 2, 1)
+		require.NoError(t, migrator.Down(ctx))
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should skip migrations that are already applied", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+		mock.ExpectCommit()
+
+		require.NoError(t, NewMigrator(db, schema, log).Up(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should skip migrations another migrator applied while it waited for the lock", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}))
+		mock.ExpectCommit()
+		for range 2 {
+			mock.ExpectBegin()
+			expectLockedVersion(mock, versionRows(2))
+			mock.ExpectCommit()
+		}
+
+		require.NoError(t, NewMigrator(db, schema, log).Up(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not roll back a version another migrator already changed", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		expectLockedVersion(mock, versionRows(1))
+		mock.ExpectRollback()
+
+		err := NewMigrator(db, schema, log).Down(ctx)
+
+		assert.ErrorContains(t, err, "version changed to 1")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should clear the version when rolling back the first migration", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+		mock.ExpectCommit()
+		expectStep(mock, "DROP TABLE things", 1, 0)
+
+		require.NoError(t, NewMigrator(db, schema, log).Down(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse to run on a dirty database", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+
+		expectVersion(mock, sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, true))
+		mock.ExpectRollback()
+
+		err := NewMigrator(db, schema, log).Up(ctx)
+
+		assert.ErrorContains(t, err, "dirty at version 1")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
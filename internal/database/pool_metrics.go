@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultPoolStatsInterval is how often RecordPoolStats samples the pool
+const DefaultPoolStatsInterval = 15 * time.Second
+
+var (
+	dbOpenConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "db_open_connections",
+		Help: "Number of open connections to the database, in use or idle.",
+	})
+	dbInUse = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "db_in_use",
+		Help: "Number of database connections currently in use.",
+	})
+)
+
+// RecordPoolStats samples sqlDB's pool into the db_* gauges every interval until ctx is done
+func RecordPoolStats(ctx context.Context, sqlDB *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		recordPoolStats(sqlDB)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func recordPoolStats(sqlDB *sql.DB) {
+	stats := sqlDB.Stats()
+	dbOpenConnections.Set(float64(stats.OpenConnections))
+	dbInUse.Set(float64(stats.InUse))
+}
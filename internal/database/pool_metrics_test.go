@@ -0,0 +1,28 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordPoolStats(t *testing.T) {
+	t.Run("should publish the pool stats as gauges", func(t *testing.T) {
+		sqlDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+
+		mock.ExpectBegin()
+		tx, err := sqlDB.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() { tx.Rollback() })
+
+		recordPoolStats(sqlDB)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(dbOpenConnections))
+		assert.Equal(t, float64(1), testutil.ToFloat64(dbInUse))
+	})
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"time"
+
+	"mini-e-commerce/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxRetryDelay caps the exponential backoff between connection attempts
+const maxRetryDelay = 30 * time.Second
+
+// RetryConfig controls how often startup connections are attempted before giving up
+type RetryConfig struct {
+	Attempts  int
+	BaseDelay time.Duration
+}
+
+// sleep is swapped out in tests
+var sleep = time.Sleep
+
+// withRetry calls dial up to retry.Attempts times, doubling the wait after each failure
+// starting from retry.BaseDelay. It returns the last error once attempts run out.
+func withRetry[T any](retry RetryConfig, target string, log logger.Logger, dial func() (T, error)) (T, error) {
+	attempts := max(retry.Attempts, 1)
+	delay := retry.BaseDelay
+
+	var conn T
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		conn, err = dial()
+		if err == nil {
+			return conn, nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Warn("Connection attempt failed, retrying",
+			zap.String("target", target),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("retry_in", delay),
+			zap.Error(err),
+		)
+		sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
+	return conn, err
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+
+	var delays []time.Duration
+	original := sleep
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	t.Cleanup(func() { sleep = original })
+	return &delays
+}
+
+func TestWithRetry(t *testing.T) {
+	log := logger.NewLoggerFromZap(zap.NewNop(), &logger.Config{})
+	retry := RetryConfig{Attempts: 5, BaseDelay: 100 * time.Millisecond}
+
+	t.Run("should return the connection once the dialer succeeds", func(t *testing.T) {
+		delays := stubSleep(t)
+
+		calls := 0
+		conn, err := withRetry(retry, "database", log, func() (string, error) {
+			calls++
+			if calls <= 2 {
+				return "", errors.New("connection refused")
+			}
+			return "conn", nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "conn", conn)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *delays)
+	})
+
+	t.Run("should give up with the last error after all attempts", func(t *testing.T) {
+		delays := stubSleep(t)
+
+		calls := 0
+		_, err := withRetry(RetryConfig{Attempts: 3, BaseDelay: 20 * time.Second}, "redis", log, func() (string, error) {
+			calls++
+			return "", errors.New("connection refused")
+		})
+
+		assert.EqualError(t, err, "connection refused")
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{20 * time.Second, maxRetryDelay}, *delays)
+	})
+}
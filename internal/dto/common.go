@@ -1,8 +1,10 @@
 package dto
 
+// PaginationQuery rejects page and page_size below one, an explicit page=0 is a client bug rather than
+// a request for the default. page_size above the listing's maximum is clamped by the service.
 type PaginationQuery struct {
-	Page     int    `form:"page" binding:"omitempty,min=1"`
-	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=10" binding:"min=1"`
 	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
 }
 
@@ -11,4 +13,15 @@ type PaginationMetadata struct {
 	PageSize   int   `json:"page_size"`
 	Total      int64 `json:"total"`
 	TotalPages int   `json:"total_pages"`
+
+	// NextCursor is set by listings that support keyset pagination, pass it back as `after`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PaginationLinks point to neighbouring pages of a listing, Prev and Next are nil at the boundaries
+type PaginationLinks struct {
+	First string  `json:"first"`
+	Prev  *string `json:"prev"`
+	Next  *string `json:"next"`
+	Last  string  `json:"last"`
 }
@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"mini-e-commerce/internal/logger"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DefaultCheckInterval is how often Run re-checks the registered dependencies
+const DefaultCheckInterval = 5 * time.Second
+
+// CheckFunc reports whether a dependency is healthy, a nil error means it is
+type CheckFunc func(ctx context.Context) error
+
+// Checker polls dependencies in the background so readiness reflects the last known state
+// instead of hitting Postgres and Redis on every probe. It starts out not ready.
+type Checker struct {
+	checks map[string]CheckFunc
+	logger logger.Logger
+
+	mu      sync.RWMutex
+	results map[string]string
+	ready   bool
+	checked bool
+}
+
+func NewChecker(log logger.Logger) *Checker {
+	return &Checker{
+		checks:  make(map[string]CheckFunc),
+		results: make(map[string]string),
+		logger:  log,
+	}
+}
+
+// Register adds a dependency, it is reported down until its first successful check.
+// Register must be called before Run.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.checks[name] = check
+
+	c.mu.Lock()
+	c.results[name] = StatusDown
+	c.ready = false
+	c.mu.Unlock()
+}
+
+// Run checks every dependency immediately and then every interval until ctx is done
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check runs every registered dependency check once and updates the readiness state
+func (c *Checker) Check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make(map[string]string, len(c.checks))
+	ready := true
+	for _, name := range c.names() {
+		results[name] = StatusUp
+		if err := c.checks[name](ctx); err != nil {
+			results[name] = StatusDown
+			ready = false
+			c.logFailure(name, err)
+		}
+	}
+
+	c.mu.Lock()
+	previous := c.results
+	wasReady := c.ready
+	wasChecked := c.checked
+	c.results = results
+	c.ready = ready
+	c.checked = true
+	c.mu.Unlock()
+
+	for name, status := range results {
+		if wasChecked && status == StatusUp && previous[name] == StatusDown {
+			c.logger.Info("Dependency recovered", zap.String("dependency", name))
+		}
+	}
+	if ready && !wasReady {
+		c.logger.Info("Service is ready to accept traffic")
+	} else if !ready && wasReady {
+		c.logger.Warn("Service is no longer ready to accept traffic")
+	}
+}
+
+// Status returns whether all dependencies are up along with a copy of the per dependency results
+func (c *Checker) Status() (bool, map[string]string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	results := make(map[string]string, len(c.results))
+	for name, status := range c.results {
+		results[name] = status
+	}
+	return c.ready, results
+}
+
+func (c *Checker) logFailure(name string, err error) {
+	c.mu.RLock()
+	wasUp := !c.checked || c.results[name] == StatusUp
+	c.mu.RUnlock()
+
+	// only warn on the transition, a dependency that stays down would otherwise log every interval
+	if wasUp {
+		c.logger.Warn("Readiness check failed", zap.String("dependency", name), zap.Error(err))
+		return
+	}
+	c.logger.Debug("Readiness check failed", zap.String("dependency", name), zap.Error(err))
+}
+
+func (c *Checker) names() []string {
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DatabaseCheck pings the connection pool behind db
+func DatabaseCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// RedisCheck pings the redis server behind rdb
+func RedisCheck(rdb *redis.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}
+}
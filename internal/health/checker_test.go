@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toggleCheck is a dependency whose health the test flips between checks
+type toggleCheck struct {
+	healthy atomic.Bool
+}
+
+func (tc *toggleCheck) check(ctx context.Context) error {
+	if tc.healthy.Load() {
+		return nil
+	}
+	return errors.New("dependency unavailable")
+}
+
+func readinessStatus(t *testing.T, r *gin.Engine) (int, Response) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var body Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestChecker_ReadinessFlips(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, cache := &toggleCheck{}, &toggleCheck{}
+	checker := NewChecker(setupLogger())
+	checker.Register("db", db.check)
+	checker.Register("cache", cache.check)
+
+	r := gin.New()
+	NewHandler(checker).RegisterRoutes(r)
+	ctx := context.Background()
+
+	code, _ := readinessStatus(t, r)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	db.healthy.Store(true)
+	checker.Check(ctx)
+	code, body := readinessStatus(t, r)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusUp, body.Checks["db"])
+	assert.Equal(t, StatusDown, body.Checks["cache"])
+
+	cache.healthy.Store(true)
+	checker.Check(ctx)
+	code, body = readinessStatus(t, r)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusUp, body.Status)
+
+	db.healthy.Store(false)
+	checker.Check(ctx)
+	code, body = readinessStatus(t, r)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDown, body.Checks["db"])
+
+	db.healthy.Store(true)
+	checker.Check(ctx)
+	code, _ = readinessStatus(t, r)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestChecker_Run(t *testing.T) {
+	dep := &toggleCheck{}
+	checker := NewChecker(setupLogger())
+	checker.Register("dep", dep.check)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	isReady := func() bool {
+		ready, _ := checker.Status()
+		return ready
+	}
+
+	assert.Never(t, isReady, 50*time.Millisecond, 10*time.Millisecond)
+
+	dep.healthy.Store(true)
+	assert.Eventually(t, isReady, time.Second, 10*time.Millisecond)
+
+	dep.healthy.Store(false)
+	assert.Eventually(t, func() bool { return !isReady() }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	checkTimeout = 2 * time.Second
+)
+
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type Handler struct {
+	checker *Checker
+}
+
+func NewHandler(checker *Checker) *Handler {
+	return &Handler{
+		checker: checker,
+	}
+}
+
+func (h *Handler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/healthz", h.Liveness)
+	r.GET("/readyz", h.Readiness)
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Report that the process is running
+// @Tags Health
+// @Produce  json
+// @Success 200 {object} Response
+// @Router /healthz [get]
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{Status: StatusUp})
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Report the last background check of Postgres and Redis, 503 until every dependency is up
+// @Tags Health
+// @Produce  json
+// @Success 200 {object} Response
+// @Failure 503 {object} Response
+// @Router /readyz [get]
+func (h *Handler) Readiness(c *gin.Context) {
+	ready, checks := h.checker.Status()
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, Response{Status: StatusDown, Checks: checks})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Status: StatusUp, Checks: checks})
+}
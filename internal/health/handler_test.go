@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupLogger() logger.Logger {
+	logConfig := &logger.Config{
+		ServiceName: "test",
+		AppVersion:  "test",
+		LogLevel:    zapcore.FatalLevel,
+		Mode:        "development",
+	}
+	log, _ := logger.NewLogger(logConfig)
+	return log
+}
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+
+	// gorm pings the connection once while opening
+	mock.ExpectPing()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func setupRouter(t *testing.T) (*gin.Engine, *Checker, sqlmock.Sqlmock, *miniredis.Miniredis) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, mock := setupTestDB(t)
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+
+	checker := NewChecker(setupLogger())
+	checker.Register("postgres", DatabaseCheck(db))
+	checker.Register("redis", RedisCheck(rdb))
+
+	r := gin.New()
+	NewHandler(checker).RegisterRoutes(r)
+
+	return r, checker, mock, mr
+}
+
+func TestHandler_Liveness(t *testing.T) {
+	r, _, _, _ := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandler_Readiness(t *testing.T) {
+	t.Run("should return 503 before the first check", func(t *testing.T) {
+		r, _, _, _ := setupRouter(t)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var body Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, StatusDown, body.Status)
+		assert.Equal(t, StatusDown, body.Checks["postgres"])
+		assert.Equal(t, StatusDown, body.Checks["redis"])
+	})
+
+	t.Run("should return 200 when all dependencies are up", func(t *testing.T) {
+		r, checker, mock, _ := setupRouter(t)
+		mock.ExpectPing()
+		checker.Check(context.Background())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, StatusUp, body.Status)
+		assert.Equal(t, StatusUp, body.Checks["postgres"])
+		assert.Equal(t, StatusUp, body.Checks["redis"])
+	})
+
+	t.Run("should return 503 when redis ping fails", func(t *testing.T) {
+		r, checker, mock, mr := setupRouter(t)
+		mock.ExpectPing()
+		mr.Close()
+		checker.Check(context.Background())
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var body Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, StatusDown, body.Status)
+		assert.Equal(t, StatusUp, body.Checks["postgres"])
+		assert.Equal(t, StatusDown, body.Checks["redis"])
+	})
+}
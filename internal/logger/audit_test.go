@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func setupObservedLogger() (*ZapLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	return &ZapLogger{logger: zap.New(core), config: &Config{}}, logs
+}
+
+func TestAuditLogger_Log(t *testing.T) {
+	t.Run("should write every schema field to the audit logger", func(t *testing.T) {
+		log, logs := setupObservedLogger()
+
+		log.WithAudit().Log(AuditEvent{
+			ActorID: "7",
+			Action:  AuditActionLogin,
+			Target:  "user:7",
+			Result:  AuditResultSuccess,
+			IP:      "10.0.0.1",
+		})
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, AuditLoggerName, entry.LoggerName)
+		assert.Equal(t, map[string]any{
+			"actor_id": "7",
+			"action":   AuditActionLogin,
+			"target":   "user:7",
+			"result":   AuditResultSuccess,
+			"ip":       "10.0.0.1",
+		}, entry.ContextMap())
+	})
+
+	t.Run("should default a missing actor and ip", func(t *testing.T) {
+		log, logs := setupObservedLogger()
+
+		log.WithAudit().Log(AuditEvent{Action: AuditActionPasswordReset, Target: "reset_token", Result: AuditResultFailure})
+
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, AnonymousUser, fields["actor_id"])
+		assert.Equal(t, DefaultValue, fields["ip"])
+	})
+}
+
+func TestAuditLogger_Record(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should take actor, ip and request id from the gin context", func(t *testing.T) {
+		log, logs := setupObservedLogger()
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodDelete, "/api/products/3", nil)
+		c.Request.RemoteAddr = "192.168.1.20:5000"
+		c.Set(UserIDKey, uint(1))
+		c.Set(RequestIDKey, "req-123")
+
+		log.WithAudit().Record(c, AuditActionProductDelete, "product:3", AuditResultFailure, zap.Error(errors.New("boom")))
+
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "1", fields["actor_id"])
+		assert.Equal(t, AuditActionProductDelete, fields["action"])
+		assert.Equal(t, "product:3", fields["target"])
+		assert.Equal(t, AuditResultFailure, fields["result"])
+		assert.Equal(t, "192.168.1.20", fields["ip"])
+		assert.Equal(t, "req-123", fields[RequestIDKey])
+		assert.Equal(t, "boom", fields["error"])
+	})
+
+	t.Run("should add the impersonating admin next to the actor", func(t *testing.T) {
+		log, logs := setupObservedLogger()
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		c.Set(UserIDKey, uint(7))
+		c.Set(ImpersonatorIDKey, uint(1))
+
+		log.WithAudit().Record(c, AuditActionImpersonatedRequest, "GET /api/orders", AuditResultSuccess)
+
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "7", fields["actor_id"])
+		assert.Equal(t, uint64(1), fields[ImpersonatorIDKey])
+	})
+
+	t.Run("should record an anonymous actor outside a request", func(t *testing.T) {
+		log, logs := setupObservedLogger()
+
+		log.WithAudit().Record(nil, AuditActionRoleChange, "user:2", AuditResultSuccess)
+
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, AnonymousUser, fields["actor_id"])
+		assert.Equal(t, DefaultValue, fields["ip"])
+	})
+}
@@ -2,8 +2,8 @@ package logger
 
 import (
 	"os"
+	"strconv"
 	"strings"
-	"time"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -12,7 +12,18 @@ const (
 	DefaultServiceName = "mini-ecommerce"
 	DefaultAppVersion  = "unknown"
 	DefaultLogLevel    = zapcore.InfoLevel
-	SessionTimeout     = 24 * time.Hour
+
+	DefaultFileMaxSizeMB  = 100
+	DefaultFileMaxBackups = 5
+	DefaultFileMaxAgeDays = 28
+
+	// Zap's production defaults: per second, log the first 100 identical entries
+	// then every 100th
+	DefaultSamplingInitial    = 100
+	DefaultSamplingThereafter = 100
+
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
 )
 
 type Config struct {
@@ -20,6 +31,21 @@ type Config struct {
 	AppVersion  string
 	LogLevel    zapcore.Level
 	Mode        string
+
+	// Encoding overrides the mode's default output, JSON in production and
+	// colored console otherwise. Empty keeps the default.
+	Encoding string
+
+	// FilePath enables a rotating JSON log file alongside console output
+	FilePath       string
+	FileMaxSizeMB  int
+	FileMaxBackups int
+	FileMaxAgeDays int
+	FileCompress   bool
+
+	// SamplingInitial of 0 turns sampling off, useful when debugging in production
+	SamplingInitial    int
+	SamplingThereafter int
 }
 
 func NewConfig() *Config {
@@ -28,6 +54,16 @@ func NewConfig() *Config {
 		AppVersion:  getAppVersion(),
 		LogLevel:    getLogLevelFromEnv(),
 		Mode:        getEnvironmentMode(),
+		Encoding:    getEncodingFromEnv(),
+
+		FilePath:       os.Getenv("LOG_FILE_PATH"),
+		FileMaxSizeMB:  getEnvInt("LOG_FILE_MAX_SIZE_MB", DefaultFileMaxSizeMB),
+		FileMaxBackups: getEnvInt("LOG_FILE_MAX_BACKUPS", DefaultFileMaxBackups),
+		FileMaxAgeDays: getEnvInt("LOG_FILE_MAX_AGE_DAYS", DefaultFileMaxAgeDays),
+		FileCompress:   getEnvBool("LOG_FILE_COMPRESS", false),
+
+		SamplingInitial:    getEnvInt("LOG_SAMPLING_INITIAL", DefaultSamplingInitial),
+		SamplingThereafter: getEnvInt("LOG_SAMPLING_THEREAFTER", DefaultSamplingThereafter),
 	}
 }
 
@@ -52,10 +88,35 @@ func getAppVersion() string {
 	return version
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func getEnvironmentMode() string {
 	return strings.ToLower(os.Getenv("GIN_MODE"))
 }
 
+func getEncodingFromEnv() string {
+	switch encoding := strings.ToLower(os.Getenv("LOG_ENCODING")); encoding {
+	case EncodingJSON, EncodingConsole:
+		return encoding
+	default:
+		return ""
+	}
+}
+
 func getLogLevelFromEnv() zapcore.Level {
 	levelStr := strings.ToUpper(os.Getenv("LOG_LEVEL"))
 	switch levelStr {
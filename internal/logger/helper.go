@@ -11,6 +11,9 @@ const (
 	UserIDKey     = "user_id"
 	DefaultValue  = "unknown"
 	AnonymousUser = "anonymous"
+
+	// ImpersonatorIDKey holds the admin acting as the user of the request, if any
+	ImpersonatorIDKey = "impersonator_id"
 )
 
 func extractContextValues(c any) (requestID, userID string) {
@@ -3,6 +3,7 @@ package logger
 import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type Logger interface {
@@ -13,6 +14,8 @@ type Logger interface {
 	Fatal(msg string, fields ...zap.Field)
 	Sync() error
 	WithContext(c any) ContextLogger
+	With(fields ...zap.Field) Logger
+	WithAudit() AuditLogger
 	GetZapLogger() *zap.Logger
 }
 
@@ -33,14 +36,57 @@ func NewLogger(config *Config) (Logger, error) {
 	}, nil
 }
 
+// NewLoggerFromZap wraps an already built zap logger, e.g. one on an observer core in tests
+func NewLoggerFromZap(logger *zap.Logger, config *Config) Logger {
+	return &ZapLogger{
+		logger: logger,
+		config: config,
+	}
+}
+
 func createLogger(config *Config) (*zap.Logger, error) {
+	var logger *zap.Logger
+	var err error
 	if config.IsProduction() {
-		return createProductionLogger(config)
+		logger, err = createProductionLogger(config)
+	} else {
+		logger, err = createDevelopmentLogger(config)
+	}
+	if err != nil || config.FilePath == "" {
+		return logger, err
 	}
-	return createDevelopmentLogger(config)
+
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, newFileCore(config))
+	})), nil
+}
+
+// newFileCore writes JSON to a rotating file whatever the console encoding is
+func newFileCore(config *Config) zapcore.Core {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "timestamp"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	file := &lumberjack.Logger{
+		Filename:   config.FilePath,
+		MaxSize:    config.FileMaxSizeMB,
+		MaxBackups: config.FileMaxBackups,
+		MaxAge:     config.FileMaxAgeDays,
+		Compress:   config.FileCompress,
+	}
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(file), zap.NewAtomicLevelAt(config.LogLevel))
+	return core.With([]zap.Field{
+		zap.String("service", config.ServiceName),
+		zap.String("version", config.AppVersion),
+	})
 }
 
 func createProductionLogger(config *Config) (*zap.Logger, error) {
+	return productionConfig(config).Build()
+}
+
+func productionConfig(config *Config) zap.Config {
 	zapConfig := zap.NewProductionConfig()
 	zapConfig.Level = zap.NewAtomicLevelAt(config.LogLevel)
 	zapConfig.EncoderConfig.TimeKey = "timestamp"
@@ -49,14 +95,41 @@ func createProductionLogger(config *Config) (*zap.Logger, error) {
 		"service": config.ServiceName,
 		"version": config.AppVersion,
 	}
-	return zapConfig.Build()
+
+	zapConfig.Sampling = nil
+	if config.SamplingInitial > 0 {
+		zapConfig.Sampling = &zap.SamplingConfig{
+			Initial:    config.SamplingInitial,
+			Thereafter: config.SamplingThereafter,
+		}
+	}
+
+	if config.Encoding == EncodingConsole {
+		zapConfig.Encoding = EncodingConsole
+		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+	return zapConfig
 }
 
 func createDevelopmentLogger(config *Config) (*zap.Logger, error) {
+	return developmentConfig(config).Build()
+}
+
+func developmentConfig(config *Config) zap.Config {
 	zapConfig := zap.NewDevelopmentConfig()
 	zapConfig.Level = zap.NewAtomicLevelAt(config.LogLevel)
-	zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	return zapConfig.Build()
+
+	// Use the production field names so local JSON matches what operators see.
+	// Color codes only make sense on a console, they would end up inside JSON strings
+	if config.Encoding == EncodingJSON {
+		zapConfig.Encoding = EncodingJSON
+		zapConfig.EncoderConfig = zap.NewProductionEncoderConfig()
+		zapConfig.EncoderConfig.TimeKey = "timestamp"
+		zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	} else {
+		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return zapConfig
 }
 
 func (l *ZapLogger) Info(msg string, fields ...zap.Field) {
@@ -91,6 +164,18 @@ func (l *ZapLogger) WithContext(c any) ContextLogger {
 	return NewContextLogger(l.logger, requestID, userID)
 }
 
+// With returns a child logger that adds fields to every entry, e.g. a module name
+func (l *ZapLogger) With(fields ...zap.Field) Logger {
+	return &ZapLogger{
+		logger: l.logger.With(fields...),
+		config: l.config,
+	}
+}
+
+func (l *ZapLogger) WithAudit() AuditLogger {
+	return NewAuditLogger(l.logger)
+}
+
 func (l *ZapLogger) GetZapLogger() *zap.Logger {
 	return l.logger
 }
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestZapLogger_With(t *testing.T) {
+	t.Run("should attach fields to every later entry without touching the parent", func(t *testing.T) {
+		log, logs := setupObservedLogger()
+
+		child := log.With(zap.String("module", "product"))
+		child.Info("cache miss")
+		child.Warn("slow query")
+		log.Info("unrelated")
+
+		entries := logs.All()
+		require.Len(t, entries, 3)
+		assert.Equal(t, "product", entries[0].ContextMap()["module"])
+		assert.Equal(t, "product", entries[1].ContextMap()["module"])
+		assert.NotContains(t, entries[2].ContextMap(), "module")
+	})
+}
+
+func TestNewLogger_FileSink(t *testing.T) {
+	t.Run("should write JSON lines to the configured file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+		log, err := NewLogger(&Config{
+			ServiceName:    "test-service",
+			AppVersion:     "1.2.3",
+			LogLevel:       zapcore.InfoLevel,
+			Mode:           "release",
+			FilePath:       path,
+			FileMaxSizeMB:  DefaultFileMaxSizeMB,
+			FileMaxBackups: DefaultFileMaxBackups,
+			FileMaxAgeDays: DefaultFileMaxAgeDays,
+		})
+		require.NoError(t, err)
+
+		log.Info("order placed")
+		_ = log.Sync()
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(string(content))), &entry))
+		assert.Equal(t, "order placed", entry["msg"])
+		assert.Equal(t, "test-service", entry["service"])
+		assert.Equal(t, "1.2.3", entry["version"])
+	})
+}
+
+func TestProductionConfig_Sampling(t *testing.T) {
+	countLines := func(t *testing.T, config *Config, emitted int) int {
+		path := filepath.Join(t.TempDir(), "app.log")
+		zapConfig := productionConfig(config)
+		zapConfig.OutputPaths = []string{path}
+
+		log, err := zapConfig.Build()
+		require.NoError(t, err)
+		for range emitted {
+			log.Info("cache miss")
+		}
+		_ = log.Sync()
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Count(string(content), "\n")
+	}
+
+	t.Run("should drop repeated entries past the initial burst", func(t *testing.T) {
+		written := countLines(t, &Config{
+			LogLevel:           zapcore.InfoLevel,
+			SamplingInitial:    10,
+			SamplingThereafter: 5,
+		}, 100)
+
+		// first 10, then every 5th of the remaining 90
+		assert.Equal(t, 10+90/5, written)
+	})
+
+	t.Run("should keep every entry when sampling is disabled", func(t *testing.T) {
+		written := countLines(t, &Config{
+			LogLevel:        zapcore.InfoLevel,
+			SamplingInitial: 0,
+		}, 100)
+
+		assert.Equal(t, 100, written)
+	})
+}
+
+func TestConfig_Encoding(t *testing.T) {
+	writeEntry := func(t *testing.T, config *Config) string {
+		path := filepath.Join(t.TempDir(), "app.log")
+		zapConfig := developmentConfig(config)
+		if config.IsProduction() {
+			zapConfig = productionConfig(config)
+		}
+		zapConfig.OutputPaths = []string{path}
+
+		log, err := zapConfig.Build()
+		require.NoError(t, err)
+		log.Info("order placed")
+		_ = log.Sync()
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.TrimSpace(string(content))
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		encoding  string
+		wantJSON  bool
+		wantColor bool
+	}{
+		{"production default", "release", "", true, false},
+		{"production console", "release", EncodingConsole, false, false},
+		{"development default", "debug", "", false, true},
+		{"development json", "debug", EncodingJSON, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := writeEntry(t, &Config{LogLevel: zapcore.InfoLevel, Mode: tt.mode, Encoding: tt.encoding})
+
+			var entry map[string]any
+			err := json.Unmarshal([]byte(line), &entry)
+			if tt.wantJSON {
+				require.NoError(t, err, line)
+				assert.Equal(t, "order placed", entry["msg"])
+			} else {
+				assert.Error(t, err, line)
+				assert.Contains(t, line, "order placed")
+			}
+			assert.Equal(t, tt.wantColor, strings.Contains(line, "\x1b["), line)
+		})
+	}
+}
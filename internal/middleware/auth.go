@@ -3,6 +3,8 @@ package middleware
 import (
 	"errors"
 	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/response"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,23 +13,86 @@ import (
 	"go.uber.org/zap"
 )
 
-func AuthMiddleware(jwtManager auth.JWTManagerInterface, sessionManager auth.SessionManagerInterface, logger *zap.Logger) gin.HandlerFunc {
+// AuthMiddleware accepts a bearer JWT or falls back to the session cookies. When the session
+// cookies can never authenticate again they are expired in the 401 response, so cookieSecure
+// and cookieSameSite must match the auth handler's. An expired bearer token sent without
+// session cookies gets code TOKEN_EXPIRED, telling the client to refresh rather than log in.
+// Either way the user is loaded, so a deactivated account is locked out at once instead
+// of keeping API access until its tokens expire. The same goes for the admin behind an
+// impersonation token, who must still be active and an admin.
+func AuthMiddleware(jwtManager auth.JWTManagerInterface, sessionManager auth.SessionManagerInterface, userRepo auth.Repository, cookieSecure bool, cookieSameSite http.SameSite, logger *zap.Logger) gin.HandlerFunc {
+	recordImpersonation := impersonationAudit(logger)
+	rejectSession := func(c *gin.Context) {
+		auth.ClearAuthCookies(c, cookieSecure, cookieSameSite)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+		c.Abort()
+	}
+
 	return func(c *gin.Context) {
 		ctx := c.Request.Context()
+		tokenExpired := false
 
 		authHeader := c.GetHeader("Authorization")
 		if strings.HasPrefix(authHeader, "Bearer ") {
 			token := strings.TrimPrefix(authHeader, "Bearer ")
 			claims, err := jwtManager.Verify(token)
+			if err == nil && claims.ID != "" {
+				revoked, revokeErr := sessionManager.IsAccessTokenRevoked(ctx, claims.ID)
+				if revokeErr != nil {
+					// A revoked token would pass unchecked, so only go on when JWT-only fallback is allowed
+					if !sessionManager.JWTOnlyFallback() {
+						logger.Error("Access token denylist unavailable, rejecting JWT auth", zap.Error(revokeErr), zap.Uint("user_id", claims.UserID))
+						c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session store unavailable"})
+						c.Abort()
+						return
+					}
+					logger.Warn("Failed to check access token denylist, continuing in JWT-only fallback", zap.Error(revokeErr))
+				} else if revoked {
+					err = auth.ErrRevokedToken
+				}
+			}
 			if err == nil {
+				user, findErr := userRepo.FindByID(ctx, claims.UserID)
+				if findErr != nil {
+					logger.Warn("Failed to load user for token", zap.Error(findErr), zap.Uint("user_id", claims.UserID))
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+					c.Abort()
+					return
+				}
+				if !user.Active {
+					logger.Warn("Token used by deactivated account", zap.Uint("user_id", claims.UserID))
+					c.JSON(http.StatusForbidden, gin.H{"error": auth.ErrAccountDeactivated.Error(), "code": response.ErrCodeAccountDeactivated})
+					c.Abort()
+					return
+				}
+				if claims.ActAs != 0 {
+					// An admin who was deactivated or demoted loses the sessions they impersonate too
+					admin, findErr := userRepo.FindByID(ctx, claims.ActAs)
+					if findErr != nil || !admin.Active || admin.Role != auth.RoleAdmin {
+						logger.Warn("Impersonation token of an admin no longer allowed to impersonate",
+							zap.Error(findErr), zap.Uint("user_id", claims.UserID), zap.Uint("impersonator_id", claims.ActAs))
+						c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+						c.Abort()
+						return
+					}
+				}
+
 				c.Set("user_id", claims.UserID)
-				logger.Debug("User authenticated via JWT", zap.Uint("user_id", claims.UserID))
+				c.Set("role", claims.Role)
+				if claims.ActAs != 0 {
+					c.Set("impersonator_id", claims.ActAs)
+					recordImpersonation(c)
+				}
+				logger.Debug("User authenticated via JWT", zap.Uint("user_id", claims.UserID), zap.Uint("impersonator_id", claims.ActAs))
 				c.Next()
 				return
 			}
 
 			if errors.Is(err, auth.ErrExpiredToken) {
+				tokenExpired = true
 				logger.Debug("JWT token expired", zap.String("token", token[:10]+"..."))
+			} else if errors.Is(err, auth.ErrRevokedToken) {
+				logger.Debug("JWT token revoked", zap.Uint("user_id", claims.UserID))
 			} else {
 				logger.Warn("Invalid JWT token", zap.Error(err))
 			}
@@ -36,11 +101,24 @@ func AuthMiddleware(jwtManager auth.JWTManagerInterface, sessionManager auth.Ses
 		sessionID, err := c.Cookie("session_id")
 		if err != nil {
 			logger.Debug("No session cookie found")
+			if tokenExpired {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token expired", "code": response.ErrCodeTokenExpired})
+				c.Abort()
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 			c.Abort()
 			return
 		}
 
+		// CSRF let the request through on the bearer header alone, now that the cookies
+		// authenticate it the token has to be checked here
+		if strings.HasPrefix(authHeader, "Bearer ") && !isSafeMethod(c.Request.Method) && !hasValidCSRFToken(c) {
+			logger.Warn("Session fallback without CSRF token after bearer auth failed")
+			abortInvalidCSRF(c)
+			return
+		}
+
 		userIDStr, err := c.Cookie("user_id")
 		if err != nil {
 			logger.Debug("No user_id cookie found")
@@ -52,8 +130,7 @@ func AuthMiddleware(jwtManager auth.JWTManagerInterface, sessionManager auth.Ses
 		userID, err := strconv.ParseUint(userIDStr, 10, 32)
 		if err != nil {
 			logger.Warn("Invalid user_id cookie format", zap.Error(err))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
-			c.Abort()
+			rejectSession(c)
 			return
 		}
 
@@ -66,20 +143,74 @@ func AuthMiddleware(jwtManager auth.JWTManagerInterface, sessionManager auth.Ses
 		}
 
 		if err := sessionManager.ValidateRefreshToken(ctx, uint(userID), sessionID, refreshToken); err != nil {
-			if errors.Is(err, auth.ErrSessionNotFound) {
+			if errors.Is(err, auth.ErrSessionStoreUnavailable) {
+				logger.Error("Session store unavailable, rejecting session auth", zap.Uint("user_id", uint(userID)))
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session store unavailable"})
+				c.Abort()
+				return
+			}
+			switch {
+			case errors.Is(err, auth.ErrSessionNotFound):
 				logger.Debug("Session not found", zap.Uint("user_id", uint(userID)))
-			} else if errors.Is(err, auth.ErrInvalidRefreshToken) {
+				rejectSession(c)
+			case errors.Is(err, auth.ErrInvalidRefreshToken):
 				logger.Warn("Invalid refresh token", zap.Uint("user_id", uint(userID)))
-			} else {
+				rejectSession(c)
+			default:
+				// The session may still be valid, keep the cookies so a retry can succeed
 				logger.Error("Session validation error", zap.Error(err), zap.Uint("user_id", uint(userID)))
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+				c.Abort()
 			}
+			return
+		}
+
+		user, err := userRepo.FindByID(ctx, uint(userID))
+		if err != nil {
+			logger.Warn("Failed to load user for session", zap.Error(err), zap.Uint("user_id", uint(userID)))
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
 			c.Abort()
 			return
 		}
+		if !user.Active {
+			logger.Warn("Session used by deactivated account", zap.Uint("user_id", uint(userID)))
+			auth.ClearAuthCookies(c, cookieSecure, cookieSameSite)
+			c.JSON(http.StatusForbidden, gin.H{"error": auth.ErrAccountDeactivated.Error(), "code": response.ErrCodeAccountDeactivated})
+			c.Abort()
+			return
+		}
 
 		c.Set("user_id", uint(userID))
+		c.Set("role", user.Role)
 		logger.Debug("User authenticated via session", zap.Uint("user_id", uint(userID)))
 		c.Next()
 	}
 }
+
+// impersonationAudit returns a recorder that audits every request made with an
+// impersonation token, under the target user with the admin alongside
+func impersonationAudit(log *zap.Logger) func(c *gin.Context) {
+	audit := logger.NewAuditLogger(log)
+	return func(c *gin.Context) {
+		audit.Record(c, logger.AuditActionImpersonatedRequest, c.Request.Method+" "+c.Request.URL.Path, logger.AuditResultSuccess)
+	}
+}
+
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("role")
+		userRole, ok := value.(string)
+		if !exists || !ok || userRole != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{
+				Success: false,
+				Message: "Forbidden",
+				Error: response.ErrorInfo{
+					Code:    response.ErrCodeForbidden,
+					Details: "insufficient role to access this resource",
+				},
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,579 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+)
+
+type stubSessionManager struct {
+	validateErr error
+	revoked     map[string]bool
+	revokedErr  error
+	jwtOnly     bool
+}
+
+func (s *stubSessionManager) StoreRefreshToken(ctx context.Context, userID uint, sessionID, token string, ttl time.Duration, meta auth.SessionMetadata) error {
+	return nil
+}
+
+func (s *stubSessionManager) ValidateRefreshToken(ctx context.Context, userID uint, sessionID, token string) error {
+	return s.validateErr
+}
+
+func (s *stubSessionManager) DeleteRefreshToken(ctx context.Context, userID uint, sessionID string) error {
+	return nil
+}
+
+func (s *stubSessionManager) DeleteAllUserSessions(ctx context.Context, userID uint) error {
+	return nil
+}
+
+func (s *stubSessionManager) ListSessions(ctx context.Context, userID uint) ([]auth.SessionInfo, error) {
+	return nil, nil
+}
+
+func (s *stubSessionManager) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return nil
+}
+
+func (s *stubSessionManager) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.revoked[jti], s.revokedErr
+}
+
+func (s *stubSessionManager) GetSessionKey(userID uint, sessionID string) string {
+	return ""
+}
+
+func (s *stubSessionManager) JWTOnlyFallback() bool {
+	return s.jwtOnly
+}
+
+type stubUserRepository struct {
+	auth.Repository
+	users map[uint]auth.User
+}
+
+func (s *stubUserRepository) FindByID(ctx context.Context, id uint) (auth.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return auth.User{}, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func setupRoleRouter(t *testing.T, users map[uint]auth.User) (*gin.Engine, auth.JWTManagerInterface) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+	authMiddleware := AuthMiddleware(jwtManager, &stubSessionManager{}, &stubUserRepository{users: users}, false, http.SameSiteLaxMode, logger)
+
+	r := gin.New()
+	r.GET("/admin", authMiddleware, RequireRole(auth.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return r, jwtManager
+}
+
+func TestRequireRole(t *testing.T) {
+	t.Run("should allow admin to access admin route via JWT", func(t *testing.T) {
+		r, jwtManager := setupRoleRouter(t, map[uint]auth.User{1: {ID: 1, Role: auth.RoleAdmin, Active: true}})
+
+		token, err := jwtManager.Generate(1, auth.RoleAdmin)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should reject normal user on admin route via JWT", func(t *testing.T) {
+		r, jwtManager := setupRoleRouter(t, map[uint]auth.User{2: {ID: 2, Role: auth.RoleUser, Active: true}})
+
+		token, err := jwtManager.Generate(2, auth.RoleUser)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var body response.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, response.ErrCodeForbidden, body.Error.Code)
+	})
+
+	t.Run("should resolve role from user record for session auth", func(t *testing.T) {
+		r, _ := setupRoleRouter(t, map[uint]auth.User{
+			1: {ID: 1, Role: auth.RoleAdmin, Active: true},
+			2: {ID: 2, Role: auth.RoleUser, Active: true},
+		})
+
+		for userID, expected := range map[string]int{"1": http.StatusOK, "2": http.StatusForbidden} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+			req.AddCookie(&http.Cookie{Name: "user_id", Value: userID})
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, expected, w.Code, "user_id=%s", userID)
+		}
+	})
+
+	t.Run("should reject request without role in context", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/admin", RequireRole(auth.RoleAdmin), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestAuthMiddleware_SessionStoreUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+	sessionManager := &stubSessionManager{validateErr: auth.ErrSessionStoreUnavailable}
+	users := &stubUserRepository{users: map[uint]auth.User{1: {ID: 1, Role: auth.RoleUser, Active: true}}}
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(jwtManager, sessionManager, users, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("should keep accepting JWT auth", func(t *testing.T) {
+		token, err := jwtManager.Generate(1, auth.RoleUser)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should return service unavailable for session auth", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: "1"})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestAuthMiddleware_DenylistUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+	users := &stubUserRepository{users: map[uint]auth.User{1: {ID: 1, Role: auth.RoleUser, Active: true}}}
+	token, err := jwtManager.Generate(1, auth.RoleUser)
+	require.NoError(t, err)
+
+	request := func(sessionManager *stubSessionManager) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.GET("/me", AuthMiddleware(jwtManager, sessionManager, users, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should return service unavailable when the denylist cannot be checked", func(t *testing.T) {
+		w := request(&stubSessionManager{revokedErr: auth.ErrSessionStoreUnavailable})
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("should accept the token in JWT-only fallback", func(t *testing.T) {
+		w := request(&stubSessionManager{revokedErr: auth.ErrSessionStoreUnavailable, jwtOnly: true})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestAuthMiddleware_ClearsStaleSessionCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+
+	sessionRequest := func(t *testing.T, validateErr error) *httptest.ResponseRecorder {
+		t.Helper()
+
+		r := gin.New()
+		sessionManager := &stubSessionManager{validateErr: validateErr}
+		r.GET("/me", AuthMiddleware(jwtManager, sessionManager, &stubUserRepository{}, true, http.SameSiteStrictMode, logger), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: "1"})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, validateErr := range []error{auth.ErrSessionNotFound, auth.ErrInvalidRefreshToken} {
+		t.Run("should expire the session cookies on "+validateErr.Error(), func(t *testing.T) {
+			w := sessionRequest(t, validateErr)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+			expired := make(map[string]*http.Cookie)
+			for _, cookie := range w.Result().Cookies() {
+				expired[cookie.Name] = cookie
+			}
+			for _, name := range []string{"session_id", "refresh_token", "user_id"} {
+				cookie, ok := expired[name]
+				require.True(t, ok, "missing Set-Cookie for %s", name)
+				assert.Empty(t, cookie.Value)
+				assert.Negative(t, cookie.MaxAge)
+				assert.True(t, cookie.Secure)
+				assert.True(t, cookie.HttpOnly)
+				assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+			}
+		})
+	}
+
+	t.Run("should keep the cookies when validation fails for another reason", func(t *testing.T) {
+		w := sessionRequest(t, errors.New("connection reset"))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestAuthMiddleware_ExpiredToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+	expiredManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", -time.Minute, 0, logger)
+
+	expired, err := expiredManager.Generate(1, auth.RoleUser)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(jwtManager, &stubSessionManager{validateErr: auth.ErrSessionNotFound}, &stubUserRepository{}, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+		t.Helper()
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	t.Run("should tell the client to refresh an expired bearer token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+expired)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, response.ErrCodeTokenExpired, decode(t, w)["code"])
+	})
+
+	t.Run("should keep the generic error when no credentials are sent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, "authentication required", body["error"])
+		assert.Empty(t, body["code"])
+	})
+
+	t.Run("should keep the generic error for a malformed bearer token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer not-a-jwt")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, decode(t, w)["code"])
+	})
+
+	t.Run("should report the dead session when its cookies come along", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+expired)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: "1"})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, "invalid session", body["error"])
+		assert.Empty(t, body["code"])
+	})
+}
+
+func TestAuthMiddleware_BearerFallbackCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+	users := &stubUserRepository{users: map[uint]auth.User{1: {ID: 1, Role: auth.RoleUser, Active: true}}}
+
+	r := gin.New()
+	r.Use(CSRF())
+	r.POST("/orders", AuthMiddleware(jwtManager, &stubSessionManager{}, users, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(csrfToken string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header.Set("Authorization", "Bearer x")
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: "1"})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: "csrf-1"})
+		if csrfToken != "" {
+			req.Header.Set(CSRFHeaderName, csrfToken)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should require the CSRF token when a bad bearer token falls back to cookies", func(t *testing.T) {
+		w := request("")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodeCSRFTokenInvalid)
+	})
+
+	t.Run("should accept the fallback with a matching CSRF token", func(t *testing.T) {
+		w := request("csrf-1")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestAuthMiddleware_DeactivatedAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+	users := &stubUserRepository{users: map[uint]auth.User{
+		1: {ID: 1, Role: auth.RoleUser, Active: false},
+		2: {ID: 2, Role: auth.RoleUser, Active: true},
+	}}
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(jwtManager, &stubSessionManager{}, users, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	sessionRequest := func(userID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-123"})
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: userID})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-token"})
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject an existing session of a deactivated account", func(t *testing.T) {
+		w := sessionRequest("1")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodeAccountDeactivated)
+
+		expired := make(map[string]bool)
+		for _, cookie := range w.Result().Cookies() {
+			expired[cookie.Name] = cookie.MaxAge < 0
+		}
+		assert.True(t, expired["session_id"])
+		assert.True(t, expired["refresh_token"])
+	})
+
+	t.Run("should accept the session of an active account", func(t *testing.T) {
+		w := sessionRequest("2")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should reject a still valid bearer token of a deactivated account", func(t *testing.T) {
+		for userID, expected := range map[uint]int{1: http.StatusForbidden, 2: http.StatusOK} {
+			token, err := jwtManager.Generate(userID, auth.RoleUser)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, expected, w.Code, "user_id=%d", userID)
+			if expected == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), response.ErrCodeAccountDeactivated)
+			}
+		}
+	})
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, logger)
+
+	token, err := jwtManager.Generate(1, auth.RoleUser)
+	require.NoError(t, err)
+	claims, err := jwtManager.Verify(token)
+	require.NoError(t, err)
+
+	sessionManager := &stubSessionManager{revoked: map[string]bool{claims.ID: true}}
+	users := &stubUserRepository{users: map[uint]auth.User{1: {ID: 1, Role: auth.RoleUser, Active: true}}}
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(jwtManager, sessionManager, users, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("should reject revoked token with valid signature and expiry", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should accept other tokens of the same user", func(t *testing.T) {
+		other, err := jwtManager.Generate(1, auth.RoleUser)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+other)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestAuthMiddleware_Impersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+	jwtManager := auth.NewJWTManager("test-secret", "mini-e-commerce", "mini-e-commerce-api", time.Hour, 0, zap.NewNop())
+
+	users := &stubUserRepository{users: map[uint]auth.User{
+		1: {ID: 1, Role: auth.RoleAdmin, Active: true},
+		2: {ID: 2, Role: auth.RoleUser, Active: true},
+		3: {ID: 3, Role: auth.RoleAdmin, Active: false},
+		7: {ID: 7, Role: auth.RoleUser, Active: true},
+	}}
+
+	var userID, impersonatorID any
+	r := gin.New()
+	r.GET("/api/orders", AuthMiddleware(jwtManager, &stubSessionManager{}, users, false, http.SameSiteLaxMode, logger), func(c *gin.Context) {
+		userID, _ = c.Get("user_id")
+		impersonatorID, _ = c.Get("impersonator_id")
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("should attribute the request to the user and audit the admin", func(t *testing.T) {
+		token, err := jwtManager.GenerateImpersonation(7, auth.RoleUser, 1, auth.ImpersonationTokenTTL)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(7), userID)
+		assert.Equal(t, uint(1), impersonatorID)
+
+		audits := logs.Filter(isAuditEntry).All()
+		require.Len(t, audits, 1)
+		fields := audits[0].ContextMap()
+		assert.Equal(t, "auth.impersonated_request", fields["action"])
+		assert.Equal(t, "7", fields["actor_id"])
+		assert.Equal(t, uint64(1), fields["impersonator_id"])
+		assert.Equal(t, "GET /api/orders", fields["target"])
+	})
+
+	for name, adminID := range map[string]uint{"demoted": 2, "deactivated": 3, "deleted": 4} {
+		t.Run("should reject the token of a "+name+" admin", func(t *testing.T) {
+			logs.TakeAll()
+			token, err := jwtManager.GenerateImpersonation(7, auth.RoleUser, adminID, auth.ImpersonationTokenTTL)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Zero(t, logs.Filter(isAuditEntry).Len())
+		})
+	}
+
+	t.Run("should not audit regular tokens", func(t *testing.T) {
+		logs.TakeAll()
+		token, err := jwtManager.Generate(7, auth.RoleUser)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, impersonatorID)
+		assert.Zero(t, logs.Filter(isAuditEntry).Len())
+	})
+}
+
+func isAuditEntry(e observer.LoggedEntry) bool {
+	return e.LoggerName == "audit"
+}
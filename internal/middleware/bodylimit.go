@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const originalBodyKey = "body_limit_original_body"
+
+// BodyLimit caps the request body at maxBytes. Reading past the limit fails with an
+// *http.MaxBytesError, which handlers answer with 413, and a body declaring a larger
+// Content-Length fails on the first read before any of it is consumed. A route can
+// raise the global limit by applying BodyLimit again, the new limit replaces the
+// previous one instead of stacking under it. That is also why an oversized
+// Content-Length is not rejected up front, the global limit runs before the route's.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := c.Request.Body
+		if original, ok := c.Get(originalBodyKey); ok {
+			body = original.(io.ReadCloser)
+		} else {
+			c.Set(originalBodyKey, body)
+		}
+		c.Request.Body = &limitedBody{
+			ReadCloser: http.MaxBytesReader(c.Writer, body, maxBytes),
+			declared:   c.Request.ContentLength,
+			limit:      maxBytes,
+		}
+
+		c.Next()
+	}
+}
+
+// limitedBody fails every read of a body whose declared length is over the limit
+type limitedBody struct {
+	io.ReadCloser
+	declared int64
+	limit    int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.declared > b.limit {
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+	return b.ReadCloser.Read(p)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func setupBodyLimitRouter(t *testing.T, limit int64) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.NewLogger(&logger.Config{
+		ServiceName: "test",
+		AppVersion:  "test",
+		LogLevel:    zapcore.FatalLevel,
+		Mode:        "development",
+	})
+	require.NoError(t, err)
+	helper := response.NewResponseHelper(log)
+
+	bind := func(c *gin.Context) {
+		var input map[string]any
+		if err := c.ShouldBindJSON(&input); err != nil {
+			helper.ValidationError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+
+	r := gin.New()
+	r.Use(BodyLimit(limit))
+	r.POST("/items", bind)
+	r.POST("/items/bulk", BodyLimit(limit*4), bind)
+	return r
+}
+
+func jsonBody(size int) string {
+	return `{"data":"` + strings.Repeat("x", size) + `"}`
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := setupBodyLimitRouter(t, 64)
+
+	t.Run("should accept a body under the limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(jsonBody(10))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should return 413 when content length is over the limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(jsonBody(100))))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodePayloadTooLarge)
+	})
+
+	t.Run("should return 413 when a body without content length grows past the limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/items", io.NopCloser(bytes.NewReader([]byte(jsonBody(100)))))
+		req.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodePayloadTooLarge)
+	})
+
+	t.Run("should let a route raise the global limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/items/bulk", io.NopCloser(bytes.NewReader([]byte(jsonBody(100)))))
+		req.ContentLength = -1
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should let a route raise the global limit for a declared content length", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/bulk", strings.NewReader(jsonBody(100))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should return 413 when content length is over the raised limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/bulk", strings.NewReader(jsonBody(300))))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCSRFRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(CSRF("/auth/login"))
+	r.POST("/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.POST("/auth/login", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return r
+}
+
+func newCSRFRequest(method, path, cookieToken, headerToken string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-1"})
+	if cookieToken != "" {
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: cookieToken})
+	}
+	if headerToken != "" {
+		req.Header.Set(CSRFHeaderName, headerToken)
+	}
+	return req
+}
+
+func TestCSRF(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          *http.Request
+		expectedCode int
+	}{
+		{
+			name:         "should reject cookie request without token header",
+			req:          newCSRFRequest(http.MethodPost, "/orders", "token-1", ""),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "should reject cookie request with mismatched token",
+			req:          newCSRFRequest(http.MethodPost, "/orders", "token-1", "token-2"),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "should reject cookie request without token cookie",
+			req:          newCSRFRequest(http.MethodPost, "/orders", "", "token-1"),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "should allow cookie request with matching token",
+			req:          newCSRFRequest(http.MethodPost, "/orders", "token-1", "token-1"),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "should allow safe methods without token",
+			req:          newCSRFRequest(http.MethodGet, "/orders", "token-1", ""),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "should allow exempt paths without token",
+			req:          newCSRFRequest(http.MethodPost, "/auth/login", "token-1", ""),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "should allow requests without session cookie",
+			req:          httptest.NewRequest(http.MethodPost, "/orders", nil),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "should allow bearer authenticated requests",
+			req: func() *http.Request {
+				req := newCSRFRequest(http.MethodPost, "/orders", "token-1", "")
+				req.Header.Set("Authorization", "Bearer access-token")
+				return req
+			}(),
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupCSRFRouter()
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, tt.req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "CSRF_TOKEN_INVALID")
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupETagRouter(t *testing.T, body *string, status *int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/products/:id", ETag(), func(c *gin.Context) {
+		c.JSON(*status, gin.H{"name": *body})
+	})
+	r.POST("/products", ETag(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"name": *body})
+	})
+	return r
+}
+
+func TestETag(t *testing.T) {
+	body, status := "Smartphone", http.StatusOK
+	r := setupETagRouter(t, &body, &status)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.JSONEq(t, `{"name":"Smartphone"}`, first.Body.String())
+
+	t.Run("should answer a matching If-None-Match with 304", func(t *testing.T) {
+		w := get(etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("should match a weak tag in a list", func(t *testing.T) {
+		w := get(`"other", W/` + etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("should return the new body once it changed", func(t *testing.T) {
+		body = "Laptop"
+		t.Cleanup(func() { body = "Smartphone" })
+
+		w := get(etag)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name":"Laptop"}`, w.Body.String())
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("should not tag error responses", func(t *testing.T) {
+		status = http.StatusNotFound
+		t.Cleanup(func() { status = http.StatusOK })
+
+		w := get(etag)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+		assert.JSONEq(t, `{"name":"Smartphone"}`, w.Body.String())
+	})
+
+	t.Run("should leave other methods alone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products", nil)
+		req.Header.Set("If-None-Match", "*")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("ETag"))
+	})
+}
@@ -9,10 +9,23 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestLogger logs every request under a request id. An X-Request-ID set by an
+// upstream gateway is kept so logs correlate across services, otherwise a new one
+// is generated. Either way the id is echoed back in the response header.
 func RequestLogger(log logger.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		requestID := uuid.New().String()
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
 		ctx.Set("request_id", requestID)
+		ctx.Header(RequestIDHeader, requestID)
 
 		start := time.Now()
 		ctx.Next()
@@ -32,6 +45,20 @@ func RequestLogger(log logger.Logger) gin.HandlerFunc {
 	}
 }
 
+// validRequestID accepts ids of a sane length made of visible ASCII only, so a client
+// cannot smuggle control characters or huge values into the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 func ErrorLogger(log logger.Logger) gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
 		requestID := extractRequestIDSafely(c)
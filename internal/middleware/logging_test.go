@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func setupRequestLoggerRouter() (*gin.Engine, *observer.ObservedLogs, *string) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := logger.NewLoggerFromZap(zap.New(core), logger.NewConfig())
+
+	var contextID string
+	r := gin.New()
+	r.Use(RequestLogger(log))
+	r.GET("/ping", func(c *gin.Context) {
+		contextID = c.GetString("request_id")
+		c.Status(http.StatusOK)
+	})
+
+	return r, logs, &contextID
+}
+
+func loggedRequestID(t *testing.T, logs *observer.ObservedLogs) string {
+	t.Helper()
+
+	entries := logs.FilterMessage("HTTP Request").All()
+	require.Len(t, entries, 1)
+	return entries[0].ContextMap()["request_id"].(string)
+}
+
+func TestRequestLogger_RequestID(t *testing.T) {
+	t.Run("should propagate incoming request id", func(t *testing.T) {
+		r, logs, contextID := setupRequestLoggerRouter()
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(RequestIDHeader, "gateway-req-42")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, "gateway-req-42", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "gateway-req-42", *contextID)
+		assert.Equal(t, "gateway-req-42", loggedRequestID(t, logs))
+	})
+
+	t.Run("should generate request id when header is missing", func(t *testing.T) {
+		r, logs, contextID := setupRequestLoggerRouter()
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		id := w.Header().Get(RequestIDHeader)
+		_, err := uuid.Parse(id)
+		require.NoError(t, err)
+		assert.Equal(t, id, *contextID)
+		assert.Equal(t, id, loggedRequestID(t, logs))
+	})
+
+	t.Run("should replace invalid request ids", func(t *testing.T) {
+		for _, id := range []string{strings.Repeat("a", maxRequestIDLength+1), "bad id", "bad\tid"} {
+			r, logs, _ := setupRequestLoggerRouter()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.Header.Set(RequestIDHeader, id)
+			r.ServeHTTP(w, req)
+
+			assert.NotEqual(t, id, w.Header().Get(RequestIDHeader))
+			assert.Equal(t, w.Header().Get(RequestIDHeader), loggedRequestID(t, logs))
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Metrics())
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/metrics-test/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, id := range []string{"1", "2", "3"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics-test/items/"+id, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics-test/missing", nil))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/metrics-test/items/:id",status="200"} 3`+"\n")
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/metrics-test/items/:id",status="200"} 3`+"\n")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"}`)
+	assert.NotContains(t, body, `/metrics-test/items/1`)
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mini-e-commerce/internal/logger"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultPayloadMaxBytes = 4096
+
+	redactedValue = "***"
+)
+
+// sensitiveKeyParts mask every JSON key containing one of them, so password, old_password,
+// new_password, token, refresh_token and client_secret alike never reach the logs
+var sensitiveKeyParts = []string{"password", "token", "secret"}
+
+type PayloadLoggerConfig struct {
+	// RedactFields are further JSON keys whose values are logged as ***, on top of those
+	// containing a sensitive part. Both are matched case-insensitively at any depth.
+	RedactFields []string
+
+	// MaxBytes caps each logged body, longer ones are cut and flagged as truncated
+	MaxBytes int
+
+	// Paths limits logging to requests under these prefixes, empty logs every path
+	Paths []string
+}
+
+// PayloadLogger logs the JSON request and response bodies of each request for debugging.
+// Only what the handler actually reads is logged, so the body is never consumed ahead of
+// BodyLimit. Bodies that are not JSON, or fail to parse, are left out since they could not
+// be redacted.
+func PayloadLogger(log logger.Logger, cfg PayloadLoggerConfig) gin.HandlerFunc {
+	redact := make(map[string]struct{}, len(cfg.RedactFields))
+	for _, field := range cfg.RedactFields {
+		redact[strings.ToLower(strings.TrimSpace(field))] = struct{}{}
+	}
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultPayloadMaxBytes
+	}
+
+	return func(c *gin.Context) {
+		if !matchesPathPrefix(c.Request.URL.Path, cfg.Paths) {
+			c.Next()
+			return
+		}
+
+		var requestBody bytes.Buffer
+		if c.Request.Body != nil && isJSONContentType(c.ContentType()) {
+			c.Request.Body = &teeReadCloser{ReadCloser: c.Request.Body, copy: &requestBody}
+			// a route raising the body limit wraps the original body again, tee that one too
+			if original, ok := c.Get(originalBodyKey); ok {
+				c.Set(originalBodyKey, &teeReadCloser{ReadCloser: original.(io.ReadCloser), copy: &requestBody})
+			}
+		}
+
+		w := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		loggedRequest, requestTruncated := redactPayload(requestBody.Bytes(), redact, maxBytes)
+		loggedResponse, responseTruncated := redactPayload(w.body.Bytes(), redact, maxBytes)
+
+		log.Info("HTTP Payload",
+			zap.String("request_id", c.GetString("request_id")),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", w.Status()),
+			zap.String("request_body", loggedRequest),
+			zap.Bool("request_truncated", requestTruncated),
+			zap.String("response_body", loggedResponse),
+			zap.Bool("response_truncated", responseTruncated),
+		)
+	}
+}
+
+func matchesPathPrefix(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSONContentType(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
+
+// redactPayload masks the redacted keys of a JSON body and cuts the result to maxBytes
+// on a rune boundary. Anything that does not parse as JSON is dropped.
+func redactPayload(body []byte, redact map[string]struct{}, maxBytes int) (string, bool) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "", false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", false
+	}
+
+	redacted, err := json.Marshal(redactValue(value, redact))
+	if err != nil {
+		return "", false
+	}
+	if len(redacted) <= maxBytes {
+		return string(redacted), false
+	}
+
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(redacted[cut]) {
+		cut--
+	}
+	return string(redacted[:cut]), true
+}
+
+func redactValue(value any, redact map[string]struct{}) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isRedactedKey(key, redact) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field, redact)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item, redact)
+		}
+	}
+	return value
+}
+
+func isRedactedKey(key string, redact map[string]struct{}) bool {
+	key = strings.ToLower(key)
+	if _, ok := redact[key]; ok {
+		return true
+	}
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// teeReadCloser copies whatever the handler reads from the request body
+type teeReadCloser struct {
+	io.ReadCloser
+	copy *bytes.Buffer
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.copy.Write(p[:n])
+	return n, err
+}
+
+// capturingWriter keeps a copy of JSON responses while passing every write through
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	if isJSONContentType(w.Header().Get("Content-Type")) {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	if isJSONContentType(w.Header().Get("Content-Type")) {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
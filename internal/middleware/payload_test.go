@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func setupPayloadLoggerRouter(cfg PayloadLoggerConfig) (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := logger.NewLoggerFromZap(zap.New(core), logger.NewConfig())
+
+	r := gin.New()
+	r.Use(BodyLimit(1 << 20))
+	r.Use(PayloadLogger(log, cfg))
+	r.POST("/auth/login", func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"email": body["email"], "access_token": "header.payload.signature"}})
+	})
+	r.POST("/auth/password", func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+	})
+	r.GET("/products", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": strings.Repeat("x", 500)})
+	})
+	r.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	return r, logs
+}
+
+func loggedPayload(t *testing.T, logs *observer.ObservedLogs) map[string]any {
+	t.Helper()
+
+	entries := logs.FilterMessage("HTTP Payload").All()
+	require.Len(t, entries, 1)
+	return entries[0].ContextMap()
+}
+
+func TestPayloadLogger(t *testing.T) {
+	t.Run("should redact sensitive fields in both bodies", func(t *testing.T) {
+		r, logs := setupPayloadLoggerRouter(PayloadLoggerConfig{})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"a@example.com","Password":"hunter22"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "header.payload.signature", "the client still gets the real body")
+
+		fields := loggedPayload(t, logs)
+		requestBody := fields["request_body"].(string)
+		responseBody := fields["response_body"].(string)
+		assert.Contains(t, requestBody, "a@example.com")
+		assert.Contains(t, requestBody, `"Password":"***"`)
+		assert.NotContains(t, requestBody, "hunter22")
+		assert.Contains(t, responseBody, `"access_token":"***"`)
+		assert.NotContains(t, responseBody, "header.payload.signature")
+	})
+
+	t.Run("should redact every password and token of a change password body", func(t *testing.T) {
+		r, logs := setupPayloadLoggerRouter(PayloadLoggerConfig{})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/auth/password", strings.NewReader(`{"old_password":"hunter22","new_password":"correct horse","token":"reset-token","client_secret":"s3cr3t"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		requestBody := loggedPayload(t, logs)["request_body"].(string)
+		assert.Equal(t, `{"client_secret":"***","new_password":"***","old_password":"***","token":"***"}`, requestBody)
+	})
+
+	t.Run("should truncate large bodies", func(t *testing.T) {
+		r, logs := setupPayloadLoggerRouter(PayloadLoggerConfig{MaxBytes: 100})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Greater(t, w.Body.Len(), 500)
+
+		fields := loggedPayload(t, logs)
+		assert.Len(t, fields["response_body"], 100)
+		assert.Equal(t, true, fields["response_truncated"])
+		assert.Equal(t, false, fields["request_truncated"])
+	})
+
+	t.Run("should redact a custom list on top of the sensitive keys", func(t *testing.T) {
+		r, logs := setupPayloadLoggerRouter(PayloadLoggerConfig{RedactFields: []string{"email"}})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"a@example.com","password":"hunter22"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		fields := loggedPayload(t, logs)
+		assert.NotContains(t, fields["request_body"], "a@example.com")
+		assert.NotContains(t, fields["request_body"], "hunter22")
+		assert.NotContains(t, fields["response_body"], "a@example.com")
+	})
+
+	t.Run("should leave out bodies that are not JSON", func(t *testing.T) {
+		r, logs := setupPayloadLoggerRouter(PayloadLoggerConfig{})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		require.Equal(t, "ok", w.Body.String())
+		assert.Empty(t, loggedPayload(t, logs)["response_body"])
+	})
+
+	t.Run("should only log the configured paths", func(t *testing.T) {
+		r, logs := setupPayloadLoggerRouter(PayloadLoggerConfig{Paths: []string{"/auth"}})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, logs.FilterMessage("HTTP Payload").All())
+	})
+}
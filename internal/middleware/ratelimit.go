@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"mini-e-commerce/internal/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimit allows at most limit requests per client IP and path within a fixed window.
+// Requests are let through when Redis is unavailable so an outage does not lock users out.
+func RateLimit(rdb *redis.Client, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		key := fmt.Sprintf("ratelimit:%s:%s", c.ClientIP(), c.FullPath())
+
+		var incr *redis.IntCmd
+		var ttl *redis.DurationCmd
+		_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			incr = pipe.Incr(ctx, key)
+			pipe.ExpireNX(ctx, key, window)
+			ttl = pipe.TTL(ctx, key)
+			return nil
+		})
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if incr.Val() > int64(limit) {
+			retryAfter := int(math.Ceil(ttl.Val().Seconds()))
+			if retryAfter <= 0 {
+				retryAfter = int(window.Seconds())
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, response.ErrorResponse{
+				Success: false,
+				Message: "Too many requests",
+				Error: response.ErrorInfo{
+					Code:    response.ErrCodeTooManyRequests,
+					Details: "rate limit exceeded, try again later",
+				},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRateLimitRouter(t *testing.T, limit int) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	r := gin.New()
+	r.POST("/auth/login", RateLimit(rdb, limit, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.POST("/auth/register", RateLimit(rdb, limit, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	return r, mr
+}
+
+func sendRequest(r *gin.Engine, path, remoteAddr string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.RemoteAddr = remoteAddr
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("should return 429 after limit is exceeded", func(t *testing.T) {
+		limit := 3
+		r, _ := setupRateLimitRouter(t, limit)
+
+		for i := 0; i < limit; i++ {
+			w := sendRequest(r, "/auth/login", "10.0.0.1:1234")
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+
+		w := sendRequest(r, "/auth/login", "10.0.0.1:1234")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("should track clients and paths separately", func(t *testing.T) {
+		r, _ := setupRateLimitRouter(t, 1)
+
+		assert.Equal(t, http.StatusOK, sendRequest(r, "/auth/login", "10.0.0.1:1234").Code)
+		assert.Equal(t, http.StatusOK, sendRequest(r, "/auth/login", "10.0.0.2:1234").Code)
+		assert.Equal(t, http.StatusOK, sendRequest(r, "/auth/register", "10.0.0.1:1234").Code)
+		assert.Equal(t, http.StatusTooManyRequests, sendRequest(r, "/auth/login", "10.0.0.1:1234").Code)
+	})
+
+	t.Run("should allow requests again after window expires", func(t *testing.T) {
+		r, mr := setupRateLimitRouter(t, 1)
+
+		assert.Equal(t, http.StatusOK, sendRequest(r, "/auth/login", "10.0.0.1:1234").Code)
+		assert.Equal(t, http.StatusTooManyRequests, sendRequest(r, "/auth/login", "10.0.0.1:1234").Code)
+
+		mr.FastForward(time.Minute)
+
+		assert.Equal(t, http.StatusOK, sendRequest(r, "/auth/login", "10.0.0.1:1234").Code)
+	})
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts the root span of each request, continuing the caller's trace
+// when a traceparent header is sent, and echoes the span's traceparent back.
+// Register it after RequestLogger so the span carries the request ID:
+//
+//	r.Use(middleware.Tracing(serviceName)...)
+func Tracing(serviceName string) gin.HandlersChain {
+	return gin.HandlersChain{
+		otelgin.Middleware(serviceName),
+		func(c *gin.Context) {
+			ctx := c.Request.Context()
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("request_id", extractRequestIDSafely(c)))
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+			c.Next()
+		},
+	}
+}
@@ -1,10 +1,24 @@
 package order
 
-import "mini-e-commerce/internal/dto"
+import (
+	"mini-e-commerce/internal/dto"
+	"time"
+)
 
 type OrderQuery struct {
 	dto.PaginationQuery
-	SortBy string `form:"sort_by" binding:"omitempty,oneof=id user_id product_id quantity total_price status created_at"`
+	SortBy      string       `form:"sort_by" binding:"omitempty,oneof=id user_id product_id quantity total_price status created_at"`
+	Status      *OrderStatus `form:"status" binding:"omitempty,oneof=PENDING PAID CANCELLED"`
+	CreatedFrom *time.Time   `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time   `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	UserID      uint         `form:"-"`
+	IncludeUser bool         `form:"-"`
+}
+
+// AdminOrderQuery lists orders of every user, optionally narrowed to one of them
+type AdminOrderQuery struct {
+	OrderQuery
+	UserID uint `form:"user_id" binding:"omitempty,min=1"`
 }
 
 type OrderItemInput struct {
@@ -13,7 +27,8 @@ type OrderItemInput struct {
 }
 
 type CreateOrderRequest struct {
-	Items []OrderItemInput `json:"items" binding:"required,min=1,dive" validate:"required,min=1,dive"`
+	Items      []OrderItemInput `json:"items" binding:"required,min=1,dive" validate:"required,min=1,dive"`
+	CouponCode string           `json:"coupon_code" binding:"omitempty,max=50" validate:"omitempty,max=50"`
 }
 
 type UpdateOrderRequest struct {
@@ -24,3 +39,44 @@ type OrderListResponse struct {
 	Data       []Order                `json:"data"`
 	Pagination dto.PaginationMetadata `json:"pagination"`
 }
+
+// OrderResponse is an order as returned by the handlers. ItemCount is the total
+// quantity over all lines and every line carries its product name, so clients do not
+// need a product lookup per line.
+type OrderResponse struct {
+	Order
+	ItemCount  int                 `json:"item_count"`
+	OrderItems []OrderItemResponse `json:"order_items"`
+}
+
+type OrderItemResponse struct {
+	OrderItem
+	ProductName string `json:"product_name"`
+}
+
+// NewOrderResponse maps order to its response. A line takes its product name from the
+// preloaded product, else from names, which is keyed by product id.
+func NewOrderResponse(order Order, names map[uint]string) OrderResponse {
+	resp := OrderResponse{
+		Order:      order,
+		OrderItems: make([]OrderItemResponse, 0, len(order.OrderItems)),
+	}
+	for _, item := range order.OrderItems {
+		name := names[item.ProductID]
+		if item.Product != nil {
+			name = item.Product.Name
+		}
+		resp.ItemCount += item.Quantity
+		resp.OrderItems = append(resp.OrderItems, OrderItemResponse{OrderItem: item, ProductName: name})
+	}
+	return resp
+}
+
+// OrderSummary is the spending overview of one user. TotalSpent is keyed by currency
+// because orders in different currencies cannot be summed.
+type OrderSummary struct {
+	TotalOrders        int64                 `json:"total_orders"`
+	OrderCountByStatus map[OrderStatus]int64 `json:"order_count_by_status"`
+	TotalSpent         map[string]int64      `json:"total_spent"`
+	TopProducts        []TopProduct          `json:"top_products"`
+}
@@ -1,8 +1,12 @@
 package order
 
 import (
+	"encoding/csv"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"mini-e-commerce/internal/auth"
 	"mini-e-commerce/internal/logger"
@@ -11,7 +15,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 const (
@@ -19,18 +22,34 @@ const (
 	ErrMsgOrderNotFound      = "Order not found"
 	ErrMsgProductNotFound    = "Product not found"
 	ErrMsgInsufficientStock  = "Stock product not available"
+	ErrMsgInvalidCoupon      = "Invalid coupon code"
+	ErrMsgCurrencyMismatch   = "Products in one order must share a currency"
 	ErrMsgNotAuthorized      = "Not allowed to update this order"
+	ErrMsgNotAuthorizedView  = "Not allowed to view this order"
 	ErrMsgInvalidStatus      = "Invalid status value"
+	ErrMsgInvalidTransition  = "Order cannot move to this status"
+	ErrMsgNotPayable         = "Only pending orders can be paid"
+	ErrMsgPaymentDeclined    = "Payment was declined"
+	ErrMsgPaymentUnavailable = "Payments are not available"
+	ErrMsgConcurrentUpdate   = "Order was changed by another request"
 	ErrMsgInvalidUserContext = "Invalid user id in context"
 	ErrMsgFailedToProcess    = "Failed to process order"
 	ErrMsgFailedToFetch      = "Failed to fetch order"
 	ErrMsgFailedToDelete     = "Failed to delete order"
+	ErrMsgFailedToRestore    = "Failed to restore order"
 	ErrMsgFailedToUpdate     = "Failed to update order"
+	ErrMsgFailedToPay        = "Failed to process payment"
+	ErrMsgFailedToExport     = "Failed to export orders"
 )
 
+var exportHeader = []string{"id", "created_at", "status", "total", "currency", "item_count"}
+
+var errMissingUserID = errors.New("missing user_id in context")
+
 type Handler struct {
 	service        Service
 	logger         logger.Logger
+	audit          logger.AuditLogger
 	responseHelper *response.ResponseHelper
 }
 
@@ -38,43 +57,53 @@ func NewHandler(service Service, log logger.Logger) *Handler {
 	return &Handler{
 		service:        service,
 		logger:         log,
+		audit:          log.WithAudit(),
 		responseHelper: response.NewResponseHelper(log),
 	}
 }
 
-func (h *Handler) RegisterRoutes(r *gin.RouterGroup, jwtManager auth.JWTManagerInterface, sessionManager auth.SessionManagerInterface, logger *zap.Logger) {
-	authMiddleware := middleware.AuthMiddleware(jwtManager, sessionManager, logger)
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
 	group := r.Group("/orders", authMiddleware)
 
 	group.POST("", h.CreateOrder)
+	group.POST("/validate", h.ValidateOrder)
 	group.GET("", h.GetOrders)
+	group.GET("/summary", h.GetOrderSummary)
+	group.GET("/export", h.ExportOrders)
 	group.GET("/:id", h.GetOrderByID)
+	group.GET("/:id/history", h.GetOrderStatusHistory)
 	group.DELETE("/:id", h.DeleteOrder)
 	group.PATCH("/:id", h.UpdateOrder)
+	group.POST("/:id/pay", h.PayOrder)
+
+	admin := r.Group("/admin/orders", authMiddleware, middleware.RequireRole(auth.RoleAdmin))
+	admin.GET("", h.GetAllOrders)
+	admin.POST("/:id/restore", h.RestoreOrder)
 }
 
 // CreateOrder godoc
 // @Summary Create new order
-// @Description Create new order with multiple products
+// @Description Create new order with multiple products and an optional coupon code
 // @Tags Orders
 // @Accept  json
 // @Produce  json
 // @Param   request body CreateOrderRequest true "Order body request"
-// @Success 201 {object} response.SuccessResponse{data=Order}
+// @Success 201 {object} response.SuccessResponse{data=OrderResponse}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /orders [post]
 func (h *Handler) CreateOrder(c *gin.Context) {
 	var input CreateOrderRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
 	userID, err := h.getUserIDFromContext(c)
 	if err != nil {
-		if err.Error() == "missing user_id in context" {
+		if errors.Is(err, errMissingUserID) {
 			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
 		} else {
 			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
@@ -84,32 +113,83 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 
 	order, err := h.service.CreateOrder(c.Request.Context(), input, userID)
 	if err != nil {
-		if err.Error() == ErrProductNotFound {
-			h.responseHelper.NotFound(c, ErrMsgProductNotFound, err.Error())
-			return
-		}
-		if err.Error() == ErrInsufficientStock {
-			h.responseHelper.BadRequest(c, ErrMsgInsufficientStock, err.Error())
-			return
+		h.handleCreateOrderError(c, err)
+		return
+	}
+
+	h.responseHelper.SuccessCreated(c, "Order created successfully", h.orderResponse(c, order))
+
+}
+
+// ValidateOrder godoc
+// @Summary Validate an order
+// @Description Check an order the way creating it would and return its items and total, without saving it, reserving stock or redeeming the coupon
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   request body CreateOrderRequest true "Order body request"
+// @Success 200 {object} response.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /orders/validate [post]
+func (h *Handler) ValidateOrder(c *gin.Context) {
+	var input CreateOrderRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
 		}
-		h.responseHelper.InternalServerError(c, ErrMsgFailedToProcess, err.Error())
 		return
 	}
 
-	h.responseHelper.SuccessCreated(c, "Order created successfully", order)
+	order, err := h.service.ValidateOrder(c.Request.Context(), input, userID)
+	if err != nil {
+		h.handleCreateOrderError(c, err)
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Order is valid", h.orderResponse(c, order))
+}
 
+func (h *Handler) handleCreateOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		h.responseHelper.Error(c, http.StatusNotFound, ErrMsgProductNotFound, response.ErrCodeProductNotFound, err.Error())
+	case errors.Is(err, ErrInsufficientStock):
+		h.responseHelper.Error(c, http.StatusBadRequest, ErrMsgInsufficientStock, response.ErrCodeInsufficientStock, err.Error())
+	case errors.Is(err, ErrCouponNotFound), errors.Is(err, ErrCouponExpired), errors.Is(err, ErrCouponExhausted), errors.Is(err, ErrCouponCurrencyMismatch):
+		h.responseHelper.Error(c, http.StatusBadRequest, ErrMsgInvalidCoupon, response.ErrCodeInvalidCoupon, err.Error())
+	case errors.Is(err, ErrCurrencyMismatch):
+		h.responseHelper.Error(c, http.StatusBadRequest, ErrMsgCurrencyMismatch, response.ErrCodeCurrencyMismatch, err.Error())
+	case errors.Is(err, ErrTooManyItems), errors.Is(err, ErrQuantityTooLarge), errors.Is(err, ErrOrderTotalOverflow):
+		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+	default:
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToProcess, err.Error())
+	}
 }
 
 // GetOrders godoc
 // @Summary Get all list order
-// @Description Get all list order
+// @Description Get all list order owned by the authenticated user
 // @Tags Orders
 // @Accept  json
 // @Produce  json
-// @Param page query int false "Page number" minimum(1)
-// @Param page_size query int false "Page size" minimum(1) maximum(100)
+// @Param page query int false "Page number" minimum(1) default(1)
+// @Param page_size query int false "Page size, values above 100 are clamped" minimum(1) default(10)
 // @Param order query string false "Sort order" Enums(asc, desc)
 // @Param sort_by query string false "Sort by field" Enums(id, user_id, product_id, quantity, total_price, status, created_at)
+// @Param status query string false "Filter by order status" Enums(PENDING, PAID, CANCELLED)
+// @Param created_from query string false "Only orders created at or after this time (RFC3339)" format(date-time)
+// @Param created_to query string false "Only orders created at or before this time (RFC3339)" format(date-time)
 // @Success 200 {object} response.SuccessResponse{data=OrderListResponse}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
@@ -118,28 +198,176 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 func (h *Handler) GetOrders(c *gin.Context) {
 	var query OrderQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+	query.UserID = userID
+
+	result, err := h.service.GetAllOrdersWithQuery(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidDateRange) {
+			h.responseHelper.Error(c, http.StatusBadRequest, response.ErrCodeValidationError, response.ErrCodeInvalidDateRange, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+	h.responseHelper.SuccessPaginated(c, "List Order retrieved successfully", h.service.OrderResponses(c.Request.Context(), result.Data), result.Pagination)
+}
+
+// ExportOrders godoc
+// @Summary Export orders as CSV
+// @Description Download every order of the authenticated user as CSV, oldest first. Takes the same filters as the order list, rows are streamed as they are read.
+// @Tags Orders
+// @Produce  text/csv
+// @Param status query string false "Filter by order status" Enums(PENDING, PAID, CANCELLED)
+// @Param created_from query string false "Only orders created at or after this time (RFC3339)" format(date-time)
+// @Param created_to query string false "Only orders created at or before this time (RFC3339)" format(date-time)
+// @Success 200 {string} string "CSV with columns id, created_at, status, total, currency, item_count"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /orders/export [get]
+func (h *Handler) ExportOrders(c *gin.Context) {
+	var query OrderQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+	query.UserID = userID
+
+	// The header goes out with the first row, until then an error can still be sent as JSON
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	start := func() error {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="orders.csv"`)
+		c.Status(http.StatusOK)
+		started = true
+		return writer.Write(exportHeader)
+	}
+
+	err = h.service.ExportOrders(c.Request.Context(), query, func(order Order) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		itemCount := 0
+		for _, item := range order.OrderItems {
+			itemCount += item.Quantity
+		}
+		// the csv writer hands its buffer to the response every few KB, so rows stream out
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(order.ID), 10),
+			order.CreatedAt.UTC().Format(time.RFC3339),
+			string(order.Status),
+			strconv.Itoa(order.TotalPrice),
+			order.Currency,
+			strconv.Itoa(itemCount),
+		})
+	})
+	if err != nil {
+		if started {
+			// the status is already sent, all that is left is to cut the download short
+			h.logger.WithContext(c).Error("Order export aborted", zap.Uint("user_id", userID), zap.Error(err))
+			c.Abort()
+			return
+		}
+		if errors.Is(err, ErrInvalidDateRange) {
+			h.responseHelper.Error(c, http.StatusBadRequest, response.ErrCodeValidationError, response.ErrCodeInvalidDateRange, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToExport, err.Error())
+		return
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			h.logger.WithContext(c).Error("Order export aborted", zap.Uint("user_id", userID), zap.Error(err))
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.WithContext(c).Error("Order export aborted", zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+// GetAllOrders godoc
+// @Summary Get all orders of every user
+// @Description Get all orders across users with the owner email, optionally filtered by user (admin only)
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param user_id query int false "Only orders of this user" minimum(1)
+// @Param page query int false "Page number" minimum(1) default(1)
+// @Param page_size query int false "Page size, values above 100 are clamped" minimum(1) default(10)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param sort_by query string false "Sort by field" Enums(id, user_id, product_id, quantity, total_price, status, created_at)
+// @Param status query string false "Filter by order status" Enums(PENDING, PAID, CANCELLED)
+// @Param created_from query string false "Only orders created at or after this time (RFC3339)" format(date-time)
+// @Param created_to query string false "Only orders created at or before this time (RFC3339)" format(date-time)
+// @Success 200 {object} response.SuccessResponse{data=OrderListResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/orders [get]
+func (h *Handler) GetAllOrders(c *gin.Context) {
+	var adminQuery AdminOrderQuery
+	if err := c.ShouldBindQuery(&adminQuery); err != nil {
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
+	query := adminQuery.OrderQuery
+	query.UserID = adminQuery.UserID
+	query.IncludeUser = true
+
 	result, err := h.service.GetAllOrdersWithQuery(c.Request.Context(), query)
 	if err != nil {
+		if errors.Is(err, ErrInvalidDateRange) {
+			h.responseHelper.Error(c, http.StatusBadRequest, response.ErrCodeValidationError, response.ErrCodeInvalidDateRange, err.Error())
+			return
+		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
 		return
 	}
-	h.responseHelper.SuccessPaginated(c, "List Order retrieved successfully", result.Data, result.Pagination)
+	h.responseHelper.SuccessPaginated(c, "List Order retrieved successfully", h.service.OrderResponses(c.Request.Context(), result.Data), result.Pagination)
 }
 
 // GetOrderByID godoc
 // @Summary Get single order
-// @Description Get an order by id
+// @Description Get an order owned by the authenticated user, admins may get any order
 // @Tags Orders
 // @Accept  json
 // @Produce  json
 // @Param   id path string true "Order ID"
-// @Success 200 {object} response.SuccessResponse{data=Order}
+// @Success 200 {object} response.SuccessResponse{data=OrderResponse}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /orders/{id} [get]
@@ -150,21 +378,113 @@ func (h *Handler) GetOrderByID(c *gin.Context) {
 		return
 	}
 
-	order, err := h.service.GetOrderByID(c.Request.Context(), id)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	order, err := h.service.GetOrderByID(c.Request.Context(), id, userID, isAdmin(c))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			h.responseHelper.NotFound(c, ErrMsgOrderNotFound, "No order with given ID")
+		if errors.Is(err, ErrOrderNotFound) {
+			h.responseHelper.Error(c, http.StatusNotFound, ErrMsgOrderNotFound, response.ErrCodeOrderNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrNotAuthorizedToView) {
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgNotAuthorizedView, response.ErrCodeOrderForbidden, err.Error())
 			return
 		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
 		return
 	}
-	h.responseHelper.SuccessOK(c, "Order retrieved successfully", order)
+	h.responseHelper.SuccessOK(c, "Order retrieved successfully", h.orderResponse(c, order))
+}
+
+// GetOrderStatusHistory godoc
+// @Summary Get order status history
+// @Description Get every status change of an order owned by the authenticated user, oldest first
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Success 200 {object} response.SuccessResponse{data=[]OrderStatusHistory}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /orders/{id}/history [get]
+func (h *Handler) GetOrderStatusHistory(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidOrderID, err.Error())
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	history, err := h.service.GetOrderStatusHistory(c.Request.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, ErrOrderNotFound) {
+			h.responseHelper.Error(c, http.StatusNotFound, ErrMsgOrderNotFound, response.ErrCodeOrderNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrNotAuthorizedToView) {
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgNotAuthorizedView, response.ErrCodeOrderForbidden, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Order status history retrieved successfully", history)
+}
+
+// GetOrderSummary godoc
+// @Summary Get order summary
+// @Description Get the authenticated user's order count by status, total spent per currency and most bought products. Spending and top products count paid orders only. The summary is cached for up to a minute.
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.SuccessResponse{data=OrderSummary}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /orders/summary [get]
+func (h *Handler) GetOrderSummary(c *gin.Context) {
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	summary, err := h.service.GetOrderSummary(c.Request.Context(), userID)
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Order summary retrieved successfully", summary)
 }
 
 // DeleteOrder godoc
 // @Summary Delete single product
-// @Description Delete an order by id
+// @Description Delete an order owned by the authenticated user, admins may delete any order
 // @Tags Orders
 // @Accept  json
 // @Produce  json
@@ -172,6 +492,7 @@ func (h *Handler) GetOrderByID(c *gin.Context) {
 // @Success 200 {object} response.SuccessResponse
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /orders/{id} [delete]
@@ -182,10 +503,24 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteOrder(c.Request.Context(), id)
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	err = h.service.DeleteOrder(c.Request.Context(), id, userID, isAdmin(c))
 	if err != nil {
-		if err.Error() == ErrOrderNotFound {
-			h.responseHelper.NotFound(c, ErrMsgOrderNotFound, err.Error())
+		if errors.Is(err, ErrOrderNotFound) {
+			h.responseHelper.Error(c, http.StatusNotFound, ErrMsgOrderNotFound, response.ErrCodeOrderNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrNotAuthorizedToUpdate) {
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgNotAuthorized, response.ErrCodeOrderForbidden, err.Error())
 			return
 		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToDelete, err.Error())
@@ -195,24 +530,67 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 	h.responseHelper.SuccessOK(c, "Order deleted successfully", nil)
 }
 
+// RestoreOrder godoc
+// @Summary Restore deleted order
+// @Description Restore a soft-deleted order and reserve its stock again (admin only)
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Success 200 {object} response.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/orders/{id}/restore [post]
+func (h *Handler) RestoreOrder(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidOrderID, err.Error())
+		return
+	}
+
+	order, err := h.service.RestoreOrder(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			h.responseHelper.Error(c, http.StatusNotFound, ErrMsgOrderNotFound, response.ErrCodeOrderNotFound, err.Error())
+		case errors.Is(err, ErrInsufficientStock):
+			h.responseHelper.Error(c, http.StatusBadRequest, ErrMsgInsufficientStock, response.ErrCodeInsufficientStock, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToRestore, err.Error())
+		}
+		return
+	}
+
+	h.logger.WithContext(c).Info("Order restored",
+		zap.Uint("order_id", order.ID),
+	)
+
+	h.responseHelper.SuccessOK(c, "Order restored successfully", h.orderResponse(c, order))
+}
+
 // UpdateProduct godoc
 // @Summary Update an order
-// @Description Update an order by Id
+// @Description Update an order by Id. Orders are marked PAID through /orders/{id}/pay, not here.
 // @Tags Orders
 // @Accept  json
 // @Produce  json
 // @Param   id path string true "Order ID"
 // @Param   request body UpdateOrderRequest true "Order body request"
-// @Success 200 {object} response.SuccessResponse{data=Order}
+// @Success 200 {object} response.SuccessResponse{data=OrderResponse}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /orders/{id} [patch]
 func (h *Handler) UpdateOrder(c *gin.Context) {
 	var input UpdateOrderRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
@@ -224,7 +602,7 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 
 	userID, err := h.getUserIDFromContext(c)
 	if err != nil {
-		if err.Error() == "missing user_id in context" {
+		if errors.Is(err, errMissingUserID) {
 			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
 		} else {
 			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
@@ -233,31 +611,127 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 	}
 
 	order, err := h.service.UpdateOrder(c.Request.Context(), id, input, userID)
+	if input.Status != nil {
+		result := logger.AuditResultSuccess
+		if err != nil {
+			result = logger.AuditResultFailure
+		}
+		h.audit.Record(c, logger.AuditActionOrderStatusChange, fmt.Sprintf("order:%d", id), result,
+			zap.String("to_status", string(*input.Status)),
+		)
+	}
 	if err != nil {
-		if err.Error() == ErrOrderNotFound {
-			h.responseHelper.NotFound(c, ErrMsgOrderNotFound, err.Error())
+		if errors.Is(err, ErrOrderNotFound) {
+			h.responseHelper.Error(c, http.StatusNotFound, ErrMsgOrderNotFound, response.ErrCodeOrderNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrNotAuthorizedToUpdate) {
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgNotAuthorized, response.ErrCodeOrderForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, ErrInvalidStatusValue) {
+			h.responseHelper.Error(c, http.StatusBadRequest, ErrMsgInvalidStatus, response.ErrCodeInvalidOrderStatus, err.Error())
+			return
+		}
+		if errors.Is(err, ErrCannotChangePaidOrderToPending) || errors.Is(err, ErrCannotChangeCancelledOrderStatus) || errors.Is(err, ErrPaidOnlyByPayment) {
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgInvalidTransition, response.ErrCodeInvalidStatusTransition, err.Error())
 			return
 		}
-		if err.Error() == ErrNotAuthorizedToUpdate {
-			h.responseHelper.Error(c, http.StatusUnauthorized, ErrMsgNotAuthorized, response.ErrCodeUnauthorized, err.Error())
+		if errors.Is(err, ErrCancellationWindowExpired) {
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgInvalidTransition, response.ErrCodeCancellationWindowExpired, err.Error())
 			return
 		}
-		if err.Error() == ErrInvalidStatusValue {
-			h.responseHelper.BadRequest(c, ErrMsgInvalidStatus, err.Error())
+		if errors.Is(err, ErrConcurrentUpdate) {
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgConcurrentUpdate, response.ErrCodeConcurrentUpdate, err.Error())
 			return
 		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToUpdate, err.Error())
 		return
 	}
 
-	h.responseHelper.SuccessOK(c, "Order updated successfully", order)
+	h.responseHelper.SuccessOK(c, "Order updated successfully", h.orderResponse(c, order))
+}
+
+// PayOrder godoc
+// @Summary Pay order
+// @Description Charge the total of a pending order owned by the authenticated user and mark it paid. A declined charge leaves the order pending.
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Success 200 {object} response.SuccessResponse{data=OrderResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 402 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Failure 503 {object} response.ErrorResponse
+// @Router /orders/{id}/pay [post]
+func (h *Handler) PayOrder(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidOrderID, err.Error())
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	order, err := h.service.PayOrder(c.Request.Context(), id, userID)
+	result := logger.AuditResultSuccess
+	if err != nil {
+		result = logger.AuditResultFailure
+	}
+	h.audit.Record(c, logger.AuditActionOrderStatusChange, fmt.Sprintf("order:%d", id), result,
+		zap.String("to_status", string(StatusPaid)),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			h.responseHelper.Error(c, http.StatusNotFound, ErrMsgOrderNotFound, response.ErrCodeOrderNotFound, err.Error())
+		case errors.Is(err, ErrNotAuthorizedToUpdate):
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgNotAuthorized, response.ErrCodeOrderForbidden, err.Error())
+		case errors.Is(err, ErrOrderNotPayable):
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgNotPayable, response.ErrCodeInvalidStatusTransition, err.Error())
+		case errors.Is(err, ErrPaymentDeclined):
+			h.responseHelper.Error(c, http.StatusPaymentRequired, ErrMsgPaymentDeclined, response.ErrCodePaymentDeclined, err.Error())
+		case errors.Is(err, ErrPaymentUnavailable):
+			h.responseHelper.Error(c, http.StatusServiceUnavailable, ErrMsgPaymentUnavailable, response.ErrCodeServiceUnavailable, err.Error())
+		case errors.Is(err, ErrConcurrentUpdate):
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgConcurrentUpdate, response.ErrCodeConcurrentUpdate, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToPay, err.Error())
+		}
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Order paid successfully", h.orderResponse(c, order))
 }
 
 // Helpers
+func (h *Handler) orderResponse(c *gin.Context, order *Order) OrderResponse {
+	return h.service.OrderResponses(c.Request.Context(), []Order{*order})[0]
+}
+
+// isAdmin reports whether the auth middleware resolved the caller to an admin
+func isAdmin(c *gin.Context) bool {
+	role, _ := c.Get("role")
+	return role == auth.RoleAdmin
+}
+
 func (h *Handler) getUserIDFromContext(c *gin.Context) (uint, error) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		return 0, errors.New("missing user_id in context")
+		return 0, errMissingUserID
 	}
 	userIDUint, ok := userID.(uint)
 	if !ok {
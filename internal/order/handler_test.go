@@ -0,0 +1,511 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/middleware"
+	"mini-e-commerce/internal/product"
+	"mini-e-commerce/internal/response"
+	"mini-e-commerce/internal/tracing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestHandler_CreateOrder_Tracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	db, mock := setupTestDB(t)
+	require.NoError(t, db.Use(tracing.GormPlugin()))
+
+	products := &stubProductService{products: map[uint]*product.Product{
+		1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: 5},
+	}}
+	handler := NewHandler(NewService(NewRepository(db), products, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger()), setupLogger())
+
+	r := gin.New()
+	r.Use(middleware.Tracing("test-service")...)
+	r.POST("/api/orders", func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Next()
+	}, handler.CreateOrder)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "orders"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_items"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(`{"items":[{"product_id":1,"quantity":2}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	var body struct {
+		Data OrderResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 2, body.Data.ItemCount)
+	require.Len(t, body.Data.OrderItems, 1)
+	assert.Equal(t, "Smartphone", body.Data.OrderItems[0].ProductName)
+
+	spansByName := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spansByName[span.Name()] = append(spansByName[span.Name()], span)
+	}
+
+	require.Len(t, spansByName["POST /api/orders"], 1)
+	root := spansByName["POST /api/orders"][0]
+	assert.False(t, root.Parent().IsValid())
+	assert.Contains(t, root.Attributes(), attribute.Int("http.response.status_code", http.StatusCreated))
+	assert.Equal(t, root.SpanContext().TraceID().String(), strings.Split(w.Header().Get("traceparent"), "-")[1])
+
+	require.Len(t, spansByName["order.CreateOrder"], 1)
+	service := spansByName["order.CreateOrder"][0]
+	assert.Equal(t, root.SpanContext().TraceID(), service.SpanContext().TraceID())
+	assert.Equal(t, root.SpanContext().SpanID(), service.Parent().SpanID())
+
+	// The order is written by the repository inside the service span, its items
+	// are saved as an association while that insert runs
+	inserts := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spansByName["gorm.Create"] {
+		assert.Equal(t, service.SpanContext().TraceID(), span.SpanContext().TraceID())
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.sql.table" {
+				inserts[attr.Value.AsString()] = span
+			}
+		}
+	}
+	require.Len(t, inserts, 2)
+	require.Contains(t, inserts, "orders")
+	require.Contains(t, inserts, "order_items")
+	assert.Equal(t, service.SpanContext().SpanID(), inserts["orders"].Parent().SpanID())
+	assert.Equal(t, inserts["orders"].SpanContext().SpanID(), inserts["order_items"].Parent().SpanID())
+}
+
+// failingService returns err from every call so handler error mapping can be exercised
+type failingService struct {
+	err error
+}
+
+func (s *failingService) CreateOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) ValidateOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) GetAllOrders(ctx context.Context) ([]Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) GetAllOrdersWithQuery(ctx context.Context, query OrderQuery) (*OrderListResponse, error) {
+	return nil, s.err
+}
+
+func (s *failingService) ExportOrders(ctx context.Context, query OrderQuery, fn func(Order) error) error {
+	return s.err
+}
+
+func (s *failingService) GetOrderByID(ctx context.Context, id uint, userID uint, isAdmin bool) (*Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) UpdateOrder(ctx context.Context, id uint, input UpdateOrderRequest, userID uint) (*Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) DeleteOrder(ctx context.Context, id uint, userID uint, isAdmin bool) error {
+	return s.err
+}
+
+func (s *failingService) GetOrderStatusHistory(ctx context.Context, id uint, userID uint) ([]OrderStatusHistory, error) {
+	return nil, s.err
+}
+
+func (s *failingService) RestoreOrder(ctx context.Context, id uint) (*Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) PayOrder(ctx context.Context, id uint, userID uint) (*Order, error) {
+	return nil, s.err
+}
+
+func (s *failingService) GetOrderSummary(ctx context.Context, userID uint) (*OrderSummary, error) {
+	return nil, s.err
+}
+
+func (s *failingService) OrderResponses(ctx context.Context, orders []Order) []OrderResponse {
+	return nil
+}
+
+func TestHandler_ErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"create with unknown product", http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":1}]}`, ErrProductNotFound, http.StatusNotFound, response.ErrCodeProductNotFound},
+		{"create with insufficient stock", http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":1}]}`, ErrInsufficientStock, http.StatusBadRequest, response.ErrCodeInsufficientStock},
+		{"create with expired coupon", http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":1}],"coupon_code":"OLD"}`, ErrCouponExpired, http.StatusBadRequest, response.ErrCodeInvalidCoupon},
+		{"create with too many lines", http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":1}]}`, ErrTooManyItems, http.StatusBadRequest, response.ErrCodeValidationError},
+		{"create with overflowing total", http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":1}]}`, ErrOrderTotalOverflow, http.StatusBadRequest, response.ErrCodeValidationError},
+		{"create with mixed currencies", http.MethodPost, "/orders", `{"items":[{"product_id":1,"quantity":1},{"product_id":2,"quantity":1}]}`, ErrCurrencyMismatch, http.StatusBadRequest, response.ErrCodeCurrencyMismatch},
+		{"validate with insufficient stock", http.MethodPost, "/orders/validate", `{"items":[{"product_id":1,"quantity":5}]}`, ErrInsufficientStock, http.StatusBadRequest, response.ErrCodeInsufficientStock},
+		{"validate with unknown product", http.MethodPost, "/orders/validate", `{"items":[{"product_id":9,"quantity":1}]}`, ErrProductNotFound, http.StatusNotFound, response.ErrCodeProductNotFound},
+		{"list with inverted date range", http.MethodGet, "/orders", "", ErrInvalidDateRange, http.StatusBadRequest, response.ErrCodeInvalidDateRange},
+		{"export with inverted date range", http.MethodGet, "/orders/export", "", ErrInvalidDateRange, http.StatusBadRequest, response.ErrCodeInvalidDateRange},
+		{"export failing before the first row", http.MethodGet, "/orders/export", "", errors.New("connection reset"), http.StatusInternalServerError, response.ErrCodeInternalServer},
+		{"get missing order", http.MethodGet, "/orders/1", "", ErrOrderNotFound, http.StatusNotFound, response.ErrCodeOrderNotFound},
+		{"history of another user's order", http.MethodGet, "/orders/1/history", "", ErrNotAuthorizedToView, http.StatusForbidden, response.ErrCodeOrderForbidden},
+		{"get another user's order", http.MethodGet, "/orders/1", "", ErrNotAuthorizedToView, http.StatusForbidden, response.ErrCodeOrderForbidden},
+		{"delete missing order", http.MethodDelete, "/orders/1", "", ErrOrderNotFound, http.StatusNotFound, response.ErrCodeOrderNotFound},
+		{"delete another user's order", http.MethodDelete, "/orders/1", "", ErrNotAuthorizedToUpdate, http.StatusForbidden, response.ErrCodeOrderForbidden},
+		{"restore missing order", http.MethodPost, "/admin/orders/1/restore", "", ErrOrderNotFound, http.StatusNotFound, response.ErrCodeOrderNotFound},
+		{"restore sold out order", http.MethodPost, "/admin/orders/1/restore", "", ErrInsufficientStock, http.StatusBadRequest, response.ErrCodeInsufficientStock},
+		{"update another user's order", http.MethodPatch, "/orders/1", `{"status":"CANCELLED"}`, ErrNotAuthorizedToUpdate, http.StatusForbidden, response.ErrCodeOrderForbidden},
+		{"update with unknown status", http.MethodPatch, "/orders/1", `{"status":"PENDING"}`, ErrInvalidStatusValue, http.StatusBadRequest, response.ErrCodeInvalidOrderStatus},
+		{"reopen a cancelled order", http.MethodPatch, "/orders/1", `{"status":"PENDING"}`, ErrCannotChangeCancelledOrderStatus, http.StatusConflict, response.ErrCodeInvalidStatusTransition},
+		{"mark an order paid without paying", http.MethodPatch, "/orders/1", `{"status":"PAID"}`, ErrPaidOnlyByPayment, http.StatusConflict, response.ErrCodeInvalidStatusTransition},
+		{"cancel after the window", http.MethodPatch, "/orders/1", `{"status":"CANCELLED"}`, ErrCancellationWindowExpired, http.StatusConflict, response.ErrCodeCancellationWindowExpired},
+		{"pay another user's order", http.MethodPost, "/orders/1/pay", "", ErrNotAuthorizedToUpdate, http.StatusForbidden, response.ErrCodeOrderForbidden},
+		{"pay a paid order", http.MethodPost, "/orders/1/pay", "", ErrOrderNotPayable, http.StatusConflict, response.ErrCodeInvalidStatusTransition},
+		{"update a stale order", http.MethodPatch, "/orders/1", `{"status":"CANCELLED"}`, ErrConcurrentUpdate, http.StatusConflict, response.ErrCodeConcurrentUpdate},
+		{"pay with a declined card", http.MethodPost, "/orders/1/pay", "", ErrPaymentDeclined, http.StatusPaymentRequired, response.ErrCodePaymentDeclined},
+		{"pay without a gateway", http.MethodPost, "/orders/1/pay", "", ErrPaymentUnavailable, http.StatusServiceUnavailable, response.ErrCodeServiceUnavailable},
+		{"unexpected failure", http.MethodGet, "/orders/1", "", errors.New("connection reset"), http.StatusInternalServerError, response.ErrCodeInternalServer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&failingService{err: tt.err}, setupLogger())
+
+			r := gin.New()
+			group := r.Group("", func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+				c.Next()
+			})
+			group.POST("/orders", handler.CreateOrder)
+			group.POST("/orders/validate", handler.ValidateOrder)
+			group.GET("/orders", handler.GetOrders)
+			group.GET("/orders/export", handler.ExportOrders)
+			group.GET("/orders/:id", handler.GetOrderByID)
+			group.GET("/orders/:id/history", handler.GetOrderStatusHistory)
+			group.DELETE("/orders/:id", handler.DeleteOrder)
+			group.PATCH("/orders/:id", handler.UpdateOrder)
+			group.POST("/orders/:id/pay", handler.PayOrder)
+			group.POST("/admin/orders/:id/restore", handler.RestoreOrder)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			var body response.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+		})
+	}
+}
+
+func TestHandler_GetAllOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(t *testing.T, role string) (*gin.Engine, sqlmock.Sqlmock) {
+		db, mock := setupTestDB(t)
+		handler := NewHandler(NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger()), setupLogger())
+
+		r := gin.New()
+		handler.RegisterRoutes(r.Group(""), func(c *gin.Context) {
+			c.Set("user_id", uint(7))
+			c.Set("role", role)
+			c.Next()
+		})
+		return r, mock
+	}
+
+	t.Run("should reject a non-admin user", func(t *testing.T) {
+		r, mock := setupRouter(t, "user")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/orders", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should list orders of every user with their email", func(t *testing.T) {
+		r, mock := setupRouter(t, "admin")
+		mock.MatchExpectationsInOrder(false)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "orders" WHERE "orders"."deleted_at" IS NULL`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE "orders"."deleted_at" IS NULL ORDER BY created_at desc LIMIT $1`)).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status"}).
+				AddRow(1, 7, 1000, StatusPending).
+				AddRow(2, 8, 2000, StatusPaid))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" IN ($1,$2)`)).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","email" FROM "users" WHERE "users"."id" IN ($1,$2)`)).
+			WithArgs(7, 8).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).
+				AddRow(7, "alice@example.com").
+				AddRow(8, "bob@example.com"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/orders", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Data []Order `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Data, 2)
+		emails := make([]string, 0, len(body.Data))
+		for _, order := range body.Data {
+			require.NotNil(t, order.User)
+			assert.Equal(t, order.UserID, order.User.ID)
+			emails = append(emails, order.User.Email)
+		}
+		assert.ElementsMatch(t, []string{"alice@example.com", "bob@example.com"}, emails)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should filter by user_id", func(t *testing.T) {
+		r, mock := setupRouter(t, "admin")
+		mock.MatchExpectationsInOrder(false)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "orders" WHERE user_id = $1 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(8).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE user_id = $1 AND "orders"."deleted_at" IS NULL ORDER BY created_at desc LIMIT $2`)).
+			WithArgs(8, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status"}).
+				AddRow(2, 8, 2000, StatusPaid))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","email" FROM "users" WHERE "users"."id" = $1`)).
+			WithArgs(8).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(8, "bob@example.com"))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/orders?user_id=8", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "bob@example.com")
+		assert.NotContains(t, w.Body.String(), "alice@example.com")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestHandler_ExportOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setupRouter := func(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+		db, mock := setupTestDB(t)
+		handler := NewHandler(NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger()), setupLogger())
+
+		r := gin.New()
+		handler.RegisterRoutes(r.Group(""), func(c *gin.Context) {
+			c.Set("user_id", uint(7))
+			c.Next()
+		})
+		return r, mock
+	}
+
+	t.Run("should stream the user's orders as CSV", func(t *testing.T) {
+		r, mock := setupRouter(t)
+		createdAt := time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE user_id = $1 AND status = $2 AND "orders"."deleted_at" IS NULL ORDER BY "orders"."id" LIMIT $3`)).
+			WithArgs(7, StatusPaid, ExportBatchSize).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "currency", "status", "created_at"}).
+				AddRow(3, 7, 25000, "IDR", StatusPaid, createdAt).
+				AddRow(5, 7, 1000, "IDR", StatusPaid, createdAt.Add(time.Hour)))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" IN ($1,$2)`)).
+			WithArgs(3, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity"}).
+				AddRow(1, 3, 1, 2).
+				AddRow(2, 3, 2, 1).
+				AddRow(3, 5, 1, 1))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/export?status=PAID", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "orders.csv")
+
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, "id,created_at,status,total,currency,item_count", lines[0])
+		assert.Equal(t, "3,2025-03-01T10:30:00Z,PAID,25000,IDR,3", lines[1])
+		assert.Equal(t, "5,2025-03-01T11:30:00Z,PAID,1000,IDR,1", lines[2])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should send only the header when there are no orders", func(t *testing.T) {
+		r, mock := setupRouter(t)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE user_id = $1`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/export", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,created_at,status,total,currency,item_count\n", w.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestHandler_UpdateOrder_StaleVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock := setupTestDB(t)
+	handler := NewHandler(NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger()), setupLogger())
+
+	r := gin.New()
+	handler.RegisterRoutes(r.Group(""), func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Next()
+	})
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE "orders"."id" = $1 AND "orders"."deleted_at" IS NULL`)).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "version", "created_at", "updated_at"}).
+			AddRow(1, 7, 1000, StatusPending, 2, now, now))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	// another request already moved the order to version 3, so the guarded update matches nothing
+	mock.ExpectExec(`UPDATE "orders" SET .* WHERE version = \$\d+ AND`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/orders/1", strings.NewReader(`{"status":"CANCELLED"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	var body response.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, response.ErrCodeConcurrentUpdate, body.Error.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_OrderOwnership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*gin.Engine, *softDeleteRepository, *stubProductService) {
+		repo := &softDeleteRepository{order: Order{
+			ID:         1,
+			UserID:     7,
+			Status:     StatusPending,
+			OrderItems: []OrderItem{{ProductID: 1, Quantity: 2}},
+		}}
+		products := &stubProductService{products: map[uint]*product.Product{
+			1: {ID: 1, Name: "Smartphone", Stock: 5},
+		}}
+		handler := NewHandler(NewService(repo, products, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger()), setupLogger())
+
+		r := gin.New()
+		// the caller is picked per request through headers standing in for the token
+		handler.RegisterRoutes(r.Group(""), func(c *gin.Context) {
+			id, _ := strconv.ParseUint(c.GetHeader("X-User-ID"), 10, 32)
+			c.Set("user_id", uint(id))
+			c.Set("role", c.GetHeader("X-Role"))
+			c.Next()
+		})
+		return r, repo, products
+	}
+
+	send := func(r *gin.Engine, method string, userID, role string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/orders/1", nil)
+		req.Header.Set("X-User-ID", userID)
+		req.Header.Set("X-Role", role)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should not let another user delete the order", func(t *testing.T) {
+		r, repo, products := setup()
+
+		w := send(r, http.MethodDelete, "8", auth.RoleUser)
+
+		require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), response.ErrCodeOrderForbidden)
+		assert.False(t, repo.deleted)
+		assert.Equal(t, 5, products.products[1].Stock)
+	})
+
+	t.Run("should not let another user read the order", func(t *testing.T) {
+		r, _, _ := setup()
+
+		w := send(r, http.MethodGet, "8", auth.RoleUser)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	t.Run("should let the owner read and delete the order", func(t *testing.T) {
+		r, repo, products := setup()
+
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "7", auth.RoleUser).Code)
+		assert.Equal(t, http.StatusOK, send(r, http.MethodDelete, "7", auth.RoleUser).Code)
+		assert.True(t, repo.deleted)
+		assert.Equal(t, 7, products.products[1].Stock)
+	})
+
+	t.Run("should let an admin read and delete any order", func(t *testing.T) {
+		r, repo, _ := setup()
+
+		assert.Equal(t, http.StatusOK, send(r, http.MethodGet, "9", auth.RoleAdmin).Code)
+		assert.Equal(t, http.StatusOK, send(r, http.MethodDelete, "9", auth.RoleAdmin).Code)
+		assert.True(t, repo.deleted)
+	})
+}
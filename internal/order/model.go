@@ -1,6 +1,11 @@
 package order
 
-import "time"
+import (
+	"mini-e-commerce/internal/product"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type OrderStatus string
 
@@ -11,13 +16,33 @@ const (
 )
 
 type Order struct {
-	ID         uint        `gorm:"primaryKey" json:"id"`
-	UserID     uint        `gorm:"not null" json:"user_id"`
-	TotalPrice int         `gorm:"not null" json:"total_price"`
-	Status     OrderStatus `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
-	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
-	CreatedAt  time.Time   `json:"created_at"`
-	UpdatedAt  time.Time   `json:"updated_at"`
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null" json:"user_id"`
+	TotalPrice int            `gorm:"not null" json:"total_price"`
+	Currency   string         `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	CouponCode *string        `gorm:"type:varchar(50)" json:"coupon_code,omitempty"`
+	Discount   int            `gorm:"not null;default:0" json:"discount"`
+	Status     OrderStatus    `gorm:"type:varchar(20);default:'PENDING'" json:"status"`
+	OrderItems []OrderItem    `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+	User       *OrderUser     `gorm:"foreignKey:UserID;-:migration" json:"user,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// PaymentTransactionID is the gateway's reference for the charge that paid the order
+	PaymentTransactionID *string `gorm:"type:varchar(100)" json:"payment_transaction_id,omitempty"`
+	// Version is bumped by every update, an update whose version moved underneath it is rejected
+	Version int `gorm:"not null;default:1" json:"version"`
+}
+
+// OrderUser is the part of the owning user shown on admin order listings
+type OrderUser struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+}
+
+func (OrderUser) TableName() string {
+	return "users"
 }
 
 type OrderItem struct {
@@ -29,4 +54,41 @@ type OrderItem struct {
 	Subtotal   int       `gorm:"not null" json:"subtotal"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	Product *product.Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+// OrderStatusHistory records a single status transition of an order
+type OrderStatusHistory struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	OrderID    uint        `gorm:"not null;index" json:"order_id"`
+	FromStatus OrderStatus `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus   OrderStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	ChangedBy  uint        `gorm:"not null" json:"changed_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// OrderStats holds the grouped aggregates of one user's orders, the service shapes them
+// into an OrderSummary
+type OrderStats struct {
+	StatusCounts []StatusCount
+	Spent        []CurrencyTotal
+	TopProducts  []TopProduct
+}
+
+type StatusCount struct {
+	Status OrderStatus
+	Count  int64
+}
+
+type CurrencyTotal struct {
+	Currency string
+	Total    int64
+}
+
+// TopProduct is a product ranked by the quantity the user has paid for
+type TopProduct struct {
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int64  `json:"quantity"`
 }
@@ -0,0 +1,84 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// ErrPaymentDeclined is returned by gateways when the charge was refused, as opposed to
+// the gateway being unreachable
+var ErrPaymentDeclined = errors.New("payment declined")
+
+// ErrPaymentUnavailable is returned by PayOrder when no gateway is configured
+var ErrPaymentUnavailable = errors.New("no payment gateway is configured")
+
+// GatewayFake selects FakeGateway in NewGateway
+const GatewayFake = "fake"
+
+// PaymentGateway charges customers for orders
+type PaymentGateway interface {
+	// Charge collects amount in the minor unit of currency and returns the gateway's transaction id.
+	// A successful charge is made at most once per idempotencyKey, repeating the key returns
+	// the transaction id of the first charge without collecting again.
+	Charge(ctx context.Context, idempotencyKey string, amount int, currency string) (string, error)
+}
+
+// paymentIdempotencyKey identifies the single charge an order may have
+func paymentIdempotencyKey(orderID uint) string {
+	return fmt.Sprintf("order-%d", orderID)
+}
+
+// NewGateway returns the gateway configured under name, or nil when none is and orders
+// cannot be paid
+func NewGateway(name string) PaymentGateway {
+	switch name {
+	case GatewayFake:
+		return NewFakeGateway()
+	default:
+		return nil
+	}
+}
+
+// FakeGateway approves every charge without contacting a payment provider, for
+// development and tests, which can make it decline charges.
+type FakeGateway struct {
+	// Decline makes every charge fail with ErrPaymentDeclined
+	Decline bool
+
+	charges atomic.Uint64
+
+	mu           sync.Mutex
+	transactions map[string]string
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{}
+}
+
+func (g *FakeGateway) Charge(ctx context.Context, idempotencyKey string, amount int, currency string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if g.Decline {
+		return "", ErrPaymentDeclined
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if transactionID, ok := g.transactions[idempotencyKey]; ok {
+		return transactionID, nil
+	}
+	if g.transactions == nil {
+		g.transactions = make(map[string]string)
+	}
+	g.charges.Add(1)
+	// random so ids stored by an earlier run never come back
+	transactionID := "fake_txn_" + uuid.NewString()
+	g.transactions[idempotencyKey] = transactionID
+	return transactionID, nil
+}
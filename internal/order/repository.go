@@ -2,22 +2,42 @@ package order
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// OrderFilter narrows paginated order listings, nil fields are ignored
+type OrderFilter struct {
+	Status      *OrderStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	IncludeUser bool
+}
+
 type Repository interface {
 	Create(ctx context.Context, order *Order) error
 	CreateWithTransaction(ctx context.Context, order *Order, txFunc func(*gorm.DB) error) error
-	FindAll(ctx context.Context) ([]Order, error)
-	FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order string) ([]Order, int64, error)
+	FindAll(ctx context.Context, limit int) ([]Order, error)
+	FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order string, filter OrderFilter) ([]Order, int64, error)
+	FindAllByUserWithPagination(ctx context.Context, userID uint, offset, limit int, sortBy, order string, filter OrderFilter) ([]Order, int64, error)
+	FindByUserInBatches(ctx context.Context, userID uint, filter OrderFilter, batchSize int, fn func([]Order) error) error
 	FindByID(ctx context.Context, id uint) (Order, error)
+	FindDeletedByID(ctx context.Context, id uint) (Order, error)
 	Update(ctx context.Context, order *Order, updateFn func(*Order)) error
 	UpdateWithTransaction(ctx context.Context, order *Order, updateFn func(*Order), txFunc func(*gorm.DB) error) error
 	Delete(ctx context.Context, id uint) error
 	DeleteWithTransaction(ctx context.Context, id uint, txFunc func(*gorm.DB) error) error
+	RestoreWithTransaction(ctx context.Context, id uint, txFunc func(*gorm.DB) error) error
+	CreateStatusHistoryWithTx(tx *gorm.DB, history *OrderStatusHistory) error
+	FindStatusHistory(ctx context.Context, orderID uint) ([]OrderStatusHistory, error)
+	UserOrderStats(ctx context.Context, userID uint) (OrderStats, error)
 }
 
+// TopProductsLimit is how many products UserOrderStats ranks
+const TopProductsLimit = 5
+
 type repository struct {
 	db *gorm.DB
 }
@@ -47,15 +67,25 @@ func (r *repository) CreateWithTransaction(ctx context.Context, order *Order, tx
 	})
 }
 
-func (r *repository) FindAll(ctx context.Context) ([]Order, error) {
+func (r *repository) FindAll(ctx context.Context, limit int) ([]Order, error) {
 	var orders []Order
-	err := r.db.WithContext(ctx).Preload("OrderItems").Find(&orders).Error
+	err := r.db.WithContext(ctx).Scopes(preloadItems).Order("id").Limit(limit).Find(&orders).Error
 	return orders, err
 }
 
 func (r *repository) FindByID(ctx context.Context, id uint) (Order, error) {
 	var order Order
-	err := r.db.WithContext(ctx).Preload("OrderItems").First(&order, id).Error
+	err := r.db.WithContext(ctx).Scopes(preloadItems).First(&order, id).Error
+	return order, err
+}
+
+// FindDeletedByID loads a soft-deleted order with its items, returning
+// gorm.ErrRecordNotFound when there is no deleted order with the given id
+func (r *repository) FindDeletedByID(ctx context.Context, id uint) (Order, error) {
+	var order Order
+	err := r.db.WithContext(ctx).Unscoped().Scopes(preloadItems).
+		Where("deleted_at IS NOT NULL").
+		First(&order, id).Error
 	return order, err
 }
 
@@ -64,7 +94,7 @@ func (r *repository) Update(ctx context.Context, order *Order, updateFn func(*Or
 		if updateFn != nil {
 			updateFn(order)
 		}
-		return tx.Save(order).Error
+		return saveVersioned(tx, order)
 	})
 }
 
@@ -84,10 +114,27 @@ func (r *repository) UpdateWithTransaction(ctx context.Context, order *Order, up
 		if updateFn != nil {
 			updateFn(order)
 		}
-		return tx.Save(order).Error
+		return saveVersioned(tx, order)
 	})
 }
 
+// saveVersioned writes order only when its version is still the one it was read with and bumps
+// the version, an update that lost the race to a concurrent one fails with ErrConcurrentUpdate
+func saveVersioned(tx *gorm.DB, order *Order) error {
+	expected := order.Version
+	order.Version++
+
+	result := tx.Omit(clause.Associations).Select("*").Where("version = ?", expected).Save(order)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrConcurrentUpdate
+	}
+	if result.Error != nil {
+		order.Version = expected
+		return result.Error
+	}
+	return nil
+}
+
 func (r *repository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Order{}, id).Error
 }
@@ -109,11 +156,33 @@ func (r *repository) DeleteWithTransaction(ctx context.Context, id uint, txFunc
 	})
 }
 
-func (r *repository) FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order string) ([]Order, int64, error) {
+// RestoreWithTransaction brings back a soft-deleted order and runs txFunc in the same
+// transaction. The order is restored first, so when two restores race only one of them
+// gets to run txFunc and the other returns gorm.ErrRecordNotFound.
+func (r *repository) RestoreWithTransaction(ctx context.Context, id uint, txFunc func(*gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&Order{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if txFunc != nil {
+			return txFunc(tx)
+		}
+		return nil
+	})
+}
+
+func (r *repository) FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order string, filter OrderFilter) ([]Order, int64, error) {
 	var orders []Order
 	var total int64
 
-	db := r.db.WithContext(ctx).Model(&Order{})
+	db := r.db.WithContext(ctx).Model(&Order{}).Scopes(filterOrders(filter))
 
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -125,6 +194,123 @@ func (r *repository) FindAllWithPagination(ctx context.Context, offset, limit in
 		db = db.Order("created_at desc")
 	}
 
-	err := db.Preload("OrderItems").Offset(offset).Limit(limit).Find(&orders).Error
+	err := db.Scopes(preloadItems, preloadUser(filter)).Offset(offset).Limit(limit).Find(&orders).Error
 	return orders, total, err
 }
+
+func (r *repository) FindAllByUserWithPagination(ctx context.Context, userID uint, offset, limit int, sortBy, order string, filter OrderFilter) ([]Order, int64, error) {
+	var orders []Order
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&Order{}).Where("user_id = ?", userID).Scopes(filterOrders(filter))
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if sortBy != "" && order != "" {
+		db = db.Order(sortBy + " " + order)
+	} else {
+		db = db.Order("created_at desc")
+	}
+
+	err := db.Scopes(preloadItems, preloadUser(filter)).Offset(offset).Limit(limit).Find(&orders).Error
+	return orders, total, err
+}
+
+// FindByUserInBatches walks the user's orders by id, batchSize at a time with their items,
+// so a long history is never held in memory at once. An error from fn stops the walk.
+func (r *repository) FindByUserInBatches(ctx context.Context, userID uint, filter OrderFilter, batchSize int, fn func([]Order) error) error {
+	var batch []Order
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Scopes(filterOrders(filter)).
+		Preload("OrderItems").
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// preloadItems loads order items together with their products, including soft-deleted
+// products so past orders can still show what was bought
+func preloadItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("OrderItems.Product", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+}
+
+// preloadUser loads the id and email of the order owner when the filter asks for it
+func preloadUser(filter OrderFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !filter.IncludeUser {
+			return db
+		}
+		return db.Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "email")
+		})
+	}
+}
+
+func (r *repository) CreateStatusHistoryWithTx(tx *gorm.DB, history *OrderStatusHistory) error {
+	return tx.Create(history).Error
+}
+
+func (r *repository) FindStatusHistory(ctx context.Context, orderID uint) ([]OrderStatusHistory, error) {
+	var history []OrderStatusHistory
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at asc, id asc").Find(&history).Error
+	return history, err
+}
+
+// UserOrderStats aggregates the user's orders in the database. Spending and top products
+// only count paid orders, cancelled and pending ones were never charged.
+func (r *repository) UserOrderStats(ctx context.Context, userID uint) (OrderStats, error) {
+	var stats OrderStats
+	db := r.db.WithContext(ctx)
+
+	err := db.Model(&Order{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("status").
+		Scan(&stats.StatusCounts).Error
+	if err != nil {
+		return stats, err
+	}
+
+	err = db.Model(&Order{}).
+		Select("currency, SUM(total_price) AS total").
+		Where("user_id = ? AND status = ?", userID, StatusPaid).
+		Group("currency").
+		Order("currency").
+		Scan(&stats.Spent).Error
+	if err != nil {
+		return stats, err
+	}
+
+	err = db.Model(&OrderItem{}).
+		Select("order_items.product_id, COALESCE(products.name, '') AS product_name, SUM(order_items.quantity) AS quantity").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Joins("LEFT JOIN products ON products.id = order_items.product_id").
+		Where("orders.user_id = ? AND orders.status = ?", userID, StatusPaid).
+		Group("order_items.product_id, products.name").
+		Order("quantity DESC, order_items.product_id").
+		Limit(TopProductsLimit).
+		Scan(&stats.TopProducts).Error
+	return stats, err
+}
+
+func filterOrders(filter OrderFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Status != nil {
+			db = db.Where("status = ?", *filter.Status)
+		}
+
+		switch {
+		case filter.CreatedFrom != nil && filter.CreatedTo != nil:
+			db = db.Where("created_at BETWEEN ? AND ?", *filter.CreatedFrom, *filter.CreatedTo)
+		case filter.CreatedFrom != nil:
+			db = db.Where("created_at >= ?", *filter.CreatedFrom)
+		case filter.CreatedTo != nil:
+			db = db.Where("created_at <= ?", *filter.CreatedTo)
+		}
+
+		return db
+	}
+}
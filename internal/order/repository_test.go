@@ -0,0 +1,309 @@
+package order
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_FindAllByUserWithPagination(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should only return orders owned by the user", func(t *testing.T) {
+		userID := uint(7)
+		now := time.Now()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "orders" WHERE user_id = $1 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE user_id = $1 AND "orders"."deleted_at" IS NULL ORDER BY created_at desc LIMIT $2`)).
+			WithArgs(userID, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
+				AddRow(1, userID, 1000, StatusPending, now, now))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "price", "subtotal"}).
+				AddRow(1, 1, 3, 2, 500, 1000))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."id" = $1`)).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "deleted_at"}).
+				AddRow(3, "Discontinued", 500, 0, now))
+
+		orders, total, err := repo.FindAllByUserWithPagination(ctx, userID, 0, 10, "", "", OrderFilter{})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, orders, 1)
+		assert.Equal(t, userID, orders[0].UserID)
+		require.Len(t, orders[0].OrderItems, 1)
+		require.NotNil(t, orders[0].OrderItems[0].Product, "soft deleted product should still be resolved")
+		assert.Equal(t, "Discontinued", orders[0].OrderItems[0].Product.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return error when count fails", func(t *testing.T) {
+		userID := uint(7)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "orders" WHERE user_id = $1 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(userID).
+			WillReturnError(errors.New("database error"))
+
+		orders, total, err := repo.FindAllByUserWithPagination(ctx, userID, 0, 10, "", "", OrderFilter{})
+
+		assert.Error(t, err)
+		assert.Nil(t, orders)
+		assert.Equal(t, int64(0), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_FindAllWithPagination_Filters(t *testing.T) {
+	ctx := context.Background()
+	status := StatusPaid
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    OrderFilter
+		where     string
+		whereArgs []driver.Value
+	}{
+		{
+			name:      "should filter by status",
+			filter:    OrderFilter{Status: &status},
+			where:     `WHERE status = $1`,
+			whereArgs: []driver.Value{status},
+		},
+		{
+			name:      "should filter by date range",
+			filter:    OrderFilter{CreatedFrom: &from, CreatedTo: &to},
+			where:     `WHERE (created_at BETWEEN $1 AND $2)`,
+			whereArgs: []driver.Value{from, to},
+		},
+		{
+			name:      "should filter by start date only",
+			filter:    OrderFilter{CreatedFrom: &from},
+			where:     `WHERE created_at >= $1`,
+			whereArgs: []driver.Value{from},
+		},
+		{
+			name:      "should filter by end date only",
+			filter:    OrderFilter{CreatedTo: &to},
+			where:     `WHERE created_at <= $1`,
+			whereArgs: []driver.Value{to},
+		},
+		{
+			name:      "should combine status and date range",
+			filter:    OrderFilter{Status: &status, CreatedFrom: &from, CreatedTo: &to},
+			where:     `WHERE status = $1 AND (created_at BETWEEN $2 AND $3)`,
+			whereArgs: []driver.Value{status, from, to},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupTestDB(t)
+			repo := NewRepository(db)
+			now := time.Now()
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "orders" ` + tt.where + ` AND "orders"."deleted_at" IS NULL`)).
+				WithArgs(tt.whereArgs...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" ` + tt.where + ` AND "orders"."deleted_at" IS NULL ORDER BY created_at desc LIMIT`)).
+				WithArgs(append(tt.whereArgs, 10)...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
+					AddRow(1, 7, 1000, StatusPaid, now, now))
+
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "price", "subtotal"}))
+
+			orders, total, err := repo.FindAllWithPagination(ctx, 0, 10, "", "", tt.filter)
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), total)
+			assert.Len(t, orders, 1)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should mark the order deleted instead of removing it", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET "deleted_at"=$1 WHERE "orders"."id" = $2 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.DeleteWithTransaction(ctx, 1, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should restore a deleted order before running txFunc", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+			WithArgs(nil, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		called := false
+		err := repo.RestoreWithTransaction(ctx, 1, func(tx *gorm.DB) error {
+			called = true
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not run txFunc when the order is not deleted", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+			WithArgs(nil, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.RestoreWithTransaction(ctx, 1, func(tx *gorm.DB) error {
+			t.Fatal("txFunc should not run")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_FindAll(t *testing.T) {
+	t.Run("should cap the number of orders", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE "orders"."deleted_at" IS NULL ORDER BY id LIMIT $1`)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status"}).
+				AddRow(1, 7, 1000, StatusPaid).
+				AddRow(2, 8, 2000, StatusPending))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" IN ($1,$2)`)).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id"}))
+
+		orders, err := repo.FindAll(context.Background(), 2)
+
+		require.NoError(t, err)
+		assert.Len(t, orders, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_Update_OptimisticLock(t *testing.T) {
+	updateSQL := `UPDATE "orders" SET .*"version"=\$\d+ WHERE version = \$\d+ AND`
+
+	t.Run("should bump the version when it has not moved", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		order := &Order{ID: 1, Status: StatusPending, Version: 3}
+		err := repo.Update(context.Background(), order, func(o *Order) { o.Status = StatusPaid })
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, order.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject an update whose version moved", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		order := &Order{ID: 1, Status: StatusPending, Version: 3}
+		err := repo.Update(context.Background(), order, func(o *Order) { o.Status = StatusPaid })
+
+		assert.ErrorIs(t, err, ErrConcurrentUpdate)
+		assert.Equal(t, 3, order.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_UserOrderStats(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	t.Run("should group counts by status and sum paid orders", func(t *testing.T) {
+		userID := uint(7)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT status, COUNT(*) AS count FROM "orders" WHERE user_id = $1 AND "orders"."deleted_at" IS NULL GROUP BY "status"`)).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+				AddRow(StatusPaid, 3).
+				AddRow(StatusCancelled, 1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT currency, SUM(total_price) AS total FROM "orders" WHERE (user_id = $1 AND status = $2) AND "orders"."deleted_at" IS NULL GROUP BY "currency" ORDER BY currency`)).
+			WithArgs(userID, StatusPaid).
+			WillReturnRows(sqlmock.NewRows([]string{"currency", "total"}).
+				AddRow("IDR", 450000))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT order_items.product_id, COALESCE(products.name, '') AS product_name, SUM(order_items.quantity) AS quantity FROM "order_items" JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL LEFT JOIN products ON products.id = order_items.product_id WHERE orders.user_id = $1 AND orders.status = $2 GROUP BY order_items.product_id, products.name ORDER BY quantity DESC, order_items.product_id LIMIT $3`)).
+			WithArgs(userID, StatusPaid, TopProductsLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"product_id", "product_name", "quantity"}).
+				AddRow(3, "Keyboard", 4).
+				AddRow(1, "Smartphone", 1))
+
+		stats, err := repo.UserOrderStats(context.Background(), userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, []StatusCount{{Status: StatusPaid, Count: 3}, {Status: StatusCancelled, Count: 1}}, stats.StatusCounts)
+		assert.Equal(t, []CurrencyTotal{{Currency: "IDR", Total: 450000}}, stats.Spent)
+		assert.Equal(t, []TopProduct{{ProductID: 3, ProductName: "Keyboard", Quantity: 4}, {ProductID: 1, ProductName: "Smartphone", Quantity: 1}}, stats.TopProducts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
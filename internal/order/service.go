@@ -3,59 +3,212 @@ package order
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
 
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/coupon"
 	"mini-e-commerce/internal/dto"
 	"mini-e-commerce/internal/logger"
 	"mini-e-commerce/internal/product"
+	"mini-e-commerce/internal/tracing"
 
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-const (
-	ErrOrderNotFound                    = "order not found"
-	ErrProductNotFound                  = "product not found"
-	ErrInsufficientStock                = "insufficient stock"
-	ErrNotAuthorizedToUpdate            = "not authorized to update this order"
-	ErrInvalidStatusValue               = "invalid status value"
-	ErrCannotChangePaidOrderToPending   = "cannot change paid order back to pending"
-	ErrCannotChangeCancelledOrderStatus = "cannot change cancelled order status"
+var (
+	ErrOrderNotFound                    = errors.New("order not found")
+	ErrProductNotFound                  = errors.New(product.ErrProductNotFound)
+	ErrInsufficientStock                = errors.New(product.ErrInsufficientStock)
+	ErrCouponNotFound                   = errors.New(coupon.ErrCouponNotFound)
+	ErrCouponExpired                    = errors.New(coupon.ErrCouponExpired)
+	ErrCouponExhausted                  = errors.New(coupon.ErrCouponExhausted)
+	ErrNotAuthorizedToUpdate            = errors.New("not authorized to update this order")
+	ErrNotAuthorizedToView              = errors.New("not authorized to view this order")
+	ErrInvalidStatusValue               = errors.New("invalid status value")
+	ErrCannotChangePaidOrderToPending   = errors.New("cannot change paid order back to pending")
+	ErrCannotChangeCancelledOrderStatus = errors.New("cannot change cancelled order status")
+	ErrInvalidDateRange                 = errors.New("created_from must not be after created_to")
+	ErrTooManyItems                     = errors.New("too many items in order")
+	ErrQuantityTooLarge                 = errors.New("item quantity too large")
+	ErrOrderTotalOverflow               = errors.New("order total is too large")
+	ErrCancellationWindowExpired        = errors.New("order can no longer be cancelled")
+	ErrCurrencyMismatch                 = errors.New("all products of an order must share one currency")
+	ErrCouponCurrencyMismatch           = errors.New("coupon amount is in another currency than the order")
+	ErrOrderNotPayable                  = errors.New("only pending orders can be paid")
+	ErrConcurrentUpdate                 = errors.New("order was modified by another request")
+	ErrPaidOnlyByPayment                = errors.New("orders are only marked paid by paying them")
+)
 
+const (
 	DefaultPage      = 1
 	DefaultPageSize  = 10
 	MaxPageSize      = 100
+	ExportBatchSize  = 500
 	MinQuantity      = 1
 	DefaultSortOrder = "desc"
 	DefaultSortField = "created_at"
+
+	DefaultMaxItemsPerOrder   = 50
+	DefaultMaxQuantityPerLine = 1000
+	DefaultCancellationWindow = 30 * time.Minute
+
+	// MaxUnpaginatedRows caps GetAllOrders, listings should go through GetAllOrdersWithQuery
+	MaxUnpaginatedRows = 1000
+
+	// CacheKeyOrderSummary is not invalidated on order changes, the short TTL bounds staleness
+	CacheKeyOrderSummary = "order:summary:user:%d"
+	CacheTTLOrderSummary = time.Minute
 )
 
+// Limits caps the size of a single order. Zero fields fall back to the defaults.
+type Limits struct {
+	// MaxItemsPerOrder is the most lines a create request may contain
+	MaxItemsPerOrder int
+	// MaxQuantityPerLine is the most units of one product, after duplicate lines are merged
+	MaxQuantityPerLine int
+	// CancellationWindow is how long after creation a non-pending order may still be cancelled
+	CancellationWindow time.Duration
+}
+
 type Service interface {
 	CreateOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*Order, error)
+	ValidateOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*Order, error)
 	GetAllOrders(ctx context.Context) ([]Order, error)
 	GetAllOrdersWithQuery(ctx context.Context, query OrderQuery) (*OrderListResponse, error)
-	GetOrderByID(ctx context.Context, id uint) (*Order, error)
+	ExportOrders(ctx context.Context, query OrderQuery, fn func(Order) error) error
+	// GetOrderByID returns the order to its owner, admins may read any order
+	GetOrderByID(ctx context.Context, id uint, userID uint, isAdmin bool) (*Order, error)
 	UpdateOrder(ctx context.Context, id uint, input UpdateOrderRequest, userID uint) (*Order, error)
-	DeleteOrder(ctx context.Context, id uint) error
+	// DeleteOrder soft-deletes the order for its owner, admins may delete any order
+	DeleteOrder(ctx context.Context, id uint, userID uint, isAdmin bool) error
+	RestoreOrder(ctx context.Context, id uint) (*Order, error)
+	PayOrder(ctx context.Context, id uint, userID uint) (*Order, error)
+	GetOrderStatusHistory(ctx context.Context, id uint, userID uint) ([]OrderStatusHistory, error)
+	GetOrderSummary(ctx context.Context, userID uint) (*OrderSummary, error)
+	OrderResponses(ctx context.Context, orders []Order) []OrderResponse
 }
 
 type service struct {
 	repo           Repository
 	productService product.Service
+	couponService  coupon.Service
+	gateway        PaymentGateway
+	cache          *cache.RedisCache
+	limits         Limits
 	validator      *validator.Validate
 	logger         logger.Logger
 }
 
-func NewService(repo Repository, productService product.Service, log logger.Logger) Service {
+func NewService(repo Repository, productService product.Service, couponService coupon.Service, gateway PaymentGateway, cache *cache.RedisCache, limits Limits, log logger.Logger) Service {
+	if limits.MaxItemsPerOrder <= 0 {
+		limits.MaxItemsPerOrder = DefaultMaxItemsPerOrder
+	}
+	if limits.MaxQuantityPerLine <= 0 {
+		limits.MaxQuantityPerLine = DefaultMaxQuantityPerLine
+	}
+	if limits.CancellationWindow <= 0 {
+		limits.CancellationWindow = DefaultCancellationWindow
+	}
+
 	return &service{
 		repo:           repo,
 		productService: productService,
+		couponService:  couponService,
+		gateway:        gateway,
+		cache:          cache,
+		limits:         limits,
 		validator:      validator.New(),
 		logger:         log,
 	}
 }
 
 func (s *service) CreateOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*Order, error) {
+	ctx, span := tracing.Start(ctx, "order.CreateOrder")
+	defer span.End()
+
+	draft, err := s.prepareOrder(ctx, input, userID)
+	if err != nil {
+		return nil, err
+	}
+	order := draft.order
+
+	// Lock rows in a fixed order so concurrent orders for overlapping products cannot deadlock
+	stockItems := make([]OrderItem, len(order.OrderItems))
+	copy(stockItems, order.OrderItems)
+	sort.Slice(stockItems, func(i, j int) bool { return stockItems[i].ProductID < stockItems[j].ProductID })
+
+	err = s.repo.CreateWithTransaction(ctx, &order, func(tx *gorm.DB) error {
+		// Stock sufficiency is checked under a row lock inside UpdateStockWithTx
+		for _, item := range stockItems {
+			if err := s.productService.UpdateStockWithTx(tx, item.ProductID, -item.Quantity); err != nil {
+				s.logger.Error("Failed to update stock in transaction",
+					zap.Uint("product_id", item.ProductID),
+					zap.Int("quantity", -item.Quantity),
+					zap.Error(err),
+				)
+				return err
+			}
+		}
+
+		if draft.coupon != nil {
+			if err := s.couponService.RedeemCouponWithTx(tx, draft.coupon.ID); err != nil {
+				s.logger.Error("Failed to redeem coupon in transaction",
+					zap.String("coupon_code", draft.coupon.Code),
+					zap.Error(err),
+				)
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		s.logger.Error("Order creation transaction failed",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, upstreamError(err)
+	}
+
+	return &order, nil
+}
+
+// ValidateOrder runs the checks of CreateOrder and returns the order it would create,
+// without saving it, reserving stock or redeeming the coupon. Stock is compared against
+// the current levels without a lock, so a later CreateOrder can still run out.
+func (s *service) ValidateOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*Order, error) {
+	ctx, span := tracing.Start(ctx, "order.ValidateOrder")
+	defer span.End()
+
+	draft, err := s.prepareOrder(ctx, input, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range draft.order.OrderItems {
+		if stock := draft.products[item.ProductID].Stock; stock < item.Quantity {
+			return nil, fmt.Errorf("%w: product %d has %d left, %d requested", ErrInsufficientStock, item.ProductID, stock, item.Quantity)
+		}
+	}
+
+	return &draft.order, nil
+}
+
+// orderDraft is a priced order that has not been saved yet
+type orderDraft struct {
+	order    Order
+	coupon   *coupon.Coupon
+	products map[uint]product.Product
+}
+
+// prepareOrder validates input and prices it, merging duplicate lines and applying the
+// coupon. It only reads, stock is left for the caller to check or reserve.
+func (s *service) prepareOrder(ctx context.Context, input CreateOrderRequest, userID uint) (*orderDraft, error) {
 	if err := s.validator.Struct(input); err != nil {
 		return nil, err
 	}
@@ -64,17 +217,70 @@ func (s *service) CreateOrder(ctx context.Context, input CreateOrderRequest, use
 		return nil, errors.New("user ID is required")
 	}
 
+	if len(input.Items) > s.limits.MaxItemsPerOrder {
+		return nil, fmt.Errorf("%w: got %d, at most %d allowed", ErrTooManyItems, len(input.Items), s.limits.MaxItemsPerOrder)
+	}
+
+	// Every product is fetched in one batch rather than once per line
+	productIDs := make([]uint, 0, len(input.Items))
+	seen := make(map[uint]bool, len(input.Items))
+	for _, item := range input.Items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	products, err := s.productService.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, upstreamError(err)
+	}
+
 	var orderItems []OrderItem
 	var totalPrice int
-	stockUpdates := make(map[uint]int)
+	// Prices are summed as plain amounts, so every line has to be in the same currency
+	var currency string
+	// Lines for the same product are merged so the order stores one item per product
+	itemIndex := make(map[uint]int)
 
 	for _, item := range input.Items {
-		product, err := s.productService.GetProductByID(ctx, item.ProductID)
-		if err != nil {
+		if i, ok := itemIndex[item.ProductID]; ok {
+			quantity := orderItems[i].Quantity + item.Quantity
+			if err := s.checkQuantity(item.ProductID, quantity); err != nil {
+				return nil, err
+			}
+			subtotal, err := lineTotal(item.ProductID, item.Quantity, orderItems[i].Price)
+			if err != nil {
+				return nil, err
+			}
+			if totalPrice, err = addTotal(totalPrice, subtotal); err != nil {
+				return nil, err
+			}
+			orderItems[i].Quantity = quantity
+			orderItems[i].Subtotal += subtotal
+			continue
+		}
+
+		if err := s.checkQuantity(item.ProductID, item.Quantity); err != nil {
 			return nil, err
 		}
 
-		subtotal := item.Quantity * product.Price
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: id %d", ErrProductNotFound, item.ProductID)
+		}
+		if currency == "" {
+			currency = product.Currency
+		} else if product.Currency != currency {
+			return nil, fmt.Errorf("%w: product %d is priced in %s, not %s", ErrCurrencyMismatch, item.ProductID, product.Currency, currency)
+		}
+
+		subtotal, err := lineTotal(item.ProductID, item.Quantity, product.Price)
+		if err != nil {
+			return nil, err
+		}
+		if totalPrice, err = addTotal(totalPrice, subtotal); err != nil {
+			return nil, err
+		}
 		orderItem := OrderItem{
 			ProductID: item.ProductID,
 			Quantity:  item.Quantity,
@@ -82,69 +288,79 @@ func (s *service) CreateOrder(ctx context.Context, input CreateOrderRequest, use
 			Subtotal:  subtotal,
 		}
 
+		itemIndex[item.ProductID] = len(orderItems)
 		orderItems = append(orderItems, orderItem)
-		totalPrice += subtotal
-		stockUpdates[item.ProductID] += item.Quantity
 	}
 
-	for productID, totalQuantity := range stockUpdates {
-		product, err := s.productService.GetProductByID(ctx, productID)
+	draft := &orderDraft{
+		order: Order{
+			UserID:     userID,
+			TotalPrice: totalPrice,
+			Currency:   currency,
+			Status:     StatusPending,
+			OrderItems: orderItems,
+		},
+		products: products,
+	}
+
+	if input.CouponCode != "" {
+		c, err := s.couponService.ValidateCoupon(ctx, input.CouponCode)
 		if err != nil {
-			return nil, err
+			return nil, upstreamError(err)
 		}
-		if totalQuantity > product.Stock {
-			return nil, errors.New(ErrInsufficientStock)
+		if !c.AppliesTo(currency) {
+			return nil, fmt.Errorf("%w: coupon is in %s, order in %s", ErrCouponCurrencyMismatch, *c.Currency, currency)
 		}
+		draft.coupon = c
+		draft.order.CouponCode = &c.Code
+		draft.order.Discount = c.Discount(totalPrice)
+		draft.order.TotalPrice = totalPrice - draft.order.Discount
 	}
 
-	order := Order{
-		UserID:     userID,
-		TotalPrice: totalPrice,
-		Status:     StatusPending,
-		OrderItems: orderItems,
-	}
+	return draft, nil
+}
 
-	err := s.repo.CreateWithTransaction(ctx, &order, func(tx *gorm.DB) error {
-		for productID, quantity := range stockUpdates {
-			if err := s.productService.UpdateStockWithTx(tx, productID, -quantity); err != nil {
-				s.logger.Error("Failed to update stock in transaction",
-					zap.Uint("product_id", productID),
-					zap.Int("quantity", -quantity),
-					zap.Error(err),
-				)
-				return err
-			}
-		}
-		return nil
-	})
+// GetAllOrders returns at most MaxUnpaginatedRows orders by id, logging a warning when
+// there were more
+func (s *service) GetAllOrders(ctx context.Context) ([]Order, error) {
+	ctx, span := tracing.Start(ctx, "order.GetAllOrders")
+	defer span.End()
 
+	orders, err := s.repo.FindAll(ctx, MaxUnpaginatedRows+1)
 	if err != nil {
-		s.logger.Error("Order creation transaction failed",
-			zap.Uint("user_id", userID),
-			zap.Error(err),
-		)
 		return nil, err
 	}
-
-	return &order, nil
+	if len(orders) > MaxUnpaginatedRows {
+		s.logger.Warn("Unpaginated order list truncated, use the paginated listing instead",
+			zap.Int("limit", MaxUnpaginatedRows),
+		)
+		orders = orders[:MaxUnpaginatedRows]
+	}
+	return orders, nil
 }
 
-func (s *service) GetAllOrders(ctx context.Context) ([]Order, error) {
-	return s.repo.FindAll(ctx)
-}
+func (s *service) GetOrderByID(ctx context.Context, id uint, userID uint, isAdmin bool) (*Order, error) {
+	ctx, span := tracing.Start(ctx, "order.GetOrderByID")
+	defer span.End()
 
-func (s *service) GetOrderByID(ctx context.Context, id uint) (*Order, error) {
 	order, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New(ErrOrderNotFound)
+			return nil, ErrOrderNotFound
 		}
 		return nil, err
 	}
+
+	if order.UserID != userID && !isAdmin {
+		return nil, ErrNotAuthorizedToView
+	}
 	return &order, nil
 }
 
 func (s *service) UpdateOrder(ctx context.Context, id uint, input UpdateOrderRequest, userID uint) (*Order, error) {
+	ctx, span := tracing.Start(ctx, "order.UpdateOrder")
+	defer span.End()
+
 	if err := s.validator.Struct(input); err != nil {
 		return nil, err
 	}
@@ -152,13 +368,13 @@ func (s *service) UpdateOrder(ctx context.Context, id uint, input UpdateOrderReq
 	order, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New(ErrOrderNotFound)
+			return nil, ErrOrderNotFound
 		}
 		return nil, err
 	}
 
 	if order.UserID != userID {
-		return nil, errors.New(ErrNotAuthorizedToUpdate)
+		return nil, ErrNotAuthorizedToUpdate
 	}
 
 	if err := s.validateStatusTransition(&order, input.Status); err != nil {
@@ -166,33 +382,32 @@ func (s *service) UpdateOrder(ctx context.Context, id uint, input UpdateOrderReq
 	}
 
 	if input.Status != nil {
-		return s.updateOrderStatus(ctx, &order, *input.Status)
+		return s.updateOrderStatus(ctx, &order, *input.Status, userID)
 	}
 
 	return &order, nil
 }
 
-func (s *service) DeleteOrder(ctx context.Context, id uint) error {
+func (s *service) DeleteOrder(ctx context.Context, id uint, userID uint, isAdmin bool) error {
+	ctx, span := tracing.Start(ctx, "order.DeleteOrder")
+	defer span.End()
+
 	order, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New(ErrOrderNotFound)
+			return ErrOrderNotFound
 		}
 		return err
 	}
 
+	// Deleting puts the stock back and only an admin can restore it, so others' orders are off limits
+	if order.UserID != userID && !isAdmin {
+		return ErrNotAuthorizedToUpdate
+	}
+
+	// The order is only soft-deleted, its stock goes back to the shelf until it is restored
 	err = s.repo.DeleteWithTransaction(ctx, id, func(tx *gorm.DB) error {
-		for _, item := range order.OrderItems {
-			if err := s.productService.UpdateStockWithTx(tx, item.ProductID, item.Quantity); err != nil {
-				s.logger.Error("Failed to restore stock in transaction",
-					zap.Uint("product_id", item.ProductID),
-					zap.Int("quantity", item.Quantity),
-					zap.Error(err),
-				)
-				return err
-			}
-		}
-		return nil
+		return s.adjustReservedStock(tx, &order, 1)
 	})
 
 	if err != nil {
@@ -202,11 +417,222 @@ func (s *service) DeleteOrder(ctx context.Context, id uint) error {
 		)
 		return err
 	}
+	if order.Status != StatusCancelled {
+		s.productService.NotifyRestocked(ctx, orderProductIDs(&order))
+	}
+
+	return nil
+}
+
+// RestoreOrder brings back a soft-deleted order and takes its stock off the shelf again,
+// failing with ErrInsufficientStock when that stock has since been sold
+func (s *service) RestoreOrder(ctx context.Context, id uint) (*Order, error) {
+	ctx, span := tracing.Start(ctx, "order.RestoreOrder")
+	defer span.End()
+
+	order, err := s.repo.FindDeletedByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
 
+	err = s.repo.RestoreWithTransaction(ctx, id, func(tx *gorm.DB) error {
+		return s.adjustReservedStock(tx, &order, -1)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		s.logger.Error("Order restore transaction failed",
+			zap.Uint("order_id", id),
+			zap.Error(err),
+		)
+		return nil, upstreamError(err)
+	}
+
+	order.DeletedAt = gorm.DeletedAt{}
+	return &order, nil
+}
+
+// PayOrder charges the order total through the payment gateway and only marks the order
+// paid once the charge succeeded. A failed charge leaves the order pending, without a
+// gateway it fails with ErrPaymentUnavailable. The charge
+// is keyed by the order, so concurrent payments of one order are charged only once and
+// the request losing the status update fails with ErrConcurrentUpdate.
+func (s *service) PayOrder(ctx context.Context, id uint, userID uint) (*Order, error) {
+	ctx, span := tracing.Start(ctx, "order.PayOrder")
+	defer span.End()
+
+	order, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, ErrNotAuthorizedToUpdate
+	}
+	if order.Status != StatusPending {
+		return nil, ErrOrderNotPayable
+	}
+	if s.gateway == nil {
+		return nil, ErrPaymentUnavailable
+	}
+
+	transactionID, err := s.gateway.Charge(ctx, paymentIdempotencyKey(order.ID), order.TotalPrice, order.Currency)
+	if err != nil {
+		s.logger.Warn("Payment charge failed",
+			zap.Uint("order_id", order.ID),
+			zap.Int("amount", order.TotalPrice),
+			zap.String("currency", order.Currency),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	order.PaymentTransactionID = &transactionID
+	paid, err := s.updateOrderStatus(ctx, &order, StatusPaid, userID)
+	if err != nil {
+		// The customer has been charged at this point, keep the reference for reconciliation
+		s.logger.Error("Failed to record payment",
+			zap.Uint("order_id", order.ID),
+			zap.String("transaction_id", transactionID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return paid, nil
+}
+
+// adjustReservedStock moves the quantities of order back to stock (sign 1) or takes them
+// off again (sign -1). Cancelled orders gave their stock back already and hold none.
+func (s *service) adjustReservedStock(tx *gorm.DB, order *Order, sign int) error {
+	if order.Status == StatusCancelled {
+		return nil
+	}
+	for _, item := range order.OrderItems {
+		if err := s.productService.UpdateStockWithTx(tx, item.ProductID, sign*item.Quantity); err != nil {
+			s.logger.Error("Failed to adjust stock in transaction",
+				zap.Uint("order_id", order.ID),
+				zap.Uint("product_id", item.ProductID),
+				zap.Int("quantity", sign*item.Quantity),
+				zap.Error(err),
+			)
+			return err
+		}
+	}
 	return nil
 }
 
+func (s *service) GetOrderStatusHistory(ctx context.Context, id uint, userID uint) ([]OrderStatusHistory, error) {
+	ctx, span := tracing.Start(ctx, "order.GetOrderStatusHistory")
+	defer span.End()
+
+	order, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, ErrNotAuthorizedToView
+	}
+
+	return s.repo.FindStatusHistory(ctx, id)
+}
+
 // Helpers
+
+// OrderResponses maps orders to their responses. Names of products that were not
+// preloaded are fetched in one batch. A failed lookup only leaves those names empty,
+// the orders themselves are already known to be fine.
+func (s *service) OrderResponses(ctx context.Context, orders []Order) []OrderResponse {
+	ctx, span := tracing.Start(ctx, "order.OrderResponses")
+	defer span.End()
+
+	var productIDs []uint
+	seen := make(map[uint]bool)
+	for _, order := range orders {
+		for _, item := range order.OrderItems {
+			if item.Product == nil && !seen[item.ProductID] {
+				seen[item.ProductID] = true
+				productIDs = append(productIDs, item.ProductID)
+			}
+		}
+	}
+
+	names := make(map[uint]string, len(productIDs))
+	if len(productIDs) > 0 {
+		products, err := s.productService.GetProductsByIDs(ctx, productIDs)
+		if err != nil {
+			s.logger.Warn("Failed to resolve product names for order response", zap.Error(err))
+		}
+		for id, p := range products {
+			names[id] = p.Name
+		}
+	}
+
+	responses := make([]OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		responses = append(responses, NewOrderResponse(order, names))
+	}
+	return responses
+}
+
+func orderProductIDs(order *Order) []uint {
+	ids := make([]uint, len(order.OrderItems))
+	for i, item := range order.OrderItems {
+		ids[i] = item.ProductID
+	}
+	return ids
+}
+
+func (s *service) checkQuantity(productID uint, quantity int) error {
+	if quantity > s.limits.MaxQuantityPerLine {
+		return fmt.Errorf("%w: product %d has %d, at most %d allowed", ErrQuantityTooLarge, productID, quantity, s.limits.MaxQuantityPerLine)
+	}
+	return nil
+}
+
+// lineTotal multiplies quantity by price, failing instead of wrapping around on overflow
+func lineTotal(productID uint, quantity, price int) (int, error) {
+	if price > 0 && quantity > math.MaxInt/price {
+		return 0, fmt.Errorf("%w: product %d quantity %d at price %d", ErrOrderTotalOverflow, productID, quantity, price)
+	}
+	return quantity * price, nil
+}
+
+func addTotal(total, subtotal int) (int, error) {
+	if subtotal > math.MaxInt-total {
+		return 0, ErrOrderTotalOverflow
+	}
+	return total + subtotal, nil
+}
+
+// upstreamError swaps the message-only errors of the product and coupon services for
+// the order sentinels, so callers can match them with errors.Is
+func upstreamError(err error) error {
+	switch err.Error() {
+	case product.ErrProductNotFound:
+		return ErrProductNotFound
+	case product.ErrInsufficientStock:
+		return ErrInsufficientStock
+	case coupon.ErrCouponNotFound:
+		return ErrCouponNotFound
+	case coupon.ErrCouponExpired:
+		return ErrCouponExpired
+	case coupon.ErrCouponExhausted:
+		return ErrCouponExhausted
+	}
+	return err
+}
+
 func (s *service) validateStatusTransition(order *Order, newStatus *OrderStatus) error {
 	if newStatus == nil {
 		return nil
@@ -214,41 +640,66 @@ func (s *service) validateStatusTransition(order *Order, newStatus *OrderStatus)
 	switch *newStatus {
 	case StatusPending, StatusPaid, StatusCancelled:
 	default:
-		return errors.New(ErrInvalidStatusValue)
+		return ErrInvalidStatusValue
+	}
+	// Only PayOrder may mark an order paid, after the charge went through
+	if *newStatus == StatusPaid && order.Status != StatusPaid {
+		return ErrPaidOnlyByPayment
 	}
 	if order.Status == StatusPaid && *newStatus == StatusPending {
-		return errors.New(ErrCannotChangePaidOrderToPending)
+		return ErrCannotChangePaidOrderToPending
 	}
 	if order.Status == StatusCancelled && *newStatus != StatusCancelled {
-		return errors.New(ErrCannotChangeCancelledOrderStatus)
+		return ErrCannotChangeCancelledOrderStatus
+	}
+	// Pending orders can always be cancelled, anything further along only shortly after creation
+	if *newStatus == StatusCancelled && order.Status != StatusCancelled && order.Status != StatusPending &&
+		time.Since(order.CreatedAt) > s.limits.CancellationWindow {
+		return fmt.Errorf("%w: created more than %s ago", ErrCancellationWindowExpired, s.limits.CancellationWindow)
 	}
 	return nil
 }
 
-func (s *service) updateOrderStatus(ctx context.Context, order *Order, newStatus OrderStatus) (*Order, error) {
-	if newStatus == StatusCancelled && order.Status != StatusCancelled {
+func (s *service) updateOrderStatus(ctx context.Context, order *Order, newStatus OrderStatus, changedBy uint) (*Order, error) {
+	fromStatus := order.Status
+	if newStatus != fromStatus {
+		// The history row shares the transaction with the status change, so it only
+		// persists when the transition does
 		err := s.repo.UpdateWithTransaction(ctx, order, func(o *Order) {
 			o.Status = newStatus
 		}, func(tx *gorm.DB) error {
-			for _, item := range order.OrderItems {
-				if err := s.productService.UpdateStockWithTx(tx, item.ProductID, item.Quantity); err != nil {
-					s.logger.Error("Failed to restore stock on cancellation",
-						zap.Uint("product_id", item.ProductID),
-						zap.Int("quantity", item.Quantity),
-						zap.Error(err),
-					)
-					return err
+			if newStatus == StatusCancelled {
+				for _, item := range order.OrderItems {
+					if err := s.productService.UpdateStockWithTx(tx, item.ProductID, item.Quantity); err != nil {
+						s.logger.Error("Failed to restore stock on cancellation",
+							zap.Uint("product_id", item.ProductID),
+							zap.Int("quantity", item.Quantity),
+							zap.Error(err),
+						)
+						return err
+					}
 				}
 			}
-			return nil
+
+			return s.repo.CreateStatusHistoryWithTx(tx, &OrderStatusHistory{
+				OrderID:    order.ID,
+				FromStatus: fromStatus,
+				ToStatus:   newStatus,
+				ChangedBy:  changedBy,
+			})
 		})
 		if err != nil {
-			s.logger.Error("Order cancellation transaction failed",
+			s.logger.Error("Order status transaction failed",
 				zap.Uint("order_id", order.ID),
+				zap.String("from_status", string(fromStatus)),
+				zap.String("to_status", string(newStatus)),
 				zap.Error(err),
 			)
 			return nil, err
 		}
+		if newStatus == StatusCancelled {
+			s.productService.NotifyRestocked(ctx, orderProductIDs(order))
+		}
 		return order, nil
 	}
 
@@ -266,6 +717,9 @@ func (s *service) updateOrderStatus(ctx context.Context, order *Order, newStatus
 }
 
 func (s *service) GetAllOrdersWithQuery(ctx context.Context, query OrderQuery) (*OrderListResponse, error) {
+	ctx, span := tracing.Start(ctx, "order.GetAllOrdersWithQuery")
+	defer span.End()
+
 	page := query.Page
 	if page <= 0 {
 		page = DefaultPage
@@ -293,9 +747,27 @@ func (s *service) GetAllOrdersWithQuery(ctx context.Context, query OrderQuery) (
 		sortBy = DefaultSortField
 	}
 
+	if query.CreatedFrom != nil && query.CreatedTo != nil && query.CreatedFrom.After(*query.CreatedTo) {
+		return nil, ErrInvalidDateRange
+	}
+
+	filter := OrderFilter{
+		Status:      query.Status,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+		IncludeUser: query.IncludeUser,
+	}
+
 	offset := (page - 1) * pageSize
 
-	orders, total, err := s.repo.FindAllWithPagination(ctx, offset, pageSize, sortBy, order)
+	var orders []Order
+	var total int64
+	var err error
+	if query.UserID != 0 {
+		orders, total, err = s.repo.FindAllByUserWithPagination(ctx, query.UserID, offset, pageSize, sortBy, order, filter)
+	} else {
+		orders, total, err = s.repo.FindAllWithPagination(ctx, offset, pageSize, sortBy, order, filter)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -314,3 +786,69 @@ func (s *service) GetAllOrdersWithQuery(ctx context.Context, query OrderQuery) (
 
 	return response, nil
 }
+
+// ExportOrders passes every order of query.UserID matching the list filters to fn, oldest
+// first. Paging and sorting are ignored. Orders are read in batches of ExportBatchSize so
+// the caller can stream them out as they come.
+func (s *service) ExportOrders(ctx context.Context, query OrderQuery, fn func(Order) error) error {
+	ctx, span := tracing.Start(ctx, "order.ExportOrders")
+	defer span.End()
+
+	if query.CreatedFrom != nil && query.CreatedTo != nil && query.CreatedFrom.After(*query.CreatedTo) {
+		return ErrInvalidDateRange
+	}
+
+	filter := OrderFilter{
+		Status:      query.Status,
+		CreatedFrom: query.CreatedFrom,
+		CreatedTo:   query.CreatedTo,
+	}
+	return s.repo.FindByUserInBatches(ctx, query.UserID, filter, ExportBatchSize, func(orders []Order) error {
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetOrderSummary returns the user's order count per status, total spent and most bought
+// products, computed in the database and cached briefly
+func (s *service) GetOrderSummary(ctx context.Context, userID uint) (*OrderSummary, error) {
+	ctx, span := tracing.Start(ctx, "order.GetOrderSummary")
+	defer span.End()
+
+	var summary OrderSummary
+	err := s.cache.GetOrSet(ctx, fmt.Sprintf(CacheKeyOrderSummary, userID), CacheTTLOrderSummary, &summary, func() (any, error) {
+		stats, err := s.repo.UserOrderStats(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return NewOrderSummary(stats), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// NewOrderSummary shapes the aggregates, every status is listed even without orders
+func NewOrderSummary(stats OrderStats) OrderSummary {
+	summary := OrderSummary{
+		OrderCountByStatus: map[OrderStatus]int64{StatusPending: 0, StatusPaid: 0, StatusCancelled: 0},
+		TotalSpent:         make(map[string]int64, len(stats.Spent)),
+		TopProducts:        stats.TopProducts,
+	}
+	for _, count := range stats.StatusCounts {
+		summary.OrderCountByStatus[count.Status] = count.Count
+		summary.TotalOrders += count.Count
+	}
+	for _, spent := range stats.Spent {
+		summary.TotalSpent[spent.Currency] = spent.Total
+	}
+	if summary.TopProducts == nil {
+		summary.TopProducts = []TopProduct{}
+	}
+	return summary
+}
@@ -0,0 +1,1052 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/coupon"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/product"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+)
+
+// stubProductService keeps stock in memory and serializes stock updates the way a
+// row lock would, so only checks made inside UpdateStockWithTx are race free
+type stubProductService struct {
+	product.Service
+	mu       sync.Mutex
+	products map[uint]*product.Product
+
+	batchCalls int
+	restocked  []uint
+}
+
+func (s *stubProductService) GetProductsByIDs(ctx context.Context, ids []uint) (map[uint]product.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batchCalls++
+	found := make(map[uint]product.Product, len(ids))
+	for _, id := range ids {
+		if p, ok := s.products[id]; ok {
+			found[id] = *p
+		}
+	}
+	return found, nil
+}
+
+func (s *stubProductService) UpdateStockWithTx(tx *gorm.DB, id uint, stockDelta int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.products[id]
+	if !ok {
+		return errors.New(product.ErrProductNotFound)
+	}
+	if p.Stock+stockDelta < 0 {
+		return errors.New(product.ErrInsufficientStock)
+	}
+	p.Stock += stockDelta
+	return nil
+}
+
+func (s *stubProductService) NotifyRestocked(ctx context.Context, ids []uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.restocked = append(s.restocked, ids...)
+}
+
+func (s *stubProductService) InvalidateProducts(ctx context.Context, ids []uint) {}
+
+type stubRepository struct {
+	Repository
+	mu     sync.Mutex
+	nextID uint
+
+	stats      OrderStats
+	statsCalls int
+}
+
+func (r *stubRepository) CreateWithTransaction(ctx context.Context, order *Order, txFunc func(*gorm.DB) error) error {
+	if err := txFunc(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	order.ID = r.nextID
+	return nil
+}
+
+func (r *stubRepository) UserOrderStats(ctx context.Context, userID uint) (OrderStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statsCalls++
+	return r.stats, nil
+}
+
+type stubCouponRepository struct {
+	coupon.Repository
+	mu      sync.Mutex
+	coupons map[string]*coupon.Coupon
+}
+
+func (r *stubCouponRepository) FindByCode(ctx context.Context, code string) (coupon.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.coupons[code]
+	if !ok {
+		return coupon.Coupon{}, gorm.ErrRecordNotFound
+	}
+	return *c, nil
+}
+
+func (r *stubCouponRepository) IncrementUsageWithTx(tx *gorm.DB, id uint, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.coupons {
+		if c.ID == id {
+			if c.IsExhausted() || c.IsExpired(now) {
+				return false, nil
+			}
+			c.UsedCount++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func setupCouponService(coupons ...coupon.Coupon) coupon.Service {
+	repo := &stubCouponRepository{coupons: make(map[string]*coupon.Coupon)}
+	for i := range coupons {
+		repo.coupons[coupons[i].Code] = &coupons[i]
+	}
+	return coupon.NewService(repo, "IDR", zap.NewNop())
+}
+
+func setupLogger() logger.Logger {
+	logConfig := &logger.Config{
+		ServiceName: "test",
+		AppVersion:  "test",
+		LogLevel:    zapcore.FatalLevel,
+		Mode:        "development",
+	}
+	log, _ := logger.NewLogger(logConfig)
+	return log
+}
+
+func TestService_CreateOrder_Concurrency(t *testing.T) {
+	t.Run("should let exactly one of two concurrent orders take the last unit", func(t *testing.T) {
+		productService := &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: 1},
+			},
+		}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		input := CreateOrderRequest{Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = service.CreateOrder(context.Background(), input, uint(i+1))
+			}(i)
+		}
+		wg.Wait()
+
+		var succeeded, insufficient int
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, ErrInsufficientStock):
+				insufficient++
+			}
+		}
+
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, 1, insufficient)
+		assert.Equal(t, 0, productService.products[1].Stock)
+	})
+}
+
+func TestService_CreateOrder(t *testing.T) {
+	t.Run("should merge duplicate product lines into a single order item", func(t *testing.T) {
+		productService := &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: 10},
+				2: {ID: 2, Name: "Laptop", Price: 5000, Stock: 10},
+			},
+		}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		input := CreateOrderRequest{Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 2},
+			{ProductID: 2, Quantity: 1},
+			{ProductID: 1, Quantity: 3},
+		}}
+
+		order, err := service.CreateOrder(context.Background(), input, 1)
+
+		require.NoError(t, err)
+		require.Len(t, order.OrderItems, 2)
+		assert.Equal(t, uint(1), order.OrderItems[0].ProductID)
+		assert.Equal(t, 5, order.OrderItems[0].Quantity)
+		assert.Equal(t, 5000, order.OrderItems[0].Subtotal)
+		assert.Equal(t, uint(2), order.OrderItems[1].ProductID)
+		assert.Equal(t, 1, order.OrderItems[1].Quantity)
+		assert.Equal(t, 10000, order.TotalPrice)
+		assert.Equal(t, 5, productService.products[1].Stock)
+		assert.Equal(t, 9, productService.products[2].Stock)
+	})
+
+	t.Run("should fetch every product of the order in a single batch", func(t *testing.T) {
+		productService := &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: 10},
+				2: {ID: 2, Name: "Laptop", Price: 5000, Stock: 10},
+				3: {ID: 3, Name: "Headphones", Price: 300, Stock: 10},
+			},
+		}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		input := CreateOrderRequest{Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+			{ProductID: 3, Quantity: 1},
+		}}
+
+		order, err := service.CreateOrder(context.Background(), input, 1)
+
+		require.NoError(t, err)
+		assert.Len(t, order.OrderItems, 3)
+		assert.Equal(t, 6300, order.TotalPrice)
+		assert.Equal(t, 1, productService.batchCalls)
+	})
+}
+
+func TestService_ValidateOrder(t *testing.T) {
+	percentOff := 10
+	future := time.Now().Add(time.Hour)
+
+	// Any query on the sqlmock connection fails the expectations, so a dry run that
+	// reached the database would be caught
+	setup := func(t *testing.T, coupons ...coupon.Coupon) (Service, *stubProductService, coupon.Service, sqlmock.Sqlmock) {
+		t.Helper()
+		db, mock := setupTestDB(t)
+		productService := &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: 10},
+				2: {ID: 2, Name: "Laptop", Price: 5000, Stock: 1},
+			},
+		}
+		couponService := setupCouponService(coupons...)
+		service := NewService(NewRepository(db), productService, couponService, NewFakeGateway(), nil, Limits{}, setupLogger())
+		return service, productService, couponService, mock
+	}
+
+	t.Run("should price the order without writing anything", func(t *testing.T) {
+		c := coupon.Coupon{ID: 1, Code: "SAVE10", PercentOff: &percentOff, ExpiresAt: &future, MaxUses: 5}
+		service, productService, couponService, mock := setup(t, c)
+
+		input := CreateOrderRequest{
+			Items: []OrderItemInput{
+				{ProductID: 1, Quantity: 2},
+				{ProductID: 2, Quantity: 1},
+				{ProductID: 1, Quantity: 1},
+			},
+			CouponCode: "SAVE10",
+		}
+
+		order, err := service.ValidateOrder(context.Background(), input, 1)
+
+		require.NoError(t, err)
+		assert.Zero(t, order.ID)
+		require.Len(t, order.OrderItems, 2)
+		assert.Equal(t, 3, order.OrderItems[0].Quantity)
+		assert.Equal(t, 3000, order.OrderItems[0].Subtotal)
+		assert.Equal(t, 800, order.Discount)
+		assert.Equal(t, 7200, order.TotalPrice)
+
+		assert.Equal(t, 10, productService.products[1].Stock)
+		assert.Equal(t, 1, productService.products[2].Stock)
+		redeemed, err := couponService.ValidateCoupon(context.Background(), "SAVE10")
+		require.NoError(t, err)
+		assert.Zero(t, redeemed.UsedCount)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject more than the current stock", func(t *testing.T) {
+		service, productService, _, mock := setup(t)
+
+		input := CreateOrderRequest{Items: []OrderItemInput{{ProductID: 2, Quantity: 1}, {ProductID: 2, Quantity: 1}}}
+
+		_, err := service.ValidateOrder(context.Background(), input, 1)
+
+		assert.ErrorIs(t, err, ErrInsufficientStock)
+		assert.Equal(t, 1, productService.products[2].Stock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject unknown products", func(t *testing.T) {
+		service, _, _, mock := setup(t)
+
+		input := CreateOrderRequest{Items: []OrderItemInput{{ProductID: 99, Quantity: 1}}}
+
+		_, err := service.ValidateOrder(context.Background(), input, 1)
+
+		assert.ErrorIs(t, err, ErrProductNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestService_CreateOrder_Currency(t *testing.T) {
+	t.Run("should price the order in the currency of its products", func(t *testing.T) {
+		productService := &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Currency: "USD", Stock: 10},
+				2: {ID: 2, Name: "Laptop", Price: 5000, Currency: "USD", Stock: 10},
+			},
+		}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), CreateOrderRequest{Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+		}}, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, "USD", order.Currency)
+		assert.Equal(t, 6000, order.TotalPrice)
+	})
+
+	t.Run("should reject products priced in different currencies", func(t *testing.T) {
+		productService := &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Currency: "USD", Stock: 10},
+				2: {ID: 2, Name: "Laptop", Price: 5000, Currency: "IDR", Stock: 10},
+			},
+		}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), CreateOrderRequest{Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+		}}, 1)
+
+		assert.Nil(t, order)
+		assert.ErrorIs(t, err, ErrCurrencyMismatch)
+		assert.Equal(t, 10, productService.products[1].Stock)
+	})
+}
+
+func TestService_CreateOrder_CouponCurrency(t *testing.T) {
+	idr, usd := "IDR", "USD"
+	amountOff := 10000
+	newProducts := func() *stubProductService {
+		return &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 20000, Currency: "USD", Stock: 10},
+			},
+		}
+	}
+	input := CreateOrderRequest{Items: []OrderItemInput{{ProductID: 1, Quantity: 1}}, CouponCode: "SAVE10K"}
+
+	t.Run("should reject a fixed amount coupon in another currency", func(t *testing.T) {
+		productService := newProducts()
+		couponService := setupCouponService(coupon.Coupon{ID: 1, Code: "SAVE10K", AmountOff: &amountOff, Currency: &idr})
+		service := NewService(&stubRepository{}, productService, couponService, NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), input, 1)
+
+		assert.Nil(t, order)
+		assert.ErrorIs(t, err, ErrCouponCurrencyMismatch)
+		assert.Equal(t, 10, productService.products[1].Stock)
+	})
+
+	t.Run("should apply a fixed amount coupon in the order currency", func(t *testing.T) {
+		couponService := setupCouponService(coupon.Coupon{ID: 1, Code: "SAVE10K", AmountOff: &amountOff, Currency: &usd})
+		service := NewService(&stubRepository{}, newProducts(), couponService, NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), input, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 10000, order.Discount)
+		assert.Equal(t, 10000, order.TotalPrice)
+	})
+}
+
+func TestService_CreateOrder_Limits(t *testing.T) {
+	newProducts := func() *stubProductService {
+		return &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: math.MaxInt},
+				2: {ID: 2, Name: "Laptop", Price: 5000, Stock: math.MaxInt},
+			},
+		}
+	}
+
+	t.Run("should reject a quantity whose total overflows", func(t *testing.T) {
+		productService := newProducts()
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{MaxQuantityPerLine: math.MaxInt}, setupLogger())
+
+		input := CreateOrderRequest{Items: []OrderItemInput{{ProductID: 1, Quantity: math.MaxInt / 2}}}
+
+		_, err := service.CreateOrder(context.Background(), input, 1)
+
+		assert.ErrorIs(t, err, ErrOrderTotalOverflow)
+		assert.Equal(t, math.MaxInt, productService.products[1].Stock)
+	})
+
+	t.Run("should reject more lines than allowed per order", func(t *testing.T) {
+		service := NewService(&stubRepository{}, newProducts(), setupCouponService(), NewFakeGateway(), nil, Limits{MaxItemsPerOrder: 2}, setupLogger())
+
+		input := CreateOrderRequest{Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 1},
+			{ProductID: 2, Quantity: 1},
+			{ProductID: 1, Quantity: 1},
+		}}
+
+		_, err := service.CreateOrder(context.Background(), input, 1)
+
+		assert.ErrorIs(t, err, ErrTooManyItems)
+		assert.ErrorContains(t, err, "got 3, at most 2 allowed")
+	})
+
+	t.Run("should apply the per-line limit after merging duplicate lines", func(t *testing.T) {
+		service := NewService(&stubRepository{}, newProducts(), setupCouponService(), NewFakeGateway(), nil, Limits{MaxQuantityPerLine: 5}, setupLogger())
+
+		input := CreateOrderRequest{Items: []OrderItemInput{
+			{ProductID: 1, Quantity: 3},
+			{ProductID: 1, Quantity: 3},
+		}}
+
+		_, err := service.CreateOrder(context.Background(), input, 1)
+
+		assert.ErrorIs(t, err, ErrQuantityTooLarge)
+	})
+}
+
+func TestService_GetAllOrdersWithQuery(t *testing.T) {
+	t.Run("should reject a date range that ends before it starts", func(t *testing.T) {
+		service := NewService(&stubRepository{}, &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		result, err := service.GetAllOrdersWithQuery(context.Background(), OrderQuery{CreatedFrom: &from, CreatedTo: &to})
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidDateRange)
+		assert.Nil(t, result)
+	})
+}
+
+func TestService_CreateOrder_Coupon(t *testing.T) {
+	percentOff := 10
+	amountOff := 2000
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	newProductService := func() *stubProductService {
+		return &stubProductService{
+			products: map[uint]*product.Product{
+				1: {ID: 1, Name: "Smartphone", Price: 1000, Stock: 10},
+			},
+		}
+	}
+	input := func(code string) CreateOrderRequest {
+		return CreateOrderRequest{
+			Items:      []OrderItemInput{{ProductID: 1, Quantity: 3}},
+			CouponCode: code,
+		}
+	}
+
+	t.Run("should apply percentage coupon and count the use", func(t *testing.T) {
+		c := coupon.Coupon{ID: 1, Code: "SAVE10", PercentOff: &percentOff, ExpiresAt: &future, MaxUses: 5}
+		couponService := setupCouponService(c)
+		service := NewService(&stubRepository{}, newProductService(), couponService, NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), input("save10"), 1)
+
+		require.NoError(t, err)
+		require.NotNil(t, order.CouponCode)
+		assert.Equal(t, "SAVE10", *order.CouponCode)
+		assert.Equal(t, 300, order.Discount)
+		assert.Equal(t, 2700, order.TotalPrice)
+
+		redeemed, err := couponService.ValidateCoupon(context.Background(), "SAVE10")
+		require.NoError(t, err)
+		assert.Equal(t, 1, redeemed.UsedCount)
+	})
+
+	t.Run("should cap fixed amount coupon at the order total", func(t *testing.T) {
+		service := NewService(&stubRepository{}, newProductService(), setupCouponService(coupon.Coupon{ID: 1, Code: "FLAT", AmountOff: &amountOff}), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), CreateOrderRequest{
+			Items:      []OrderItemInput{{ProductID: 1, Quantity: 1}},
+			CouponCode: "FLAT",
+		}, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1000, order.Discount)
+		assert.Equal(t, 0, order.TotalPrice)
+	})
+
+	t.Run("should reject expired coupon without touching stock", func(t *testing.T) {
+		productService := newProductService()
+		service := NewService(&stubRepository{}, productService, setupCouponService(coupon.Coupon{ID: 1, Code: "OLD", PercentOff: &percentOff, ExpiresAt: &past}), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), input("OLD"), 1)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrCouponExpired)
+		assert.Nil(t, order)
+		assert.Equal(t, 10, productService.products[1].Stock)
+	})
+
+	t.Run("should reject exhausted coupon", func(t *testing.T) {
+		service := NewService(&stubRepository{}, newProductService(), setupCouponService(coupon.Coupon{ID: 1, Code: "ONCE", PercentOff: &percentOff, MaxUses: 1, UsedCount: 1}), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), input("ONCE"), 1)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrCouponExhausted)
+		assert.Nil(t, order)
+	})
+
+	t.Run("should reject unknown coupon", func(t *testing.T) {
+		service := NewService(&stubRepository{}, newProductService(), setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		order, err := service.CreateOrder(context.Background(), input("NOPE"), 1)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrCouponNotFound)
+		assert.Nil(t, order)
+	})
+}
+
+func TestService_UpdateOrder_StatusHistory(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(7)
+
+	expectFindOrder := func(mock sqlmock.Sqlmock, status OrderStatus) {
+		now := time.Now()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE "orders"."id" = $1 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
+				AddRow(1, userID, 1000, status, now, now))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "price", "subtotal"}))
+	}
+
+	t.Run("should insert exactly one history row for PENDING to CANCELLED", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories" ("order_id","from_status","to_status","changed_by","created_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+			WithArgs(1, StatusPending, StatusCancelled, userID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		status := StatusCancelled
+		order, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &status}, userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusCancelled, order.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back history when status update fails", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET`)).
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		status := StatusCancelled
+		order, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &status}, userID)
+
+		assert.Error(t, err)
+		assert.Nil(t, order)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse to mark an unpaid order paid", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+
+		status := StatusPaid
+		order, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &status}, userID)
+
+		assert.ErrorIs(t, err, ErrPaidOnlyByPayment)
+		assert.Nil(t, order)
+		// the order was read but never written
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not write history when status is unchanged", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPaid)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		status := StatusPaid
+		_, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &status}, userID)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestService_UpdateOrder_CancellationWindow(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(7)
+	cancelled := StatusCancelled
+
+	expectFindOrder := func(mock sqlmock.Sqlmock, status OrderStatus, createdAt time.Time) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE "orders"."id" = $1 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at", "updated_at"}).
+				AddRow(1, userID, 1000, status, createdAt, createdAt))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "price", "subtotal"}))
+	}
+	expectCancel := func(mock sqlmock.Sqlmock, from OrderStatus) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories"`)).
+			WithArgs(1, from, StatusCancelled, userID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "orders" SET`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	t.Run("should cancel a fresh paid order", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{CancellationWindow: time.Hour}, setupLogger())
+
+		expectFindOrder(mock, StatusPaid, time.Now().Add(-time.Minute))
+		expectCancel(mock, StatusPaid)
+
+		order, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &cancelled}, userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusCancelled, order.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject cancelling a paid order older than the window", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{CancellationWindow: time.Hour}, setupLogger())
+
+		expectFindOrder(mock, StatusPaid, time.Now().Add(-2*time.Hour))
+
+		order, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &cancelled}, userID)
+
+		assert.ErrorIs(t, err, ErrCancellationWindowExpired)
+		assert.Nil(t, order)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should still cancel an old pending order", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{CancellationWindow: time.Hour}, setupLogger())
+
+		expectFindOrder(mock, StatusPending, time.Now().Add(-2*time.Hour))
+		expectCancel(mock, StatusPending)
+
+		_, err := service.UpdateOrder(ctx, 1, UpdateOrderRequest{Status: &cancelled}, userID)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFakeGateway(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the first transaction for a repeated key", func(t *testing.T) {
+		gateway := NewFakeGateway()
+
+		first, err := gateway.Charge(ctx, paymentIdempotencyKey(1), 1000, "IDR")
+		require.NoError(t, err)
+		again, err := gateway.Charge(ctx, paymentIdempotencyKey(1), 1000, "IDR")
+		require.NoError(t, err)
+
+		assert.Equal(t, first, again)
+		assert.Equal(t, uint64(1), gateway.charges.Load())
+	})
+
+	t.Run("should not reuse transaction ids after a restart", func(t *testing.T) {
+		before, err := NewFakeGateway().Charge(ctx, paymentIdempotencyKey(1), 1000, "IDR")
+		require.NoError(t, err)
+		after, err := NewFakeGateway().Charge(ctx, paymentIdempotencyKey(2), 1000, "IDR")
+		require.NoError(t, err)
+
+		assert.NotEqual(t, before, after)
+	})
+}
+
+func TestService_PayOrder(t *testing.T) {
+	ctx := context.Background()
+	userID := uint(7)
+
+	expectFindOrder := func(mock sqlmock.Sqlmock, status OrderStatus) {
+		now := time.Now()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "orders" WHERE "orders"."id" = $1 AND "orders"."deleted_at" IS NULL`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "currency", "status", "created_at", "updated_at"}).
+				AddRow(1, userID, 1000, "IDR", status, now, now))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "order_items" WHERE "order_items"."order_id" = $1`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "product_id", "quantity", "price", "subtotal"}))
+	}
+
+	t.Run("should mark the order paid and store the transaction id", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories"`)).
+			WithArgs(1, StatusPending, StatusPaid, userID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE "orders" SET .*"payment_transaction_id"=`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		order, err := service.PayOrder(ctx, 1, userID)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusPaid, order.Status)
+		require.NotNil(t, order.PaymentTransactionID)
+		assert.True(t, strings.HasPrefix(*order.PaymentTransactionID, "fake_txn_"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should leave the order pending when the charge is declined", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), &FakeGateway{Decline: true}, nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+
+		order, err := service.PayOrder(ctx, 1, userID)
+
+		assert.ErrorIs(t, err, ErrPaymentDeclined)
+		assert.Nil(t, order)
+		// no transaction was opened, so the order row was never touched
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not charge an order that is no longer pending", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		gateway := NewFakeGateway()
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), gateway, nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPaid)
+
+		_, err := service.PayOrder(ctx, 1, userID)
+
+		assert.ErrorIs(t, err, ErrOrderNotPayable)
+		assert.Zero(t, gateway.charges.Load())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should charge once when two payments of an order race", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		gateway := NewFakeGateway()
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), gateway, nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE "orders" SET`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		// the second request read the order before the first one marked it paid
+		expectFindOrder(mock, StatusPending)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "order_status_histories"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec(`UPDATE "orders" SET`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := service.PayOrder(ctx, 1, userID)
+		require.NoError(t, err)
+		_, err = service.PayOrder(ctx, 1, userID)
+
+		assert.ErrorIs(t, err, ErrConcurrentUpdate)
+		assert.Equal(t, uint64(1), gateway.charges.Load())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse to pay without a gateway", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), NewGateway(""), nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+
+		order, err := service.PayOrder(ctx, 1, userID)
+
+		assert.ErrorIs(t, err, ErrPaymentUnavailable)
+		assert.Nil(t, order)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not charge another user's order", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		gateway := NewFakeGateway()
+		service := NewService(NewRepository(db), &stubProductService{}, setupCouponService(), gateway, nil, Limits{}, setupLogger())
+
+		expectFindOrder(mock, StatusPending)
+
+		_, err := service.PayOrder(ctx, 1, userID+1)
+
+		assert.ErrorIs(t, err, ErrNotAuthorizedToUpdate)
+		assert.Zero(t, gateway.charges.Load())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestService_OrderResponses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should count items and name every line", func(t *testing.T) {
+		productService := &stubProductService{products: map[uint]*product.Product{
+			2: {ID: 2, Name: "Headphones"},
+		}}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		orders := []Order{{
+			ID: 1,
+			OrderItems: []OrderItem{
+				{ProductID: 1, Quantity: 2, Product: &product.Product{ID: 1, Name: "Smartphone"}},
+				{ProductID: 2, Quantity: 3},
+			},
+		}}
+
+		responses := service.OrderResponses(ctx, orders)
+
+		require.Len(t, responses, 1)
+		assert.Equal(t, 5, responses[0].ItemCount)
+		require.Len(t, responses[0].OrderItems, 2)
+		assert.Equal(t, "Smartphone", responses[0].OrderItems[0].ProductName)
+		assert.Equal(t, "Headphones", responses[0].OrderItems[1].ProductName)
+		assert.Equal(t, 1, productService.batchCalls, "only the line without a preloaded product is looked up")
+	})
+
+	t.Run("should skip the lookup when every product is preloaded", func(t *testing.T) {
+		productService := &stubProductService{}
+		service := NewService(&stubRepository{}, productService, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger())
+
+		orders := []Order{{ID: 1, OrderItems: []OrderItem{
+			{ProductID: 1, Quantity: 1, Product: &product.Product{ID: 1, Name: "Smartphone"}},
+		}}}
+
+		responses := service.OrderResponses(ctx, orders)
+
+		require.Len(t, responses, 1)
+		assert.Equal(t, 1, responses[0].ItemCount)
+		assert.Zero(t, productService.batchCalls)
+	})
+}
+
+// softDeleteRepository holds a single order and tracks whether it is soft-deleted
+type softDeleteRepository struct {
+	Repository
+	order   Order
+	deleted bool
+}
+
+func (r *softDeleteRepository) FindByID(ctx context.Context, id uint) (Order, error) {
+	if r.deleted || id != r.order.ID {
+		return Order{}, gorm.ErrRecordNotFound
+	}
+	return r.order, nil
+}
+
+func (r *softDeleteRepository) FindDeletedByID(ctx context.Context, id uint) (Order, error) {
+	if !r.deleted || id != r.order.ID {
+		return Order{}, gorm.ErrRecordNotFound
+	}
+	return r.order, nil
+}
+
+func (r *softDeleteRepository) DeleteWithTransaction(ctx context.Context, id uint, txFunc func(*gorm.DB) error) error {
+	if err := txFunc(nil); err != nil {
+		return err
+	}
+	r.deleted = true
+	return nil
+}
+
+func (r *softDeleteRepository) RestoreWithTransaction(ctx context.Context, id uint, txFunc func(*gorm.DB) error) error {
+	if !r.deleted {
+		return gorm.ErrRecordNotFound
+	}
+	if err := txFunc(nil); err != nil {
+		return err
+	}
+	r.deleted = false
+	return nil
+}
+
+func TestService_DeleteAndRestoreOrder(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(status OrderStatus, stock int) (Service, *softDeleteRepository, *stubProductService) {
+		repo := &softDeleteRepository{order: Order{
+			ID:         1,
+			UserID:     7,
+			Status:     status,
+			OrderItems: []OrderItem{{ProductID: 1, Quantity: 2}},
+		}}
+		products := &stubProductService{products: map[uint]*product.Product{
+			1: {ID: 1, Name: "Smartphone", Stock: stock},
+		}}
+		return NewService(repo, products, setupCouponService(), NewFakeGateway(), nil, Limits{}, setupLogger()), repo, products
+	}
+
+	t.Run("should hide a deleted order and bring it back", func(t *testing.T) {
+		service, _, products := setup(StatusPending, 5)
+
+		require.NoError(t, service.DeleteOrder(ctx, 1, 7, false))
+		assert.Equal(t, 7, products.products[1].Stock)
+		assert.Equal(t, []uint{1}, products.restocked, "back in stock subscribers are checked after the commit")
+
+		_, err := service.GetOrderByID(ctx, 1, 7, false)
+		assert.ErrorIs(t, err, ErrOrderNotFound)
+
+		restored, err := service.RestoreOrder(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), restored.ID)
+		assert.Equal(t, 5, products.products[1].Stock)
+
+		_, err = service.GetOrderByID(ctx, 1, 7, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should not adjust stock when restoring twice", func(t *testing.T) {
+		service, _, products := setup(StatusPending, 5)
+
+		require.NoError(t, service.DeleteOrder(ctx, 1, 7, false))
+		_, err := service.RestoreOrder(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = service.RestoreOrder(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrOrderNotFound)
+		assert.Equal(t, 5, products.products[1].Stock)
+	})
+
+	t.Run("should leave stock alone for a cancelled order", func(t *testing.T) {
+		service, _, products := setup(StatusCancelled, 5)
+
+		require.NoError(t, service.DeleteOrder(ctx, 1, 7, false))
+		assert.Equal(t, 5, products.products[1].Stock)
+		assert.Empty(t, products.restocked)
+
+		_, err := service.RestoreOrder(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 5, products.products[1].Stock)
+	})
+
+	t.Run("should keep the order deleted when its stock was sold meanwhile", func(t *testing.T) {
+		service, repo, products := setup(StatusPending, 0)
+
+		require.NoError(t, service.DeleteOrder(ctx, 1, 7, false))
+		products.products[1].Stock = 1
+
+		_, err := service.RestoreOrder(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrInsufficientStock)
+		assert.True(t, repo.deleted)
+		assert.Equal(t, 1, products.products[1].Stock)
+	})
+}
+
+func TestService_GetOrderSummary(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	redisCache := cache.NewRedisCache(client, zap.NewNop())
+
+	t.Run("should shape the aggregates and cache the summary", func(t *testing.T) {
+		repo := &stubRepository{stats: OrderStats{
+			StatusCounts: []StatusCount{{Status: StatusPaid, Count: 3}, {Status: StatusPending, Count: 2}},
+			Spent:        []CurrencyTotal{{Currency: "IDR", Total: 450000}, {Currency: "USD", Total: 25}},
+			TopProducts:  []TopProduct{{ProductID: 3, ProductName: "Keyboard", Quantity: 4}},
+		}}
+		service := NewService(repo, &stubProductService{}, setupCouponService(), NewFakeGateway(), redisCache, Limits{}, setupLogger())
+
+		summary, err := service.GetOrderSummary(context.Background(), 7)
+
+		require.NoError(t, err)
+		assert.Equal(t, &OrderSummary{
+			TotalOrders:        5,
+			OrderCountByStatus: map[OrderStatus]int64{StatusPending: 2, StatusPaid: 3, StatusCancelled: 0},
+			TotalSpent:         map[string]int64{"IDR": 450000, "USD": 25},
+			TopProducts:        []TopProduct{{ProductID: 3, ProductName: "Keyboard", Quantity: 4}},
+		}, summary)
+
+		_, err = service.GetOrderSummary(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.statsCalls)
+	})
+
+	t.Run("should return empty collections for a user without orders", func(t *testing.T) {
+		service := NewService(&stubRepository{}, &stubProductService{}, setupCouponService(), NewFakeGateway(), redisCache, Limits{}, setupLogger())
+
+		summary, err := service.GetOrderSummary(context.Background(), 8)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), summary.TotalOrders)
+		assert.Equal(t, int64(0), summary.OrderCountByStatus[StatusPaid])
+		assert.NotNil(t, summary.TotalSpent)
+		assert.NotNil(t, summary.TopProducts)
+	})
+}
@@ -0,0 +1,103 @@
+package product
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+const ErrInvalidCursor = "invalid pagination cursor"
+
+var validSortFields = map[string]bool{
+	"id": true, "name": true, "price": true, "stock": true, "created_at": true,
+}
+
+// Cursor marks the last product of a keyset page. Value holds the sort column of
+// that product typed for the query (int64, string or time.Time), ID breaks ties.
+type Cursor struct {
+	SortBy string
+	Order  string
+	Value  any
+	ID     uint
+}
+
+type cursorPayload struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v"`
+	ID     uint   `json:"id"`
+}
+
+func newCursor(p Product, sortBy, order string) Cursor {
+	c := Cursor{SortBy: sortBy, Order: order, ID: p.ID}
+	switch sortBy {
+	case "id":
+		c.Value = int64(p.ID)
+	case "name":
+		c.Value = p.Name
+	case "price":
+		c.Value = int64(p.Price)
+	case "stock":
+		c.Value = int64(p.Stock)
+	default:
+		c.Value = p.CreatedAt
+	}
+	return c
+}
+
+// EncodeCursor turns a cursor into the opaque token handed to clients
+func EncodeCursor(c Cursor) string {
+	payload := cursorPayload{SortBy: c.SortBy, Order: c.Order, ID: c.ID}
+	switch v := c.Value.(type) {
+	case int64:
+		payload.Value = strconv.FormatInt(v, 10)
+	case string:
+		payload.Value = v
+	case time.Time:
+		payload.Value = v.UTC().Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor, rejecting anything that
+// does not name a sortable column or carry a value of the right type
+func DecodeCursor(token string) (Cursor, error) {
+	invalid := errors.New(ErrInvalidCursor)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return Cursor{}, invalid
+	}
+	if !validSortFields[payload.SortBy] || (payload.Order != "asc" && payload.Order != "desc") || payload.ID == 0 {
+		return Cursor{}, invalid
+	}
+
+	c := Cursor{SortBy: payload.SortBy, Order: payload.Order, ID: payload.ID}
+	switch payload.SortBy {
+	case "name":
+		c.Value = payload.Value
+	case "created_at":
+		t, err := time.Parse(time.RFC3339Nano, payload.Value)
+		if err != nil {
+			return Cursor{}, invalid
+		}
+		c.Value = t
+	default:
+		n, err := strconv.ParseInt(payload.Value, 10, 64)
+		if err != nil {
+			return Cursor{}, invalid
+		}
+		c.Value = n
+	}
+
+	return c, nil
+}
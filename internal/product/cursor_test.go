@@ -0,0 +1,66 @@
+package product
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 10, 30, 0, 123456789, time.UTC)
+	product := Product{ID: 42, Name: "Smartphone", Price: 1000, Stock: 5, CreatedAt: createdAt}
+
+	tests := []struct {
+		sortBy string
+		order  string
+		want   any
+	}{
+		{"id", "asc", int64(42)},
+		{"name", "asc", "Smartphone"},
+		{"price", "desc", int64(1000)},
+		{"stock", "asc", int64(5)},
+		{"created_at", "desc", createdAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			token := EncodeCursor(newCursor(product, tt.sortBy, tt.order))
+
+			cursor, err := DecodeCursor(token)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.sortBy, cursor.SortBy)
+			assert.Equal(t, tt.order, cursor.Order)
+			assert.Equal(t, uint(42), cursor.ID)
+			assert.Equal(t, tt.want, cursor.Value)
+		})
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := map[string]string{
+		"not base64":          "%%%",
+		"not json":            encode("price:100"),
+		"unknown sort column": encode(`{"s":"password","o":"asc","v":"x","id":1}`),
+		"unknown order":       encode(`{"s":"price","o":"up","v":"100","id":1}`),
+		"missing id":          encode(`{"s":"price","o":"asc","v":"100"}`),
+		"non numeric value":   encode(`{"s":"price","o":"asc","v":"cheap","id":1}`),
+		"malformed time":      encode(`{"s":"created_at","o":"desc","v":"yesterday","id":1}`),
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeCursor(token)
+
+			require.Error(t, err)
+			assert.Equal(t, ErrInvalidCursor, err.Error())
+		})
+	}
+}
@@ -4,22 +4,56 @@ import "mini-e-commerce/internal/dto"
 
 type ProductQuery struct {
 	dto.PaginationQuery
-	SortBy string `form:"sort_by" binding:"omitempty,oneof=id name price stock created_at"`
+	SortBy     string `form:"sort_by" binding:"omitempty,oneof=id name price stock created_at"`
+	Search     string `form:"search" binding:"omitempty,max=100"`
+	CategoryID uint   `form:"category_id" binding:"omitempty,min=1"`
+	After      string `form:"after" binding:"omitempty,max=512"`
 }
 
 type CreateProductRequest struct {
 	Name  string `json:"name" binding:"required" validate:"required"`
 	Price int    `json:"price" binding:"required" validate:"required,gt=0"`
 	Stock int    `json:"stock" binding:"required" validate:"gte=0"`
+
+	CategoryID *uint `json:"category_id" validate:"omitempty,min=1"`
+	// Currency is an ISO 4217 code, the configured base currency when omitted
+	Currency string `json:"currency" binding:"omitempty,iso4217" validate:"omitempty,iso4217"`
 }
 
 type UpdateProductRequest struct {
 	Name  *string `json:"name" validate:"omitempty"`
 	Price *int    `json:"price" validate:"omitempty,gt=0"`
 	Stock *int    `json:"stock" validate:"omitempty,gte=0"`
+
+	CategoryID *uint   `json:"category_id" validate:"omitempty,min=1"`
+	Currency   *string `json:"currency" binding:"omitempty,iso4217" validate:"omitempty,iso4217"`
+}
+
+// AdjustStockRequest moves stock by delta, negative values take stock out
+type AdjustStockRequest struct {
+	Delta int `json:"delta" binding:"required"`
+}
+
+// AddProductImageRequest links an image hosted elsewhere, uploads are sent as multipart instead
+type AddProductImageRequest struct {
+	URL string `json:"url" binding:"required,http_url,max=2048"`
 }
 
 type ProductListResponse struct {
 	Data       []Product              `json:"data"`
 	Pagination dto.PaginationMetadata `json:"pagination"`
 }
+
+// ImportRowResult is the outcome of one data row of an imported file, Line is its line in
+// the file with the header on line 1
+type ImportRowResult struct {
+	Line  int    `json:"line"`
+	ID    uint   `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type ImportResult struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
@@ -1,10 +1,18 @@
 package product
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/category"
 	"mini-e-commerce/internal/logger"
 	"mini-e-commerce/internal/middleware"
 	"mini-e-commerce/internal/response"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -16,30 +24,72 @@ const (
 	ErrMsgFailedToFetch    = "Failed to fetch products"
 	ErrMsgFailedToUpdate   = "Failed to update product"
 	ErrMsgFailedToDelete   = "Failed to delete product"
+	ErrMsgFailedToRestore  = "Failed to restore product"
+	ErrMsgInvalidCategory  = "Invalid category"
+	ErrMsgFailedToAdjust   = "Failed to adjust product stock"
+	ErrMsgFailedToNotify   = "Failed to subscribe to back in stock notification"
+	ErrMsgProductInStock   = "Product is already in stock"
+	ErrMsgInvalidImageID   = "Invalid image ID"
+	ErrMsgInvalidImage     = "Invalid product image"
+	ErrMsgFailedToAddImage = "Failed to add product image"
+	ErrMsgFailedToGetImage = "Failed to fetch product images"
+	ErrMsgFailedToDelImage = "Failed to delete product image"
+	ErrMsgInvalidImport    = "Invalid import file"
+	ErrMsgFailedToImport   = "Failed to import products"
+
+	ErrMsgInvalidUserContext = "Invalid user id in context"
 )
 
+var errMissingUserID = errors.New("missing user_id in context")
+
 type Handler struct {
 	service        Service
 	logger         logger.Logger
+	audit          logger.AuditLogger
 	responseHelper *response.ResponseHelper
+	maxUploadBytes int64
 }
 
-func NewHandler(service Service, log logger.Logger) *Handler {
+func NewHandler(service Service, log logger.Logger, maxUploadBytes int64) *Handler {
 	return &Handler{
 		service:        service,
 		logger:         log,
+		audit:          log.WithAudit(),
 		responseHelper: response.NewResponseHelper(log),
+		maxUploadBytes: maxUploadBytes,
 	}
 }
 
-func (h *Handler) RegisterRoutes(r *gin.RouterGroup, jwtManager auth.JWTManagerInterface, sessionManager auth.SessionManagerInterface, logger *zap.Logger) {
-	authMiddleware := middleware.AuthMiddleware(jwtManager, sessionManager, logger)
+// RegisterRoutes registers the product routes of the given API version. Only the
+// catalog reads differ between versions, v2 answers them with the data envelope.
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc, version string) {
+	adminOnly := middleware.RequireRole(auth.RoleAdmin)
+
+	// The catalog can be browsed without logging in, conditional GETs skip unchanged bodies
+	public := r.Group("/products")
+	etag := middleware.ETag()
+	if version == response.APIVersion2 {
+		public.GET("", etag, h.GetAllProductsV2)
+		public.GET("/:id", etag, h.GetProductByIDV2)
+	} else {
+		public.GET("", etag, h.GetAllProducts)
+		public.GET("/:id", etag, h.GetProductByID)
+	}
+	public.GET("/:id/images", h.GetProductImages)
+
 	group := r.Group("/products", authMiddleware)
-	group.POST("", h.CreateProduct)
-	group.GET("", h.GetAllProducts)
-	group.GET("/:id", h.GetProductByID)
-	group.PATCH("/:id", h.UpdateProduct)
-	group.DELETE("/:id", h.DeleteProduct)
+	group.POST("", adminOnly, h.CreateProduct)
+	group.PUT("/:id", adminOnly, h.ReplaceProduct)
+	group.PATCH("/:id", adminOnly, h.UpdateProduct)
+	group.DELETE("/:id", adminOnly, h.DeleteProduct)
+	group.POST("/:id/restore", adminOnly, h.RestoreProduct)
+	group.POST("/:id/stock", adminOnly, h.AdjustStock)
+	group.POST("/:id/notify-me", h.NotifyMe)
+	group.POST("/:id/images", adminOnly, middleware.BodyLimit(h.maxUploadBytes), h.AddProductImage)
+	group.DELETE("/:id/images/:imageId", adminOnly, h.DeleteProductImage)
+
+	admin := r.Group("/admin/products", authMiddleware, adminOnly)
+	admin.POST("/import", middleware.BodyLimit(MaxImportBytes), h.ImportProducts)
 }
 
 // CreateProduct godoc
@@ -52,17 +102,22 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup, jwtManager auth.JWTManagerI
 // @Success 201 {object} response.SuccessResponse{data=Product}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /products [post]
 func (h *Handler) CreateProduct(c *gin.Context) {
 	var input CreateProductRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
 	product, err := h.service.CreateProduct(c.Request.Context(), input)
 	if err != nil {
+		if err.Error() == category.ErrCategoryNotFound {
+			h.responseHelper.BadRequest(c, ErrMsgInvalidCategory, err.Error())
+			return
+		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToCreate, err.Error())
 		return
 	}
@@ -84,29 +139,64 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 // @Tags Products
 // @Accept  json
 // @Produce  json
-// @Param page query int false "Page number" minimum(1)
-// @Param page_size query int false "Page size" minimum(1) maximum(100)
+// @Param page query int false "Page number" minimum(1) default(1)
+// @Param page_size query int false "Page size, values above 100 are clamped" minimum(1) default(10)
 // @Param order query string false "Sort order" Enums(asc, desc)
 // @Param sort_by query string false "Sort by field" Enums(id, name, price, stock, created_at)
+// @Param search query string false "Case-insensitive search on product name" maxlength(100)
+// @Param category_id query int false "Filter by category ID" minimum(1)
+// @Param after query string false "Opaque cursor from pagination.next_cursor, switches to keyset pagination and ignores page"
+// @Param no_cache query bool false "Skip the cached value, ignored in release mode"
 // @Success 200 {object} response.SuccessResponse{data=ProductListResponse}
 // @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /products [get]
 func (h *Handler) GetAllProducts(c *gin.Context) {
+	result, ok := h.listProducts(c)
+	if !ok {
+		return
+	}
+	h.responseHelper.SuccessPaginated(c, "List product retrieved successfully", result.Data, result.Pagination)
+
+}
+
+// GetAllProductsV2 godoc
+// @Summary List products (v2)
+// @Description Same listing as v1, wrapped in the v2 data envelope with pagination under meta
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} response.DataResponse{data=[]Product}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /v2/products [get]
+func (h *Handler) GetAllProductsV2(c *gin.Context) {
+	result, ok := h.listProducts(c)
+	if !ok {
+		return
+	}
+	h.responseHelper.DataPaginated(c, result.Data, result.Pagination)
+}
+
+// listProducts runs the listing query shared by every version, it writes the error
+// response itself and reports false on failure
+func (h *Handler) listProducts(c *gin.Context) (*ProductListResponse, bool) {
 	var query ProductQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
-		return
+		h.responseHelper.ValidationError(c, err)
+		return nil, false
 	}
 
-	result, err := h.service.GetAllProductsWithQuery(c.Request.Context(), query)
+	result, err := h.service.GetAllProductsWithQuery(readContext(c), query)
 	if err != nil {
+		if err.Error() == ErrInvalidCursor {
+			h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+			return nil, false
+		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
-		return
+		return nil, false
 	}
-	h.responseHelper.SuccessPaginated(c, "List product retrieved successfully", result.Data, result.Pagination)
-
+	return result, true
 }
 
 // GetProductByID godoc
@@ -116,27 +206,71 @@ func (h *Handler) GetAllProducts(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   id path string true "Product ID"
+// @Param no_cache query bool false "Skip the cached value, ignored in release mode"
 // @Success 200 {object} response.SuccessResponse{data=Product}
 // @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /products/{id} [get]
 func (h *Handler) GetProductByID(c *gin.Context) {
+	product, ok := h.getProduct(c)
+	if !ok {
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Product retrieved successfully", product)
+
+}
+
+// GetProductByIDV2 godoc
+// @Summary Get single product (v2)
+// @Description Same as v1, wrapped in the v2 data envelope
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param no_cache query bool false "Skip the cached value, ignored in release mode"
+// @Success 200 {object} response.DataResponse{data=Product}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /v2/products/{id} [get]
+func (h *Handler) GetProductByIDV2(c *gin.Context) {
+	product, ok := h.getProduct(c)
+	if !ok {
+		return
+	}
+
+	h.responseHelper.Data(c, http.StatusOK, product)
+}
+
+func (h *Handler) getProduct(c *gin.Context) (*Product, bool) {
 	id, err := ParseIDFromString(c.Param("id"))
 	if err != nil {
 		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
-		return
+		return nil, false
 	}
 
-	product, err := h.service.GetProductByID(c.Request.Context(), id)
+	product, err := h.service.GetProductByID(readContext(c), id)
 	if err != nil {
 		h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
-		return
+		return nil, false
 	}
+	return product, true
+}
 
-	h.responseHelper.SuccessOK(c, "Product retrieved successfully", product)
-
+// readContext returns the request context for a catalog read. A client verifying stale
+// data can ask for a fresh read with no_cache=true or Cache-Control: no-cache, the cache
+// is then skipped but still refreshed. The bypass is ignored in release mode so it can
+// not be used to push load past Redis onto the database in production.
+func readContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if gin.Mode() == gin.ReleaseMode {
+		return ctx
+	}
+	if c.Query("no_cache") == "true" || strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache") {
+		return cache.WithBypass(ctx)
+	}
+	return ctx
 }
 
 // UpdateProduct godoc
@@ -150,6 +284,7 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 // @Success 200 {object} response.SuccessResponse{data=Product}
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /products/{id} [patch]
@@ -162,12 +297,16 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 
 	var input UpdateProductRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.responseHelper.BadRequest(c, response.ErrCodeValidationError, err.Error())
+		h.responseHelper.ValidationError(c, err)
 		return
 	}
 
 	product, err := h.service.UpdateProduct(c.Request.Context(), id, input)
 	if err != nil {
+		if err.Error() == category.ErrCategoryNotFound {
+			h.responseHelper.BadRequest(c, ErrMsgInvalidCategory, err.Error())
+			return
+		}
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToUpdate, err.Error())
 		return
 	}
@@ -183,6 +322,165 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	h.responseHelper.SuccessOK(c, "Product updated successfully", product)
 }
 
+// ReplaceProduct godoc
+// @Summary Replace exist product
+// @Description Replace every field of a product, name, price and stock are required and an omitted category is removed
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   request body CreateProductRequest true "Product request body"
+// @Success 200 {object} response.SuccessResponse{data=Product}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id} [put]
+func (h *Handler) ReplaceProduct(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	var input CreateProductRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	product, err := h.service.ReplaceProduct(c.Request.Context(), id, input)
+	if err != nil {
+		if err.Error() == ErrProductNotFound {
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+			return
+		}
+		if err.Error() == category.ErrCategoryNotFound {
+			h.responseHelper.BadRequest(c, ErrMsgInvalidCategory, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToUpdate, err.Error())
+		return
+	}
+
+	ctxLogger := h.logger.WithContext(c)
+	ctxLogger.Info("Product inventory replaced",
+		zap.Uint("product_id", product.ID),
+		zap.String("product_name", product.Name),
+		zap.Int("new_price", product.Price),
+		zap.Int("new_stock", product.Stock),
+	)
+
+	h.responseHelper.SuccessOK(c, "Product replaced successfully", product)
+}
+
+// AdjustStock godoc
+// @Summary Adjust product stock
+// @Description Add to or take from the stock of a product without touching its other fields
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   request body AdjustStockRequest true "Stock adjustment"
+// @Success 200 {object} response.SuccessResponse{data=Product}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/stock [post]
+func (h *Handler) AdjustStock(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	var input AdjustStockRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	target := fmt.Sprintf("product:%d", id)
+	if err := h.service.UpdateStock(c.Request.Context(), id, input.Delta); err != nil {
+		h.audit.Record(c, logger.AuditActionStockAdjust, target, logger.AuditResultFailure,
+			zap.Int("delta", input.Delta), zap.Error(err))
+		switch err.Error() {
+		case ErrProductNotFound:
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+		case ErrInsufficientStock:
+			h.responseHelper.Error(c, http.StatusBadRequest, ErrMsgFailedToAdjust, response.ErrCodeInsufficientStock, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToAdjust, err.Error())
+		}
+		return
+	}
+	h.audit.Record(c, logger.AuditActionStockAdjust, target, logger.AuditResultSuccess, zap.Int("delta", input.Delta))
+
+	product, err := h.service.GetProductByID(c.Request.Context(), id)
+	if err != nil {
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+
+	ctxLogger := h.logger.WithContext(c)
+	ctxLogger.Info("Product stock adjusted",
+		zap.Uint("product_id", id),
+		zap.Int("delta", input.Delta),
+		zap.Int("new_stock", product.Stock),
+	)
+
+	h.responseHelper.SuccessOK(c, "Product stock adjusted successfully", product)
+}
+
+// NotifyMe godoc
+// @Summary Subscribe to back in stock notification
+// @Description Email the current user once the sold out product is back in stock
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Success 201 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/notify-me [post]
+func (h *Handler) NotifyMe(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	if err := h.service.SubscribeToRestock(c.Request.Context(), id, userID); err != nil {
+		switch err.Error() {
+		case ErrProductNotFound:
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+		case ErrProductInStock:
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgProductInStock, response.ErrCodeProductInStock, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToNotify, err.Error())
+		}
+		return
+	}
+
+	h.responseHelper.SuccessCreated(c, "Subscribed to back in stock notification", nil)
+}
+
 // DeleteProduct godoc
 // @Summary Delete exist product
 // @Description Delete exist single product
@@ -193,6 +491,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 // @Success 200 {object} response.SuccessResponse
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /products/{id} [delete]
@@ -203,10 +502,13 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
+	target := fmt.Sprintf("product:%d", id)
 	if err := h.service.DeleteProduct(c.Request.Context(), id); err != nil {
+		h.audit.Record(c, logger.AuditActionProductDelete, target, logger.AuditResultFailure, zap.Error(err))
 		h.responseHelper.InternalServerError(c, ErrMsgFailedToDelete, err.Error())
 		return
 	}
+	h.audit.Record(c, logger.AuditActionProductDelete, target, logger.AuditResultSuccess)
 
 	ctxLogger := h.logger.WithContext(c)
 	ctxLogger.Info("Product removed from inventory",
@@ -215,3 +517,267 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 
 	h.responseHelper.SuccessOK(c, "Product deleted successfully", nil)
 }
+
+// RestoreProduct godoc
+// @Summary Restore deleted product
+// @Description Restore a soft-deleted product so it is listed again
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Success 200 {object} response.SuccessResponse{data=Product}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/restore [post]
+func (h *Handler) RestoreProduct(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	product, err := h.service.RestoreProduct(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == ErrProductNotFound {
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToRestore, err.Error())
+		return
+	}
+
+	ctxLogger := h.logger.WithContext(c)
+	ctxLogger.Info("Product restored to inventory",
+		zap.Uint("product_id", product.ID),
+	)
+
+	h.responseHelper.SuccessOK(c, "Product restored successfully", product)
+}
+
+// AddProductImage godoc
+// @Summary Add product image
+// @Description Append an image to the product, either a JSON body with the url of an image hosted elsewhere or a multipart upload in the image field. Uploads must be JPEG, PNG, GIF or WebP.
+// @Tags Products
+// @Accept  json,mpfd
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   request body AddProductImageRequest false "Image hosted elsewhere"
+// @Param   image formData file false "Image file"
+// @Success 201 {object} response.SuccessResponse{data=ProductImage}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 413 {object} response.ErrorResponse
+// @Failure 415 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/images [post]
+func (h *Handler) AddProductImage(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	var image *ProductImage
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, formErr := c.FormFile("image")
+		if formErr != nil {
+			if h.bodyTooLarge(c, formErr) {
+				return
+			}
+			h.responseHelper.BadRequest(c, ErrMsgInvalidImage, formErr.Error())
+			return
+		}
+		f, openErr := file.Open()
+		if openErr != nil {
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToAddImage, openErr.Error())
+			return
+		}
+		defer f.Close()
+		image, err = h.service.UploadImage(c.Request.Context(), id, f)
+	} else {
+		var input AddProductImageRequest
+		if err := c.ShouldBindJSON(&input); err != nil {
+			h.responseHelper.ValidationError(c, err)
+			return
+		}
+		image, err = h.service.AddImageURL(c.Request.Context(), id, input.URL)
+	}
+	if err != nil {
+		switch err.Error() {
+		case ErrProductNotFound:
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+		case ErrUnsupportedImage:
+			h.responseHelper.Error(c, http.StatusUnsupportedMediaType, ErrMsgInvalidImage, response.ErrCodeUnsupportedMediaType, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToAddImage, err.Error())
+		}
+		return
+	}
+
+	ctxLogger := h.logger.WithContext(c)
+	ctxLogger.Info("Product image added",
+		zap.Uint("product_id", id),
+		zap.Uint("image_id", image.ID),
+	)
+
+	h.responseHelper.SuccessCreated(c, "Product image added successfully", image)
+}
+
+// ImportProducts godoc
+// @Summary Import products from CSV
+// @Description Create products from a CSV file with a name,price,stock header, sent as a multipart upload in the file field or as a text/csv body (admin only). A malformed file is rejected as a whole. Rows failing validation are reported and skipped, the others are created in one transaction. At most 1000 rows per file.
+// @Tags Products
+// @Accept  mpfd,plain
+// @Produce  json
+// @Param   file formData file false "CSV file"
+// @Success 200 {object} response.SuccessResponse{data=ImportResult}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 413 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/products/import [post]
+func (h *Handler) ImportProducts(c *gin.Context) {
+	var file io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			if !h.bodyTooLarge(c, err) {
+				h.responseHelper.BadRequest(c, ErrMsgInvalidImport, err.Error())
+			}
+			return
+		}
+		f, err := header.Open()
+		if err != nil {
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToImport, err.Error())
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	result, err := h.service.ImportProducts(c.Request.Context(), file)
+	if err != nil {
+		var fileErr *ImportFileError
+		if errors.As(err, &fileErr) {
+			h.responseHelper.BadRequest(c, ErrMsgInvalidImport, err.Error())
+			return
+		}
+		if !h.bodyTooLarge(c, err) {
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToImport, err.Error())
+		}
+		return
+	}
+
+	ctxLogger := h.logger.WithContext(c)
+	ctxLogger.Info("Products imported",
+		zap.Int("created", result.Created),
+		zap.Int("failed", result.Failed),
+	)
+
+	h.responseHelper.SuccessOK(c, "Products imported successfully", result)
+}
+
+// GetProductImages godoc
+// @Summary List product images
+// @Description List the images of a product ordered by position
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Success 200 {object} response.SuccessResponse{data=[]ProductImage}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/images [get]
+func (h *Handler) GetProductImages(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	images, err := h.service.GetImages(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == ErrProductNotFound {
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToGetImage, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Product images retrieved successfully", images)
+}
+
+// DeleteProductImage godoc
+// @Summary Delete product image
+// @Description Remove an image from the product, uploaded files are deleted from storage
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   imageId path string true "Image ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/images/{imageId} [delete]
+func (h *Handler) DeleteProductImage(c *gin.Context) {
+	id, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+	imageID, err := ParseIDFromString(c.Param("imageId"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidImageID, err.Error())
+		return
+	}
+
+	if err := h.service.DeleteImage(c.Request.Context(), id, imageID); err != nil {
+		if err.Error() == ErrImageNotFound {
+			h.responseHelper.NotFound(c, response.ErrCodeDataNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToDelImage, err.Error())
+		return
+	}
+
+	ctxLogger := h.logger.WithContext(c)
+	ctxLogger.Info("Product image deleted",
+		zap.Uint("product_id", id),
+		zap.Uint("image_id", imageID),
+	)
+
+	h.responseHelper.SuccessOK(c, "Product image deleted successfully", nil)
+}
+
+func (h *Handler) getUserIDFromContext(c *gin.Context) (uint, error) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return 0, errMissingUserID
+	}
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		return 0, errors.New("invalid user_id type in context")
+	}
+	return userIDUint, nil
+}
+
+// bodyTooLarge answers 413 when err comes from reading past middleware.BodyLimit
+func (h *Handler) bodyTooLarge(c *gin.Context, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return false
+	}
+	h.responseHelper.Error(c, http.StatusRequestEntityTooLarge, "Request body too large", response.ErrCodePayloadTooLarge, err.Error())
+	return true
+}
@@ -0,0 +1,568 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/middleware"
+	"mini-e-commerce/internal/response"
+	"mini-e-commerce/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// testMaxUploadBytes is the image upload limit of handlers built in tests
+const testMaxUploadBytes = 1 << 20
+
+// stubService records update calls, other methods are left unimplemented
+type stubService struct {
+	Service
+	replaced *CreateProductRequest
+	updated  *UpdateProductRequest
+	listed   *ProductQuery
+	stock    int
+}
+
+func (s *stubService) ReplaceProduct(ctx context.Context, id uint, input CreateProductRequest) (*Product, error) {
+	s.replaced = &input
+	return &Product{ID: id, Name: input.Name, Price: input.Price, Stock: input.Stock}, nil
+}
+
+func (s *stubService) UpdateProduct(ctx context.Context, id uint, input UpdateProductRequest) (*Product, error) {
+	s.updated = &input
+	return &Product{ID: id, Name: "Smartphone", Price: *input.Price, Stock: 5}, nil
+}
+
+func (s *stubService) UpdateStock(ctx context.Context, id uint, stockDelta int) error {
+	if s.stock+stockDelta < 0 {
+		return errors.New(ErrInsufficientStock)
+	}
+	s.stock += stockDelta
+	return nil
+}
+
+func (s *stubService) GetProductByID(ctx context.Context, id uint) (*Product, error) {
+	return &Product{ID: id, Name: "Smartphone", Price: 1000, Stock: s.stock}, nil
+}
+
+func (s *stubService) GetAllProductsWithQuery(ctx context.Context, query ProductQuery) (*ProductListResponse, error) {
+	s.listed = &query
+	return &ProductListResponse{Data: []Product{{ID: 1, Name: "Smartphone", Price: 1000, Stock: s.stock}}}, nil
+}
+
+func (s *stubService) SubscribeToRestock(ctx context.Context, productID, userID uint) error {
+	if s.stock > 0 {
+		return errors.New(ErrProductInStock)
+	}
+	return nil
+}
+
+func setupProductRouter(service Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewHandler(service, setupLogger(), testMaxUploadBytes)
+
+	r := gin.New()
+	r.PUT("/products/:id", handler.ReplaceProduct)
+	r.PATCH("/products/:id", handler.UpdateProduct)
+	r.POST("/products/:id/stock", handler.AdjustStock)
+	return r
+}
+
+func TestHandler_ReplaceProduct(t *testing.T) {
+	send := func(r *gin.Engine, method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/products/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject a PUT body missing required fields", func(t *testing.T) {
+		service := &stubService{}
+
+		w := send(setupProductRouter(service), http.MethodPut, `{"price":1200}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"name"`)
+		assert.Nil(t, service.replaced)
+	})
+
+	t.Run("should replace the product when PUT has every field", func(t *testing.T) {
+		service := &stubService{}
+
+		w := send(setupProductRouter(service), http.MethodPut, `{"name":"Smartphone X","price":1200,"stock":3}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, &CreateProductRequest{Name: "Smartphone X", Price: 1200, Stock: 3}, service.replaced)
+	})
+
+	t.Run("should reject an invalid currency code", func(t *testing.T) {
+		service := &stubService{}
+
+		w := send(setupProductRouter(service), http.MethodPut, `{"name":"Smartphone X","price":1200,"stock":3,"currency":"XYZ"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"currency"`)
+		assert.Nil(t, service.replaced)
+	})
+
+	t.Run("should accept the same partial body with PATCH", func(t *testing.T) {
+		service := &stubService{}
+
+		w := send(setupProductRouter(service), http.MethodPatch, `{"price":1200}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		if assert.NotNil(t, service.updated) {
+			assert.Nil(t, service.updated.Name)
+			assert.Equal(t, 1200, *service.updated.Price)
+		}
+	})
+}
+
+func TestHandler_AdjustStock(t *testing.T) {
+	send := func(r *gin.Engine, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products/1/stock", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should add stock for a positive delta", func(t *testing.T) {
+		service := &stubService{stock: 5}
+
+		w := send(setupProductRouter(service), `{"delta":3}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 8, service.stock)
+		assert.Contains(t, w.Body.String(), `"stock":8`)
+	})
+
+	t.Run("should take stock for a negative delta within stock", func(t *testing.T) {
+		service := &stubService{stock: 5}
+
+		w := send(setupProductRouter(service), `{"delta":-5}`)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 0, service.stock)
+	})
+
+	t.Run("should reject a delta that would make stock negative", func(t *testing.T) {
+		service := &stubService{stock: 5}
+
+		w := send(setupProductRouter(service), `{"delta":-6}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodeInsufficientStock)
+		assert.Equal(t, 5, service.stock)
+	})
+}
+
+func TestHandler_NotifyMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	send := func(service Service, withUser bool) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.POST("/products/:id/notify-me", func(c *gin.Context) {
+			if withUser {
+				c.Set("user_id", uint(7))
+			}
+		}, NewHandler(service, setupLogger(), testMaxUploadBytes).NotifyMe)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products/1/notify-me", nil))
+		return w
+	}
+
+	t.Run("should subscribe to a sold out product", func(t *testing.T) {
+		w := send(&stubService{stock: 0}, true)
+
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("should reject a product that is in stock", func(t *testing.T) {
+		w := send(&stubService{stock: 3}, true)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodeProductInStock)
+	})
+
+	t.Run("should require a user", func(t *testing.T) {
+		w := send(&stubService{}, false)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestHandler_Versions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	handler := NewHandler(&stubService{stock: 5}, setupLogger(), testMaxUploadBytes)
+	noAuth := func(c *gin.Context) { c.Next() }
+	handler.RegisterRoutes(r.Group("/api"), noAuth, response.APIVersion1)
+	handler.RegisterRoutes(r.Group("/api/v2"), noAuth, response.APIVersion2)
+
+	get := func(t *testing.T, path string) map[string]json.RawMessage {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var body map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	t.Run("v1 listing keeps the flat envelope", func(t *testing.T) {
+		body := get(t, "/api/products")
+
+		assert.Contains(t, body, "success")
+		assert.Contains(t, body, "message")
+		assert.Contains(t, body, "pagination")
+		assert.Contains(t, body, "links")
+		assert.NotContains(t, body, "meta")
+	})
+
+	t.Run("v2 listing nests pagination under meta", func(t *testing.T) {
+		body := get(t, "/api/v2/products")
+
+		assert.NotContains(t, body, "success")
+		assert.NotContains(t, body, "message")
+		assert.NotContains(t, body, "pagination")
+		require.Contains(t, body, "meta")
+		assert.Contains(t, string(body["data"]), "Smartphone")
+
+		var meta map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(body["meta"], &meta))
+		assert.Contains(t, meta, "pagination")
+		assert.Contains(t, meta, "links")
+	})
+
+	t.Run("detail differs only in the envelope", func(t *testing.T) {
+		v1 := get(t, "/api/products/1")
+		v2 := get(t, "/api/v2/products/1")
+
+		assert.Contains(t, v1, "success")
+		assert.NotContains(t, v2, "success")
+		assert.JSONEq(t, string(v1["data"]), string(v2["data"]))
+	})
+}
+
+func TestHandler_PublicCatalog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	requireToken := func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+	NewHandler(&stubService{stock: 5}, setupLogger(), testMaxUploadBytes).RegisterRoutes(r.Group(""), requireToken, response.APIVersion1)
+
+	t.Run("should list products without a token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "Smartphone")
+	})
+
+	t.Run("should get a single product without a token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("should still require a token to create", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"Laptop","price":5000,"stock":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestHandler_GetAllProducts_Pagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*gin.Engine, *stubService) {
+		service := &stubService{stock: 5}
+		r := gin.New()
+		r.GET("/products", NewHandler(service, setupLogger(), testMaxUploadBytes).GetAllProducts)
+		return r, service
+	}
+
+	t.Run("should default page and page size when omitted", func(t *testing.T) {
+		r, service := setup()
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NotNil(t, service.listed)
+		assert.Equal(t, 1, service.listed.Page)
+		assert.Equal(t, 10, service.listed.PageSize)
+	})
+
+	t.Run("should pass a page size above the maximum through for clamping", func(t *testing.T) {
+		r, service := setup()
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?page_size=500", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 500, service.listed.PageSize)
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		field string
+	}{
+		{name: "page zero", query: "page=0", field: "page"},
+		{name: "negative page size", query: "page_size=-5", field: "page_size"},
+	}
+	for _, tt := range tests {
+		t.Run("should reject "+tt.name, func(t *testing.T) {
+			r, service := setup()
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?"+tt.query, nil))
+
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.Nil(t, service.listed)
+
+			var body struct {
+				Error response.ErrorInfo `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, response.ErrCodeValidationError, body.Error.Code)
+			assert.Contains(t, body.Error.Fields, tt.field)
+		})
+	}
+}
+
+func TestHandler_ProductImages(t *testing.T) {
+	// pngHeader is enough for content sniffing to report image/png
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	setup := func(t *testing.T) (*gin.Engine, *MockRepository, string) {
+		gin.SetMode(gin.TestMode)
+		redisCache, _ := setupTestCache(t)
+		dir := t.TempDir()
+
+		mockRepo := new(MockRepository)
+		mockRepo.On("FindByID", mock.Anything, uint(1)).Return(Product{ID: 1, Name: "Smartphone"}, nil).Maybe()
+		mockRepo.On("FindByID", mock.Anything, uint(2)).Return(Product{}, gorm.ErrRecordNotFound).Maybe()
+
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, storage.NewLocalStore(dir, "/uploads"), setupLogger())
+		handler := NewHandler(service, setupLogger(), testMaxUploadBytes)
+
+		r := gin.New()
+		r.GET("/products/:id/images", handler.GetProductImages)
+		r.POST("/products/:id/images", handler.AddProductImage)
+		r.DELETE("/products/:id/images/:imageId", handler.DeleteProductImage)
+		return r, mockRepo, dir
+	}
+
+	upload := func(r *gin.Engine, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("image", "photo")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products/1/images", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should add an image by url", func(t *testing.T) {
+		r, mockRepo, _ := setup(t)
+		mockRepo.On("CreateImage", mock.Anything, mock.MatchedBy(func(image *ProductImage) bool {
+			return image.ProductID == 1 && image.URL == "https://cdn.example.com/a.png" && image.StorageKey == ""
+		})).Return(nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products/1/images", strings.NewReader(`{"url":"https://cdn.example.com/a.png"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"url":"https://cdn.example.com/a.png"`)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a url that is not http", func(t *testing.T) {
+		r, mockRepo, _ := setup(t)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products/1/images", strings.NewReader(`{"url":"javascript:alert(1)"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRepo.AssertNotCalled(t, "CreateImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should store an uploaded image", func(t *testing.T) {
+		r, mockRepo, dir := setup(t)
+		var created *ProductImage
+		mockRepo.On("CreateImage", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			created = args.Get(1).(*ProductImage)
+		}).Return(nil)
+
+		w := upload(r, pngHeader)
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NotNil(t, created)
+		assert.True(t, strings.HasPrefix(created.StorageKey, "products/1/"))
+		assert.True(t, strings.HasSuffix(created.StorageKey, ".png"))
+		assert.Equal(t, "/uploads/"+created.StorageKey, created.URL)
+
+		stored, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(created.StorageKey)))
+		require.NoError(t, err)
+		assert.Equal(t, pngHeader, stored)
+	})
+
+	t.Run("should reject an upload that is not an image", func(t *testing.T) {
+		r, mockRepo, dir := setup(t)
+
+		w := upload(r, []byte("just some text"))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodeUnsupportedMediaType)
+		mockRepo.AssertNotCalled(t, "CreateImage", mock.Anything, mock.Anything)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should return 404 when adding to a missing product", func(t *testing.T) {
+		r, _, _ := setup(t)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products/2/images", strings.NewReader(`{"url":"https://cdn.example.com/a.png"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should list images in position order", func(t *testing.T) {
+		r, mockRepo, _ := setup(t)
+		mockRepo.On("FindImages", mock.Anything, uint(1)).Return([]ProductImage{
+			{ID: 5, ProductID: 1, URL: "/uploads/front.png", Position: 0},
+			{ID: 3, ProductID: 1, URL: "/uploads/back.png", Position: 1},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/1/images", nil))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Data []ProductImage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Data, 2)
+		assert.Equal(t, uint(5), body.Data[0].ID)
+		assert.Equal(t, uint(3), body.Data[1].ID)
+		assert.NotContains(t, w.Body.String(), "storage_key")
+	})
+
+	t.Run("should delete an uploaded image and its file", func(t *testing.T) {
+		r, mockRepo, dir := setup(t)
+		key := "products/1/photo.png"
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "products", "1"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(key)), pngHeader, 0o644))
+		mockRepo.On("DeleteImage", mock.Anything, uint(1), uint(5)).
+			Return(ProductImage{ID: 5, ProductID: 1, URL: "/uploads/" + key, StorageKey: key}, nil)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/products/1/images/5", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should return 404 when deleting a missing image", func(t *testing.T) {
+		r, mockRepo, _ := setup(t)
+		mockRepo.On("DeleteImage", mock.Anything, uint(1), uint(9)).Return(ProductImage{}, gorm.ErrRecordNotFound)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/products/1/images/9", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandler_AddProductImage_BodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	redisCache, _ := setupTestCache(t)
+	mockRepo := new(MockRepository)
+	mockRepo.On("FindByID", mock.Anything, uint(1)).Return(Product{ID: 1, Name: "Smartphone"}, nil)
+	mockRepo.On("CreateImage", mock.Anything, mock.Anything).Return(nil)
+	service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, storage.NewLocalStore(t.TempDir(), "/uploads"), setupLogger())
+	handler := NewHandler(service, setupLogger(), 1024)
+
+	r := gin.New()
+	r.Use(middleware.BodyLimit(64))
+	asAdmin := func(c *gin.Context) {
+		c.Set("role", auth.RoleAdmin)
+		c.Next()
+	}
+	handler.RegisterRoutes(r.Group(""), asAdmin, response.APIVersion1)
+
+	upload := func(size int) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("image", "photo")
+		require.NoError(t, err)
+		_, err = part.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+		require.NoError(t, err)
+		_, err = part.Write(make([]byte, size))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products/1/images", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should accept an image over the global limit", func(t *testing.T) {
+		w := upload(256)
+
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	t.Run("should return 413 for an image over the upload limit", func(t *testing.T) {
+		w := upload(2048)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), response.ErrCodePayloadTooLarge)
+		mockRepo.AssertNumberOfCalls(t, "CreateImage", 1)
+	})
+}
@@ -0,0 +1,177 @@
+package product
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mini-e-commerce/internal/tracing"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// MaxImportRows caps the data rows of one import file
+const MaxImportRows = 1000
+
+// MaxImportBytes is the body limit of the import route, above the global one
+const MaxImportBytes = 4 << 20
+
+// maxProductNameLength matches the VARCHAR(255) name column
+const maxProductNameLength = 255
+
+// importColumns are the columns an import file must have, in any order
+var importColumns = []string{"name", "price", "stock"}
+
+// ImportFileError rejects a whole import file, nothing is inserted. Line is 0 when the
+// problem is not tied to one line.
+type ImportFileError struct {
+	Line   int
+	Reason string
+}
+
+func (e *ImportFileError) Error() string {
+	if e.Line == 0 {
+		return "invalid import file: " + e.Reason
+	}
+	return fmt.Sprintf("invalid import file: line %d: %s", e.Line, e.Reason)
+}
+
+// ImportProducts creates products from a CSV file with a name,price,stock header. The whole
+// file is parsed before anything is written, a malformed file is rejected with an
+// *ImportFileError. Rows that parse but fail validation are reported and skipped, the
+// valid ones are inserted in a single transaction.
+func (s *service) ImportProducts(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	ctx, span := tracing.Start(ctx, "product.ImportProducts")
+	defer span.End()
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &ImportFileError{Reason: "file is empty"}
+		}
+		return nil, importParseError(err)
+	}
+	columns, err := importColumnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	var products []Product
+	var created []int // index into result.Rows of each product
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, importParseError(err)
+		}
+		if len(result.Rows) == MaxImportRows {
+			return nil, &ImportFileError{Reason: fmt.Sprintf("more than %d rows", MaxImportRows)}
+		}
+
+		line, _ := reader.FieldPos(0)
+		product, rowErr := s.parseImportRow(record, columns)
+		if rowErr != nil {
+			result.Rows = append(result.Rows, ImportRowResult{Line: line, Error: rowErr.Error()})
+			result.Failed++
+			continue
+		}
+		created = append(created, len(result.Rows))
+		result.Rows = append(result.Rows, ImportRowResult{Line: line})
+		products = append(products, product)
+	}
+	if len(result.Rows) == 0 {
+		return nil, &ImportFileError{Reason: "file has no rows"}
+	}
+
+	if len(products) > 0 {
+		if err := s.repo.CreateMany(ctx, products); err != nil {
+			s.logger.Error("Failed to import products", zap.Error(err), zap.Int("rows", len(products)))
+			return nil, err
+		}
+		missingKeys := make([]string, len(products))
+		for i, product := range products {
+			result.Rows[created[i]].ID = product.ID
+			missingKeys[i] = fmt.Sprintf(CacheKeyMissing, product.ID)
+		}
+		_ = s.cache.Delete(ctx, missingKeys...)
+		s.invalidateProductListCache(ctx)
+		for _, product := range products {
+			s.stockWritten(ctx, product, 0)
+		}
+	}
+	result.Created = len(products)
+
+	return result, nil
+}
+
+// importColumnIndexes maps each import column to its position in header
+func importColumnIndexes(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// spreadsheets often save CSV with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := columns[name]; dup {
+			return nil, &ImportFileError{Line: 1, Reason: fmt.Sprintf("duplicate column %q", name)}
+		}
+		columns[name] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, &ImportFileError{Line: 1, Reason: fmt.Sprintf("missing column %q", name)}
+		}
+	}
+	return columns, nil
+}
+
+func importParseError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &ImportFileError{Line: parseErr.Line, Reason: parseErr.Err.Error()}
+	}
+	// a failed read, e.g. past the body limit, is not a problem with the file itself
+	return err
+}
+
+// parseImportRow turns a record into a product with the same rules as CreateProduct. Values
+// the columns cannot hold are rejected here too, so one bad row never fails the whole insert.
+func (s *service) parseImportRow(record []string, columns map[string]int) (Product, error) {
+	name := strings.TrimSpace(record[columns["name"]])
+	if name == "" {
+		return Product{}, errors.New("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxProductNameLength {
+		return Product{}, fmt.Errorf("name must be at most %d characters", maxProductNameLength)
+	}
+	price, err := strconv.Atoi(strings.TrimSpace(record[columns["price"]]))
+	if err != nil || price <= 0 {
+		return Product{}, errors.New("price must be a positive integer")
+	}
+	if price > math.MaxInt32 {
+		return Product{}, fmt.Errorf("price must be at most %d", math.MaxInt32)
+	}
+	stock, err := strconv.Atoi(strings.TrimSpace(record[columns["stock"]]))
+	if err != nil || stock < 0 {
+		return Product{}, errors.New("stock must be a non-negative integer")
+	}
+	if stock > math.MaxInt32 {
+		return Product{}, fmt.Errorf("stock must be at most %d", math.MaxInt32)
+	}
+
+	return Product{
+		Name:     name,
+		Price:    price,
+		Currency: s.currencyOrBase(""),
+		Stock:    stock,
+	}, nil
+}
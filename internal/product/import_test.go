@@ -0,0 +1,258 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mini-e-commerce/internal/auth"
+	"mini-e-commerce/internal/middleware"
+	"mini-e-commerce/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// assignIDs mimics the insert filling in ids, starting at first
+func assignIDs(first uint) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		products := args.Get(1).([]Product)
+		for i := range products {
+			products[i].ID = first + uint(i)
+		}
+	}
+}
+
+func TestService_ImportProducts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should create every row of a clean file", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("CreateMany", ctx, []Product{
+			{Name: "Smartphone", Price: 1000, Currency: "IDR", Stock: 5},
+			{Name: "Laptop, 14 inch", Price: 5000, Currency: "IDR", Stock: 0},
+		}).Run(assignIDs(10)).Return(nil)
+		// only the product created with stock goes through the back in stock check
+		mockRepo.On("PopStockSubscribers", ctx, uint(10)).Return([]StockSubscriber(nil), nil).Once()
+
+		result, err := service.ImportProducts(ctx, strings.NewReader("name,price,stock\nSmartphone,1000,5\n\"Laptop, 14 inch\",5000,0\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, 0, result.Failed)
+		assert.Equal(t, []ImportRowResult{{Line: 2, ID: 10}, {Line: 3, ID: 11}}, result.Rows)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should report an invalid row and create the others", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("CreateMany", ctx, mock.MatchedBy(func(products []Product) bool {
+			return len(products) == 2 && products[0].Name == "Smartphone" && products[1].Name == "Tablet"
+		})).Run(assignIDs(10)).Return(nil)
+		mockRepo.On("PopStockSubscribers", ctx, mock.Anything).Return([]StockSubscriber(nil), nil)
+
+		result, err := service.ImportProducts(ctx, strings.NewReader("stock,name,price\n5,Smartphone,1000\n3,Laptop,free\n1,Tablet,2000\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, []ImportRowResult{
+			{Line: 2, ID: 10},
+			{Line: 3, Error: "price must be a positive integer"},
+			{Line: 4, ID: 11},
+		}, result.Rows)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should report values the columns cannot hold as row errors", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("CreateMany", ctx, mock.MatchedBy(func(products []Product) bool {
+			return len(products) == 1 && products[0].Name == "Tablet"
+		})).Run(assignIDs(10)).Return(nil)
+		mockRepo.On("PopStockSubscribers", ctx, mock.Anything).Return([]StockSubscriber(nil), nil)
+
+		file := "name,price,stock\n" +
+			strings.Repeat("x", 256) + ",1000,1\n" +
+			"Laptop,3000000000,1\n" +
+			"Phone,1000,3000000000\n" +
+			"Tablet,2000,1\n"
+		result, err := service.ImportProducts(ctx, strings.NewReader(file))
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 3, result.Failed)
+		assert.Equal(t, []ImportRowResult{
+			{Line: 2, Error: "name must be at most 255 characters"},
+			{Line: 3, Error: "price must be at most 2147483647"},
+			{Line: 4, Error: "stock must be at most 2147483647"},
+			{Line: 5, ID: 10},
+		}, result.Rows)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a malformed file without inserting", func(t *testing.T) {
+		tests := []struct {
+			name string
+			file string
+			line int
+		}{
+			{name: "empty", file: ""},
+			{name: "header only", file: "name,price,stock\n"},
+			{name: "missing column", file: "name,price\nSmartphone,1000\n", line: 1},
+			{name: "short row after valid ones", file: "name,price,stock\nSmartphone,1000,5\nLaptop,5000\n", line: 3},
+			{name: "bad quoting", file: "name,price,stock\n\"Smartphone,1000,5\n", line: 2},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := new(MockRepository)
+				redisCache, _ := setupTestCache(t)
+				service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+				result, err := service.ImportProducts(ctx, strings.NewReader(tt.file))
+
+				assert.Nil(t, result)
+				var fileErr *ImportFileError
+				require.True(t, errors.As(err, &fileErr), "got %v", err)
+				assert.Equal(t, tt.line, fileErr.Line)
+				mockRepo.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestHandler_ImportProducts(t *testing.T) {
+	setup := func(t *testing.T) (*gin.Engine, *MockRepository) {
+		gin.SetMode(gin.TestMode)
+		redisCache, _ := setupTestCache(t)
+		mockRepo := new(MockRepository)
+		handler := NewHandler(NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger()), setupLogger(), testMaxUploadBytes)
+
+		r := gin.New()
+		r.POST("/admin/products/import", handler.ImportProducts)
+		return r, mockRepo
+	}
+
+	upload := func(r *gin.Engine, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "products.csv")
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/products/import", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should return the per row results of an upload", func(t *testing.T) {
+		r, mockRepo := setup(t)
+		mockRepo.On("CreateMany", mock.Anything, mock.Anything).Run(assignIDs(7)).Return(nil)
+		mockRepo.On("PopStockSubscribers", mock.Anything, uint(7)).Return([]StockSubscriber(nil), nil)
+
+		w := upload(r, "name,price,stock\nSmartphone,1000,5\n,2000,1\n")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			Data ImportResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 1, body.Data.Created)
+		assert.Equal(t, 1, body.Data.Failed)
+		assert.Equal(t, []ImportRowResult{{Line: 2, ID: 7}, {Line: 3, Error: "name is required"}}, body.Data.Rows)
+	})
+
+	t.Run("should accept a plain CSV body", func(t *testing.T) {
+		r, mockRepo := setup(t)
+		mockRepo.On("CreateMany", mock.Anything, mock.Anything).Run(assignIDs(7)).Return(nil)
+		mockRepo.On("PopStockSubscribers", mock.Anything, uint(7)).Return([]StockSubscriber(nil), nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/products/import", strings.NewReader("name,price,stock\nSmartphone,1000,5\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"created":1`)
+	})
+
+	t.Run("should reject a malformed file with 400", func(t *testing.T) {
+		r, mockRepo := setup(t)
+
+		w := upload(r, "name,price\nSmartphone,1000\n")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `missing column \"stock\"`)
+		mockRepo.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should fail the whole import when the insert fails", func(t *testing.T) {
+		r, mockRepo := setup(t)
+		mockRepo.On("CreateMany", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+
+		w := upload(r, "name,price,stock\nSmartphone,1000,5\n")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestHandler_ImportProducts_BodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	redisCache, _ := setupTestCache(t)
+	mockRepo := new(MockRepository)
+	mockRepo.On("CreateMany", mock.Anything, mock.Anything).Run(assignIDs(7)).Return(nil)
+	mockRepo.On("PopStockSubscribers", mock.Anything, mock.Anything).Return([]StockSubscriber(nil), nil)
+	handler := NewHandler(NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger()), setupLogger(), testMaxUploadBytes)
+
+	r := gin.New()
+	r.Use(middleware.BodyLimit(64))
+	asAdmin := func(c *gin.Context) {
+		c.Set("role", auth.RoleAdmin)
+		c.Next()
+	}
+	handler.RegisterRoutes(r.Group(""), asAdmin, response.APIVersion1)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/admin/products/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should accept a file over the global limit", func(t *testing.T) {
+		w := post("name,price,stock\n" + strings.Repeat("Smartphone,1000,5\n", 10))
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("should return 413 for a file over the import limit", func(t *testing.T) {
+		w := post("name,price,stock\n" + strings.Repeat("x", MaxImportBytes))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), response.ErrCodePayloadTooLarge)
+		mockRepo.AssertNumberOfCalls(t, "CreateMany", 1)
+	})
+}
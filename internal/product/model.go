@@ -1,12 +1,49 @@
 package product
 
-import "time"
+import (
+	"mini-e-commerce/internal/category"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Product struct {
+	ID         uint               `gorm:"primaryKey" json:"id"`
+	Name       string             `gorm:"not null" json:"name"`
+	Price      int                `gorm:"not null" json:"price"`
+	Currency   string             `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Stock      int                `gorm:"not null;default:0" json:"stock"`
+	CategoryID *uint              `gorm:"index" json:"category_id"`
+	Category   *category.Category `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"category,omitempty"`
+	Images     []ProductImage     `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt     `gorm:"index" json:"-"`
+}
+
+// ProductImage is a picture of a product, products list their images by Position
+type ProductImage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
+	URL       string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// StorageKey locates uploads in the BlobStore, it is empty for images added by URL
+	StorageKey string `gorm:"type:varchar(255)" json:"-"`
+}
+
+// StockSubscription asks for an email once a sold out product is back in stock.
+// Subscriptions are removed when the notification goes out.
+type StockSubscription struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Price     int       `gorm:"not null" json:"price"`
-	Stock     int       `gorm:"not null;default:0" json:"stock"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_stock_subscriptions_user_product" json:"user_id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_stock_subscriptions_user_product;index" json:"product_id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockSubscriber is a subscribed user resolved to the address to notify
+type StockSubscriber struct {
+	UserID uint
+	Email  string
 }
@@ -3,18 +3,34 @@ package product
 import (
 	"context"
 
+	"mini-e-commerce/internal/utils"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(ctx context.Context, product *Product) error
-	FindAll(ctx context.Context) ([]Product, error)
-	FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order string) ([]Product, int64, error)
+	CreateMany(ctx context.Context, products []Product) error
+	FindAll(ctx context.Context, limit int) ([]Product, error)
+	FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order, search string, categoryID uint) ([]Product, int64, error)
+	FindAllAfterCursor(ctx context.Context, cursor Cursor, limit int, search string, categoryID uint) ([]Product, int64, error)
 	FindByID(ctx context.Context, id uint) (Product, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]Product, error)
 	Update(ctx context.Context, product *Product) error
+	AdjustStock(ctx context.Context, id uint, delta int) (Product, error)
 	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	CreateStockSubscription(ctx context.Context, subscription *StockSubscription) error
+	PopStockSubscribers(ctx context.Context, productID uint) ([]StockSubscriber, error)
+	CreateImage(ctx context.Context, image *ProductImage) error
+	FindImages(ctx context.Context, productID uint) ([]ProductImage, error)
+	DeleteImage(ctx context.Context, productID, imageID uint) (ProductImage, error)
 }
 
+// createBatchSize bounds the rows of one INSERT statement in CreateMany
+const createBatchSize = 100
+
 type repository struct {
 	db *gorm.DB
 }
@@ -27,31 +43,77 @@ func (r *repository) Create(ctx context.Context, p *Product) error {
 	return r.db.WithContext(ctx).Create(p).Error
 }
 
-func (r *repository) FindAll(ctx context.Context) ([]Product, error) {
+// CreateMany inserts products in one transaction, either all of them are created or none
+func (r *repository) CreateMany(ctx context.Context, products []Product) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&products, createBatchSize).Error
+	})
+}
+
+func (r *repository) FindAll(ctx context.Context, limit int) ([]Product, error) {
 	var products []Product
-	err := r.db.WithContext(ctx).Find(&products).Error
+	err := r.db.WithContext(ctx).Order("id").Limit(limit).Find(&products).Error
 	return products, err
 }
 
 func (r *repository) FindByID(ctx context.Context, id uint) (Product, error) {
 	var p Product
-	err := r.db.WithContext(ctx).First(&p, id).Error
+	err := r.db.WithContext(ctx).Preload("Category").Scopes(preloadImages).First(&p, id).Error
 	return p, err
 }
 
+// FindByIDs loads every listed product in one query, ids that do not exist are skipped
+func (r *repository) FindByIDs(ctx context.Context, ids []uint) ([]Product, error) {
+	var products []Product
+	err := r.db.WithContext(ctx).Preload("Category").Scopes(preloadImages).Where("id IN ?", ids).Find(&products).Error
+	return products, err
+}
+
 func (r *repository) Update(ctx context.Context, p *Product) error {
-	return r.db.WithContext(ctx).Save(p).Error
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(p).Error
+}
+
+// AdjustStock adds delta to the stock in a single conditional UPDATE and returns the row as
+// written, gorm.ErrRecordNotFound when there is no such product or too little stock.
+// Other columns are left alone, so it cannot undo a concurrent write to the product.
+func (r *repository) AdjustStock(ctx context.Context, id uint, delta int) (Product, error) {
+	var p Product
+	result := r.db.WithContext(ctx).Model(&p).Clauses(clause.Returning{}).
+		Where("id = ? AND stock + ? >= 0", id, delta).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return Product{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return Product{}, gorm.ErrRecordNotFound
+	}
+	return p, nil
 }
 
 func (r *repository) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&Product{}, id).Error
 }
 
-func (r *repository) FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order string) ([]Product, int64, error) {
+// Restore brings back a soft-deleted product, returning gorm.ErrRecordNotFound when
+// there is no deleted product with the given id
+func (r *repository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order, search string, categoryID uint) ([]Product, int64, error) {
 	var products []Product
 	var total int64
 
-	db := r.db.WithContext(ctx).Model(&Product{})
+	db := r.db.WithContext(ctx).Model(&Product{}).Scopes(filterProducts(search, categoryID))
 
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -63,6 +125,112 @@ func (r *repository) FindAllWithPagination(ctx context.Context, offset, limit in
 		db = db.Order("created_at desc")
 	}
 
-	err := db.Offset(offset).Limit(limit).Find(&products).Error
+	err := db.Preload("Category").Scopes(preloadImages).Offset(offset).Limit(limit).Find(&products).Error
 	return products, total, err
 }
+
+// FindAllAfterCursor returns the page following cursor using keyset pagination,
+// ordered by the cursor's sort column with id as tie breaker so pages never overlap
+func (r *repository) FindAllAfterCursor(ctx context.Context, cursor Cursor, limit int, search string, categoryID uint) ([]Product, int64, error) {
+	var products []Product
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&Product{}).Scopes(filterProducts(search, categoryID))
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	op := ">"
+	if cursor.Order == "desc" {
+		op = "<"
+	}
+
+	if cursor.SortBy == "id" {
+		db = db.Where("id "+op+" ?", cursor.ID).Order("id " + cursor.Order)
+	} else {
+		db = db.Where("("+cursor.SortBy+", id) "+op+" (?, ?)", cursor.Value, cursor.ID).
+			Order(cursor.SortBy + " " + cursor.Order + ", id " + cursor.Order)
+	}
+
+	err := db.Preload("Category").Scopes(preloadImages).Limit(limit).Find(&products).Error
+	return products, total, err
+}
+
+func preloadImages(db *gorm.DB) *gorm.DB {
+	return db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	})
+}
+
+// CreateImage appends the image after the product's existing images
+func (r *repository) CreateImage(ctx context.Context, image *ProductImage) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next int
+		err := tx.Model(&ProductImage{}).
+			Where("product_id = ?", image.ProductID).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Scan(&next).Error
+		if err != nil {
+			return err
+		}
+
+		image.Position = next
+		return tx.Create(image).Error
+	})
+}
+
+func (r *repository) FindImages(ctx context.Context, productID uint) ([]ProductImage, error) {
+	var images []ProductImage
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("position, id").Find(&images).Error
+	return images, err
+}
+
+// DeleteImage removes an image of the product and returns it, gorm.ErrRecordNotFound when
+// the product has no image with that id
+func (r *repository) DeleteImage(ctx context.Context, productID, imageID uint) (ProductImage, error) {
+	var image ProductImage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).First(&image, imageID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&image).Error
+	})
+	return image, err
+}
+
+func filterProducts(search string, categoryID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if search != "" {
+			db = db.Where("name ILIKE ?", "%"+utils.EscapeLike(search)+"%")
+		}
+		if categoryID != 0 {
+			db = db.Where("category_id = ?", categoryID)
+		}
+		return db
+	}
+}
+
+// CreateStockSubscription is idempotent, subscribing twice to the same product keeps one row
+func (r *repository) CreateStockSubscription(ctx context.Context, subscription *StockSubscription) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(subscription).Error
+}
+
+// PopStockSubscribers deletes every subscription of the product and returns who held them.
+// Deleting and reading in one statement means concurrent restocks notify each user once.
+func (r *repository) PopStockSubscribers(ctx context.Context, productID uint) ([]StockSubscriber, error) {
+	var subscriptions []StockSubscription
+	err := r.db.WithContext(ctx).Clauses(clause.Returning{}).Where("product_id = ?", productID).Delete(&subscriptions).Error
+	if err != nil || len(subscriptions) == 0 {
+		return nil, err
+	}
+
+	userIDs := make([]uint, len(subscriptions))
+	for i, subscription := range subscriptions {
+		userIDs[i] = subscription.UserID
+	}
+
+	var subscribers []StockSubscriber
+	err = r.db.WithContext(ctx).Table("users").Select("id AS user_id, email").Where("id IN ?", userIDs).Scan(&subscribers).Error
+	return subscribers, err
+}
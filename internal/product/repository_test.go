@@ -0,0 +1,378 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_FindAllWithPagination(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should filter by name with ILIKE when search is set", func(t *testing.T) {
+		now := time.Now()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE name ILIKE $1 AND "products"."deleted_at" IS NULL`)).
+			WithArgs("%phone%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE name ILIKE $1 AND "products"."deleted_at" IS NULL ORDER BY name asc LIMIT $2`)).
+			WithArgs("%phone%", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}).
+				AddRow(1, "Smartphone", 1000, 5, now, now))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "product_images" WHERE "product_images"."product_id" = $1 ORDER BY position, id`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "url", "position", "created_at"}).
+				AddRow(5, 1, "/uploads/front.png", 0, now).
+				AddRow(6, 1, "/uploads/back.png", 1, now))
+
+		products, total, err := repo.FindAllWithPagination(ctx, 0, 10, "name", "asc", "phone", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, products, 1)
+		assert.Equal(t, "Smartphone", products[0].Name)
+		require.Len(t, products[0].Images, 2)
+		assert.Equal(t, "/uploads/front.png", products[0].Images[0].URL)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should escape LIKE wildcards in search term", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE name ILIKE $1 AND "products"."deleted_at" IS NULL`)).
+			WithArgs(`%50\%\_off%`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE name ILIKE $1 AND "products"."deleted_at" IS NULL ORDER BY created_at desc LIMIT $2`)).
+			WithArgs(`%50\%\_off%`, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}))
+
+		_, total, err := repo.FindAllWithPagination(ctx, 0, 10, "", "", "50%_off", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not filter when search is empty", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE "products"."deleted_at" IS NULL`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."deleted_at" IS NULL ORDER BY created_at desc LIMIT $1`)).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}))
+
+		_, _, err := repo.FindAllWithPagination(ctx, 0, 10, "", "", "", 0)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_FindAllWithPagination_CategoryFilter(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should filter by category and preload it", func(t *testing.T) {
+		now := time.Now()
+		categoryID := uint(3)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE category_id = $1 AND "products"."deleted_at" IS NULL`)).
+			WithArgs(categoryID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE category_id = $1 AND "products"."deleted_at" IS NULL ORDER BY created_at desc LIMIT $2`)).
+			WithArgs(categoryID, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "category_id", "created_at", "updated_at"}).
+				AddRow(1, "Smartphone", 1000, 5, categoryID, now, now))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "categories" WHERE "categories"."id" = $1`)).
+			WithArgs(categoryID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "created_at", "updated_at"}).
+				AddRow(categoryID, "Electronics", "electronics", now, now))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "product_images" WHERE "product_images"."product_id" = $1 ORDER BY position, id`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "url", "position", "created_at"}))
+
+		products, total, err := repo.FindAllWithPagination(ctx, 0, 10, "", "", "", categoryID)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, products, 1)
+		require.NotNil(t, products[0].Category)
+		assert.Equal(t, "electronics", products[0].Category.Slug)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_FindAllAfterCursor(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should seek past the cursor using sort column and id", func(t *testing.T) {
+		now := time.Now()
+		cursor := Cursor{SortBy: "price", Order: "asc", Value: int64(300), ID: 2}
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE name ILIKE $1 AND "products"."deleted_at" IS NULL`)).
+			WithArgs("%o%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE name ILIKE $1 AND "products"."deleted_at" IS NULL AND (price, id) > ($2, $3) ORDER BY price asc, id asc LIMIT $4`)).
+			WithArgs("%o%", int64(300), uint(2), 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}).
+				AddRow(3, "Keyboard", 300, 5, now, now).
+				AddRow(4, "Monitor", 900, 2, now, now))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "product_images" WHERE "product_images"."product_id" IN ($1,$2) ORDER BY position, id`)).
+			WithArgs(3, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "url", "position", "created_at"}))
+
+		products, total, err := repo.FindAllAfterCursor(ctx, cursor, 3, "o", 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+		require.Len(t, products, 2)
+		assert.Equal(t, uint(3), products[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should seek backwards for descending order", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)
+		cursor := Cursor{SortBy: "created_at", Order: "desc", Value: createdAt, ID: 7}
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "products" WHERE "products"."deleted_at" IS NULL`)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."deleted_at" IS NULL AND (created_at, id) < ($1, $2) ORDER BY created_at desc, id desc LIMIT $3`)).
+			WithArgs(createdAt, uint(7), 11).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}))
+
+		_, _, err := repo.FindAllAfterCursor(ctx, cursor, 11, "", 0)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_SoftDelete(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should soft delete product instead of removing the row", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "deleted_at"=$1 WHERE "products"."id" = $2 AND "products"."deleted_at" IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Delete(ctx, 1)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should exclude soft deleted products from FindAll", func(t *testing.T) {
+		now := time.Now()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."deleted_at" IS NULL ORDER BY id LIMIT $1`)).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}).
+				AddRow(2, "Laptop", 2000, 3, now, now))
+
+		products, err := repo.FindAll(ctx, 10)
+
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+		assert.Equal(t, uint(2), products[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should restore soft deleted product", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+			WithArgs(nil, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Restore(ctx, 1)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when restoring product that is not deleted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+			WithArgs(nil, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.Restore(ctx, 1)
+
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_PopStockSubscribers(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should delete subscriptions and resolve subscriber emails", func(t *testing.T) {
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "stock_subscriptions" WHERE product_id = $1 RETURNING *`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "product_id", "created_at"}).
+				AddRow(1, 2, 1, now).
+				AddRow(2, 3, 1, now))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT id AS user_id, email FROM "users" WHERE id IN ($1,$2)`)).
+			WithArgs(2, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "email"}).
+				AddRow(2, "a@example.com").
+				AddRow(3, "b@example.com"))
+
+		subscribers, err := repo.PopStockSubscribers(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, []StockSubscriber{{UserID: 2, Email: "a@example.com"}, {UserID: 3, Email: "b@example.com"}}, subscribers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should skip the user lookup without subscriptions", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "stock_subscriptions" WHERE product_id = $1 RETURNING *`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "product_id", "created_at"}))
+		mock.ExpectCommit()
+
+		subscribers, err := repo.PopStockSubscribers(ctx, 1)
+
+		require.NoError(t, err)
+		assert.Empty(t, subscribers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_CreateImage(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should append the image after the last position", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(position) + 1, 0) FROM "product_images" WHERE product_id = $1`)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "product_images" ("product_id","url","position","created_at","storage_key") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+			WithArgs(1, "https://cdn.example.com/a.png", 2, sqlmock.AnyArg(), "").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectCommit()
+
+		image := &ProductImage{ProductID: 1, URL: "https://cdn.example.com/a.png"}
+		err := repo.CreateImage(ctx, image)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(9), image.ID)
+		assert.Equal(t, 2, image.Position)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_CreateMany(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should insert every product in one transaction and fill in the ids", func(t *testing.T) {
+		products := []Product{
+			{Name: "Smartphone", Price: 1000, Currency: "IDR", Stock: 5},
+			{Name: "Laptop", Price: 5000, Currency: "IDR", Stock: 2},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "products"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10).AddRow(11))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.CreateMany(ctx, products))
+
+		assert.Equal(t, uint(10), products[0].ID)
+		assert.Equal(t, uint(11), products[1].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back when the insert fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "products"`)).
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		err := repo.CreateMany(ctx, []Product{{Name: "Smartphone", Price: 1000, Currency: "IDR"}})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_AdjustStock(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	t.Run("should add to the stock in one conditional update", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE "products" SET "stock"=stock + $1,"updated_at"=$2 WHERE (id = $3 AND stock + $4 >= 0) AND "products"."deleted_at" IS NULL RETURNING *`)).
+			WithArgs(-2, sqlmock.AnyArg(), 1, -2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "stock"}).AddRow(1, "Smartphone", 3))
+		mock.ExpectCommit()
+
+		product, err := repo.AdjustStock(ctx, 1, -2)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), product.ID)
+		assert.Equal(t, 3, product.Stock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when no row qualifies", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`UPDATE "products" SET "stock"=stock + $1`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "stock"}))
+		mock.ExpectCommit()
+
+		_, err := repo.AdjustStock(ctx, 1, -5)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
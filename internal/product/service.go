@@ -1,115 +1,306 @@
 package product
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/category"
 	"mini-e-commerce/internal/dto"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/storage"
+	"mini-e-commerce/internal/tracing"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/redis/go-redis/v9"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
-	ErrProductNotFound  = "product not found"
-	CacheKeyProductByID = "product:id:%d"
-	CacheKeyProductList = "product:list:%d:%d:%s:%s" // page:pageSize:sortBy:order
-	CacheTTLProduct     = 5 * time.Minute
-	CacheTTLProductList = 2 * time.Minute
+	ErrProductNotFound   = "product not found"
+	ErrInsufficientStock = "insufficient stock"
+	ErrProductInStock    = "product is in stock"
+	ErrImageNotFound     = "product image not found"
+	ErrUnsupportedImage  = "unsupported image type"
+	CacheKeyProductByID  = "product:id:%d"
+	CacheKeyMissing      = "product:missing:%d"
+	CacheKeyProductList  = "product:list:v%d:%d:%d:%s:%s:%s:%d" // version:page:pageSize:sortBy:order:search:categoryID
+	CacheKeyProductAfter = "product:list:v%d:after:%s:%d:%s:%d" // version:cursor:pageSize:search:categoryID
+	CacheKeyListVersion  = "product:list:version"
+	CacheTTLProduct      = 5 * time.Minute
+	CacheTTLProductList  = 2 * time.Minute
+	CacheTTLMissing      = 30 * time.Second
 )
 
+// MaxUnpaginatedRows caps GetAllProducts, listings should go through GetAllProductsWithQuery
+const MaxUnpaginatedRows = 1000
+
+// imageExtensions are the upload types accepted for product images, keyed by sniffed content type
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
 type Service interface {
 	CreateProduct(ctx context.Context, input CreateProductRequest) (*Product, error)
 	GetAllProducts(ctx context.Context) ([]Product, error)
 	GetAllProductsWithQuery(ctx context.Context, query ProductQuery) (*ProductListResponse, error)
 	GetProductByID(ctx context.Context, id uint) (*Product, error)
+	GetProductsByIDs(ctx context.Context, ids []uint) (map[uint]Product, error)
 	UpdateProduct(ctx context.Context, id uint, input UpdateProductRequest) (*Product, error)
+	ReplaceProduct(ctx context.Context, id uint, input CreateProductRequest) (*Product, error)
 	DeleteProduct(ctx context.Context, id uint) error
+	RestoreProduct(ctx context.Context, id uint) (*Product, error)
 	UpdateStock(ctx context.Context, id uint, stockDelta int) error
 	UpdateStockWithTx(tx *gorm.DB, id uint, stockDelta int) error
+	NotifyRestocked(ctx context.Context, ids []uint)
+	InvalidateProducts(ctx context.Context, ids []uint)
+	SubscribeToRestock(ctx context.Context, productID, userID uint) error
+	AddImageURL(ctx context.Context, productID uint, imageURL string) (*ProductImage, error)
+	UploadImage(ctx context.Context, productID uint, r io.Reader) (*ProductImage, error)
+	GetImages(ctx context.Context, productID uint) ([]ProductImage, error)
+	DeleteImage(ctx context.Context, productID, imageID uint) error
+	ImportProducts(ctx context.Context, r io.Reader) (*ImportResult, error)
+}
+
+// Notifier sends back in stock emails, auth.Notifier satisfies it
+type Notifier interface {
+	SendBackInStockEmail(ctx context.Context, email, productName string) error
 }
 type service struct {
-	repo      Repository
-	cache     *cache.RedisCache
-	validator *validator.Validate
-	logger    *zap.Logger
+	repo         Repository
+	categoryRepo category.Repository
+	cache        *cache.RedisCache
+	validator    *validator.Validate
+	// negativeCache remembers ids that were not found so repeated lookups skip the database
+	negativeCache bool
+	// baseCurrency is used for products created without a currency
+	baseCurrency string
+	notifier     Notifier
+	// blobs stores uploaded product images
+	blobs  storage.BlobStore
+	logger logger.Logger
 }
 
-func NewService(repo Repository, cache *cache.RedisCache, logger *zap.Logger) Service {
+func NewService(repo Repository, categoryRepo category.Repository, cache *cache.RedisCache, negativeCache bool, baseCurrency string, notifier Notifier, blobs storage.BlobStore, log logger.Logger) Service {
 	return &service{
-		repo:      repo,
-		cache:     cache,
-		validator: validator.New(),
-		logger:    logger,
+		repo:          repo,
+		categoryRepo:  categoryRepo,
+		cache:         cache,
+		validator:     validator.New(),
+		negativeCache: negativeCache,
+		baseCurrency:  baseCurrency,
+		notifier:      notifier,
+		blobs:         blobs,
+		logger:        log.With(zap.String("module", "product")),
+	}
+}
+
+func (s *service) currencyOrBase(currency string) string {
+	if currency == "" {
+		return s.baseCurrency
 	}
+	return currency
+}
+
+func (s *service) ensureCategoryExists(ctx context.Context, categoryID *uint) error {
+	if categoryID == nil {
+		return nil
+	}
+	if _, err := s.categoryRepo.FindByID(ctx, *categoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(category.ErrCategoryNotFound)
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *service) invalidateProductCache(ctx context.Context, id uint) {
 	cacheKey := fmt.Sprintf(CacheKeyProductByID, id)
-	_ = s.cache.Delete(ctx, cacheKey)
-	_ = s.cache.DeletePattern(ctx, "product:list:*")
+	_ = s.cache.Delete(ctx, cacheKey, fmt.Sprintf(CacheKeyMissing, id))
+	s.invalidateProductListCache(ctx)
 }
 
+// invalidateProductListCache bumps the list version so every cached page goes out of
+// use at once, old pages are left to expire on their own
 func (s *service) invalidateProductListCache(ctx context.Context) {
-	_ = s.cache.DeletePattern(ctx, "product:list:*")
+	_, _ = s.cache.Incr(ctx, CacheKeyListVersion)
+}
+
+// InvalidateProducts drops the cached copies of the listed products and every cached list
+// page, for writes to product data made outside this service such as a category rename
+func (s *service) InvalidateProducts(ctx context.Context, ids []uint) {
+	if len(ids) > 0 {
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = fmt.Sprintf(CacheKeyProductByID, id)
+		}
+		_ = s.cache.Delete(ctx, keys...)
+	}
+	s.invalidateProductListCache(ctx)
 }
 
+// listVersion returns the current list version, zero when it was never bumped or
+// Redis cannot be read
+func (s *service) listVersion(ctx context.Context) int64 {
+	var version int64
+	_ = s.cache.Get(ctx, CacheKeyListVersion, &version)
+	return version
+}
+
+// GetAllProducts returns at most MaxUnpaginatedRows products by id, logging a warning
+// when there were more
 func (s *service) GetAllProducts(ctx context.Context) ([]Product, error) {
-	return s.repo.FindAll(ctx)
+	ctx, span := tracing.Start(ctx, "product.GetAllProducts")
+	defer span.End()
+
+	products, err := s.repo.FindAll(ctx, MaxUnpaginatedRows+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) > MaxUnpaginatedRows {
+		s.logger.Warn("Unpaginated product list truncated, use the paginated listing instead",
+			zap.Int("limit", MaxUnpaginatedRows),
+		)
+		products = products[:MaxUnpaginatedRows]
+	}
+	return products, nil
 }
 
 func (s *service) GetProductByID(ctx context.Context, id uint) (*Product, error) {
+	ctx, span := tracing.Start(ctx, "product.GetProductByID")
+	defer span.End()
+
+	missingKey := fmt.Sprintf(CacheKeyMissing, id)
+	bypass := cache.Bypassed(ctx)
+	if s.negativeCache && !bypass {
+		var missing bool
+		if err := s.cache.Get(ctx, missingKey, &missing); err == nil && missing {
+			return nil, errors.New(ErrProductNotFound)
+		}
+	}
+
 	cacheKey := fmt.Sprintf(CacheKeyProductByID, id)
 	var product Product
-	err := s.cache.Get(ctx, cacheKey, &product)
-	if err == nil {
-		return &product, nil
+	err := s.cache.GetOrSet(ctx, cacheKey, CacheTTLProduct, &product, func() (any, error) {
+		product, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New(ErrProductNotFound)
+			}
+			return nil, err
+		}
+		return product, nil
+	})
+	if err != nil {
+		if s.negativeCache && err.Error() == ErrProductNotFound {
+			_ = s.cache.Set(ctx, missingKey, true, CacheTTLMissing)
+		}
+		return nil, err
+	}
+	if s.negativeCache && bypass {
+		_ = s.cache.Delete(ctx, missingKey)
 	}
 
-	if err != redis.Nil {
-		s.logger.Warn("Cache error on GetProductByID, falling back to database",
-			zap.Uint("product_id", id),
-			zap.Error(err),
-		)
+	return &product, nil
+}
+
+// GetProductsByIDs returns the listed products keyed by id. Cached products are read in
+// one round trip and the rest with a single query, ids that do not exist are left out.
+func (s *service) GetProductsByIDs(ctx context.Context, ids []uint) (map[uint]Product, error) {
+	ctx, span := tracing.Start(ctx, "product.GetProductsByIDs")
+	defer span.End()
+
+	products := make(map[uint]Product, len(ids))
+	if len(ids) == 0 {
+		return products, nil
 	}
 
-	product, err = s.repo.FindByID(ctx, id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New(ErrProductNotFound)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprintf(CacheKeyProductByID, id)
+	}
+	cached := make([]Product, len(ids))
+	missing, _ := s.cache.GetMany(ctx, keys, func(i int) any {
+		return &cached[i]
+	})
+
+	isMissing := make(map[int]bool, len(missing))
+	for _, i := range missing {
+		isMissing[i] = true
+	}
+	for i, id := range ids {
+		if !isMissing[i] {
+			products[id] = cached[i]
 		}
-		return nil, err
+	}
+	missingIDs := make([]uint, 0, len(missing))
+	for _, i := range missing {
+		if _, ok := products[ids[i]]; !ok {
+			missingIDs = append(missingIDs, ids[i])
+		}
+	}
+	if len(missingIDs) == 0 {
+		return products, nil
 	}
 
-	_ = s.cache.Set(ctx, cacheKey, product, CacheTTLProduct)
+	found, err := s.repo.FindByIDs(ctx, missingIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range found {
+		products[product.ID] = product
+		_ = s.cache.Set(ctx, fmt.Sprintf(CacheKeyProductByID, product.ID), product, CacheTTLProduct)
+	}
 
-	return &product, nil
+	return products, nil
 }
 
 func (s *service) CreateProduct(ctx context.Context, input CreateProductRequest) (*Product, error) {
+	ctx, span := tracing.Start(ctx, "product.CreateProduct")
+	defer span.End()
+
 	if err := s.validator.Struct(input); err != nil {
 		return nil, err
 	}
 
+	if err := s.ensureCategoryExists(ctx, input.CategoryID); err != nil {
+		return nil, err
+	}
+
 	product := Product{
-		Name:  input.Name,
-		Price: input.Price,
-		Stock: input.Stock,
+		Name:       input.Name,
+		Price:      input.Price,
+		Currency:   s.currencyOrBase(input.Currency),
+		Stock:      input.Stock,
+		CategoryID: input.CategoryID,
 	}
 	if err := s.repo.Create(ctx, &product); err != nil {
 		return nil, err
 	}
 
+	// A lookup made before the insert may have marked this id as missing
+	_ = s.cache.Delete(ctx, fmt.Sprintf(CacheKeyMissing, product.ID))
 	s.invalidateProductListCache(ctx)
 
 	return &product, nil
 }
 
 func (s *service) UpdateProduct(ctx context.Context, id uint, input UpdateProductRequest) (*Product, error) {
+	ctx, span := tracing.Start(ctx, "product.UpdateProduct")
+	defer span.End()
+
 	if err := s.validator.Struct(input); err != nil {
 		return nil, err
 	}
@@ -128,19 +319,73 @@ func (s *service) UpdateProduct(ctx context.Context, id uint, input UpdateProduc
 	if input.Price != nil {
 		product.Price = *input.Price
 	}
+	if input.Currency != nil {
+		product.Currency = *input.Currency
+	}
+	previousStock := product.Stock
 	if input.Stock != nil {
 		product.Stock = *input.Stock
 	}
+	if input.CategoryID != nil {
+		if err := s.ensureCategoryExists(ctx, input.CategoryID); err != nil {
+			return nil, err
+		}
+		product.CategoryID = input.CategoryID
+		// The preloaded category no longer matches, leave it out of the response
+		product.Category = nil
+	}
+	if err := s.repo.Update(ctx, &product); err != nil {
+		return nil, err
+	}
+
+	s.invalidateProductCache(ctx, id)
+	s.stockWritten(ctx, product, previousStock)
+
+	return &product, nil
+}
+
+// ReplaceProduct overwrites every field with input, so an omitted category_id removes the category
+func (s *service) ReplaceProduct(ctx context.Context, id uint, input CreateProductRequest) (*Product, error) {
+	ctx, span := tracing.Start(ctx, "product.ReplaceProduct")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrProductNotFound)
+		}
+		return nil, err
+	}
+
+	if err := s.ensureCategoryExists(ctx, input.CategoryID); err != nil {
+		return nil, err
+	}
+
+	product.Name = input.Name
+	product.Price = input.Price
+	product.Currency = s.currencyOrBase(input.Currency)
+	previousStock := product.Stock
+	product.Stock = input.Stock
+	product.CategoryID = input.CategoryID
+	product.Category = nil
 	if err := s.repo.Update(ctx, &product); err != nil {
 		return nil, err
 	}
 
 	s.invalidateProductCache(ctx, id)
+	s.stockWritten(ctx, product, previousStock)
 
 	return &product, nil
 }
 
 func (s *service) DeleteProduct(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "product.DeleteProduct")
+	defer span.End()
+
 	_, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -158,32 +403,234 @@ func (s *service) DeleteProduct(ctx context.Context, id uint) error {
 	return nil
 }
 
-func (s *service) UpdateStock(ctx context.Context, id uint, stockDelta int) error {
+func (s *service) RestoreProduct(ctx context.Context, id uint) (*Product, error) {
+	ctx, span := tracing.Start(ctx, "product.RestoreProduct")
+	defer span.End()
+
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New(ErrProductNotFound)
+		}
+		return nil, err
+	}
+
+	s.invalidateProductCache(ctx, id)
+
 	product, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// UpdateStock applies stockDelta atomically in the database, so it cannot lose a
+// concurrent order's decrement or take stock below zero
+func (s *service) UpdateStock(ctx context.Context, id uint, stockDelta int) error {
+	ctx, span := tracing.Start(ctx, "product.UpdateStock")
+	defer span.End()
+
+	product, err := s.repo.AdjustStock(ctx, id, stockDelta)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// Nothing was updated, tell a missing product from one with too little stock
+		if err := s.ensureProductExists(ctx, id); err != nil {
+			return err
+		}
+		return errors.New(ErrInsufficientStock)
+	}
+
+	s.invalidateProductCache(ctx, id)
+	s.stockWritten(ctx, product, product.Stock-stockDelta)
+
+	return nil
+}
+
+// SubscribeToRestock registers the user for an email once the sold out product is restocked
+func (s *service) SubscribeToRestock(ctx context.Context, productID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "product.SubscribeToRestock")
+	defer span.End()
+
+	product, err := s.repo.FindByID(ctx, productID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New(ErrProductNotFound)
 		}
 		return err
 	}
+	if product.Stock > 0 {
+		return errors.New(ErrProductInStock)
+	}
 
-	product.Stock += stockDelta
-	if product.Stock < 0 {
-		return errors.New("insufficient stock")
+	return s.repo.CreateStockSubscription(ctx, &StockSubscription{UserID: userID, ProductID: productID})
+}
+
+// stockWritten runs once a write to the stock of product has committed and notifies the
+// back in stock subscribers when it went from sold out to available. Every path that sets
+// stock goes through it, or through NotifyRestocked for writes made in a transaction.
+func (s *service) stockWritten(ctx context.Context, product Product, previousStock int) {
+	if previousStock <= 0 && product.Stock > 0 {
+		s.notifyBackInStock(ctx, product)
 	}
+}
 
-	if err := s.repo.Update(ctx, &product); err != nil {
+// NotifyRestocked is called with the products UpdateStockWithTx returned stock to, once that
+// transaction committed. The stock they had before is no longer known, but subscriptions
+// are only taken while a product is sold out, so any product now in stock is notified.
+func (s *service) NotifyRestocked(ctx context.Context, ids []uint) {
+	ctx, span := tracing.Start(ctx, "product.NotifyRestocked")
+	defer span.End()
+
+	for _, id := range ids {
+		product, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			// a product deleted since still got its stock back, but is no longer sold
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Error("Failed to load restocked product", zap.Uint("product_id", id), zap.Error(err))
+			}
+			continue
+		}
+		s.stockWritten(ctx, product, 0)
+	}
+}
+
+// notifyBackInStock emails and drops the subscribers of a product that just came back
+// in stock. The stock update already succeeded, so failures are only logged.
+func (s *service) notifyBackInStock(ctx context.Context, product Product) {
+	subscribers, err := s.repo.PopStockSubscribers(ctx, product.ID)
+	if err != nil {
+		s.logger.Error("Failed to load back in stock subscribers", zap.Uint("product_id", product.ID), zap.Error(err))
+		return
+	}
+
+	for _, subscriber := range subscribers {
+		if err := s.notifier.SendBackInStockEmail(ctx, subscriber.Email, product.Name); err != nil {
+			s.logger.Error("Failed to send back in stock email",
+				zap.Uint("product_id", product.ID),
+				zap.Uint("user_id", subscriber.UserID),
+				zap.Error(err),
+			)
+		}
+	}
+	if len(subscribers) > 0 {
+		s.logger.Info("Back in stock notifications sent", zap.Uint("product_id", product.ID), zap.Int("count", len(subscribers)))
+	}
+}
+
+// AddImageURL appends an image hosted elsewhere to the product
+func (s *service) AddImageURL(ctx context.Context, productID uint, imageURL string) (*ProductImage, error) {
+	ctx, span := tracing.Start(ctx, "product.AddImageURL")
+	defer span.End()
+
+	if err := s.ensureProductExists(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	image := &ProductImage{ProductID: productID, URL: imageURL}
+	if err := s.repo.CreateImage(ctx, image); err != nil {
+		return nil, err
+	}
+
+	s.invalidateProductCache(ctx, productID)
+	return image, nil
+}
+
+// UploadImage stores the uploaded file in the blob store and appends it to the product.
+// The content type is sniffed from the data, only the types in imageExtensions are accepted.
+func (s *service) UploadImage(ctx context.Context, productID uint, r io.Reader) (*ProductImage, error) {
+	ctx, span := tracing.Start(ctx, "product.UploadImage")
+	defer span.End()
+
+	if err := s.ensureProductExists(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	ext, ok := imageExtensions[http.DetectContentType(head)]
+	if !ok {
+		return nil, errors.New(ErrUnsupportedImage)
+	}
+
+	key := fmt.Sprintf("products/%d/%s%s", productID, uuid.New().String(), ext)
+	imageURL, err := s.blobs.Put(ctx, key, io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		return nil, err
+	}
+
+	image := &ProductImage{ProductID: productID, URL: imageURL, StorageKey: key}
+	if err := s.repo.CreateImage(ctx, image); err != nil {
+		s.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	s.invalidateProductCache(ctx, productID)
+	return image, nil
+}
+
+func (s *service) GetImages(ctx context.Context, productID uint) ([]ProductImage, error) {
+	ctx, span := tracing.Start(ctx, "product.GetImages")
+	defer span.End()
+
+	if err := s.ensureProductExists(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindImages(ctx, productID)
+}
+
+// DeleteImage removes the image from the product and drops the uploaded file, if any
+func (s *service) DeleteImage(ctx context.Context, productID, imageID uint) error {
+	ctx, span := tracing.Start(ctx, "product.DeleteImage")
+	defer span.End()
+
+	image, err := s.repo.DeleteImage(ctx, productID, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(ErrImageNotFound)
+		}
 		return err
 	}
 
-	s.invalidateProductCache(ctx, id)
+	if image.StorageKey != "" {
+		s.deleteBlob(ctx, image.StorageKey)
+	}
+	s.invalidateProductCache(ctx, productID)
+	return nil
+}
 
+func (s *service) ensureProductExists(ctx context.Context, productID uint) error {
+	if _, err := s.repo.FindByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New(ErrProductNotFound)
+		}
+		return err
+	}
 	return nil
 }
 
+// deleteBlob removes an uploaded file whose row is gone, an orphaned file is only logged
+func (s *service) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil {
+		s.logger.Warn("Failed to delete product image blob", zap.String("key", key), zap.Error(err))
+	}
+}
+
 func (s *service) UpdateStockWithTx(tx *gorm.DB, id uint, stockDelta int) error {
+	// Returning stock, e.g. for a cancelled order, must still work for products deleted since
+	if stockDelta > 0 {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+	}
+
+	// Lock the row so concurrent orders cannot both pass the stock check below
 	var product Product
-	if err := tx.First(&product, id).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New(ErrProductNotFound)
 		}
@@ -192,7 +639,7 @@ func (s *service) UpdateStockWithTx(tx *gorm.DB, id uint, stockDelta int) error
 
 	product.Stock += stockDelta
 	if product.Stock < 0 {
-		return errors.New("insufficient stock")
+		return errors.New(ErrInsufficientStock)
 	}
 
 	if err := tx.Save(&product).Error; err != nil {
@@ -205,6 +652,9 @@ func (s *service) UpdateStockWithTx(tx *gorm.DB, id uint, stockDelta int) error
 }
 
 func (s *service) GetAllProductsWithQuery(ctx context.Context, query ProductQuery) (*ProductListResponse, error) {
+	ctx, span := tracing.Start(ctx, "product.GetAllProductsWithQuery")
+	defer span.End()
+
 	page := query.Page
 	if page <= 0 {
 		page = 1
@@ -225,48 +675,86 @@ func (s *service) GetAllProductsWithQuery(ctx context.Context, query ProductQuer
 	}
 
 	sortBy := query.SortBy
-	validSortFields := map[string]bool{
-		"id": true, "name": true, "price": true, "stock": true, "created_at": true,
-	}
 	if sortBy != "" && !validSortFields[sortBy] {
 		sortBy = "created_at"
 	}
 
-	cacheKey := fmt.Sprintf(CacheKeyProductList, page, pageSize, sortBy, order)
-	var response ProductListResponse
-	err := s.cache.Get(ctx, cacheKey, &response)
-	if err == nil {
-		return &response, nil
+	search := strings.TrimSpace(query.Search)
+
+	if query.After != "" {
+		return s.getProductsAfterCursor(ctx, query.After, pageSize, search, query.CategoryID)
 	}
 
-	if err != redis.Nil {
-		s.logger.Warn("Cache error on GetAllProductsWithQuery, falling back to database",
-			zap.Int("page", page),
-			zap.Int("page_size", pageSize),
-			zap.Error(err),
-		)
+	// The repository falls back to newest first when no sort column is given
+	cursorSortBy, cursorOrder := sortBy, order
+	if cursorSortBy == "" {
+		cursorSortBy, cursorOrder = "created_at", "desc"
 	}
 
+	cacheKey := fmt.Sprintf(CacheKeyProductList, s.listVersion(ctx), page, pageSize, sortBy, order, url.QueryEscape(strings.ToLower(search)), query.CategoryID)
 	offset := (page - 1) * pageSize
 
-	products, total, err := s.repo.FindAllWithPagination(ctx, offset, pageSize, sortBy, order)
-	if err != nil {
-		return nil, err
-	}
+	var response ProductListResponse
+	err := s.cache.GetOrSet(ctx, cacheKey, CacheTTLProductList, &response, func() (any, error) {
+		products, total, err := s.repo.FindAllWithPagination(ctx, offset, pageSize, sortBy, order, search, query.CategoryID)
+		if err != nil {
+			return nil, err
+		}
 
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+		totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
 
-	response = ProductListResponse{
-		Data: products,
-		Pagination: dto.PaginationMetadata{
+		pagination := dto.PaginationMetadata{
 			Page:       page,
 			PageSize:   pageSize,
 			Total:      total,
 			TotalPages: totalPages,
-		},
+		}
+		if page < totalPages && len(products) > 0 {
+			pagination.NextCursor = EncodeCursor(newCursor(products[len(products)-1], cursorSortBy, cursorOrder))
+		}
+
+		return ProductListResponse{Data: products, Pagination: pagination}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// getProductsAfterCursor serves a keyset page. Sorting comes from the cursor itself
+// so a client cannot change the order halfway through a listing.
+func (s *service) getProductsAfterCursor(ctx context.Context, after string, pageSize int, search string, categoryID uint) (*ProductListResponse, error) {
+	cursor, err := DecodeCursor(after)
+	if err != nil {
+		return nil, err
 	}
 
-	_ = s.cache.Set(ctx, cacheKey, response, CacheTTLProductList)
+	cacheKey := fmt.Sprintf(CacheKeyProductAfter, s.listVersion(ctx), after, pageSize, url.QueryEscape(strings.ToLower(search)), categoryID)
+
+	var response ProductListResponse
+	err = s.cache.GetOrSet(ctx, cacheKey, CacheTTLProductList, &response, func() (any, error) {
+		// Fetch one extra row to know whether another page follows
+		products, total, err := s.repo.FindAllAfterCursor(ctx, cursor, pageSize+1, search, categoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		pagination := dto.PaginationMetadata{
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		}
+		if len(products) > pageSize {
+			products = products[:pageSize]
+			pagination.NextCursor = EncodeCursor(newCursor(products[pageSize-1], cursor.SortBy, cursor.Order))
+		}
+
+		return ProductListResponse{Data: products, Pagination: pagination}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &response, nil
 }
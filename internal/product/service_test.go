@@ -0,0 +1,895 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/category"
+	"mini-e-commerce/internal/dto"
+	"mini-e-commerce/internal/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, product *Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateMany(ctx context.Context, products []Product) error {
+	args := m.Called(ctx, products)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindAll(ctx context.Context, limit int) ([]Product, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Product), args.Error(1)
+}
+
+func (m *MockRepository) FindAllWithPagination(ctx context.Context, offset, limit int, sortBy, order, search string, categoryID uint) ([]Product, int64, error) {
+	args := m.Called(ctx, offset, limit, sortBy, order, search, categoryID)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) FindAllAfterCursor(ctx context.Context, cursor Cursor, limit int, search string, categoryID uint) ([]Product, int64, error) {
+	args := m.Called(ctx, cursor, limit, search, categoryID)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) FindByID(ctx context.Context, id uint) (Product, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Product), args.Error(1)
+}
+
+func (m *MockRepository) FindByIDs(ctx context.Context, ids []uint) ([]Product, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Product), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, product *Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AdjustStock(ctx context.Context, id uint, delta int) (Product, error) {
+	args := m.Called(ctx, id, delta)
+	return args.Get(0).(Product), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateStockSubscription(ctx context.Context, subscription *StockSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PopStockSubscribers(ctx context.Context, productID uint) ([]StockSubscriber, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]StockSubscriber), args.Error(1)
+}
+
+func (m *MockRepository) CreateImage(ctx context.Context, image *ProductImage) error {
+	args := m.Called(ctx, image)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindImages(ctx context.Context, productID uint) ([]ProductImage, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ProductImage), args.Error(1)
+}
+
+func (m *MockRepository) DeleteImage(ctx context.Context, productID, imageID uint) (ProductImage, error) {
+	args := m.Called(ctx, productID, imageID)
+	return args.Get(0).(ProductImage), args.Error(1)
+}
+
+// spyNotifier records every back in stock email instead of sending it
+type spyNotifier struct {
+	sent []string
+}
+
+func (n *spyNotifier) SendBackInStockEmail(ctx context.Context, email, productName string) error {
+	n.sent = append(n.sent, email+":"+productName)
+	return nil
+}
+
+type MockCategoryRepository struct {
+	category.Repository
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) FindByID(ctx context.Context, id uint) (category.Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(category.Category), args.Error(1)
+}
+
+func setupTestCache(t *testing.T) (*cache.RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewRedisCache(client, zap.NewNop()), mr
+}
+
+func setupLogger() logger.Logger {
+	logConfig := &logger.Config{
+		ServiceName: "test",
+		AppVersion:  "test",
+		LogLevel:    zapcore.FatalLevel,
+		Mode:        "development",
+	}
+	log, _ := logger.NewLogger(logConfig)
+	return log
+}
+
+func TestService_GetAllProducts(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, rows int) ([]Product, *observer.ObservedLogs) {
+		redisCache, _ := setupTestCache(t)
+		core, logs := observer.New(zapcore.WarnLevel)
+		log := logger.NewLoggerFromZap(zap.New(core), &logger.Config{})
+
+		products := make([]Product, rows)
+		for i := range products {
+			products[i] = Product{ID: uint(i + 1)}
+		}
+		mockRepo := new(MockRepository)
+		mockRepo.On("FindAll", ctx, MaxUnpaginatedRows+1).Return(products, nil)
+
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, log)
+		got, err := service.GetAllProducts(ctx)
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		return got, logs
+	}
+
+	t.Run("should truncate beyond the cap and log a warning", func(t *testing.T) {
+		got, logs := setup(t, MaxUnpaginatedRows+1)
+
+		assert.Len(t, got, MaxUnpaginatedRows)
+		assert.Equal(t, uint(MaxUnpaginatedRows), got[len(got)-1].ID)
+		assert.Equal(t, 1, logs.FilterMessageSnippet("truncated").Len())
+	})
+
+	t.Run("should return everything at the cap without warning", func(t *testing.T) {
+		got, logs := setup(t, MaxUnpaginatedRows)
+
+		assert.Len(t, got, MaxUnpaginatedRows)
+		assert.Zero(t, logs.Len())
+	})
+}
+
+func TestService_GetAllProductsWithQuery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should pass search term to repository", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		products := []Product{{ID: 1, Name: "Smartphone"}}
+		mockRepo.On("FindAllWithPagination", ctx, 0, 10, "", "desc", "phone", uint(0)).Return(products, int64(1), nil)
+
+		result, err := service.GetAllProductsWithQuery(ctx, ProductQuery{Search: " phone "})
+
+		require.NoError(t, err)
+		assert.Equal(t, products, result.Data)
+		assert.Equal(t, int64(1), result.Pagination.Total)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should cache pages separately per search term", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindAllWithPagination", ctx, 0, 10, "", "desc", "phone", uint(0)).
+			Return([]Product{{ID: 1, Name: "Smartphone"}}, int64(1), nil).Once()
+		mockRepo.On("FindAllWithPagination", ctx, 0, 10, "", "desc", "laptop", uint(0)).
+			Return([]Product{{ID: 2, Name: "Laptop"}}, int64(1), nil).Once()
+
+		phones, err := service.GetAllProductsWithQuery(ctx, ProductQuery{Search: "phone"})
+		require.NoError(t, err)
+
+		laptops, err := service.GetAllProductsWithQuery(ctx, ProductQuery{Search: "laptop"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Smartphone", phones.Data[0].Name)
+		assert.Equal(t, "Laptop", laptops.Data[0].Name)
+		assert.True(t, mr.Exists("product:list:v0:1:10::desc:phone:0"))
+		assert.True(t, mr.Exists("product:list:v0:1:10::desc:laptop:0"))
+
+		cached, err := service.GetAllProductsWithQuery(ctx, ProductQuery{Search: "PHONE"})
+		require.NoError(t, err)
+		assert.Equal(t, "Smartphone", cached.Data[0].Name)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should bump the list version on write and miss the old page", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindAllWithPagination", ctx, 0, 10, "", "desc", "", uint(0)).
+			Return([]Product{{ID: 1, Name: "Smartphone"}}, int64(1), nil).Once()
+		_, err := service.GetAllProductsWithQuery(ctx, ProductQuery{})
+		require.NoError(t, err)
+		assert.True(t, mr.Exists("product:list:v0:1:10::desc::0"))
+
+		mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+		_, err = service.CreateProduct(ctx, CreateProductRequest{Name: "Laptop", Price: 5000, Stock: 1})
+		require.NoError(t, err)
+
+		version, err := mr.Get(CacheKeyListVersion)
+		require.NoError(t, err)
+		assert.Equal(t, "1", version)
+		// The old page is not deleted, it is just no longer read
+		assert.True(t, mr.Exists("product:list:v0:1:10::desc::0"))
+
+		mockRepo.On("FindAllWithPagination", ctx, 0, 10, "", "desc", "", uint(0)).
+			Return([]Product{{ID: 1, Name: "Smartphone"}, {ID: 2, Name: "Laptop"}}, int64(2), nil).Once()
+		fresh, err := service.GetAllProductsWithQuery(ctx, ProductQuery{})
+
+		require.NoError(t, err)
+		assert.Len(t, fresh.Data, 2)
+		assert.True(t, mr.Exists("product:list:v1:1:10::desc::0"))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_GetAllProductsWithQuery_Cursor(t *testing.T) {
+	ctx := context.Background()
+
+	catalog := []Product{
+		{ID: 1, Name: "Cable", Price: 100},
+		{ID: 2, Name: "Mouse", Price: 300},
+		{ID: 3, Name: "Keyboard", Price: 300},
+		{ID: 4, Name: "Monitor", Price: 900},
+		{ID: 5, Name: "Laptop", Price: 900},
+	}
+
+	after := func(price int64, id uint) any {
+		return mock.MatchedBy(func(c Cursor) bool {
+			return c.SortBy == "price" && c.Order == "asc" && c.Value == price && c.ID == id
+		})
+	}
+
+	t.Run("should walk every product once in stable order", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindAllWithPagination", ctx, 0, 2, "price", "asc", "", uint(0)).
+			Return([]Product{catalog[0], catalog[1]}, int64(5), nil)
+		mockRepo.On("FindAllAfterCursor", ctx, after(300, 2), 3, "", uint(0)).
+			Return([]Product{catalog[2], catalog[3], catalog[4]}, int64(5), nil).Once()
+		mockRepo.On("FindAllAfterCursor", ctx, after(900, 4), 3, "", uint(0)).
+			Return([]Product{catalog[4]}, int64(5), nil).Once()
+
+		result, err := service.GetAllProductsWithQuery(ctx, ProductQuery{
+			PaginationQuery: dto.PaginationQuery{PageSize: 2, Order: "asc"},
+			SortBy:          "price",
+		})
+		require.NoError(t, err)
+
+		var seen []uint
+		for _, p := range result.Data {
+			seen = append(seen, p.ID)
+		}
+		for result.Pagination.NextCursor != "" {
+			result, err = service.GetAllProductsWithQuery(ctx, ProductQuery{
+				PaginationQuery: dto.PaginationQuery{PageSize: 2},
+				After:           result.Pagination.NextCursor,
+			})
+			require.NoError(t, err)
+			for _, p := range result.Data {
+				seen = append(seen, p.ID)
+			}
+		}
+
+		// Products sharing a price stay in id order and straddle pages without repeats
+		assert.Equal(t, []uint{1, 2, 3, 4, 5}, seen)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a malformed cursor", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		result, err := service.GetAllProductsWithQuery(ctx, ProductQuery{After: "not-a-cursor"})
+
+		assert.Nil(t, result)
+		require.Error(t, err)
+		assert.Equal(t, ErrInvalidCursor, err.Error())
+		mockRepo.AssertNotCalled(t, "FindAllAfterCursor", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetProductByID_NegativeCache(t *testing.T) {
+	ctx := context.Background()
+	id := uint(42)
+
+	t.Run("should answer repeated misses from the cache", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, true, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindByID", ctx, id).Return(Product{}, gorm.ErrRecordNotFound).Once()
+
+		for range 3 {
+			product, err := service.GetProductByID(ctx, id)
+			assert.Nil(t, product)
+			require.Error(t, err)
+			assert.Equal(t, ErrProductNotFound, err.Error())
+		}
+
+		mockRepo.AssertNumberOfCalls(t, "FindByID", 1)
+		assert.True(t, mr.Exists(fmt.Sprintf(CacheKeyMissing, id)))
+	})
+
+	t.Run("should clear the missing marker when the product is created", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, true, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindByID", ctx, id).Return(Product{}, gorm.ErrRecordNotFound).Once()
+		_, err := service.GetProductByID(ctx, id)
+		require.Error(t, err)
+
+		mockRepo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*Product).ID = id
+		}).Return(nil)
+		_, err = service.CreateProduct(ctx, CreateProductRequest{Name: "Smartphone", Price: 1000, Stock: 5})
+		require.NoError(t, err)
+		assert.False(t, mr.Exists(fmt.Sprintf(CacheKeyMissing, id)))
+
+		mockRepo.On("FindByID", ctx, id).Return(Product{ID: id, Name: "Smartphone"}, nil).Once()
+		product, err := service.GetProductByID(ctx, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Smartphone", product.Name)
+		mockRepo.AssertNumberOfCalls(t, "FindByID", 2)
+	})
+
+	t.Run("should always hit the database when disabled", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindByID", ctx, id).Return(Product{}, gorm.ErrRecordNotFound)
+
+		_, _ = service.GetProductByID(ctx, id)
+		_, _ = service.GetProductByID(ctx, id)
+
+		mockRepo.AssertNumberOfCalls(t, "FindByID", 2)
+		assert.False(t, mr.Exists(fmt.Sprintf(CacheKeyMissing, id)))
+	})
+}
+
+func TestService_CacheBypass(t *testing.T) {
+	ctx := cache.WithBypass(context.Background())
+	id := uint(42)
+
+	t.Run("should read the product from the database and refresh the cache", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, true, "IDR", nil, nil, setupLogger())
+
+		key := fmt.Sprintf(CacheKeyProductByID, id)
+		require.NoError(t, redisCache.Set(ctx, key, Product{ID: id, Name: "Stale"}, CacheTTLProduct))
+		require.NoError(t, redisCache.Set(ctx, fmt.Sprintf(CacheKeyMissing, id), true, CacheTTLMissing))
+		mockRepo.On("FindByID", ctx, id).Return(Product{ID: id, Name: "Fresh"}, nil).Once()
+
+		product, err := service.GetProductByID(ctx, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Fresh", product.Name)
+		mockRepo.AssertExpectations(t)
+
+		var cached Product
+		require.NoError(t, redisCache.Get(context.Background(), key, &cached))
+		assert.Equal(t, "Fresh", cached.Name)
+		assert.False(t, mr.Exists(fmt.Sprintf(CacheKeyMissing, id)))
+	})
+
+	t.Run("should read the list from the database and refresh the cache", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("FindAllWithPagination", mock.Anything, 0, 10, "", "desc", "", uint(0)).
+			Return([]Product{{ID: 1, Name: "Stale"}}, int64(1), nil).Once()
+		_, err := service.GetAllProductsWithQuery(context.Background(), ProductQuery{})
+		require.NoError(t, err)
+
+		mockRepo.On("FindAllWithPagination", mock.Anything, 0, 10, "", "desc", "", uint(0)).
+			Return([]Product{{ID: 1, Name: "Fresh"}}, int64(1), nil).Once()
+		result, err := service.GetAllProductsWithQuery(ctx, ProductQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, "Fresh", result.Data[0].Name)
+
+		cached, err := service.GetAllProductsWithQuery(context.Background(), ProductQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, "Fresh", cached.Data[0].Name)
+		mockRepo.AssertNumberOfCalls(t, "FindAllWithPagination", 2)
+	})
+}
+
+func TestService_GetProductsByIDs(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should load only uncached products in one query", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		require.NoError(t, redisCache.Set(ctx, fmt.Sprintf(CacheKeyProductByID, 1), Product{ID: 1, Name: "Smartphone"}, time.Minute))
+		mockRepo.On("FindByIDs", ctx, []uint{2, 3}).Return([]Product{{ID: 2, Name: "Laptop"}}, nil).Once()
+
+		products, err := service.GetProductsByIDs(ctx, []uint{1, 2, 3})
+
+		require.NoError(t, err)
+		assert.Len(t, products, 2)
+		assert.Equal(t, "Smartphone", products[1].Name)
+		assert.Equal(t, "Laptop", products[2].Name)
+		assert.NotContains(t, products, uint(3))
+
+		// The product loaded from the database is now cached too
+		mockRepo.On("FindByIDs", ctx, []uint{3}).Return([]Product{}, nil).Once()
+		products, err = service.GetProductsByIDs(ctx, []uint{1, 2, 3})
+
+		require.NoError(t, err)
+		assert.Len(t, products, 2)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_InvalidateProducts(t *testing.T) {
+	ctx := context.Background()
+	redisCache, mr := setupTestCache(t)
+	service := NewService(new(MockRepository), new(MockCategoryRepository), redisCache, true, "IDR", nil, nil, setupLogger())
+
+	require.NoError(t, redisCache.Set(ctx, fmt.Sprintf(CacheKeyProductByID, 1), Product{ID: 1}, CacheTTLProduct))
+	require.NoError(t, redisCache.Set(ctx, fmt.Sprintf(CacheKeyProductByID, 2), Product{ID: 2}, CacheTTLProduct))
+
+	service.InvalidateProducts(ctx, []uint{1})
+
+	assert.False(t, mr.Exists(fmt.Sprintf(CacheKeyProductByID, 1)))
+	assert.True(t, mr.Exists(fmt.Sprintf(CacheKeyProductByID, 2)))
+	version, err := mr.Get(CacheKeyListVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "1", version)
+}
+
+func TestService_CreateProduct(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should create product in existing category", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockCategoryRepo := new(MockCategoryRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, mockCategoryRepo, redisCache, false, "IDR", nil, nil, setupLogger())
+
+		categoryID := uint(3)
+		input := CreateProductRequest{Name: "Smartphone", Price: 1000, Stock: 5, CategoryID: &categoryID}
+
+		mockCategoryRepo.On("FindByID", ctx, categoryID).Return(category.Category{ID: categoryID, Name: "Electronics"}, nil)
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(p *Product) bool {
+			return p.CategoryID != nil && *p.CategoryID == categoryID
+		})).Return(nil)
+
+		product, err := service.CreateProduct(ctx, input)
+
+		require.NoError(t, err)
+		require.NotNil(t, product.CategoryID)
+		assert.Equal(t, categoryID, *product.CategoryID)
+		mockCategoryRepo.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should default to the base currency", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*product.Product")).Return(nil)
+
+		product, err := service.CreateProduct(ctx, CreateProductRequest{Name: "Smartphone", Price: 1000, Stock: 5})
+
+		require.NoError(t, err)
+		assert.Equal(t, "IDR", product.Currency)
+	})
+
+	t.Run("should keep a non-default currency", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(p *Product) bool {
+			return p.Currency == "USD"
+		})).Return(nil)
+
+		product, err := service.CreateProduct(ctx, CreateProductRequest{Name: "Smartphone", Price: 1999, Stock: 5, Currency: "USD"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "USD", product.Currency)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject an invalid currency code", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		for _, currency := range []string{"XYZ", "usd", "RUPIAH"} {
+			product, err := service.CreateProduct(ctx, CreateProductRequest{Name: "Smartphone", Price: 1000, Stock: 5, Currency: currency})
+
+			assert.Nil(t, product, currency)
+			assert.Error(t, err, currency)
+		}
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject unknown category", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockCategoryRepo := new(MockCategoryRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, mockCategoryRepo, redisCache, false, "IDR", nil, nil, setupLogger())
+
+		categoryID := uint(99)
+		input := CreateProductRequest{Name: "Smartphone", Price: 1000, Stock: 5, CategoryID: &categoryID}
+
+		mockCategoryRepo.On("FindByID", ctx, categoryID).Return(category.Category{}, gorm.ErrRecordNotFound)
+
+		product, err := service.CreateProduct(ctx, input)
+
+		assert.Nil(t, product)
+		require.Error(t, err)
+		assert.Equal(t, category.ErrCategoryNotFound, err.Error())
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_GetAllProductsWithQuery_CategoryFilter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should filter by category and key cache by category", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, mr := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		products := []Product{{ID: 1, Name: "Smartphone"}}
+		mockRepo.On("FindAllWithPagination", ctx, 0, 10, "", "desc", "", uint(3)).Return(products, int64(1), nil)
+
+		result, err := service.GetAllProductsWithQuery(ctx, ProductQuery{CategoryID: 3})
+
+		require.NoError(t, err)
+		assert.Equal(t, products, result.Data)
+		assert.True(t, mr.Exists("product:list:v0:1:10::desc::3"))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_UpdateStock_BackInStock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should notify subscribers exactly once on restock", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		notifier := &spyNotifier{}
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", notifier, nil, setupLogger())
+
+		mockRepo.On("AdjustStock", ctx, uint(1), 5).Return(Product{ID: 1, Name: "Smartphone", Stock: 5}, nil).Once()
+		mockRepo.On("AdjustStock", ctx, uint(1), 5).Return(Product{ID: 1, Name: "Smartphone", Stock: 10}, nil).Once()
+		mockRepo.On("PopStockSubscribers", ctx, uint(1)).Return([]StockSubscriber{
+			{UserID: 2, Email: "a@example.com"},
+			{UserID: 3, Email: "b@example.com"},
+		}, nil).Once()
+
+		require.NoError(t, service.UpdateStock(ctx, 1, 5))
+		require.NoError(t, service.UpdateStock(ctx, 1, 5))
+
+		assert.Equal(t, []string{"a@example.com:Smartphone", "b@example.com:Smartphone"}, notifier.sent)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should not notify when stock was not sold out", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		notifier := &spyNotifier{}
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", notifier, nil, setupLogger())
+
+		mockRepo.On("AdjustStock", ctx, uint(1), 3).Return(Product{ID: 1, Name: "Smartphone", Stock: 5}, nil)
+
+		require.NoError(t, service.UpdateStock(ctx, 1, 3))
+
+		assert.Empty(t, notifier.sent)
+		mockRepo.AssertNotCalled(t, "PopStockSubscribers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should notify when a product update restocks", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		notifier := &spyNotifier{}
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", notifier, nil, setupLogger())
+
+		stock := 4
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Product{ID: 1, Name: "Smartphone", Stock: 0}, nil)
+		mockRepo.On("Update", ctx, mock.AnythingOfType("*product.Product")).Return(nil)
+		mockRepo.On("PopStockSubscribers", ctx, uint(1)).Return([]StockSubscriber{{UserID: 2, Email: "a@example.com"}}, nil).Once()
+
+		_, err := service.UpdateProduct(ctx, 1, UpdateProductRequest{Stock: &stock})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a@example.com:Smartphone"}, notifier.sent)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should notify the products a committed transaction restocked", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		notifier := &spyNotifier{}
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", notifier, nil, setupLogger())
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Product{ID: 1, Name: "Smartphone", Stock: 2}, nil)
+		mockRepo.On("FindByID", ctx, uint(2)).Return(Product{ID: 2, Name: "Laptop", Stock: 0}, nil)
+		mockRepo.On("FindByID", ctx, uint(3)).Return(Product{}, gorm.ErrRecordNotFound)
+		mockRepo.On("PopStockSubscribers", ctx, uint(1)).Return([]StockSubscriber{{UserID: 2, Email: "a@example.com"}}, nil).Once()
+
+		service.NotifyRestocked(ctx, []uint{1, 2, 3})
+
+		assert.Equal(t, []string{"a@example.com:Smartphone"}, notifier.sent)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// stockRepository keeps stock in memory and applies each adjustment under a lock, the
+// way the conditional UPDATE behind AdjustStock is atomic in the database
+type stockRepository struct {
+	MockRepository
+	mu       sync.Mutex
+	products map[uint]*Product
+}
+
+func (r *stockRepository) AdjustStock(ctx context.Context, id uint, delta int) (Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok || p.Stock+delta < 0 {
+		return Product{}, gorm.ErrRecordNotFound
+	}
+	p.Stock += delta
+	return *p, nil
+}
+
+func (r *stockRepository) FindByID(ctx context.Context, id uint) (Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return Product{}, gorm.ErrRecordNotFound
+	}
+	return *p, nil
+}
+
+func TestService_UpdateStock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should let exactly one of two concurrent adjustments take the last unit", func(t *testing.T) {
+		repo := &stockRepository{products: map[uint]*Product{
+			1: {ID: 1, Name: "Smartphone", Stock: 1},
+		}}
+		redisCache, _ := setupTestCache(t)
+		service := NewService(repo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = service.UpdateStock(ctx, 1, -1)
+			}(i)
+		}
+		wg.Wait()
+
+		var succeeded, insufficient int
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case err.Error() == ErrInsufficientStock:
+				insufficient++
+			}
+		}
+
+		assert.Equal(t, 1, succeeded)
+		assert.Equal(t, 1, insufficient)
+		assert.Equal(t, 0, repo.products[1].Stock)
+	})
+
+	t.Run("should keep every concurrent adjustment", func(t *testing.T) {
+		repo := &stockRepository{products: map[uint]*Product{
+			1: {ID: 1, Name: "Smartphone", Stock: 10},
+		}}
+		redisCache, _ := setupTestCache(t)
+		service := NewService(repo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		var wg sync.WaitGroup
+		for _, delta := range []int{5, -1, -1, 5, -1} {
+			wg.Add(1)
+			go func(delta int) {
+				defer wg.Done()
+				assert.NoError(t, service.UpdateStock(ctx, 1, delta))
+			}(delta)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 17, repo.products[1].Stock)
+	})
+
+	t.Run("should return not found for a missing product", func(t *testing.T) {
+		repo := &stockRepository{products: map[uint]*Product{}}
+		redisCache, _ := setupTestCache(t)
+		service := NewService(repo, new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+
+		err := service.UpdateStock(ctx, 1, 1)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrProductNotFound, err.Error())
+	})
+}
+
+func TestService_SubscribeToRestock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should subscribe to a sold out product", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", &spyNotifier{}, nil, setupLogger())
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Product{ID: 1, Stock: 0}, nil)
+		mockRepo.On("CreateStockSubscription", ctx, &StockSubscription{UserID: 7, ProductID: 1}).Return(nil)
+
+		require.NoError(t, service.SubscribeToRestock(ctx, 1, 7))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a product that is in stock", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(mockRepo, new(MockCategoryRepository), redisCache, false, "IDR", &spyNotifier{}, nil, setupLogger())
+
+		mockRepo.On("FindByID", ctx, uint(1)).Return(Product{ID: 1, Stock: 3}, nil)
+
+		err := service.SubscribeToRestock(ctx, 1, 7)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrProductInStock, err.Error())
+		mockRepo.AssertNotCalled(t, "CreateStockSubscription", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_UpdateStockWithTx(t *testing.T) {
+	t.Run("should lock the product row before updating stock", func(t *testing.T) {
+		db, sqlMock := setupTestDB(t)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(new(MockRepository), new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+		now := time.Now()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."id" = $1 AND "products"."deleted_at" IS NULL ORDER BY "products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}).
+				AddRow(1, "Smartphone", 1000, 1, now, now))
+		sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return service.UpdateStockWithTx(tx, 1, -1)
+		})
+
+		require.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return insufficient stock inside the transaction", func(t *testing.T) {
+		db, sqlMock := setupTestDB(t)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(new(MockRepository), new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+		now := time.Now()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at"}).
+				AddRow(1, "Smartphone", 1000, 0, now, now))
+		sqlMock.ExpectRollback()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return service.UpdateStockWithTx(tx, 1, -1)
+		})
+
+		require.Error(t, err)
+		assert.Equal(t, ErrInsufficientStock, err.Error())
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should restock soft deleted product", func(t *testing.T) {
+		db, sqlMock := setupTestDB(t)
+		redisCache, _ := setupTestCache(t)
+		service := NewService(new(MockRepository), new(MockCategoryRepository), redisCache, false, "IDR", nil, nil, setupLogger())
+		now := time.Now()
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "products" WHERE "products"."id" = $1 ORDER BY "products"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "Smartphone", 1000, 0, now, now, now))
+		sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "products" SET`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return service.UpdateStockWithTx(tx, 1, 2)
+		})
+
+		require.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
@@ -4,6 +4,7 @@ const (
 	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"
 	ErrCodeUnauthorized       = "UNAUTHORIZED"
 	ErrCodeForbidden          = "FORBIDDEN"
+	ErrCodeCSRFTokenInvalid   = "CSRF_TOKEN_INVALID"
 
 	ErrCodeDataNotFound      = "DATA_NOT_FOUND"
 	ErrCodeDataAlreadyExists = "DATA_ALREADY_EXISTS"
@@ -14,4 +15,27 @@ const (
 	ErrCodeValidationError = "VALIDATION_ERROR"
 	ErrCodeDatabaseError   = "DATABASE_ERROR"
 	ErrCodeInternalServer  = "INTERNAL_SERVER_ERROR"
+
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	ErrCodeTooManyRequests    = "TOO_MANY_REQUESTS"
+	ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+
+	ErrCodeOrderNotFound           = "ORDER_NOT_FOUND"
+	ErrCodeOrderForbidden          = "ORDER_FORBIDDEN"
+	ErrCodeProductNotFound         = "PRODUCT_NOT_FOUND"
+	ErrCodeInsufficientStock       = "INSUFFICIENT_STOCK"
+	ErrCodeProductInStock          = "PRODUCT_IN_STOCK"
+	ErrCodeInvalidCoupon           = "INVALID_COUPON"
+	ErrCodeInvalidOrderStatus      = "INVALID_ORDER_STATUS"
+	ErrCodeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
+	ErrCodeInvalidDateRange        = "INVALID_DATE_RANGE"
+	ErrCodeCurrencyMismatch        = "CURRENCY_MISMATCH"
+
+	ErrCodeCancellationWindowExpired = "CANCELLATION_WINDOW_EXPIRED"
+	ErrCodePaymentDeclined           = "PAYMENT_DECLINED"
+	ErrCodeConcurrentUpdate          = "CONCURRENT_UPDATE"
+	ErrCodeUnsupportedMediaType      = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeReviewNotAllowed          = "REVIEW_NOT_ALLOWED"
+	ErrCodeAccountDeactivated        = "ACCOUNT_DEACTIVATED"
+	ErrCodeTokenExpired              = "TOKEN_EXPIRED"
 )
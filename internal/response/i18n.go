@@ -0,0 +1,135 @@
+package response
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultLanguage = "en"
+
+// messageCatalog holds the user facing message of every error code per language.
+// Codes stay the same in every language, only the message changes.
+var messageCatalog = map[string]map[string]string{
+	"en": {
+		ErrCodeInvalidCredentials:        "Invalid credentials",
+		ErrCodeUnauthorized:              "Authentication required",
+		ErrCodeForbidden:                 "You are not allowed to access this resource",
+		ErrCodeCSRFTokenInvalid:          "Invalid CSRF token",
+		ErrCodeDataNotFound:              "Data not found",
+		ErrCodeDataAlreadyExists:         "Data already exists",
+		ErrCodeDataCreateFail:            "Failed to create data",
+		ErrCodeDataUpdateFail:            "Failed to update data",
+		ErrCodeDataDeleteFail:            "Failed to delete data",
+		ErrCodeValidationError:           "Invalid request",
+		ErrCodeDatabaseError:             "Database error",
+		ErrCodeInternalServer:            "Internal server error",
+		ErrCodePayloadTooLarge:           "Request body too large",
+		ErrCodeTooManyRequests:           "Too many requests, please try again later",
+		ErrCodeServiceUnavailable:        "Service temporarily unavailable",
+		ErrCodeOrderNotFound:             "Order not found",
+		ErrCodeOrderForbidden:            "You are not allowed to access this order",
+		ErrCodeProductNotFound:           "Product not found",
+		ErrCodeInsufficientStock:         "Insufficient stock",
+		ErrCodeProductInStock:            "Product is already in stock",
+		ErrCodeInvalidCoupon:             "Invalid coupon",
+		ErrCodeInvalidOrderStatus:        "Invalid order status",
+		ErrCodeInvalidStatusTransition:   "Invalid order status transition",
+		ErrCodeInvalidDateRange:          "Invalid date range",
+		ErrCodeCurrencyMismatch:          "Products in one order must share a currency",
+		ErrCodeCancellationWindowExpired: "The order can no longer be cancelled",
+		ErrCodePaymentDeclined:           "The payment was declined",
+		ErrCodeConcurrentUpdate:          "The data was changed by another request, please reload and try again",
+		ErrCodeUnsupportedMediaType:      "Unsupported file type",
+		ErrCodeReviewNotAllowed:          "Only customers who bought the product can review it",
+		ErrCodeAccountDeactivated:        "This account has been deactivated",
+		ErrCodeTokenExpired:              "The access token has expired, refresh it to continue",
+	},
+	"id": {
+		ErrCodeInvalidCredentials:        "Kredensial tidak valid",
+		ErrCodeUnauthorized:              "Autentikasi diperlukan",
+		ErrCodeForbidden:                 "Anda tidak diizinkan mengakses sumber daya ini",
+		ErrCodeCSRFTokenInvalid:          "Token CSRF tidak valid",
+		ErrCodeDataNotFound:              "Data tidak ditemukan",
+		ErrCodeDataAlreadyExists:         "Data sudah ada",
+		ErrCodeDataCreateFail:            "Gagal membuat data",
+		ErrCodeDataUpdateFail:            "Gagal memperbarui data",
+		ErrCodeDataDeleteFail:            "Gagal menghapus data",
+		ErrCodeValidationError:           "Permintaan tidak valid",
+		ErrCodeDatabaseError:             "Kesalahan basis data",
+		ErrCodeInternalServer:            "Terjadi kesalahan pada server",
+		ErrCodePayloadTooLarge:           "Ukuran permintaan terlalu besar",
+		ErrCodeTooManyRequests:           "Terlalu banyak permintaan, silakan coba lagi nanti",
+		ErrCodeServiceUnavailable:        "Layanan sementara tidak tersedia",
+		ErrCodeOrderNotFound:             "Pesanan tidak ditemukan",
+		ErrCodeOrderForbidden:            "Anda tidak diizinkan mengakses pesanan ini",
+		ErrCodeProductNotFound:           "Produk tidak ditemukan",
+		ErrCodeInsufficientStock:         "Stok tidak mencukupi",
+		ErrCodeProductInStock:            "Produk masih tersedia",
+		ErrCodeInvalidCoupon:             "Kupon tidak valid",
+		ErrCodeInvalidOrderStatus:        "Status pesanan tidak valid",
+		ErrCodeInvalidStatusTransition:   "Perubahan status pesanan tidak valid",
+		ErrCodeInvalidDateRange:          "Rentang tanggal tidak valid",
+		ErrCodeCurrencyMismatch:          "Produk dalam satu pesanan harus memiliki mata uang yang sama",
+		ErrCodeCancellationWindowExpired: "Pesanan sudah tidak dapat dibatalkan",
+		ErrCodePaymentDeclined:           "Pembayaran ditolak",
+		ErrCodeConcurrentUpdate:          "Data telah diubah oleh permintaan lain, silakan muat ulang dan coba lagi",
+		ErrCodeUnsupportedMediaType:      "Jenis berkas tidak didukung",
+		ErrCodeReviewNotAllowed:          "Hanya pelanggan yang telah membeli produk ini yang dapat mengulasnya",
+		ErrCodeAccountDeactivated:        "Akun ini telah dinonaktifkan",
+		ErrCodeTokenExpired:              "Token akses telah kedaluwarsa, perbarui token untuk melanjutkan",
+	},
+}
+
+// LocalizedMessage returns the catalog message of code in lang
+func LocalizedMessage(lang, code string) (string, bool) {
+	message, ok := messageCatalog[lang][code]
+	return message, ok
+}
+
+// NegotiateLanguage picks the supported language the client prefers most from an
+// Accept-Language header, falling back to DefaultLanguage. Region subtags are ignored.
+func NegotiateLanguage(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if _, ok := messageCatalog[lang]; ok && q > 0 {
+			candidates = append(candidates, candidate{lang: lang, q: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	return candidates[0].lang
+}
+
+// localizeMessage resolves the message sent with an error. Other languages always use
+// the catalog, English keeps the handler's more specific message unless the handler
+// only passed the code itself.
+func localizeMessage(lang, code, message string) string {
+	if lang == DefaultLanguage && message != "" && message != code {
+		return message
+	}
+	if localized, ok := LocalizedMessage(lang, code); ok {
+		return localized
+	}
+	return message
+}
@@ -1,8 +1,12 @@
 package response
 
 import (
+	"errors"
+	"mini-e-commerce/internal/dto"
 	"mini-e-commerce/internal/logger"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -21,8 +25,9 @@ type ErrorResponse struct {
 }
 
 type ErrorInfo struct {
-	Code    string `json:"code"`
-	Details string `json:"details"`
+	Code    string            `json:"code"`
+	Details string            `json:"details"`
+	Fields  map[string]string `json:"fields,omitempty"`
 }
 
 type ResponseHelper struct {
@@ -61,21 +66,30 @@ func (r *ResponseHelper) SuccessCreated(c *gin.Context, message string, data any
 }
 
 func (r *ResponseHelper) Error(c *gin.Context, statusCode int, message string, errorCode string, details string) {
+	r.writeError(c, statusCode, message, ErrorInfo{
+		Code:    errorCode,
+		Details: details,
+	})
+}
+
+func (r *ResponseHelper) writeError(c *gin.Context, statusCode int, message string, info ErrorInfo) {
+	lang := NegotiateLanguage(c.GetHeader("Accept-Language"))
+	message = localizeMessage(lang, info.Code, message)
+	c.Header("Content-Language", lang)
+
 	response := &ErrorResponse{
 		Success: false,
 		Message: message,
-		Error: ErrorInfo{
-			Code:    errorCode,
-			Details: details,
-		},
+		Error:   info,
 	}
 
 	ctxLogger := r.logger.WithContext(c)
 	ctxLogger.Error("API Error Response",
 		zap.Int("status_code", statusCode),
 		zap.String("message", message),
-		zap.String("error_code", errorCode),
-		zap.String("error_details", details),
+		zap.String("error_code", info.Code),
+		zap.String("error_details", info.Details),
+		zap.Any("error_fields", info.Fields),
 		zap.String("method", c.Request.Method),
 		zap.String("path", c.Request.URL.Path),
 		zap.String("remote_addr", c.ClientIP()),
@@ -89,6 +103,29 @@ func (r *ResponseHelper) BadRequest(c *gin.Context, message string, details stri
 	r.Error(c, http.StatusBadRequest, message, ErrCodeValidationError, details)
 }
 
+// ValidationError responds with 400 for a failed request bind. Validator failures are
+// reported per field in Error.Fields, anything else (e.g. malformed JSON) in Error.Details.
+func (r *ResponseHelper) ValidationError(c *gin.Context, err error) {
+	// Binding fails this way when the body went past middleware.BodyLimit
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		r.Error(c, http.StatusRequestEntityTooLarge, "Request body too large", ErrCodePayloadTooLarge, err.Error())
+		return
+	}
+
+	fields := ValidationFields(err)
+	if fields == nil {
+		r.BadRequest(c, ErrCodeValidationError, err.Error())
+		return
+	}
+
+	r.writeError(c, http.StatusBadRequest, ErrCodeValidationError, ErrorInfo{
+		Code:    ErrCodeValidationError,
+		Details: "one or more fields are invalid",
+		Fields:  fields,
+	})
+}
+
 func (r *ResponseHelper) NotFound(c *gin.Context, message string, details string) {
 	r.Error(c, http.StatusNotFound, message, ErrCodeDataNotFound, details)
 }
@@ -97,12 +134,13 @@ func (r *ResponseHelper) InternalServerError(c *gin.Context, message string, det
 	r.Error(c, http.StatusInternalServerError, message, ErrCodeInternalServer, details)
 }
 
-func (r *ResponseHelper) SuccessPaginated(c *gin.Context, message string, data any, pagination any) {
+func (r *ResponseHelper) SuccessPaginated(c *gin.Context, message string, data any, pagination dto.PaginationMetadata) {
 	response := gin.H{
 		"success":    true,
 		"message":    message,
 		"data":       data,
 		"pagination": pagination,
+		"links":      paginationLinks(c.Request.URL, pagination),
 	}
 
 	ctxLogger := r.logger.WithContext(c)
@@ -116,3 +154,45 @@ func (r *ResponseHelper) SuccessPaginated(c *gin.Context, message string, data a
 
 	c.JSON(http.StatusOK, response)
 }
+
+// paginationLinks rebuilds the request URL for other pages, keeping every other query param.
+// Keyset pages carry no page number, so only their next link follows the cursor.
+func paginationLinks(u *url.URL, pagination dto.PaginationMetadata) dto.PaginationLinks {
+	lastPage := pagination.TotalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	pageURL := func(page int) string {
+		query := u.Query()
+		query.Del("after")
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(pagination.PageSize))
+		return u.Path + "?" + query.Encode()
+	}
+
+	links := dto.PaginationLinks{
+		First: pageURL(1),
+		Last:  pageURL(lastPage),
+	}
+	if pagination.Page == 0 {
+		if pagination.NextCursor != "" {
+			query := u.Query()
+			query.Del("page")
+			query.Set("after", pagination.NextCursor)
+			query.Set("page_size", strconv.Itoa(pagination.PageSize))
+			next := u.Path + "?" + query.Encode()
+			links.Next = &next
+		}
+		return links
+	}
+	if pagination.Page > 1 {
+		prev := pageURL(min(pagination.Page-1, lastPage))
+		links.Prev = &prev
+	}
+	if pagination.Page < lastPage {
+		next := pageURL(pagination.Page + 1)
+		links.Next = &next
+	}
+	return links
+}
@@ -0,0 +1,171 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mini-e-commerce/internal/dto"
+	"mini-e-commerce/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func setupHelper(t *testing.T) *ResponseHelper {
+	t.Helper()
+
+	log, err := logger.NewLogger(&logger.Config{
+		ServiceName: "test",
+		AppVersion:  "test",
+		LogLevel:    zapcore.FatalLevel,
+		Mode:        "development",
+	})
+	require.NoError(t, err)
+
+	return NewResponseHelper(log)
+}
+
+func paginate(t *testing.T, target string, pagination dto.PaginationMetadata) dto.PaginationLinks {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+
+	setupHelper(t).SuccessPaginated(c, "ok", []string{}, pagination)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Links dto.PaginationLinks `json:"links"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Links
+}
+
+func TestResponseHelper_SuccessPaginated(t *testing.T) {
+	t.Run("should build all links for a middle page", func(t *testing.T) {
+		links := paginate(t, "/api/products?page=2&page_size=10&search=phone", dto.PaginationMetadata{
+			Page: 2, PageSize: 10, Total: 45, TotalPages: 5,
+		})
+
+		assert.Equal(t, "/api/products?page=1&page_size=10&search=phone", links.First)
+		require.NotNil(t, links.Prev)
+		assert.Equal(t, "/api/products?page=1&page_size=10&search=phone", *links.Prev)
+		require.NotNil(t, links.Next)
+		assert.Equal(t, "/api/products?page=3&page_size=10&search=phone", *links.Next)
+		assert.Equal(t, "/api/products?page=5&page_size=10&search=phone", links.Last)
+	})
+
+	t.Run("should null out prev on the first page and next on the last page", func(t *testing.T) {
+		first := paginate(t, "/api/orders", dto.PaginationMetadata{Page: 1, PageSize: 10, Total: 30, TotalPages: 3})
+		assert.Nil(t, first.Prev)
+		require.NotNil(t, first.Next)
+		assert.Equal(t, "/api/orders?page=2&page_size=10", *first.Next)
+
+		last := paginate(t, "/api/orders?page=3", dto.PaginationMetadata{Page: 3, PageSize: 10, Total: 30, TotalPages: 3})
+		require.NotNil(t, last.Prev)
+		assert.Equal(t, "/api/orders?page=2&page_size=10", *last.Prev)
+		assert.Nil(t, last.Next)
+	})
+
+	t.Run("should point to page one when there are no results", func(t *testing.T) {
+		links := paginate(t, "/api/orders", dto.PaginationMetadata{Page: 1, PageSize: 10})
+
+		assert.Equal(t, "/api/orders?page=1&page_size=10", links.First)
+		assert.Equal(t, "/api/orders?page=1&page_size=10", links.Last)
+		assert.Nil(t, links.Prev)
+		assert.Nil(t, links.Next)
+	})
+
+	t.Run("should follow the cursor on keyset pages", func(t *testing.T) {
+		links := paginate(t, "/api/products?after=abc&page_size=10", dto.PaginationMetadata{
+			PageSize: 10, Total: 45, TotalPages: 5, NextCursor: "def",
+		})
+
+		require.NotNil(t, links.Next)
+		assert.Equal(t, "/api/products?after=def&page_size=10", *links.Next)
+		assert.Nil(t, links.Prev)
+		assert.Equal(t, "/api/products?page=1&page_size=10", links.First)
+	})
+}
+
+func TestResponseHelper_ErrorLogsRequestContext(t *testing.T) {
+	t.Run("should log error responses with the request and user ID from the context", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		core, logs := observer.New(zapcore.InfoLevel)
+		helper := NewResponseHelper(logger.NewLoggerFromZap(zap.New(core), &logger.Config{}))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/orders/9", nil)
+		c.Set(logger.RequestIDKey, "req-abc")
+		c.Set(logger.UserIDKey, uint(7))
+
+		helper.NotFound(c, ErrCodeDataNotFound, "order not found")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		entries := logs.FilterMessage("API Error Response").All()
+		require.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "req-abc", fields[logger.RequestIDKey])
+		assert.Equal(t, "7", fields[logger.UserIDKey])
+		assert.Equal(t, int64(http.StatusNotFound), fields["status_code"])
+	})
+}
+
+func TestResponseHelper_LocalizedErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	notFound := func(t *testing.T, acceptLanguage, message string) (ErrorResponse, http.Header) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/orders/1", nil)
+		if acceptLanguage != "" {
+			c.Request.Header.Set("Accept-Language", acceptLanguage)
+		}
+
+		setupHelper(t).NotFound(c, message, "record not found")
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		var body ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body, w.Header()
+	}
+
+	t.Run("should localize message for Accept-Language id", func(t *testing.T) {
+		body, header := notFound(t, "id", "Order not found")
+
+		assert.Equal(t, "Data tidak ditemukan", body.Message)
+		assert.Equal(t, ErrCodeDataNotFound, body.Error.Code)
+		assert.Equal(t, "id", header.Get("Content-Language"))
+	})
+
+	t.Run("should honor region tags and quality values", func(t *testing.T) {
+		body, _ := notFound(t, "fr;q=1.0, en;q=0.5, id-ID;q=0.8", "Order not found")
+
+		assert.Equal(t, "Data tidak ditemukan", body.Message)
+	})
+
+	t.Run("should keep handler message in default language", func(t *testing.T) {
+		body, header := notFound(t, "", "Order not found")
+
+		assert.Equal(t, "Order not found", body.Message)
+		assert.Equal(t, DefaultLanguage, header.Get("Content-Language"))
+	})
+
+	t.Run("should replace a bare code with the english message", func(t *testing.T) {
+		body, _ := notFound(t, "en-US", ErrCodeDataNotFound)
+
+		assert.Equal(t, "Data not found", body.Message)
+		assert.Equal(t, ErrCodeDataNotFound, body.Error.Code)
+	})
+}
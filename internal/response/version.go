@@ -0,0 +1,54 @@
+package response
+
+import (
+	"mini-e-commerce/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// API versions, v1 is served under /api and every later version under /api/<version>
+const (
+	APIVersion1 = "v1"
+	APIVersion2 = "v2"
+)
+
+// DataResponse is the v2 success envelope. It drops the success flag and message of
+// SuccessResponse, clients rely on the status code instead.
+type DataResponse struct {
+	Data any   `json:"data"`
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// Meta groups listing metadata that v1 puts at the top level of the response
+type Meta struct {
+	Pagination dto.PaginationMetadata `json:"pagination"`
+	Links      dto.PaginationLinks    `json:"links"`
+}
+
+func (r *ResponseHelper) Data(c *gin.Context, statusCode int, data any) {
+	r.writeData(c, statusCode, &DataResponse{Data: data})
+}
+
+func (r *ResponseHelper) DataPaginated(c *gin.Context, data any, pagination dto.PaginationMetadata) {
+	r.writeData(c, http.StatusOK, &DataResponse{
+		Data: data,
+		Meta: &Meta{
+			Pagination: pagination,
+			Links:      paginationLinks(c.Request.URL, pagination),
+		},
+	})
+}
+
+func (r *ResponseHelper) writeData(c *gin.Context, statusCode int, response *DataResponse) {
+	ctxLogger := r.logger.WithContext(c)
+	ctxLogger.Info("API Success Response",
+		zap.Int("status_code", statusCode),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.String("user_agent", c.Request.UserAgent()),
+	)
+
+	c.JSON(statusCode, response)
+}
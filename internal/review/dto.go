@@ -0,0 +1,17 @@
+package review
+
+import "mini-e-commerce/internal/dto"
+
+type CreateReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=2000" validate:"max=2000"`
+}
+
+type ReviewQuery struct {
+	dto.PaginationQuery
+}
+
+type ReviewListResponse struct {
+	Data       []Review               `json:"data"`
+	Pagination dto.PaginationMetadata `json:"pagination"`
+}
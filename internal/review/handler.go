@@ -0,0 +1,194 @@
+package review
+
+import (
+	"errors"
+	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/product"
+	"mini-e-commerce/internal/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	ErrMsgInvalidProductID = "Invalid product ID"
+	ErrMsgFailedToCreate   = "Failed to create review"
+	ErrMsgFailedToFetch    = "Failed to fetch reviews"
+	ErrMsgFailedToRate     = "Failed to fetch product rating"
+
+	ErrMsgInvalidUserContext = "Invalid user id in context"
+)
+
+var errMissingUserID = errors.New("missing user_id in context")
+
+type Handler struct {
+	service        Service
+	logger         logger.Logger
+	responseHelper *response.ResponseHelper
+}
+
+func NewHandler(service Service, log logger.Logger) *Handler {
+	return &Handler{
+		service:        service,
+		logger:         log,
+		responseHelper: response.NewResponseHelper(log),
+	}
+}
+
+// RegisterRoutes adds the review routes under /products/:id, reading reviews needs no login
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	public := r.Group("/products")
+	public.GET("/:id/reviews", h.GetReviews)
+	public.GET("/:id/rating", h.GetRatingSummary)
+
+	group := r.Group("/products", authMiddleware)
+	group.POST("/:id/reviews", h.CreateReview)
+}
+
+// CreateReview godoc
+// @Summary Review a product
+// @Description Rate a product from 1 to 5 with an optional comment. Only customers with a paid order containing the product can review it, once.
+// @Tags Reviews
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   request body CreateReviewRequest true "Review request body"
+// @Success 201 {object} response.SuccessResponse{data=Review}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/reviews [post]
+func (h *Handler) CreateReview(c *gin.Context) {
+	productID, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	userID, err := h.getUserIDFromContext(c)
+	if err != nil {
+		if errors.Is(err, errMissingUserID) {
+			h.responseHelper.Error(c, http.StatusUnauthorized, response.ErrCodeUnauthorized, response.ErrCodeUnauthorized, err.Error())
+		} else {
+			h.responseHelper.InternalServerError(c, ErrMsgInvalidUserContext, err.Error())
+		}
+		return
+	}
+
+	var input CreateReviewRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	review, err := h.service.CreateReview(c.Request.Context(), productID, userID, input)
+	if err != nil {
+		switch err.Error() {
+		case product.ErrProductNotFound:
+			h.responseHelper.NotFound(c, response.ErrCodeProductNotFound, err.Error())
+		case ErrNotPurchased:
+			h.responseHelper.Error(c, http.StatusForbidden, ErrMsgFailedToCreate, response.ErrCodeReviewNotAllowed, err.Error())
+		case ErrAlreadyReviewed:
+			h.responseHelper.Error(c, http.StatusConflict, ErrMsgFailedToCreate, response.ErrCodeDataAlreadyExists, err.Error())
+		default:
+			h.responseHelper.InternalServerError(c, ErrMsgFailedToCreate, err.Error())
+		}
+		return
+	}
+
+	h.logger.WithContext(c).Info("Product reviewed",
+		zap.Uint("review_id", review.ID),
+		zap.Uint("product_id", productID),
+		zap.Int("rating", review.Rating),
+	)
+
+	h.responseHelper.SuccessCreated(c, "Review created successfully", review)
+}
+
+// GetReviews godoc
+// @Summary List product reviews
+// @Description List the reviews of a product, newest first
+// @Tags Reviews
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param page query int false "Page number" minimum(1) default(1)
+// @Param page_size query int false "Page size, values above 100 are clamped" minimum(1) default(10)
+// @Success 200 {object} response.SuccessResponse{data=[]Review}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/reviews [get]
+func (h *Handler) GetReviews(c *gin.Context) {
+	productID, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	var query ReviewQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.responseHelper.ValidationError(c, err)
+		return
+	}
+
+	result, err := h.service.GetReviews(c.Request.Context(), productID, query)
+	if err != nil {
+		if err.Error() == product.ErrProductNotFound {
+			h.responseHelper.NotFound(c, response.ErrCodeProductNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToFetch, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessPaginated(c, "Reviews retrieved successfully", result.Data, result.Pagination)
+}
+
+// GetRatingSummary godoc
+// @Summary Get product rating
+// @Description Get the average rating and number of reviews of a product
+// @Tags Reviews
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Success 200 {object} response.SuccessResponse{data=RatingSummary}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /products/{id}/rating [get]
+func (h *Handler) GetRatingSummary(c *gin.Context) {
+	productID, err := ParseIDFromString(c.Param("id"))
+	if err != nil {
+		h.responseHelper.BadRequest(c, ErrMsgInvalidProductID, err.Error())
+		return
+	}
+
+	summary, err := h.service.GetRatingSummary(c.Request.Context(), productID)
+	if err != nil {
+		if err.Error() == product.ErrProductNotFound {
+			h.responseHelper.NotFound(c, response.ErrCodeProductNotFound, err.Error())
+			return
+		}
+		h.responseHelper.InternalServerError(c, ErrMsgFailedToRate, err.Error())
+		return
+	}
+
+	h.responseHelper.SuccessOK(c, "Product rating retrieved successfully", summary)
+}
+
+func (h *Handler) getUserIDFromContext(c *gin.Context) (uint, error) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return 0, errMissingUserID
+	}
+	userIDUint, ok := userID.(uint)
+	if !ok {
+		return 0, errors.New("invalid user_id type in context")
+	}
+	return userIDUint, nil
+}
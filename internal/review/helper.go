@@ -0,0 +1,5 @@
+package review
+
+import "mini-e-commerce/internal/utils"
+
+var ParseIDFromString = utils.ParseIDFromString
@@ -0,0 +1,21 @@
+package review
+
+import "time"
+
+// Review is a customer's rating of a product they bought, each user reviews a product once
+type Review struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_reviews_product_user" json:"product_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_reviews_product_user;index" json:"user_id"`
+	Rating    int       `gorm:"not null" json:"rating"`
+	Comment   string    `gorm:"type:text;not null;default:''" json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RatingSummary is the average rating of a product, AverageRating is zero without reviews
+type RatingSummary struct {
+	ProductID     uint    `json:"product_id"`
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int64   `json:"review_count"`
+}
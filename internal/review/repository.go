@@ -0,0 +1,68 @@
+package review
+
+import (
+	"context"
+	"mini-e-commerce/internal/order"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, review *Review) error
+	FindByProductAndUser(ctx context.Context, productID, userID uint) (Review, error)
+	FindByProduct(ctx context.Context, productID uint, offset, limit int) ([]Review, int64, error)
+	RatingSummary(ctx context.Context, productID uint) (RatingSummary, error)
+	HasPurchased(ctx context.Context, userID, productID uint) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, review *Review) error {
+	return r.db.WithContext(ctx).Create(review).Error
+}
+
+func (r *repository) FindByProductAndUser(ctx context.Context, productID, userID uint) (Review, error) {
+	var review Review
+	err := r.db.WithContext(ctx).Where("product_id = ? AND user_id = ?", productID, userID).First(&review).Error
+	return review, err
+}
+
+// FindByProduct returns a page of the product's reviews, newest first, with the total count
+func (r *repository) FindByProduct(ctx context.Context, productID uint, offset, limit int) ([]Review, int64, error) {
+	var total int64
+	query := r.db.WithContext(ctx).Model(&Review{}).Where("product_id = ?", productID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []Review
+	err := query.Order("created_at desc, id desc").Offset(offset).Limit(limit).Find(&reviews).Error
+	return reviews, total, err
+}
+
+func (r *repository) RatingSummary(ctx context.Context, productID uint) (RatingSummary, error) {
+	var summary RatingSummary
+	err := r.db.WithContext(ctx).Model(&Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average_rating, COUNT(*) AS review_count").
+		Where("product_id = ?", productID).
+		Scan(&summary).Error
+	summary.ProductID = productID
+	return summary, err
+}
+
+// HasPurchased reports whether the user has a paid order containing the product
+func (r *repository) HasPurchased(ctx context.Context, userID, productID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&order.Order{}).
+		Joins("JOIN order_items ON order_items.order_id = orders.id").
+		Where("orders.user_id = ? AND order_items.product_id = ? AND orders.status = ?", userID, productID, order.StatusPaid).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
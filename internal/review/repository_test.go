@@ -0,0 +1,72 @@
+package review
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return gormDB, mock
+}
+
+func TestRepository_RatingSummary(t *testing.T) {
+	query := regexp.QuoteMeta(`SELECT COALESCE(AVG(rating), 0) AS average_rating, COUNT(*) AS review_count FROM "reviews" WHERE product_id = $1`)
+
+	t.Run("should average the ratings of the product", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectQuery(query).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"average_rating", "review_count"}).AddRow(4.25, 4))
+
+		summary, err := repo.RatingSummary(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, RatingSummary{ProductID: 1, AverageRating: 4.25, ReviewCount: 4}, summary)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should report zero for a product without reviews", func(t *testing.T) {
+		db, mock := setupTestDB(t)
+		repo := NewRepository(db)
+
+		mock.ExpectQuery(query).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"average_rating", "review_count"}).AddRow(0, 0))
+
+		summary, err := repo.RatingSummary(context.Background(), 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, RatingSummary{ProductID: 2}, summary)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRepository_HasPurchased(t *testing.T) {
+	db, mock := setupTestDB(t)
+	repo := NewRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "orders" JOIN order_items ON order_items.order_id = orders.id WHERE (orders.user_id = $1 AND order_items.product_id = $2 AND orders.status = $3) AND "orders"."deleted_at" IS NULL LIMIT $4`)).
+		WithArgs(7, 1, "PAID", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	purchased, err := repo.HasPurchased(context.Background(), 7, 1)
+
+	require.NoError(t, err)
+	assert.True(t, purchased)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
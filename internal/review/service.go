@@ -0,0 +1,162 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/dto"
+	"mini-e-commerce/internal/product"
+	"mini-e-commerce/internal/tracing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrAlreadyReviewed = "product already reviewed"
+	ErrNotPurchased    = "only customers who bought the product can review it"
+	CacheKeyRating     = "review:rating:%d"
+	CacheTTLRating     = 10 * time.Minute
+	DefaultPage        = 1
+	DefaultPageSize    = 10
+	MaxPageSize        = 100
+)
+
+type Service interface {
+	CreateReview(ctx context.Context, productID, userID uint, input CreateReviewRequest) (*Review, error)
+	GetReviews(ctx context.Context, productID uint, query ReviewQuery) (*ReviewListResponse, error)
+	GetRatingSummary(ctx context.Context, productID uint) (*RatingSummary, error)
+}
+
+type service struct {
+	repo           Repository
+	productService product.Service
+	cache          *cache.RedisCache
+	validator      *validator.Validate
+	logger         *zap.Logger
+}
+
+func NewService(repo Repository, productService product.Service, cache *cache.RedisCache, logger *zap.Logger) Service {
+	return &service{
+		repo:           repo,
+		productService: productService,
+		cache:          cache,
+		validator:      validator.New(),
+		logger:         logger,
+	}
+}
+
+// CreateReview records the user's rating of a product they paid for, a second review of
+// the same product is rejected with ErrAlreadyReviewed
+func (s *service) CreateReview(ctx context.Context, productID, userID uint, input CreateReviewRequest) (*Review, error) {
+	ctx, span := tracing.Start(ctx, "review.CreateReview")
+	defer span.End()
+
+	if err := s.validator.Struct(input); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.productService.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	purchased, err := s.repo.HasPurchased(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !purchased {
+		return nil, errors.New(ErrNotPurchased)
+	}
+
+	reviewed, err := s.hasReviewed(ctx, productID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if reviewed {
+		return nil, errors.New(ErrAlreadyReviewed)
+	}
+
+	review := Review{
+		ProductID: productID,
+		UserID:    userID,
+		Rating:    input.Rating,
+		Comment:   input.Comment,
+	}
+	if err := s.repo.Create(ctx, &review); err != nil {
+		// a concurrent request may have won the unique index since the check above
+		if reviewed, _ := s.hasReviewed(ctx, productID, userID); reviewed {
+			return nil, errors.New(ErrAlreadyReviewed)
+		}
+		s.logger.Error("Failed to create review", zap.Error(err), zap.Uint("product_id", productID))
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, fmt.Sprintf(CacheKeyRating, productID))
+	return &review, nil
+}
+
+func (s *service) GetReviews(ctx context.Context, productID uint, query ReviewQuery) (*ReviewListResponse, error) {
+	ctx, span := tracing.Start(ctx, "review.GetReviews")
+	defer span.End()
+
+	if _, err := s.productService.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	page := query.Page
+	if page <= 0 {
+		page = DefaultPage
+	}
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	reviews, total, err := s.repo.FindByProduct(ctx, productID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReviewListResponse{
+		Data: reviews,
+		Pagination: dto.PaginationMetadata{
+			Page:       page,
+			PageSize:   pageSize,
+			Total:      total,
+			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		},
+	}, nil
+}
+
+// GetRatingSummary returns the product's average rating, cached until the next review
+func (s *service) GetRatingSummary(ctx context.Context, productID uint) (*RatingSummary, error) {
+	ctx, span := tracing.Start(ctx, "review.GetRatingSummary")
+	defer span.End()
+
+	if _, err := s.productService.GetProductByID(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	var summary RatingSummary
+	err := s.cache.GetOrSet(ctx, fmt.Sprintf(CacheKeyRating, productID), CacheTTLRating, &summary, func() (any, error) {
+		return s.repo.RatingSummary(ctx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (s *service) hasReviewed(ctx context.Context, productID, userID uint) (bool, error) {
+	_, err := s.repo.FindByProductAndUser(ctx, productID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
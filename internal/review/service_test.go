@@ -0,0 +1,183 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/product"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, review *Review) error {
+	args := m.Called(ctx, review)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindByProductAndUser(ctx context.Context, productID, userID uint) (Review, error) {
+	args := m.Called(ctx, productID, userID)
+	return args.Get(0).(Review), args.Error(1)
+}
+
+func (m *MockRepository) FindByProduct(ctx context.Context, productID uint, offset, limit int) ([]Review, int64, error) {
+	args := m.Called(ctx, productID, offset, limit)
+	return args.Get(0).([]Review), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) RatingSummary(ctx context.Context, productID uint) (RatingSummary, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(RatingSummary), args.Error(1)
+}
+
+func (m *MockRepository) HasPurchased(ctx context.Context, userID, productID uint) (bool, error) {
+	args := m.Called(ctx, userID, productID)
+	return args.Bool(0), args.Error(1)
+}
+
+// stubProductService knows product 1, every other product is missing
+type stubProductService struct {
+	product.Service
+}
+
+func (stubProductService) GetProductByID(ctx context.Context, id uint) (*product.Product, error) {
+	if id != 1 {
+		return nil, errors.New(product.ErrProductNotFound)
+	}
+	return &product.Product{ID: id, Name: "Smartphone"}, nil
+}
+
+func setupTestCache(t *testing.T) *cache.RedisCache {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewRedisCache(client, zap.NewNop())
+}
+
+func TestService_CreateReview(t *testing.T) {
+	ctx := context.Background()
+	input := CreateReviewRequest{Rating: 4, Comment: "Works well"}
+
+	t.Run("should create a review for a purchased product", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("HasPurchased", mock.Anything, uint(7), uint(1)).Return(true, nil)
+		mockRepo.On("FindByProductAndUser", mock.Anything, uint(1), uint(7)).Return(Review{}, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(r *Review) bool {
+			return r.ProductID == 1 && r.UserID == 7 && r.Rating == 4 && r.Comment == "Works well"
+		})).Return(nil)
+
+		service := NewService(mockRepo, stubProductService{}, setupTestCache(t), zap.NewNop())
+		review, err := service.CreateReview(ctx, 1, 7, input)
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, review.Rating)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a second review of the same product", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("HasPurchased", mock.Anything, uint(7), uint(1)).Return(true, nil)
+		mockRepo.On("FindByProductAndUser", mock.Anything, uint(1), uint(7)).Return(Review{ID: 3, ProductID: 1, UserID: 7}, nil)
+
+		service := NewService(mockRepo, stubProductService{}, setupTestCache(t), zap.NewNop())
+		_, err := service.CreateReview(ctx, 1, 7, input)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrAlreadyReviewed, err.Error())
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should report a duplicate when a concurrent review wins the unique index", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("HasPurchased", mock.Anything, uint(7), uint(1)).Return(true, nil)
+		mockRepo.On("FindByProductAndUser", mock.Anything, uint(1), uint(7)).Return(Review{}, gorm.ErrRecordNotFound).Once()
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("duplicate key value violates unique constraint"))
+		mockRepo.On("FindByProductAndUser", mock.Anything, uint(1), uint(7)).Return(Review{ID: 3, ProductID: 1, UserID: 7}, nil).Once()
+
+		service := NewService(mockRepo, stubProductService{}, setupTestCache(t), zap.NewNop())
+		_, err := service.CreateReview(ctx, 1, 7, input)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrAlreadyReviewed, err.Error())
+	})
+
+	t.Run("should reject a review without a purchase", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("HasPurchased", mock.Anything, uint(7), uint(1)).Return(false, nil)
+
+		service := NewService(mockRepo, stubProductService{}, setupTestCache(t), zap.NewNop())
+		_, err := service.CreateReview(ctx, 1, 7, input)
+
+		require.Error(t, err)
+		assert.Equal(t, ErrNotPurchased, err.Error())
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject a rating outside 1 to 5", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+
+		service := NewService(mockRepo, stubProductService{}, setupTestCache(t), zap.NewNop())
+		_, err := service.CreateReview(ctx, 1, 7, CreateReviewRequest{Rating: 6})
+
+		require.Error(t, err)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return product not found for a missing product", func(t *testing.T) {
+		service := NewService(new(MockRepository), stubProductService{}, setupTestCache(t), zap.NewNop())
+		_, err := service.CreateReview(ctx, 2, 7, input)
+
+		require.Error(t, err)
+		assert.Equal(t, product.ErrProductNotFound, err.Error())
+	})
+}
+
+func TestService_GetRatingSummary(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should cache the average until the next review", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockRepo.On("RatingSummary", mock.Anything, uint(1)).Return(RatingSummary{ProductID: 1, AverageRating: 4.5, ReviewCount: 2}, nil).Once()
+		mockRepo.On("HasPurchased", mock.Anything, uint(7), uint(1)).Return(true, nil)
+		mockRepo.On("FindByProductAndUser", mock.Anything, uint(1), uint(7)).Return(Review{}, gorm.ErrRecordNotFound)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		service := NewService(mockRepo, stubProductService{}, setupTestCache(t), zap.NewNop())
+
+		summary, err := service.GetRatingSummary(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 4.5, summary.AverageRating)
+
+		summary, err = service.GetRatingSummary(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), summary.ReviewCount)
+		mockRepo.AssertNumberOfCalls(t, "RatingSummary", 1)
+
+		_, err = service.CreateReview(ctx, 1, 7, CreateReviewRequest{Rating: 1})
+		require.NoError(t, err)
+
+		mockRepo.On("RatingSummary", mock.Anything, uint(1)).Return(RatingSummary{ProductID: 1, AverageRating: 10.0 / 3, ReviewCount: 3}, nil).Once()
+		summary, err = service.GetRatingSummary(ctx, 1)
+		require.NoError(t, err)
+		assert.InDelta(t, 3.33, summary.AverageRating, 0.01)
+		assert.Equal(t, int64(3), summary.ReviewCount)
+		mockRepo.AssertNumberOfCalls(t, "RatingSummary", 2)
+	})
+}
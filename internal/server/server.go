@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"mini-e-commerce/internal/logger"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Server struct {
+	httpServer *http.Server
+	logger     logger.Logger
+}
+
+func New(handler http.Handler, addr string, log logger.Logger) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:    addr,
+			Handler: handler,
+		},
+		logger: log,
+	}
+}
+
+// Start listens on the configured address and blocks until the server is shut down.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	s.logger.Info("HTTP server listening", zap.String("addr", listener.Addr().String()))
+
+	if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting new connections and waits up to timeout for in-flight requests to finish.
+func (s *Server) Shutdown(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.httpServer.Shutdown(ctx)
+
+	s.logger.Info("HTTP server stopped",
+		zap.Float64("waited_seconds", time.Since(start).Seconds()),
+		zap.Error(err),
+	)
+	return err
+}
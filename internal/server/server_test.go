@@ -0,0 +1,95 @@
+package server
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"mini-e-commerce/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func setupLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger(&logger.Config{
+		ServiceName: "test",
+		AppVersion:  "test",
+		LogLevel:    zapcore.FatalLevel,
+		Mode:        "development",
+	})
+	require.NoError(t, err)
+	return log
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Run("should finish in-flight request before shutting down", func(t *testing.T) {
+		started := make(chan struct{})
+		mux := http.NewServeMux()
+		mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("done"))
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		srv := New(mux, listener.Addr().String(), setupLogger(t))
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- srv.Serve(listener) }()
+
+		type result struct {
+			status int
+			body   string
+			err    error
+		}
+		resultCh := make(chan result, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+			if err != nil {
+				resultCh <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			resultCh <- result{status: resp.StatusCode, body: string(body)}
+		}()
+
+		<-started
+		require.NoError(t, srv.Shutdown(5*time.Second))
+
+		res := <-resultCh
+		require.NoError(t, res.err)
+		assert.Equal(t, http.StatusOK, res.status)
+		assert.Equal(t, "done", res.body)
+		assert.NoError(t, <-serveErr)
+	})
+
+	t.Run("should return error when in-flight request outlives timeout", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		mux := http.NewServeMux()
+		mux.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		srv := New(mux, listener.Addr().String(), setupLogger(t))
+		go srv.Serve(listener)
+		go http.Get("http://" + listener.Addr().String() + "/stuck")
+
+		<-started
+		err = srv.Shutdown(50 * time.Millisecond)
+		close(release)
+
+		assert.Error(t, err)
+	})
+}
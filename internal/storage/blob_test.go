@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write the blob and return its url", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewLocalStore(dir, "/uploads/")
+
+		url, err := store.Put(ctx, "products/1/image.png", strings.NewReader("png"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "/uploads/products/1/image.png", url)
+		content, err := os.ReadFile(filepath.Join(dir, "products", "1", "image.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "png", string(content))
+	})
+
+	t.Run("should delete the blob and ignore missing ones", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewLocalStore(dir, "/uploads")
+		_, err := store.Put(ctx, "products/1/image.png", strings.NewReader("png"))
+		require.NoError(t, err)
+
+		require.NoError(t, store.Delete(ctx, "products/1/image.png"))
+		assert.NoFileExists(t, filepath.Join(dir, "products", "1", "image.png"))
+		assert.NoError(t, store.Delete(ctx, "products/1/image.png"))
+	})
+
+	t.Run("should reject keys escaping the directory", func(t *testing.T) {
+		store := NewLocalStore(t.TempDir(), "/uploads")
+
+		for _, key := range []string{"", "../secret", "/etc/passwd", "products/../../secret"} {
+			_, err := store.Put(ctx, key, strings.NewReader("x"))
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+}
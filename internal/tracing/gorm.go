@@ -0,0 +1,13 @@
+package tracing
+
+import (
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+	"gorm.io/gorm"
+)
+
+// GormPlugin opens a span around every query, taking the parent from the
+// context passed to db.WithContext. Bound values are left out of db.statement
+// so customer data does not end up in the collector.
+func GormPlugin() gorm.Plugin {
+	return otelgorm.NewPlugin(otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())
+}
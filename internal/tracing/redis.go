@@ -0,0 +1,11 @@
+package tracing
+
+import (
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// InstrumentRedis opens a span for every command or pipeline sent through rdb
+func InstrumentRedis(rdb redis.UniversalClient) error {
+	return redisotel.InstrumentTracing(rdb)
+}
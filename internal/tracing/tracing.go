@@ -0,0 +1,62 @@
+// Package tracing wires the service into OpenTelemetry. Spans for requests,
+// service calls, queries and Redis commands go to the global tracer provider,
+// which NewProvider points at an OTLP/HTTP collector.
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	instrumentationName = "mini-e-commerce"
+	otlpTracesPath      = "/v1/traces"
+)
+
+// Start begins a span as a child of the span in ctx and returns a context
+// carrying the new span. Until a provider is installed the span is a no-op
+// and, with no trace to continue, ctx comes back as it was passed.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer(instrumentationName).Start(ctx, name)
+	if !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+	return spanCtx, span
+}
+
+// NewProvider exports spans in batches to endpoint, the collector base URL,
+// and installs the provider globally together with the W3C trace context
+// propagator. Shut the provider down on exit to flush the last batch.
+func NewProvider(ctx context.Context, endpoint, serviceName string, logger *zap.Logger) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(strings.TrimRight(endpoint, "/")+otlpTracesPath))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("Failed to export spans", zap.Error(err))
+	}))
+	return provider, nil
+}
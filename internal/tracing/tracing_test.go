@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("should return a span that records nothing when no provider is set", func(t *testing.T) {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+
+		ctx, span := Start(context.Background(), "noop")
+
+		assert.False(t, span.IsRecording())
+		assert.Equal(t, context.Background(), ctx)
+		span.End()
+	})
+
+	t.Run("should start children of the span in the context", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+		ctx, root := Start(context.Background(), "root")
+		_, child := Start(ctx, "child")
+		child.End()
+		root.End()
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "child", spans[0].Name())
+		assert.Equal(t, spans[1].SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.False(t, spans[1].Parent().IsValid())
+	})
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Run("should flush spans to the collector on shutdown", func(t *testing.T) {
+		received := make(chan *coltracepb.ExportTraceServiceRequest, 1)
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/traces", r.URL.Path)
+			body, _ := io.ReadAll(r.Body)
+			payload := &coltracepb.ExportTraceServiceRequest{}
+			assert.NoError(t, proto.Unmarshal(body, payload))
+			received <- payload
+		}))
+		t.Cleanup(collector.Close)
+		t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+		provider, err := NewProvider(context.Background(), collector.URL+"/", "test-service", zap.NewNop())
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "order.CreateOrder")
+		span.End()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, provider.Shutdown(ctx))
+
+		payload := <-received
+		require.Len(t, payload.ResourceSpans, 1)
+		resourceSpans := payload.ResourceSpans[0]
+
+		serviceName := ""
+		for _, attr := range resourceSpans.Resource.Attributes {
+			if attr.Key == "service.name" {
+				serviceName = attr.Value.GetStringValue()
+			}
+		}
+		assert.Equal(t, "test-service", serviceName)
+		require.Len(t, resourceSpans.ScopeSpans, 1)
+		require.Len(t, resourceSpans.ScopeSpans[0].Spans, 1)
+		assert.Equal(t, "order.CreateOrder", resourceSpans.ScopeSpans[0].Spans[0].Name)
+	})
+}
@@ -0,0 +1,10 @@
+package utils
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EscapeLike escapes LIKE wildcards so user input is matched literally
+func EscapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
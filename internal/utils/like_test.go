@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, "phone", EscapeLike("phone"))
+	assert.Equal(t, `100\%`, EscapeLike("100%"))
+	assert.Equal(t, `snake\_case`, EscapeLike("snake_case"))
+	assert.Equal(t, `C:\\tmp`, EscapeLike(`C:\tmp`))
+}
@@ -1,19 +1,27 @@
 package utils
 
-import "strconv"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
 
+// ErrInvalidID is returned for ids that are not a positive integer fitting the database's
+// SERIAL columns, which are a signed int4
+var ErrInvalidID = errors.New("id must be a positive integer")
+
+// ParseIDFromString parses a path id. Zero, negatives and values past math.MaxInt32 are
+// rejected up front instead of reaching the database as a lookup that cannot match.
 func ParseIDFromString(idStr string) (uint, error) {
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		return 0, err
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 || id > math.MaxInt32 {
+		return 0, fmt.Errorf("%w, got %q", ErrInvalidID, idStr)
 	}
 	return uint(id), nil
 }
 
+// ParseUserIDFromString parses a user id with the same rules as ParseIDFromString
 func ParseUserIDFromString(userIDStr string) (uint, error) {
-	uid, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		return 0, err
-	}
-	return uint(uid), nil
-}
\ No newline at end of file
+	return ParseIDFromString(userIDStr)
+}
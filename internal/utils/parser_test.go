@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIDFromString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  uint
+		valid bool
+	}{
+		{name: "valid id", input: "42", want: 42, valid: true},
+		{name: "largest int32", input: "2147483647", want: 2147483647, valid: true},
+		{name: "zero", input: "0"},
+		{name: "negative", input: "-1"},
+		{name: "past int32", input: "2147483648"},
+		{name: "largest uint32", input: "4294967295"},
+		{name: "overflowing uint64", input: "99999999999999999999"},
+		{name: "not a number", input: "abc"},
+		{name: "empty", input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, parse := range []func(string) (uint, error){ParseIDFromString, ParseUserIDFromString} {
+				id, err := parse(tt.input)
+
+				if !tt.valid {
+					assert.ErrorIs(t, err, ErrInvalidID)
+					assert.Zero(t, id)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, id)
+			}
+		})
+	}
+}
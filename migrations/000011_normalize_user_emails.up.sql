@@ -0,0 +1,22 @@
+-- Accounts whose emails differ only by case would collide once lowered, they have to be
+-- merged or renamed by hand before this migration can run
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(emails, '; ') INTO duplicates
+    FROM (
+        SELECT string_agg(email, ', ' ORDER BY id) AS emails
+        FROM users
+        GROUP BY LOWER(TRIM(email))
+        HAVING COUNT(*) > 1
+    ) AS collisions;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users with emails that differ only by case must be merged first: %', duplicates;
+    END IF;
+END $$;
+
+UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
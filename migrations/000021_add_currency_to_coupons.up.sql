@@ -0,0 +1,4 @@
+-- Fixed amount coupons created before currencies existed were in the default base currency
+ALTER TABLE coupons ADD COLUMN IF NOT EXISTS currency CHAR(3);
+
+UPDATE coupons SET currency = 'IDR' WHERE amount_off IS NOT NULL AND currency IS NULL;
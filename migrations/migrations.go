@@ -0,0 +1,8 @@
+// Package migrations embeds the numbered SQL migrations so the binary can apply them
+// without the files on disk. New ones are created with `make migrate-create`.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
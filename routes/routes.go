@@ -1,12 +1,21 @@
 package routes
 
 import (
+	"mini-e-commerce/internal/admin"
 	"mini-e-commerce/internal/auth"
 	"mini-e-commerce/internal/cache"
+	"mini-e-commerce/internal/category"
 	"mini-e-commerce/internal/config"
+	"mini-e-commerce/internal/coupon"
+	"mini-e-commerce/internal/health"
 	"mini-e-commerce/internal/logger"
+	"mini-e-commerce/internal/middleware"
 	"mini-e-commerce/internal/order"
 	"mini-e-commerce/internal/product"
+	"mini-e-commerce/internal/response"
+	"mini-e-commerce/internal/review"
+	"mini-e-commerce/internal/storage"
+	"strings"
 
 	_ "mini-e-commerce/docs" // generated docs
 
@@ -14,27 +23,81 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB, cache *cache.RedisCache, log logger.Logger, jwtManager auth.JWTManagerInterface, sessionManager auth.SessionManagerInterface, cfg *config.Config) {
-	api := r.Group("/api")
+// apiVersions are registered in order, v1 keeps the unversioned /api prefix
+var apiVersions = []string{response.APIVersion1, response.APIVersion2}
 
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, rdb *redis.Client, cache *cache.RedisCache, log logger.Logger, healthChecker *health.Checker, jwtManager auth.JWTManagerInterface, sessionManager auth.SessionManagerInterface, tokenManager auth.TokenManagerInterface, cfg *config.Config) {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	healthHandler := health.NewHandler(healthChecker)
+	healthHandler.RegisterRoutes(r)
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	authRepo := auth.NewRepository(db)
-	authService := auth.NewService(authRepo, jwtManager, sessionManager, log.GetZapLogger(), cfg.JWTExpiration, cfg.RefreshExpiration)
-	authHandler := auth.NewHandler(authService, log)
-	authHandler.RegisterRoutes(api)
+	authMiddleware := middleware.AuthMiddleware(jwtManager, sessionManager, authRepo, cfg.CookieSecure, cfg.CookieSameSite, log.GetZapLogger())
+	adminMiddleware := middleware.RequireRole(auth.RoleAdmin)
+	authRateLimiter := middleware.RateLimit(rdb, cfg.AuthRateLimit, cfg.AuthRateLimitWindow)
+
+	notifier := auth.NewNoopNotifier(log.GetZapLogger())
+	authService := auth.NewService(authRepo, jwtManager, sessionManager, tokenManager, notifier, log.GetZapLogger(), cfg.JWTExpiration, cfg.SessionTTL, cfg.RememberMeExpiration, cfg.RequireEmailVerification)
+	authHandler := auth.NewHandler(authService, log, cfg.CookieSecure, cfg.CookieSameSite)
+
+	categoryRepo := category.NewRepository(db)
 
 	productRepo := product.NewRepository(db)
-	productService := product.NewService(productRepo, cache, log.GetZapLogger())
-	productHandler := product.NewHandler(productService, log)
-	productHandler.RegisterRoutes(api, jwtManager, sessionManager, log.GetZapLogger())
+	imageStore := storage.NewLocalStore(cfg.UploadDir, cfg.UploadBaseURL)
+	if strings.HasPrefix(cfg.UploadBaseURL, "/") {
+		r.Static(cfg.UploadBaseURL, cfg.UploadDir)
+	}
+	productService := product.NewService(productRepo, categoryRepo, cache, cfg.ProductNegativeCache, cfg.BaseCurrency, notifier, imageStore, log)
+	productHandler := product.NewHandler(productService, log, cfg.MaxUploadBytes)
+
+	categoryService := category.NewService(categoryRepo, productService, log.GetZapLogger())
+	categoryHandler := category.NewHandler(categoryService, log)
+
+	couponRepo := coupon.NewRepository(db)
+	couponService := coupon.NewService(couponRepo, cfg.BaseCurrency, log.GetZapLogger())
+	couponHandler := coupon.NewHandler(couponService, log)
 
 	orderRepo := order.NewRepository(db)
-	orderService := order.NewService(orderRepo, productService, log)
+	orderService := order.NewService(orderRepo, productService, couponService, order.NewGateway(cfg.PaymentGateway), cache, order.Limits{
+		MaxItemsPerOrder:   cfg.OrderMaxItems,
+		MaxQuantityPerLine: cfg.OrderMaxQuantityPerLine,
+		CancellationWindow: cfg.OrderCancellationWindow,
+	}, log)
 	orderHandler := order.NewHandler(orderService, log)
-	orderHandler.RegisterRoutes(api, jwtManager, sessionManager, log.GetZapLogger())
 
+	reviewRepo := review.NewRepository(db)
+	reviewService := review.NewService(reviewRepo, productService, cache, log.GetZapLogger())
+	reviewHandler := review.NewHandler(reviewService, log)
+
+	adminRepo := admin.NewRepository(db)
+	adminService := admin.NewService(adminRepo, cache, log.GetZapLogger())
+	adminHandler := admin.NewHandler(adminService, log)
+
+	for _, version := range apiVersions {
+		api := r.Group(apiPrefix(version))
+		api.Use(middleware.CSRF(api.BasePath()+"/auth/login", api.BasePath()+"/auth/register"))
+
+		authHandler.RegisterRoutes(api, authMiddleware, adminMiddleware, authRateLimiter)
+		categoryHandler.RegisterRoutes(api, authMiddleware)
+		productHandler.RegisterRoutes(api, authMiddleware, version)
+		couponHandler.RegisterRoutes(api, authMiddleware)
+		orderHandler.RegisterRoutes(api, authMiddleware)
+		reviewHandler.RegisterRoutes(api, authMiddleware)
+		adminHandler.RegisterRoutes(api, authMiddleware, adminMiddleware)
+	}
+}
+
+func apiPrefix(version string) string {
+	if version == response.APIVersion1 {
+		return "/api"
+	}
+	return "/api/" + version
 }